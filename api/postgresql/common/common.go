@@ -9,3 +9,28 @@ type CRLink struct {
 	// +optional
 	Namespace string `json:"namespace,omitempty"`
 }
+
+type EngineKind string
+
+const PostgresqlEngineConfigurationKind EngineKind = "PostgresqlEngineConfiguration"
+const ClusterPostgresqlEngineConfigurationKind EngineKind = "ClusterPostgresqlEngineConfiguration"
+
+type EngineCRLink struct {
+	// Engine configuration kind
+	// +optional
+	// +kubebuilder:validation:Enum=PostgresqlEngineConfiguration;ClusterPostgresqlEngineConfiguration
+	// +kubebuilder:default=PostgresqlEngineConfiguration
+	Kind EngineKind `json:"kind,omitempty"`
+	// Custom resource name
+	// +required
+	// +kubebuilder:validation:Required
+	Name string `json:"name"`
+	// Custom resource namespace (ignored for ClusterPostgresqlEngineConfiguration kind)
+	// +optional
+	Namespace string `json:"namespace,omitempty"`
+}
+
+// IsClusterKind will return true if link is targeting a cluster engine configuration.
+func (l *EngineCRLink) IsClusterKind() bool {
+	return l.Kind == ClusterPostgresqlEngineConfigurationKind
+}
@@ -0,0 +1,90 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// EngineConfiguration is implemented by namespaced and cluster engine configurations.
+// +kubebuilder:object:generate=false
+type EngineConfiguration interface {
+	metav1.Object
+	runtime.Object
+	// Get engine configuration spec
+	GetEngineSpec() *PostgresqlEngineConfigurationSpec
+	// Get engine configuration status
+	GetEngineStatus() *PostgresqlEngineConfigurationStatus
+	// Get a namespaced engine configuration view sharing the same spec and status values.
+	// Cluster engine configurations have an empty namespace.
+	ToPostgresqlEngineConfiguration() *PostgresqlEngineConfiguration
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:subresource:status
+// +kubebuilder:resource:path=clusterpostgresqlengineconfigurations,scope=Cluster,shortName=cpgengcfg;cpgec
+// +kubebuilder:printcolumn:name="Last Validation",type=date,description="Last time validated",JSONPath=".status.lastValidatedTime"
+// +kubebuilder:printcolumn:name="Phase",type=string,description="Status phase",JSONPath=".status.phase"
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
+
+// ClusterPostgresqlEngineConfiguration is the Schema for the clusterpostgresqlengineconfigurations API.
+// Secrets are read from the operator namespace.
+type ClusterPostgresqlEngineConfiguration struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   PostgresqlEngineConfigurationSpec   `json:"spec,omitempty"`
+	Status PostgresqlEngineConfigurationStatus `json:"status,omitempty"`
+}
+
+func (c *ClusterPostgresqlEngineConfiguration) GetEngineSpec() *PostgresqlEngineConfigurationSpec {
+	return &c.Spec
+}
+
+func (c *ClusterPostgresqlEngineConfiguration) GetEngineStatus() *PostgresqlEngineConfigurationStatus {
+	return &c.Status
+}
+
+func (c *ClusterPostgresqlEngineConfiguration) ToPostgresqlEngineConfiguration() *PostgresqlEngineConfiguration {
+	return &PostgresqlEngineConfiguration{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              c.Name,
+			UID:               c.UID,
+			Generation:        c.Generation,
+			CreationTimestamp: c.CreationTimestamp,
+			DeletionTimestamp: c.DeletionTimestamp,
+			Labels:            c.Labels,
+			Annotations:       c.Annotations,
+		},
+		Spec:   *c.Spec.DeepCopy(),
+		Status: *c.Status.DeepCopy(),
+	}
+}
+
+//+kubebuilder:object:root=true
+
+// ClusterPostgresqlEngineConfigurationList contains a list of ClusterPostgresqlEngineConfiguration.
+type ClusterPostgresqlEngineConfigurationList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ClusterPostgresqlEngineConfiguration `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&ClusterPostgresqlEngineConfiguration{}, &ClusterPostgresqlEngineConfigurationList{})
+}
@@ -55,7 +55,116 @@ type PostgresqlDatabaseSpec struct {
 	// Postgresql Engine Configuration link
 	// +required
 	// +kubebuilder:validation:Required
-	EngineConfiguration *common.CRLink `json:"engineConfiguration"`
+	EngineConfiguration *common.EngineCRLink `json:"engineConfiguration"`
+	// Namespaces allowed to reference this database from another namespace.
+	// All namespaces are allowed when allowedNamespaces and allowedNamespaceSelector aren't set.
+	// +optional
+	// +listType=set
+	AllowedNamespaces []string `json:"allowedNamespaces,omitempty"`
+	// Label selector of namespaces allowed to reference this database from another namespace.
+	// All namespaces are allowed when allowedNamespaces and allowedNamespaceSelector aren't set.
+	// +optional
+	AllowedNamespaceSelector *metav1.LabelSelector `json:"allowedNamespaceSelector,omitempty"`
+	// Options used when database is created.
+	// They are only applied on creation, differences with an existing database are reported in status.
+	// +optional
+	CreationOptions *DatabaseCreationOptions `json:"creationOptions,omitempty"`
+	// Runtime settings set on database with ALTER DATABASE SET (like statement_timeout or search_path).
+	// Settings removed from this list are reset.
+	// +optional
+	Settings map[string]string `json:"settings,omitempty"`
+	// Runtime settings set for database group roles with ALTER ROLE IN DATABASE SET.
+	// Settings removed from these lists are reset.
+	// +optional
+	RoleSettings *DatabaseRoleSettings `json:"roleSettings,omitempty"`
+	// Maximum number of concurrent connections on database (-1 means no limit).
+	// Engine default (no limit) is restored when not set.
+	// +optional
+	// +kubebuilder:validation:Minimum=-1
+	ConnectionLimit *int `json:"connectionLimit,omitempty"`
+	// Allow connections on database. Set it to false to block new connections during a maintenance.
+	// Extensions, schemas and their privileges aren't managed while connections are disabled,
+	// status reason is "ConnectionsNotAllowed" in this case.
+	// Engine default (allowed) is restored when not set.
+	// +optional
+	AllowConnections *bool `json:"allowConnections,omitempty"`
+	// Terminate existing sessions when connections are disabled with allowConnections.
+	// +optional
+	TerminateExistingSessions bool `json:"terminateExistingSessions,omitempty"`
+	// Custom privilege profiles. Each profile creates a "<database>-<name>" group role
+	// having profile privileges on objects of each schema.
+	// +optional
+	// +listType=map
+	// +listMapKey=name
+	PrivilegeProfiles []*DatabasePrivilegeProfile `json:"privilegeProfiles,omitempty"`
+}
+
+// +kubebuilder:validation:Enum=SELECT;INSERT;UPDATE;DELETE;TRUNCATE;REFERENCES;TRIGGER
+type TablePrivilege string
+
+// +kubebuilder:validation:Enum=USAGE;SELECT;UPDATE
+type SequencePrivilege string
+
+// +kubebuilder:validation:Enum=EXECUTE
+type FunctionPrivilege string
+
+// DatabasePrivilegeProfile defines a custom privilege profile.
+type DatabasePrivilegeProfile struct {
+	// Profile name used in group role name and referenced by PostgresqlUserRole privileges.
+	// owner, reader and writer are reserved.
+	// +required
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:Pattern=`^[a-z0-9]([-_a-z0-9]*[a-z0-9])?$`
+	Name string `json:"name"`
+	// Privileges on tables
+	// +optional
+	// +listType=set
+	Tables []TablePrivilege `json:"tables,omitempty"`
+	// Privileges on sequences
+	// +optional
+	// +listType=set
+	Sequences []SequencePrivilege `json:"sequences,omitempty"`
+	// Privileges on functions
+	// +optional
+	// +listType=set
+	Functions []FunctionPrivilege `json:"functions,omitempty"`
+}
+
+// DatabaseRoleSettings defines runtime settings per database group role.
+type DatabaseRoleSettings struct {
+	// Owner group role settings
+	// +optional
+	Owner map[string]string `json:"owner,omitempty"`
+	// Reader group role settings
+	// +optional
+	Reader map[string]string `json:"reader,omitempty"`
+	// Writer group role settings
+	// +optional
+	Writer map[string]string `json:"writer,omitempty"`
+}
+
+// DatabaseCreationOptions defines options used when database is created.
+// Engine defaults are used for missing values.
+type DatabaseCreationOptions struct {
+	// Character set encoding (like UTF8)
+	// +optional
+	Encoding string `json:"encoding,omitempty"`
+	// Locale used for collation and character classification (LC_COLLATE and LC_CTYPE)
+	// +optional
+	Locale string `json:"locale,omitempty"`
+	// Locale provider. This needs at least PostgreSQL 15.
+	// +optional
+	// +kubebuilder:validation:Enum=libc;icu
+	LocaleProvider string `json:"localeProvider,omitempty"`
+	// ICU locale used when locale provider is icu. This needs at least PostgreSQL 15.
+	// +optional
+	ICULocale string `json:"icuLocale,omitempty"`
+	// Template database to copy
+	// +optional
+	Template string `json:"template,omitempty"`
+	// Tablespace of database
+	// +optional
+	Tablespace string `json:"tablespace,omitempty"`
 }
 
 type DatabaseModulesList struct {
@@ -88,6 +197,9 @@ type PostgresqlDatabaseStatus struct {
 	// Human-readable message indicating details about current operator phase or error.
 	// +optional
 	Message string `json:"message"`
+	// Machine-readable reason of current operator error (like "Forbidden" when a reference isn't allowed).
+	// +optional
+	Reason string `json:"reason,omitempty"`
 	// True if all resources are in a ready state and all work is done.
 	// +optional
 	Ready bool `json:"ready"`
@@ -105,6 +217,70 @@ type PostgresqlDatabaseStatus struct {
 	// +optional
 	// +listType=set
 	Extensions []string `json:"extensions,omitempty"`
+	// Last plan computed when plan mode is enabled
+	// +optional
+	Plan *PlanStatus `json:"plan,omitempty"`
+	// Effective creation options of database
+	// +optional
+	CreationOptions *DatabaseCreationOptionsStatus `json:"creationOptions,omitempty"`
+	// Applied runtime settings on database
+	// +optional
+	Settings map[string]string `json:"settings,omitempty"`
+	// Applied runtime settings for database group roles
+	// +optional
+	RoleSettings *DatabaseRoleSettings `json:"roleSettings,omitempty"`
+	// Connection configuration and usage of database
+	// +optional
+	Connections *DatabaseConnectionsStatus `json:"connections,omitempty"`
+}
+
+// DatabaseConnectionsStatus stores connection configuration and usage of database as reported by engine.
+// +k8s:openapi-gen=true
+type DatabaseConnectionsStatus struct {
+	// Connection limit (-1 means no limit)
+	ConnectionLimit int `json:"connectionLimit"`
+	// Are connections allowed ?
+	AllowConnections bool `json:"allowConnections"`
+	// Number of sessions connected to database (from pg_stat_activity) during last reconcile
+	Count int `json:"count"`
+}
+
+// DatabaseCreationOptionsStatus stores effective creation options of database as reported by engine.
+// +k8s:openapi-gen=true
+type DatabaseCreationOptionsStatus struct {
+	// Character set encoding
+	// +optional
+	Encoding string `json:"encoding,omitempty"`
+	// Locale (LC_COLLATE/LC_CTYPE when they are different)
+	// +optional
+	Locale string `json:"locale,omitempty"`
+	// Locale provider
+	// +optional
+	LocaleProvider string `json:"localeProvider,omitempty"`
+	// ICU locale
+	// +optional
+	ICULocale string `json:"icuLocale,omitempty"`
+	// Template used when operator created database. Engine doesn't keep it so it isn't checked for drift.
+	// +optional
+	Template string `json:"template,omitempty"`
+	// Tablespace of database
+	// +optional
+	Tablespace string `json:"tablespace,omitempty"`
+	// Creation options asked in spec that are different on existing database
+	// +optional
+	// +listType=set
+	Drifts []string `json:"drifts,omitempty"`
+}
+
+// PlanStatus stores the statements that would have been executed on engine in plan mode.
+// +k8s:openapi-gen=true
+type PlanStatus struct {
+	// Statements that would have been executed on engine
+	// +optional
+	PendingStatements []string `json:"pendingStatements,omitempty"`
+	// Last plan time
+	// +optional
+	LastPlanTime string `json:"lastPlanTime,omitempty"`
 }
 
 // StatusPostgresRoles stores the different group roles already created for database
@@ -113,6 +289,9 @@ type StatusPostgresRoles struct {
 	Owner  string `json:"owner"`
 	Reader string `json:"reader"`
 	Writer string `json:"writer"`
+	// Privilege profile name => group role
+	// +optional
+	Profiles map[string]string `json:"profiles,omitempty"`
 }
 
 //+kubebuilder:object:root=true
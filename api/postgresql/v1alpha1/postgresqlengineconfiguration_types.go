@@ -28,6 +28,18 @@ type ProviderType string
 const NoProvider ProviderType = ""
 const AWSProvider ProviderType = "AWS"
 const AzureProvider ProviderType = "AZURE"
+const GCPProvider ProviderType = "GCP"
+const RestrictedAdminProvider ProviderType = "RESTRICTED"
+
+type CredentialSourceType string
+
+const SecretCredentialSourceType CredentialSourceType = "secret"
+const ExecCredentialSourceType CredentialSourceType = "exec"
+
+type PasswordEncryptionType string
+
+const ScramPasswordEncryption PasswordEncryptionType = "scram"
+const PlaintextPasswordEncryption PasswordEncryptionType = "plaintext"
 
 // PostgresqlEngineConfigurationSpec defines the desired state of PostgresqlEngineConfiguration.
 type PostgresqlEngineConfigurationSpec struct {
@@ -37,7 +49,7 @@ type PostgresqlEngineConfigurationSpec struct {
 	// Add custom validation using kubebuilder tags: https://book-v1.book.kubebuilder.io/beyond_basics/generating_crd.html
 
 	// Provider
-	// +kubebuilder:validation:Enum="";AWS;AZURE
+	// Unknown providers are reported by engine configuration reconcile with the list of supported ones.
 	Provider ProviderType `json:"provider,omitempty"`
 	// Hostname
 	// +required
@@ -59,16 +71,169 @@ type PostgresqlEngineConfigurationSpec struct {
 	// Wait for linked resource to be deleted
 	WaitLinkedResourcesDeletion bool `json:"waitLinkedResourcesDeletion,omitempty"`
 	// User and password secret
-	// +required
-	// +kubebuilder:validation:Required
-	// +kubebuilder:validation:MinLength=1
-	SecretName string `json:"secretName"`
+	// Mandatory when credential source is "secret".
+	// +optional
+	SecretName string `json:"secretName,omitempty"`
+	// Credential source used for engine user and password.
+	// Default is the "secret" source using secretName.
+	// +optional
+	CredentialSource *CredentialSource `json:"credentialSource,omitempty"`
 	// User connections used for secret generation
 	// That will be used to generate secret with primary server as url or
 	// to use the pg bouncer one.
-	// Note: Operator won't check those values.
+	// Note: Operator will probe all of them with engine user on every check interval.
 	// +optional
 	UserConnections *UserConnections `json:"userConnections"`
+	// Block user role reconciles when the user connections they would publish in secrets are unreachable.
+	// +optional
+	BlockUnreachableUserConnections bool `json:"blockUnreachableUserConnections,omitempty"`
+	// Password encryption done by operator for created or updated roles.
+	// "scram" will send a SCRAM-SHA-256 verifier computed by operator instead of the password.
+	// "plaintext" will send the password and let the engine encrypt it (for providers rejecting pre-hashed secrets).
+	// +kubebuilder:validation:Enum=scram;plaintext
+	// +optional
+	PasswordEncryption PasswordEncryptionType `json:"passwordEncryption,omitempty"`
+	// Iteration count used for SCRAM-SHA-256 verifiers. Operator wide default is used if not set.
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	ScramIterations int `json:"scramIterations,omitempty"`
+	// Connection pool settings used by operator on this engine.
+	// Operator wide defaults are used for values that aren't set.
+	// +optional
+	Pool *PoolSettings `json:"pool,omitempty"`
+	// TLS client certificate authentication used by operator to connect to engine.
+	// +optional
+	TLS *EngineTLS `json:"tls,omitempty"`
+	// Namespaces allowed to reference this engine configuration from another namespace.
+	// All namespaces are allowed when allowedNamespaces and allowedNamespaceSelector aren't set.
+	// +optional
+	// +listType=set
+	AllowedNamespaces []string `json:"allowedNamespaces,omitempty"`
+	// Label selector of namespaces allowed to reference this engine configuration from another namespace.
+	// All namespaces are allowed when allowedNamespaces and allowedNamespaceSelector aren't set.
+	// +optional
+	AllowedNamespaceSelector *metav1.LabelSelector `json:"allowedNamespaceSelector,omitempty"`
+	// Rotation policy of engine user password.
+	// Operator will save a new password as pending in secret, apply it on engine, check a fresh login and promote it in secret.
+	// Skipped with "exec" credential source and with TLS client certificate authentication without password.
+	// +optional
+	AdminPasswordRotation *AdminPasswordRotation `json:"adminPasswordRotation,omitempty"`
+	// Quotas enforced on resources using this engine configuration.
+	// +optional
+	Quotas *EngineQuotas `json:"quotas,omitempty"`
+}
+
+type EngineQuotas struct {
+	// Maximum number of databases (PostgresqlDatabase) using this engine configuration
+	// +optional
+	// +kubebuilder:validation:Minimum=0
+	MaxDatabases *int `json:"maxDatabases,omitempty"`
+	// Maximum number of login roles (PostgresqlUserRole) using this engine configuration
+	// +optional
+	// +kubebuilder:validation:Minimum=0
+	MaxLoginRoles *int `json:"maxLoginRoles,omitempty"`
+	// Maximum number of publications (PostgresqlPublication) and so of their replication slots using this engine configuration
+	// +optional
+	// +kubebuilder:validation:Minimum=0
+	MaxPublications *int `json:"maxPublications,omitempty"`
+	// Maximum number of replication slots used by subscriptions (PostgresqlSubscription) on databases of this engine configuration.
+	// Each subscription needs one replication slot on its engine (see max_replication_slots).
+	// +optional
+	// +kubebuilder:validation:Minimum=0
+	MaxReplicationSlots *int `json:"maxReplicationSlots,omitempty"`
+	// Percentage of each maximum that a single namespace can use.
+	// There isn't any namespace limit if not set.
+	// +optional
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=100
+	NamespaceSharePercent *int `json:"namespaceSharePercent,omitempty"`
+}
+
+type AdminPasswordRotation struct {
+	// Duration between two engine user password changes (duration like "720h")
+	// +required
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:MinLength=1
+	Duration string `json:"duration"`
+	// Generated password length. Default is 32.
+	// +optional
+	// +kubebuilder:validation:Minimum=16
+	PasswordLength int `json:"passwordLength,omitempty"`
+}
+
+type EngineTLS struct {
+	// Secret containing client certificate ("tls.crt"), client key ("tls.key") and CA bundle ("ca.crt").
+	// Secret must be in the same namespace as the engine configuration.
+	// +required
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:MinLength=1
+	SecretName string `json:"secretName"`
+	// SSL mode used for operator connections. Default is "verify-full".
+	// +kubebuilder:validation:Enum=require;verify-ca;verify-full
+	// +optional
+	SSLMode string `json:"sslMode,omitempty"`
+	// Propagate CA bundle in generated user secrets.
+	// +optional
+	PropagateCAInUserSecrets bool `json:"propagateCAInUserSecrets,omitempty"`
+}
+
+type CredentialSource struct {
+	// Credential source type
+	// +kubebuilder:validation:Enum=secret;exec
+	// +kubebuilder:default=secret
+	Type CredentialSourceType `json:"type,omitempty"`
+	// Exec plugin printing credentials as JSON like {"user": "", "password": "", "expiresAt": "RFC3339 date"}.
+	// Mandatory when type is "exec". Only allowed on ClusterPostgresqlEngineConfiguration.
+	// +optional
+	Exec *ExecCredentialSource `json:"exec,omitempty"`
+}
+
+type ExecCredentialSource struct {
+	// Command to execute. It must be allowed in operator configuration.
+	// +required
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:MinLength=1
+	Command string `json:"command"`
+	// Command arguments
+	// +optional
+	Args []string `json:"args,omitempty"`
+	// Environment variables added to command environment
+	// +optional
+	Env []*ExecEnvVar `json:"env,omitempty"`
+	// Credentials are refreshed this duration before expiry (duration like "1m"). Default is "1m".
+	// +optional
+	RefreshBefore string `json:"refreshBefore,omitempty"`
+}
+
+type ExecEnvVar struct {
+	// Name
+	// +required
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:MinLength=1
+	Name string `json:"name"`
+	// Value
+	Value string `json:"value"`
+}
+
+type PoolSettings struct {
+	// Maximum number of open connections per database
+	// +optional
+	// +kubebuilder:validation:Minimum=1
+	MaxOpenConnections int `json:"maxOpenConnections,omitempty"`
+	// Maximum number of idle connections per database
+	// +optional
+	// +kubebuilder:validation:Minimum=0
+	MaxIdleConnections *int `json:"maxIdleConnections,omitempty"`
+	// Maximum lifetime of a connection (duration like "60s"). "0s" means no limit.
+	// +optional
+	ConnMaxLifetime string `json:"connMaxLifetime,omitempty"`
+	// Database pools unused for this duration are closed (duration like "10m"). "0s" disables it.
+	// +optional
+	IdleTimeout string `json:"idleTimeout,omitempty"`
+	// Maximum number of open connections across all database pools of this engine. 0 means no limit.
+	// +optional
+	// +kubebuilder:validation:Minimum=0
+	MaxTotalConnections *int `json:"maxTotalConnections,omitempty"`
 }
 
 type UserConnections struct {
@@ -97,6 +262,23 @@ type GenericUserConnection struct {
 	Port int `json:"port,omitempty"`
 }
 
+type UserConnectionType string
+
+const PrimaryUserConnectionType UserConnectionType = "Primary"
+const BouncerUserConnectionType UserConnectionType = "Bouncer"
+const ReplicaUserConnectionType UserConnectionType = "Replica"
+const ReplicaBouncerUserConnectionType UserConnectionType = "ReplicaBouncer"
+
+// User connection condition types.
+const PrimaryConnectionReachableCondition = "PrimaryConnectionReachable"
+const BouncerConnectionReachableCondition = "BouncerConnectionReachable"
+const ReplicaConnectionsReachableCondition = "ReplicaConnectionsReachable"
+const ReplicaBouncerConnectionsReachableCondition = "ReplicaBouncerConnectionsReachable"
+
+// User connection condition reasons.
+const ReachableConditionReason = "Reachable"
+const UnreachableConditionReason = "Unreachable"
+
 type EngineStatusPhase string
 
 const EngineNoPhase EngineStatusPhase = ""
@@ -124,6 +306,133 @@ type PostgresqlEngineConfigurationStatus struct {
 	// Resource Spec hash
 	// +optional
 	Hash string `json:"hash"`
+	// Capabilities discovered on engine during last validation
+	// +optional
+	Capabilities *EngineCapabilities `json:"capabilities,omitempty"`
+	// Health of user connections probed during last validation
+	// +optional
+	UserConnections []*UserConnectionHealth `json:"userConnections,omitempty"`
+	// Conditions summarizing user connections reachability per connection type
+	// +optional
+	// +listType=map
+	// +listMapKey=type
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+	// Last time engine user password was changed by operator
+	// +optional
+	LastAdminPasswordChangedTime string `json:"lastAdminPasswordChangedTime,omitempty"`
+	// Usage of resources counted in quotas during last validation
+	// +optional
+	QuotaUsage *EngineQuotaUsage `json:"quotaUsage,omitempty"`
+	// Identity of the server behind engine host during last validation.
+	// All operator connection pools are closed when it changes (failover detection).
+	// +optional
+	ServerIdentity *EngineServerIdentity `json:"serverIdentity,omitempty"`
+}
+
+type EngineServerIdentity struct {
+	// System identifier of the cluster (pg_control_system()). Empty when engine user cannot read it.
+	// +optional
+	SystemIdentifier string `json:"systemIdentifier,omitempty"`
+	// Result of pg_is_in_recovery()
+	InRecovery bool `json:"inRecovery"`
+	// Result of inet_server_addr(). Empty for unix socket connections.
+	// +optional
+	ServerAddress string `json:"serverAddress,omitempty"`
+}
+
+type EngineQuotaUsage struct {
+	// Number of admitted databases
+	Databases int `json:"databases"`
+	// Number of admitted login roles
+	LoginRoles int `json:"loginRoles"`
+	// Number of admitted publications
+	Publications int `json:"publications"`
+	// Number of replication slots used by admitted subscriptions
+	ReplicationSlots int `json:"replicationSlots"`
+	// Resources refused because of quotas. They aren't counted in usage.
+	// +optional
+	Refused *QuotaRefusedUsage `json:"refused,omitempty"`
+	// Usage per namespace
+	// +optional
+	Namespaces []*NamespaceQuotaUsage `json:"namespaces,omitempty"`
+}
+
+type NamespaceQuotaUsage struct {
+	// Namespace
+	Namespace string `json:"namespace"`
+	// Number of admitted databases
+	Databases int `json:"databases"`
+	// Number of admitted login roles
+	LoginRoles int `json:"loginRoles"`
+	// Number of admitted publications
+	Publications int `json:"publications"`
+	// Number of replication slots used by admitted subscriptions
+	ReplicationSlots int `json:"replicationSlots"`
+	// Resources refused because of quotas. They aren't counted in usage.
+	// +optional
+	Refused *QuotaRefusedUsage `json:"refused,omitempty"`
+}
+
+type QuotaRefusedUsage struct {
+	// Number of refused databases
+	Databases int `json:"databases"`
+	// Number of refused login roles
+	LoginRoles int `json:"loginRoles"`
+	// Number of refused publications
+	Publications int `json:"publications"`
+	// Number of refused subscriptions
+	ReplicationSlots int `json:"replicationSlots"`
+}
+
+type UserConnectionHealth struct {
+	// Connection type
+	Type UserConnectionType `json:"type"`
+	// Index in connection list (replica and replica bouncer connections only)
+	// +optional
+	Index int `json:"index,omitempty"`
+	// Hostname
+	Host string `json:"host"`
+	// Port
+	Port int `json:"port"`
+	// True if operator was able to connect with engine user
+	Reachable bool `json:"reachable"`
+	// Connection and ping latency in milliseconds
+	// +optional
+	LatencyMilliseconds int64 `json:"latencyMilliseconds,omitempty"`
+	// Result of pg_is_in_recovery() on endpoint
+	// +optional
+	InRecovery *bool `json:"inRecovery,omitempty"`
+	// Time since last replayed transaction in milliseconds when endpoint is in recovery
+	// +optional
+	ReplicationLagMilliseconds *int64 `json:"replicationLagMilliseconds,omitempty"`
+	// Human-readable message indicating why endpoint isn't reachable
+	// +optional
+	Message string `json:"message,omitempty"`
+}
+
+type EngineCapabilities struct {
+	// Server version number (server_version_num setting, like 150004)
+	ServerVersionNum int `json:"serverVersionNum"`
+	// Write ahead log level (wal_level setting)
+	WalLevel string `json:"walLevel"`
+	// Maximum number of connections (max_connections setting)
+	MaxConnections int `json:"maxConnections"`
+	// Attributes of the engine configuration user
+	AdminAttributes *EngineAdminAttributes `json:"adminAttributes"`
+	// Extensions available for installation
+	// +optional
+	AvailableExtensions []string `json:"availableExtensions,omitempty"`
+}
+
+type EngineAdminAttributes struct {
+	// Superuser attribute
+	Superuser bool `json:"superuser"`
+	// CREATEDB attribute
+	CreateDB bool `json:"createDB"`
+	// CREATEROLE attribute
+	CreateRole bool `json:"createRole"`
+	// REPLICATION attribute
+	Replication bool `json:"replication"`
 }
 
 //+kubebuilder:object:root=true
@@ -142,6 +451,18 @@ type PostgresqlEngineConfiguration struct {
 	Status PostgresqlEngineConfigurationStatus `json:"status,omitempty"`
 }
 
+func (c *PostgresqlEngineConfiguration) GetEngineSpec() *PostgresqlEngineConfigurationSpec {
+	return &c.Spec
+}
+
+func (c *PostgresqlEngineConfiguration) GetEngineStatus() *PostgresqlEngineConfigurationStatus {
+	return &c.Status
+}
+
+func (c *PostgresqlEngineConfiguration) ToPostgresqlEngineConfiguration() *PostgresqlEngineConfiguration {
+	return c
+}
+
 //+kubebuilder:object:root=true
 
 // PostgresqlEngineConfigurationList contains a list of PostgresqlEngineConfiguration.
@@ -45,6 +45,11 @@ type PostgresqlPublicationSpec struct {
 	// Default value will be "pgoutput"
 	// +optional
 	ReplicationSlotPlugin string `json:"replicationSlotPlugin,omitempty"`
+	// Replication user name used by subscriptions to consume publication
+	// This user will only have replication attribute and select privilege on published tables
+	// Default value will be the publication name suffixed by "_replication"
+	// +optional
+	ReplicationUsername string `json:"replicationUsername,omitempty"`
 	// Should drop database on Custom Resource deletion ?
 	// +optional
 	DropOnDelete bool `json:"dropOnDelete,omitempty"`
@@ -66,10 +71,12 @@ type PostgresqlPublicationSpec struct {
 
 type PostgresqlPublicationTable struct {
 	// Table name to use for publication
+	// Can be prefixed by its schema (schema.table)
 	TableName string `json:"tableName"`
 	// Columns to export
 	Columns *[]string `json:"columns,omitempty"`
 	// Additional WHERE for table
+	// Note: This is a raw SQL expression, it isn't escaped
 	AdditionalWhere *string `json:"additionalWhere,omitempty"`
 }
 
@@ -98,6 +105,9 @@ type PostgresqlPublicationStatus struct {
 	// Human-readable message indicating details about current operator phase or error.
 	// +optional
 	Message string `json:"message"`
+	// Machine-readable reason of current operator error (like "Forbidden" when a reference isn't allowed).
+	// +optional
+	Reason string `json:"reason,omitempty"`
 	// True if all resources are in a ready state and all work is done.
 	// +optional
 	Ready bool `json:"ready"`
@@ -110,12 +120,21 @@ type PostgresqlPublicationStatus struct {
 	// Created replication slot plugin
 	// +optional
 	ReplicationSlotPlugin string `json:"replicationSlotPlugin,omitempty"`
+	// Created replication user name
+	// +optional
+	ReplicationUsername string `json:"replicationUsername,omitempty"`
+	// Secret containing replication user credentials
+	// +optional
+	ReplicationUserSecretName string `json:"replicationUserSecretName,omitempty"`
 	// Marker for save
 	// +optional
 	AllTables *bool `json:"allTables,omitempty"`
 	// Resource Spec hash
 	// +optional
 	Hash string `json:"hash,omitempty"`
+	// Last plan computed when plan mode is enabled
+	// +optional
+	Plan *PlanStatus `json:"plan,omitempty"`
 }
 
 //+kubebuilder:object:root=true
@@ -0,0 +1,142 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"github.com/easymile/postgresql-operator/api/postgresql/common"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// EDIT THIS FILE!  THIS IS SCAFFOLDING FOR YOU TO OWN!
+// NOTE: json tags are required.  Any new fields you add must have json tags for the fields to be serialized.
+
+// PostgresqlSubscriptionSpec defines the desired state of PostgresqlSubscription.
+type PostgresqlSubscriptionSpec struct {
+	// INSERT ADDITIONAL SPEC FIELDS - desired state of cluster
+	// Important: Run "make" to regenerate code after modifying this file
+
+	// Postgresql Publication to consume
+	// +required
+	// +kubebuilder:validation:Required
+	Publication *common.CRLink `json:"publication"`
+	// Postgresql Database in which subscription will be created
+	// +required
+	// +kubebuilder:validation:Required
+	Database *common.CRLink `json:"database"`
+	// Postgresql Subscription name
+	// +required
+	// +kubebuilder:validation:Required
+	Name string `json:"name"`
+	// Should drop subscription on Custom Resource deletion ?
+	// +optional
+	DropOnDelete bool `json:"dropOnDelete,omitempty"`
+	// Subscription with parameters
+	// +optional
+	WithParameters *PostgresqlSubscriptionWith `json:"withParameters,omitempty"`
+}
+
+type PostgresqlSubscriptionWith struct {
+	// Enabled param
+	// Default value will be true
+	// See here: https://www.postgresql.org/docs/current/sql-createsubscription.html#SQL-CREATESUBSCRIPTION-PARAMS-WITH-ENABLED
+	// +optional
+	Enabled *bool `json:"enabled,omitempty"`
+	// Copy data param
+	// Note: This is only used on creation
+	// See here: https://www.postgresql.org/docs/current/sql-createsubscription.html#SQL-CREATESUBSCRIPTION-PARAMS-WITH-COPY-DATA
+	// +optional
+	CopyData *bool `json:"copyData,omitempty"`
+}
+
+type SubscriptionStatusPhase string
+
+const SubscriptionNoPhase SubscriptionStatusPhase = ""
+const SubscriptionFailedPhase SubscriptionStatusPhase = "Failed"
+const SubscriptionCreatedPhase SubscriptionStatusPhase = "Created"
+
+type SubscriptionState string
+
+const SubscriptionDisabledState SubscriptionState = "Disabled"
+const SubscriptionEnabledState SubscriptionState = "Enabled"
+const SubscriptionStreamingState SubscriptionState = "Streaming"
+
+// PostgresqlSubscriptionStatus defines the observed state of PostgresqlSubscription.
+type PostgresqlSubscriptionStatus struct {
+	// INSERT ADDITIONAL STATUS FIELD - define observed state of cluster
+	// Important: Run "make" to regenerate code after modifying this file
+
+	// Current phase of the operator
+	Phase SubscriptionStatusPhase `json:"phase"`
+	// Human-readable message indicating details about current operator phase or error.
+	// +optional
+	Message string `json:"message"`
+	// Machine-readable reason of current operator error (like "Forbidden" when a reference isn't allowed).
+	// +optional
+	Reason string `json:"reason,omitempty"`
+	// True if all resources are in a ready state and all work is done.
+	// +optional
+	Ready bool `json:"ready"`
+	// Created subscription name
+	// +optional
+	Name string `json:"name,omitempty"`
+	// Consumed publication name
+	// +optional
+	PublicationName string `json:"publicationName,omitempty"`
+	// Used replication slot name
+	// +optional
+	ReplicationSlotName string `json:"replicationSlotName,omitempty"`
+	// Subscription state as seen in engine
+	// +optional
+	State SubscriptionState `json:"state,omitempty"`
+	// Last write-ahead log location received by the subscription worker
+	// +optional
+	LastReceivedLSN string `json:"lastReceivedLsn,omitempty"`
+	// Resource Spec hash
+	// +optional
+	Hash string `json:"hash,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:subresource:status
+//+kubebuilder:resource:path=postgresqlsubscriptions,scope=Namespaced,shortName=pgsubscription;pgsub
+//+kubebuilder:printcolumn:name="Subscription",type=string,description="Subscription",JSONPath=".status.name"
+//+kubebuilder:printcolumn:name="Publication",type=string,description="Consumed publication",JSONPath=".status.publicationName"
+//+kubebuilder:printcolumn:name="State",type=string,description="Subscription state",JSONPath=".status.state"
+//+kubebuilder:printcolumn:name="Last received LSN",type=string,description="Last received LSN",JSONPath=".status.lastReceivedLsn"
+//+kubebuilder:printcolumn:name="Phase",type=string,description="Status phase",JSONPath=".status.phase"
+
+// PostgresqlSubscription is the Schema for the postgresqlsubscriptions API.
+type PostgresqlSubscription struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   PostgresqlSubscriptionSpec   `json:"spec,omitempty"`
+	Status PostgresqlSubscriptionStatus `json:"status,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// PostgresqlSubscriptionList contains a list of PostgresqlSubscription.
+type PostgresqlSubscriptionList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []PostgresqlSubscription `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&PostgresqlSubscription{}, &PostgresqlSubscriptionList{})
+}
@@ -29,6 +29,7 @@ type PrivilegesSpecEnum string
 const OwnerPrivilege PrivilegesSpecEnum = "OWNER"
 const ReaderPrivilege PrivilegesSpecEnum = "READER"
 const WriterPrivilege PrivilegesSpecEnum = "WRITER"
+const ProfilePrivilege PrivilegesSpecEnum = "PROFILE"
 
 type ConnectionTypesSpecEnum string
 
@@ -45,8 +46,11 @@ type PostgresqlUserRolePrivilege struct {
 	// User privileges
 	// +required
 	// +kubebuilder:validation:Required
-	// +kubebuilder:validation:Enum=OWNER;WRITER;READER
+	// +kubebuilder:validation:Enum=OWNER;WRITER;READER;PROFILE
 	Privilege PrivilegesSpecEnum `json:"privilege"`
+	// Privilege profile name of database. This must be set when privilege is PROFILE.
+	// +optional
+	Profile string `json:"profile,omitempty"`
 	// Postgresql Database
 	// +required
 	// +kubebuilder:validation:Required
@@ -127,6 +131,9 @@ type PostgresqlUserRoleStatus struct {
 	// Human-readable message indicating details about current operator phase or error.
 	// +optional
 	Message string `json:"message"`
+	// Machine-readable reason of current operator error (like "Forbidden" when a reference isn't allowed).
+	// +optional
+	Reason string `json:"reason,omitempty"`
 	// True if all resources are in a ready state and all work is done.
 	// +optional
 	Ready bool `json:"ready"`
@@ -142,6 +149,9 @@ type PostgresqlUserRoleStatus struct {
 	// Last password changed time
 	// +optional
 	LastPasswordChangedTime string `json:"lastPasswordChangedTime"`
+	// Last plan computed when plan mode is enabled
+	// +optional
+	Plan *PlanStatus `json:"plan,omitempty"`
 }
 
 //+kubebuilder:object:root=true
@@ -22,40 +22,503 @@ package v1alpha1
 
 import (
 	"github.com/easymile/postgresql-operator/api/postgresql/common"
-	runtime "k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
 )
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AdminPasswordRotation) DeepCopyInto(out *AdminPasswordRotation) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AdminPasswordRotation.
+func (in *AdminPasswordRotation) DeepCopy() *AdminPasswordRotation {
+	if in == nil {
+		return nil
+	}
+	out := new(AdminPasswordRotation)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClusterPostgresqlEngineConfiguration) DeepCopyInto(out *ClusterPostgresqlEngineConfiguration) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterPostgresqlEngineConfiguration.
+func (in *ClusterPostgresqlEngineConfiguration) DeepCopy() *ClusterPostgresqlEngineConfiguration {
+	if in == nil {
+		return nil
+	}
+	out := new(ClusterPostgresqlEngineConfiguration)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ClusterPostgresqlEngineConfiguration) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClusterPostgresqlEngineConfigurationList) DeepCopyInto(out *ClusterPostgresqlEngineConfigurationList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]ClusterPostgresqlEngineConfiguration, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterPostgresqlEngineConfigurationList.
+func (in *ClusterPostgresqlEngineConfigurationList) DeepCopy() *ClusterPostgresqlEngineConfigurationList {
+	if in == nil {
+		return nil
+	}
+	out := new(ClusterPostgresqlEngineConfigurationList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ClusterPostgresqlEngineConfigurationList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CredentialSource) DeepCopyInto(out *CredentialSource) {
+	*out = *in
+	if in.Exec != nil {
+		in, out := &in.Exec, &out.Exec
+		*out = new(ExecCredentialSource)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CredentialSource.
+func (in *CredentialSource) DeepCopy() *CredentialSource {
+	if in == nil {
+		return nil
+	}
+	out := new(CredentialSource)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DatabaseConnectionsStatus) DeepCopyInto(out *DatabaseConnectionsStatus) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DatabaseConnectionsStatus.
+func (in *DatabaseConnectionsStatus) DeepCopy() *DatabaseConnectionsStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(DatabaseConnectionsStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DatabaseCreationOptions) DeepCopyInto(out *DatabaseCreationOptions) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DatabaseCreationOptions.
+func (in *DatabaseCreationOptions) DeepCopy() *DatabaseCreationOptions {
+	if in == nil {
+		return nil
+	}
+	out := new(DatabaseCreationOptions)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DatabaseCreationOptionsStatus) DeepCopyInto(out *DatabaseCreationOptionsStatus) {
+	*out = *in
+	if in.Drifts != nil {
+		in, out := &in.Drifts, &out.Drifts
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DatabaseCreationOptionsStatus.
+func (in *DatabaseCreationOptionsStatus) DeepCopy() *DatabaseCreationOptionsStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(DatabaseCreationOptionsStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *DatabaseModulesList) DeepCopyInto(out *DatabaseModulesList) {
 	*out = *in
-	if in.List != nil {
-		in, out := &in.List, &out.List
+	if in.List != nil {
+		in, out := &in.List, &out.List
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DatabaseModulesList.
+func (in *DatabaseModulesList) DeepCopy() *DatabaseModulesList {
+	if in == nil {
+		return nil
+	}
+	out := new(DatabaseModulesList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DatabasePrivilegeProfile) DeepCopyInto(out *DatabasePrivilegeProfile) {
+	*out = *in
+	if in.Tables != nil {
+		in, out := &in.Tables, &out.Tables
+		*out = make([]TablePrivilege, len(*in))
+		copy(*out, *in)
+	}
+	if in.Sequences != nil {
+		in, out := &in.Sequences, &out.Sequences
+		*out = make([]SequencePrivilege, len(*in))
+		copy(*out, *in)
+	}
+	if in.Functions != nil {
+		in, out := &in.Functions, &out.Functions
+		*out = make([]FunctionPrivilege, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DatabasePrivilegeProfile.
+func (in *DatabasePrivilegeProfile) DeepCopy() *DatabasePrivilegeProfile {
+	if in == nil {
+		return nil
+	}
+	out := new(DatabasePrivilegeProfile)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DatabaseRoleSettings) DeepCopyInto(out *DatabaseRoleSettings) {
+	*out = *in
+	if in.Owner != nil {
+		in, out := &in.Owner, &out.Owner
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.Reader != nil {
+		in, out := &in.Reader, &out.Reader
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.Writer != nil {
+		in, out := &in.Writer, &out.Writer
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DatabaseRoleSettings.
+func (in *DatabaseRoleSettings) DeepCopy() *DatabaseRoleSettings {
+	if in == nil {
+		return nil
+	}
+	out := new(DatabaseRoleSettings)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *EngineAdminAttributes) DeepCopyInto(out *EngineAdminAttributes) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EngineAdminAttributes.
+func (in *EngineAdminAttributes) DeepCopy() *EngineAdminAttributes {
+	if in == nil {
+		return nil
+	}
+	out := new(EngineAdminAttributes)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *EngineCapabilities) DeepCopyInto(out *EngineCapabilities) {
+	*out = *in
+	if in.AdminAttributes != nil {
+		in, out := &in.AdminAttributes, &out.AdminAttributes
+		*out = new(EngineAdminAttributes)
+		**out = **in
+	}
+	if in.AvailableExtensions != nil {
+		in, out := &in.AvailableExtensions, &out.AvailableExtensions
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EngineCapabilities.
+func (in *EngineCapabilities) DeepCopy() *EngineCapabilities {
+	if in == nil {
+		return nil
+	}
+	out := new(EngineCapabilities)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *EngineQuotaUsage) DeepCopyInto(out *EngineQuotaUsage) {
+	*out = *in
+	if in.Refused != nil {
+		in, out := &in.Refused, &out.Refused
+		*out = new(QuotaRefusedUsage)
+		**out = **in
+	}
+	if in.Namespaces != nil {
+		in, out := &in.Namespaces, &out.Namespaces
+		*out = make([]*NamespaceQuotaUsage, len(*in))
+		for i := range *in {
+			if (*in)[i] != nil {
+				in, out := &(*in)[i], &(*out)[i]
+				*out = new(NamespaceQuotaUsage)
+				(*in).DeepCopyInto(*out)
+			}
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EngineQuotaUsage.
+func (in *EngineQuotaUsage) DeepCopy() *EngineQuotaUsage {
+	if in == nil {
+		return nil
+	}
+	out := new(EngineQuotaUsage)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *EngineQuotas) DeepCopyInto(out *EngineQuotas) {
+	*out = *in
+	if in.MaxDatabases != nil {
+		in, out := &in.MaxDatabases, &out.MaxDatabases
+		*out = new(int)
+		**out = **in
+	}
+	if in.MaxLoginRoles != nil {
+		in, out := &in.MaxLoginRoles, &out.MaxLoginRoles
+		*out = new(int)
+		**out = **in
+	}
+	if in.MaxPublications != nil {
+		in, out := &in.MaxPublications, &out.MaxPublications
+		*out = new(int)
+		**out = **in
+	}
+	if in.MaxReplicationSlots != nil {
+		in, out := &in.MaxReplicationSlots, &out.MaxReplicationSlots
+		*out = new(int)
+		**out = **in
+	}
+	if in.NamespaceSharePercent != nil {
+		in, out := &in.NamespaceSharePercent, &out.NamespaceSharePercent
+		*out = new(int)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EngineQuotas.
+func (in *EngineQuotas) DeepCopy() *EngineQuotas {
+	if in == nil {
+		return nil
+	}
+	out := new(EngineQuotas)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *EngineServerIdentity) DeepCopyInto(out *EngineServerIdentity) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EngineServerIdentity.
+func (in *EngineServerIdentity) DeepCopy() *EngineServerIdentity {
+	if in == nil {
+		return nil
+	}
+	out := new(EngineServerIdentity)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *EngineTLS) DeepCopyInto(out *EngineTLS) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EngineTLS.
+func (in *EngineTLS) DeepCopy() *EngineTLS {
+	if in == nil {
+		return nil
+	}
+	out := new(EngineTLS)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ExecCredentialSource) DeepCopyInto(out *ExecCredentialSource) {
+	*out = *in
+	if in.Args != nil {
+		in, out := &in.Args, &out.Args
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Env != nil {
+		in, out := &in.Env, &out.Env
+		*out = make([]*ExecEnvVar, len(*in))
+		for i := range *in {
+			if (*in)[i] != nil {
+				in, out := &(*in)[i], &(*out)[i]
+				*out = new(ExecEnvVar)
+				**out = **in
+			}
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ExecCredentialSource.
+func (in *ExecCredentialSource) DeepCopy() *ExecCredentialSource {
+	if in == nil {
+		return nil
+	}
+	out := new(ExecCredentialSource)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ExecEnvVar) DeepCopyInto(out *ExecEnvVar) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ExecEnvVar.
+func (in *ExecEnvVar) DeepCopy() *ExecEnvVar {
+	if in == nil {
+		return nil
+	}
+	out := new(ExecEnvVar)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GenericUserConnection) DeepCopyInto(out *GenericUserConnection) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GenericUserConnection.
+func (in *GenericUserConnection) DeepCopy() *GenericUserConnection {
+	if in == nil {
+		return nil
+	}
+	out := new(GenericUserConnection)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NamespaceQuotaUsage) DeepCopyInto(out *NamespaceQuotaUsage) {
+	*out = *in
+	if in.Refused != nil {
+		in, out := &in.Refused, &out.Refused
+		*out = new(QuotaRefusedUsage)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NamespaceQuotaUsage.
+func (in *NamespaceQuotaUsage) DeepCopy() *NamespaceQuotaUsage {
+	if in == nil {
+		return nil
+	}
+	out := new(NamespaceQuotaUsage)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PlanStatus) DeepCopyInto(out *PlanStatus) {
+	*out = *in
+	if in.PendingStatements != nil {
+		in, out := &in.PendingStatements, &out.PendingStatements
 		*out = make([]string, len(*in))
 		copy(*out, *in)
 	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DatabaseModulesList.
-func (in *DatabaseModulesList) DeepCopy() *DatabaseModulesList {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PlanStatus.
+func (in *PlanStatus) DeepCopy() *PlanStatus {
 	if in == nil {
 		return nil
 	}
-	out := new(DatabaseModulesList)
+	out := new(PlanStatus)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *GenericUserConnection) DeepCopyInto(out *GenericUserConnection) {
+func (in *PoolSettings) DeepCopyInto(out *PoolSettings) {
 	*out = *in
+	if in.MaxIdleConnections != nil {
+		in, out := &in.MaxIdleConnections, &out.MaxIdleConnections
+		*out = new(int)
+		**out = **in
+	}
+	if in.MaxTotalConnections != nil {
+		in, out := &in.MaxTotalConnections, &out.MaxTotalConnections
+		*out = new(int)
+		**out = **in
+	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GenericUserConnection.
-func (in *GenericUserConnection) DeepCopy() *GenericUserConnection {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PoolSettings.
+func (in *PoolSettings) DeepCopy() *PoolSettings {
 	if in == nil {
 		return nil
 	}
-	out := new(GenericUserConnection)
+	out := new(PoolSettings)
 	in.DeepCopyInto(out)
 	return out
 }
@@ -126,9 +589,57 @@ func (in *PostgresqlDatabaseSpec) DeepCopyInto(out *PostgresqlDatabaseSpec) {
 	in.Extensions.DeepCopyInto(&out.Extensions)
 	if in.EngineConfiguration != nil {
 		in, out := &in.EngineConfiguration, &out.EngineConfiguration
-		*out = new(common.CRLink)
+		*out = new(common.EngineCRLink)
+		**out = **in
+	}
+	if in.AllowedNamespaces != nil {
+		in, out := &in.AllowedNamespaces, &out.AllowedNamespaces
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.AllowedNamespaceSelector != nil {
+		in, out := &in.AllowedNamespaceSelector, &out.AllowedNamespaceSelector
+		*out = new(v1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.CreationOptions != nil {
+		in, out := &in.CreationOptions, &out.CreationOptions
+		*out = new(DatabaseCreationOptions)
+		**out = **in
+	}
+	if in.Settings != nil {
+		in, out := &in.Settings, &out.Settings
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.RoleSettings != nil {
+		in, out := &in.RoleSettings, &out.RoleSettings
+		*out = new(DatabaseRoleSettings)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ConnectionLimit != nil {
+		in, out := &in.ConnectionLimit, &out.ConnectionLimit
+		*out = new(int)
+		**out = **in
+	}
+	if in.AllowConnections != nil {
+		in, out := &in.AllowConnections, &out.AllowConnections
+		*out = new(bool)
 		**out = **in
 	}
+	if in.PrivilegeProfiles != nil {
+		in, out := &in.PrivilegeProfiles, &out.PrivilegeProfiles
+		*out = make([]*DatabasePrivilegeProfile, len(*in))
+		for i := range *in {
+			if (*in)[i] != nil {
+				in, out := &(*in)[i], &(*out)[i]
+				*out = new(DatabasePrivilegeProfile)
+				(*in).DeepCopyInto(*out)
+			}
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PostgresqlDatabaseSpec.
@@ -144,7 +655,7 @@ func (in *PostgresqlDatabaseSpec) DeepCopy() *PostgresqlDatabaseSpec {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *PostgresqlDatabaseStatus) DeepCopyInto(out *PostgresqlDatabaseStatus) {
 	*out = *in
-	out.Roles = in.Roles
+	in.Roles.DeepCopyInto(&out.Roles)
 	if in.Schemas != nil {
 		in, out := &in.Schemas, &out.Schemas
 		*out = make([]string, len(*in))
@@ -155,6 +666,33 @@ func (in *PostgresqlDatabaseStatus) DeepCopyInto(out *PostgresqlDatabaseStatus)
 		*out = make([]string, len(*in))
 		copy(*out, *in)
 	}
+	if in.Plan != nil {
+		in, out := &in.Plan, &out.Plan
+		*out = new(PlanStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.CreationOptions != nil {
+		in, out := &in.CreationOptions, &out.CreationOptions
+		*out = new(DatabaseCreationOptionsStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Settings != nil {
+		in, out := &in.Settings, &out.Settings
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.RoleSettings != nil {
+		in, out := &in.RoleSettings, &out.RoleSettings
+		*out = new(DatabaseRoleSettings)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Connections != nil {
+		in, out := &in.Connections, &out.Connections
+		*out = new(DatabaseConnectionsStatus)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PostgresqlDatabaseStatus.
@@ -173,7 +711,7 @@ func (in *PostgresqlEngineConfiguration) DeepCopyInto(out *PostgresqlEngineConfi
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
 	in.Spec.DeepCopyInto(&out.Spec)
-	out.Status = in.Status
+	in.Status.DeepCopyInto(&out.Status)
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PostgresqlEngineConfiguration.
@@ -229,11 +767,46 @@ func (in *PostgresqlEngineConfigurationList) DeepCopyObject() runtime.Object {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *PostgresqlEngineConfigurationSpec) DeepCopyInto(out *PostgresqlEngineConfigurationSpec) {
 	*out = *in
+	if in.CredentialSource != nil {
+		in, out := &in.CredentialSource, &out.CredentialSource
+		*out = new(CredentialSource)
+		(*in).DeepCopyInto(*out)
+	}
 	if in.UserConnections != nil {
 		in, out := &in.UserConnections, &out.UserConnections
 		*out = new(UserConnections)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.Pool != nil {
+		in, out := &in.Pool, &out.Pool
+		*out = new(PoolSettings)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.TLS != nil {
+		in, out := &in.TLS, &out.TLS
+		*out = new(EngineTLS)
+		**out = **in
+	}
+	if in.AllowedNamespaces != nil {
+		in, out := &in.AllowedNamespaces, &out.AllowedNamespaces
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.AllowedNamespaceSelector != nil {
+		in, out := &in.AllowedNamespaceSelector, &out.AllowedNamespaceSelector
+		*out = new(v1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.AdminPasswordRotation != nil {
+		in, out := &in.AdminPasswordRotation, &out.AdminPasswordRotation
+		*out = new(AdminPasswordRotation)
+		**out = **in
+	}
+	if in.Quotas != nil {
+		in, out := &in.Quotas, &out.Quotas
+		*out = new(EngineQuotas)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PostgresqlEngineConfigurationSpec.
@@ -249,6 +822,39 @@ func (in *PostgresqlEngineConfigurationSpec) DeepCopy() *PostgresqlEngineConfigu
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *PostgresqlEngineConfigurationStatus) DeepCopyInto(out *PostgresqlEngineConfigurationStatus) {
 	*out = *in
+	if in.Capabilities != nil {
+		in, out := &in.Capabilities, &out.Capabilities
+		*out = new(EngineCapabilities)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.UserConnections != nil {
+		in, out := &in.UserConnections, &out.UserConnections
+		*out = make([]*UserConnectionHealth, len(*in))
+		for i := range *in {
+			if (*in)[i] != nil {
+				in, out := &(*in)[i], &(*out)[i]
+				*out = new(UserConnectionHealth)
+				(*in).DeepCopyInto(*out)
+			}
+		}
+	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]v1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.QuotaUsage != nil {
+		in, out := &in.QuotaUsage, &out.QuotaUsage
+		*out = new(EngineQuotaUsage)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ServerIdentity != nil {
+		in, out := &in.ServerIdentity, &out.ServerIdentity
+		*out = new(EngineServerIdentity)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PostgresqlEngineConfigurationStatus.
@@ -369,6 +975,11 @@ func (in *PostgresqlPublicationStatus) DeepCopyInto(out *PostgresqlPublicationSt
 		*out = new(bool)
 		**out = **in
 	}
+	if in.Plan != nil {
+		in, out := &in.Plan, &out.Plan
+		*out = new(PlanStatus)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PostgresqlPublicationStatus.
@@ -430,6 +1041,135 @@ func (in *PostgresqlPublicationWith) DeepCopy() *PostgresqlPublicationWith {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PostgresqlSubscription) DeepCopyInto(out *PostgresqlSubscription) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	out.Status = in.Status
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PostgresqlSubscription.
+func (in *PostgresqlSubscription) DeepCopy() *PostgresqlSubscription {
+	if in == nil {
+		return nil
+	}
+	out := new(PostgresqlSubscription)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *PostgresqlSubscription) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PostgresqlSubscriptionList) DeepCopyInto(out *PostgresqlSubscriptionList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]PostgresqlSubscription, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PostgresqlSubscriptionList.
+func (in *PostgresqlSubscriptionList) DeepCopy() *PostgresqlSubscriptionList {
+	if in == nil {
+		return nil
+	}
+	out := new(PostgresqlSubscriptionList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *PostgresqlSubscriptionList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PostgresqlSubscriptionSpec) DeepCopyInto(out *PostgresqlSubscriptionSpec) {
+	*out = *in
+	if in.Publication != nil {
+		in, out := &in.Publication, &out.Publication
+		*out = new(common.CRLink)
+		**out = **in
+	}
+	if in.Database != nil {
+		in, out := &in.Database, &out.Database
+		*out = new(common.CRLink)
+		**out = **in
+	}
+	if in.WithParameters != nil {
+		in, out := &in.WithParameters, &out.WithParameters
+		*out = new(PostgresqlSubscriptionWith)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PostgresqlSubscriptionSpec.
+func (in *PostgresqlSubscriptionSpec) DeepCopy() *PostgresqlSubscriptionSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(PostgresqlSubscriptionSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PostgresqlSubscriptionStatus) DeepCopyInto(out *PostgresqlSubscriptionStatus) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PostgresqlSubscriptionStatus.
+func (in *PostgresqlSubscriptionStatus) DeepCopy() *PostgresqlSubscriptionStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(PostgresqlSubscriptionStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PostgresqlSubscriptionWith) DeepCopyInto(out *PostgresqlSubscriptionWith) {
+	*out = *in
+	if in.Enabled != nil {
+		in, out := &in.Enabled, &out.Enabled
+		*out = new(bool)
+		**out = **in
+	}
+	if in.CopyData != nil {
+		in, out := &in.CopyData, &out.CopyData
+		*out = new(bool)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PostgresqlSubscriptionWith.
+func (in *PostgresqlSubscriptionWith) DeepCopy() *PostgresqlSubscriptionWith {
+	if in == nil {
+		return nil
+	}
+	out := new(PostgresqlSubscriptionWith)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *PostgresqlUserRole) DeepCopyInto(out *PostgresqlUserRole) {
 	*out = *in
@@ -585,6 +1325,11 @@ func (in *PostgresqlUserRoleStatus) DeepCopyInto(out *PostgresqlUserRoleStatus)
 		*out = make([]string, len(*in))
 		copy(*out, *in)
 	}
+	if in.Plan != nil {
+		in, out := &in.Plan, &out.Plan
+		*out = new(PlanStatus)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PostgresqlUserRoleStatus.
@@ -597,9 +1342,31 @@ func (in *PostgresqlUserRoleStatus) DeepCopy() *PostgresqlUserRoleStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *QuotaRefusedUsage) DeepCopyInto(out *QuotaRefusedUsage) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new QuotaRefusedUsage.
+func (in *QuotaRefusedUsage) DeepCopy() *QuotaRefusedUsage {
+	if in == nil {
+		return nil
+	}
+	out := new(QuotaRefusedUsage)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *StatusPostgresRoles) DeepCopyInto(out *StatusPostgresRoles) {
 	*out = *in
+	if in.Profiles != nil {
+		in, out := &in.Profiles, &out.Profiles
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new StatusPostgresRoles.
@@ -612,6 +1379,31 @@ func (in *StatusPostgresRoles) DeepCopy() *StatusPostgresRoles {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *UserConnectionHealth) DeepCopyInto(out *UserConnectionHealth) {
+	*out = *in
+	if in.InRecovery != nil {
+		in, out := &in.InRecovery, &out.InRecovery
+		*out = new(bool)
+		**out = **in
+	}
+	if in.ReplicationLagMilliseconds != nil {
+		in, out := &in.ReplicationLagMilliseconds, &out.ReplicationLagMilliseconds
+		*out = new(int64)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new UserConnectionHealth.
+func (in *UserConnectionHealth) DeepCopy() *UserConnectionHealth {
+	if in == nil {
+		return nil
+	}
+	out := new(UserConnectionHealth)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *UserConnections) DeepCopyInto(out *UserConnections) {
 	*out = *in
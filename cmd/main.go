@@ -17,9 +17,12 @@ limitations under the License.
 package main
 
 import (
+	"context"
 	"flag"
 	"fmt"
+	"io"
 	"os"
+	"strings"
 	"time"
 
 	// Import all Kubernetes client auth plugins (e.g. Azure, GCP, OIDC, etc.)
@@ -32,15 +35,27 @@ import (
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/healthz"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
 	"sigs.k8s.io/controller-runtime/pkg/metrics"
 
 	"github.com/prometheus/client_golang/prometheus"
+	"github.com/samber/lo"
 
 	postgresqlv1alpha1 "github.com/easymile/postgresql-operator/api/postgresql/v1alpha1"
+	"github.com/easymile/postgresql-operator/internal/controller/config"
 	postgresqlcontrollers "github.com/easymile/postgresql-operator/internal/controller/postgresql"
+	"github.com/easymile/postgresql-operator/internal/controller/postgresql/postgres"
+	postgresqlwebhooks "github.com/easymile/postgresql-operator/internal/webhook/postgresql"
 	//+kubebuilder:scaffold:imports
 )
 
+const (
+	auditSinkNone = "none"
+	auditSinkLog  = "log"
+	auditSinkFile = "file"
+	auditSinkHTTP = "http"
+)
+
 var (
 	scheme                              = runtime.NewScheme()
 	setupLog                            = ctrl.Log.WithName("setup")
@@ -56,6 +71,12 @@ var (
 func init() {
 	// Register custom metrics with the global prometheus registry
 	metrics.Registry.MustRegister(controllerRuntimeDetailedErrorTotal)
+	// Register pool metrics
+	metrics.Registry.MustRegister(postgres.GetPoolMetricsCollector())
+	// Register user connection endpoint metrics
+	metrics.Registry.MustRegister(postgres.GetEndpointMetricsCollector())
+	// Register audit sink metrics
+	metrics.Registry.MustRegister(postgres.GetAuditMetricsCollector())
 
 	utilruntime.Must(clientgoscheme.AddToScheme(scheme))
 
@@ -66,7 +87,19 @@ func init() {
 func main() {
 	var metricsAddr, probeAddr, resyncPeriodStr, reconcileTimeoutStr string
 
-	var enableLeaderElection bool
+	var auditSinkType, auditFilePath, auditHTTPURL, auditHTTPTimeoutStr string
+
+	var auditFileMaxSize int64
+
+	var auditFileMaxBackups, auditHTTPQueueSize int
+
+	var poolConnMaxLifetimeStr, poolIdleTimeoutStr, poolJanitorIntervalStr, tlsDirectory, execCredentialAllowedCommandsStr, operatorNamespace string
+
+	var execCredentialAllowedEnvStr, execCredentialTimeoutStr string
+
+	var poolMaxOpenConnections, poolMaxIdleConnections, poolMaxTotalConnections, scramIterations int
+
+	var enableLeaderElection, planMode, enableWebhooks bool
 
 	flag.StringVar(&metricsAddr, "metrics-bind-address", ":8080", "The address the metric endpoint binds to.")
 	flag.StringVar(&probeAddr, "health-probe-bind-address", ":8081", "The address the probe endpoint binds to.")
@@ -75,6 +108,44 @@ func main() {
 	flag.BoolVar(&enableLeaderElection, "leader-elect", false,
 		"Enable leader election for controller manager. "+
 			"Enabling this will ensure there is only one active controller manager.")
+	flag.BoolVar(&planMode, "plan-mode", false,
+		"Enable plan mode for all resources. "+
+			"In this mode, statements that would be executed on engines are reported in status and events instead of being executed.")
+	flag.StringVar(&auditSinkType, "audit-sink", auditSinkNone,
+		"The sink used to audit mutating statements executed on engines. One of none, log, file or http.")
+	flag.StringVar(&auditFilePath, "audit-file-path", "/tmp/postgresql-operator-audit.log", "The audit file path for file sink.")
+	flag.Int64Var(&auditFileMaxSize, "audit-file-max-size", 100*1024*1024, //nolint: gomnd // Default value
+		"The audit file size in bytes triggering a rotation for file sink. 0 disables rotation.")
+	flag.IntVar(&auditFileMaxBackups, "audit-file-max-backups", 3, "The number of rotated audit files kept for file sink.") //nolint: gomnd // Default value
+	flag.StringVar(&auditHTTPURL, "audit-http-url", "", "The endpoint receiving audit entries as JSON with a POST for http sink.")
+	flag.StringVar(&auditHTTPTimeoutStr, "audit-http-timeout", "5s", "The request timeout for http sink.")
+	flag.IntVar(&auditHTTPQueueSize, "audit-http-queue-size", 1000, //nolint: gomnd // Default value
+		"The number of audit entries waiting to be sent for http sink. Entries are dropped when queue is full.")
+	flag.IntVar(&poolMaxOpenConnections, "pool-max-open-connections", postgres.GetDefaultPoolSettings().MaxOpenConnections,
+		"The default maximum number of open connections per engine database pool.")
+	flag.IntVar(&poolMaxIdleConnections, "pool-max-idle-connections", postgres.GetDefaultPoolSettings().MaxIdleConnections,
+		"The default maximum number of idle connections per engine database pool.")
+	flag.IntVar(&poolMaxTotalConnections, "pool-max-total-connections", postgres.GetDefaultPoolSettings().MaxTotalConnections,
+		"The default maximum number of open connections across all database pools of an engine. 0 means no limit.")
+	flag.StringVar(&poolConnMaxLifetimeStr, "pool-conn-max-lifetime", postgres.GetDefaultPoolSettings().ConnMaxLifetime.String(),
+		"The default maximum lifetime of a pool connection. 0s means no limit.")
+	flag.StringVar(&poolIdleTimeoutStr, "pool-idle-timeout", postgres.GetDefaultPoolSettings().IdleTimeout.String(),
+		"The default duration after which an unused database pool is closed. 0s disables it.")
+	flag.IntVar(&scramIterations, "scram-iterations", postgres.DefaultScramIterations,
+		"The default iteration count used for SCRAM-SHA-256 password verifiers computed by operator.")
+	flag.StringVar(&poolJanitorIntervalStr, "pool-janitor-interval", "1m", "The interval between two checks for unused database pools.")
+	flag.StringVar(&tlsDirectory, "tls-directory", "/tmp/postgresql-operator-tls",
+		"The directory where engine client certificates, keys and CA bundles are written for connections.")
+	flag.StringVar(&execCredentialAllowedCommandsStr, "exec-credential-allowed-commands", "",
+		"The comma separated list of commands allowed for cluster engine exec credential plugins. Empty disables exec credential plugins.")
+	flag.StringVar(&execCredentialAllowedEnvStr, "exec-credential-allowed-env", "",
+		"The comma separated list of environment variable names that engine exec credential plugins can set. Empty disables them.")
+	flag.StringVar(&execCredentialTimeoutStr, "exec-credential-timeout", postgres.DefaultExecCredentialTimeout.String(),
+		"The maximum duration of an engine exec credential plugin command.")
+	flag.StringVar(&operatorNamespace, "operator-namespace", os.Getenv("POD_NAMESPACE"),
+		"The operator namespace where cluster engine configuration secrets are read. Defaults to POD_NAMESPACE environment variable.")
+	flag.BoolVar(&enableWebhooks, "enable-webhooks", false,
+		"Enable validating webhooks checking that cross namespace references are allowed. Webhook server certificates are required.")
 
 	opts := zap.Options{
 		Development: false,
@@ -98,6 +169,118 @@ func main() {
 		setupLog.Error(err, "unable to parse reconcile timeout")
 		os.Exit(1)
 	}
+	// Parse duration
+	auditHTTPTimeout, err := time.ParseDuration(auditHTTPTimeoutStr)
+	// Check error
+	if err != nil {
+		setupLog.Error(err, "unable to parse audit http timeout")
+		os.Exit(1)
+	}
+	// Check queue size
+	if auditHTTPQueueSize <= 0 {
+		setupLog.Error(nil, "audit http queue size must be positive")
+		os.Exit(1)
+	}
+
+	// Parse duration
+	poolConnMaxLifetime, err := time.ParseDuration(poolConnMaxLifetimeStr)
+	// Check error
+	if err != nil {
+		setupLog.Error(err, "unable to parse pool connection max lifetime")
+		os.Exit(1)
+	}
+	// Parse duration
+	poolIdleTimeout, err := time.ParseDuration(poolIdleTimeoutStr)
+	// Check error
+	if err != nil {
+		setupLog.Error(err, "unable to parse pool idle timeout")
+		os.Exit(1)
+	}
+	// Parse duration
+	poolJanitorInterval, err := time.ParseDuration(poolJanitorIntervalStr)
+	// Check value
+	if err == nil && poolJanitorInterval <= 0 {
+		err = fmt.Errorf("pool janitor interval must be positive")
+	}
+	// Check error
+	if err != nil {
+		setupLog.Error(err, "unable to parse pool janitor interval")
+		os.Exit(1)
+	}
+	// Parse duration
+	execCredentialTimeout, err := time.ParseDuration(execCredentialTimeoutStr)
+	// Check value
+	if err == nil && execCredentialTimeout <= 0 {
+		err = fmt.Errorf("exec credential timeout must be positive")
+	}
+	// Check error
+	if err != nil {
+		setupLog.Error(err, "unable to parse exec credential timeout")
+		os.Exit(1)
+	}
+
+	// Set operator wide pool settings
+	postgres.SetDefaultPoolSettings(&postgres.PoolSettings{
+		MaxOpenConnections:  poolMaxOpenConnections,
+		MaxIdleConnections:  poolMaxIdleConnections,
+		ConnMaxLifetime:     poolConnMaxLifetime,
+		IdleTimeout:         poolIdleTimeout,
+		MaxTotalConnections: poolMaxTotalConnections,
+	})
+
+	// Check value
+	if scramIterations <= 0 {
+		setupLog.Error(nil, "scram iterations must be positive")
+		os.Exit(1)
+	}
+	// Set operator wide scram iterations
+	postgres.SetDefaultScramIterations(scramIterations)
+	// Set operator wide TLS directory
+	postgres.SetTLSDirectory(tlsDirectory)
+	// Set operator wide allowed exec credential commands
+	postgres.SetAllowedCredentialCommands(lo.Compact(strings.Split(execCredentialAllowedCommandsStr, ",")))
+	// Set operator wide allowed exec credential environment variable names
+	postgres.SetAllowedCredentialEnvNames(lo.Compact(strings.Split(execCredentialAllowedEnvStr, ",")))
+	// Set operator wide exec credential timeout
+	postgres.SetExecCredentialTimeout(execCredentialTimeout)
+	// Set operator namespace
+	config.SetOperatorNamespace(operatorNamespace)
+
+	// Audit sink to close on exit
+	var auditSinkCloser io.Closer
+
+	// Create audit sink
+	switch auditSinkType {
+	case auditSinkNone:
+	case auditSinkLog:
+		postgres.SetAuditSink(postgres.NewLogAuditSink(ctrl.Log.WithName("audit")))
+	case auditSinkFile:
+		fileSink, err := postgres.NewFileAuditSink(auditFilePath, auditFileMaxSize, auditFileMaxBackups)
+		// Check error
+		if err != nil {
+			setupLog.Error(err, "unable to open audit file")
+			os.Exit(1)
+		}
+
+		auditSinkCloser = fileSink
+
+		postgres.SetAuditSink(fileSink)
+	case auditSinkHTTP:
+		// Check url
+		if auditHTTPURL == "" {
+			setupLog.Error(nil, "audit http url must be set with http audit sink")
+			os.Exit(1)
+		}
+
+		httpSink := postgres.NewHTTPAuditSink(auditHTTPURL, auditHTTPTimeout, auditHTTPQueueSize, ctrl.Log.WithName("audit"))
+		auditSinkCloser = httpSink
+
+		postgres.SetAuditSink(httpSink)
+	default:
+		setupLog.Error(nil, fmt.Sprintf("unsupported audit sink %s", auditSinkType))
+		os.Exit(1)
+	}
+
 	// Log
 	setupLog.Info(fmt.Sprintf("Starting manager with %s resync period", resyncPeriodStr))
 
@@ -126,6 +309,15 @@ func main() {
 		os.Exit(1)
 	}
 
+	// Setup signal handler context
+	ctx := ctrl.SetupSignalHandler()
+
+	// Register field indexes used by controller watches
+	if err = postgresqlcontrollers.SetupIndexes(ctx, mgr); err != nil {
+		setupLog.Error(err, "unable to set up field indexes")
+		os.Exit(1)
+	}
+
 	if err = (&postgresqlcontrollers.PostgresqlEngineConfigurationReconciler{
 		Client:   mgr.GetClient(),
 		Scheme:   mgr.GetScheme(),
@@ -146,6 +338,28 @@ func main() {
 		os.Exit(1)
 	}
 
+	if err = (&postgresqlcontrollers.ClusterPostgresqlEngineConfigurationReconciler{
+		PostgresqlEngineConfigurationReconciler: postgresqlcontrollers.PostgresqlEngineConfigurationReconciler{
+			Client:   mgr.GetClient(),
+			Scheme:   mgr.GetScheme(),
+			Recorder: mgr.GetEventRecorderFor("clusterpostgresqlengineconfiguration-controller"),
+			Log: ctrl.Log.WithValues(
+				"controller",
+				"clusterpostgresqlengineconfiguration",
+				"controllerKind",
+				"ClusterPostgresqlEngineConfiguration",
+				"controllerGroup",
+				"postgresql.easymile.com",
+			),
+			ControllerRuntimeDetailedErrorTotal: controllerRuntimeDetailedErrorTotal,
+			ControllerName:                      "clusterpostgresqlengineconfiguration",
+			ReconcileTimeout:                    reconcileTimeout,
+		},
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "ClusterPostgresqlEngineConfiguration")
+		os.Exit(1)
+	}
+
 	if err = (&postgresqlcontrollers.PostgresqlDatabaseReconciler{
 		Client:   mgr.GetClient(),
 		Scheme:   mgr.GetScheme(),
@@ -161,6 +375,7 @@ func main() {
 		ControllerRuntimeDetailedErrorTotal: controllerRuntimeDetailedErrorTotal,
 		ControllerName:                      "postgresqldatabase",
 		ReconcileTimeout:                    reconcileTimeout,
+		PlanMode:                            planMode,
 	}).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "PostgresqlDatabase")
 		os.Exit(1)
@@ -181,6 +396,7 @@ func main() {
 		ControllerRuntimeDetailedErrorTotal: controllerRuntimeDetailedErrorTotal,
 		ControllerName:                      "postgresqluserrole",
 		ReconcileTimeout:                    reconcileTimeout,
+		PlanMode:                            planMode,
 	}).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "PostgresqlUserRole")
 		os.Exit(1)
@@ -201,12 +417,66 @@ func main() {
 		ControllerRuntimeDetailedErrorTotal: controllerRuntimeDetailedErrorTotal,
 		ControllerName:                      "postgresqlpublication",
 		ReconcileTimeout:                    reconcileTimeout,
+		PlanMode:                            planMode,
 	}).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "PostgresqlPublication")
 		os.Exit(1)
 	}
+
+	if err = (&postgresqlcontrollers.PostgresqlSubscriptionReconciler{
+		Client:   mgr.GetClient(),
+		Scheme:   mgr.GetScheme(),
+		Recorder: mgr.GetEventRecorderFor("postgresqlsubscription-controller"),
+		Log: ctrl.Log.WithValues(
+			"controller",
+			"postgresqlsubscription",
+			"controllerKind",
+			"PostgresqlSubscription",
+			"controllerGroup",
+			"postgresql.easymile.com",
+		),
+		ControllerRuntimeDetailedErrorTotal: controllerRuntimeDetailedErrorTotal,
+		ControllerName:                      "postgresqlsubscription",
+		ReconcileTimeout:                    reconcileTimeout,
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "PostgresqlSubscription")
+		os.Exit(1)
+	}
 	//+kubebuilder:scaffold:builder
 
+	// Check if webhooks are enabled
+	if enableWebhooks {
+		if err = (&postgresqlwebhooks.PostgresqlDatabaseValidator{Client: mgr.GetClient()}).SetupWebhookWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create webhook", "webhook", "PostgresqlDatabase")
+			os.Exit(1)
+		}
+
+		if err = (&postgresqlwebhooks.PostgresqlUserRoleValidator{Client: mgr.GetClient()}).SetupWebhookWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create webhook", "webhook", "PostgresqlUserRole")
+			os.Exit(1)
+		}
+
+		if err = (&postgresqlwebhooks.PostgresqlPublicationValidator{Client: mgr.GetClient()}).SetupWebhookWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create webhook", "webhook", "PostgresqlPublication")
+			os.Exit(1)
+		}
+
+		if err = (&postgresqlwebhooks.PostgresqlSubscriptionValidator{Client: mgr.GetClient()}).SetupWebhookWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create webhook", "webhook", "PostgresqlSubscription")
+			os.Exit(1)
+		}
+	}
+
+	// Add pool janitor
+	if err := mgr.Add(manager.RunnableFunc(func(ctx context.Context) error {
+		postgres.RunPoolJanitor(ctx, poolJanitorInterval, ctrl.Log.WithName("pool-janitor"))
+
+		return nil
+	})); err != nil {
+		setupLog.Error(err, "unable to set up pool janitor")
+		os.Exit(1)
+	}
+
 	if err := mgr.AddHealthzCheck("healthz", healthz.Ping); err != nil {
 		setupLog.Error(err, "unable to set up health check")
 		os.Exit(1)
@@ -219,8 +489,18 @@ func main() {
 
 	setupLog.Info("starting manager")
 
-	if err := mgr.Start(ctrl.SetupSignalHandler()); err != nil {
+	if err := mgr.Start(ctx); err != nil {
 		setupLog.Error(err, "problem running manager")
 		os.Exit(1)
 	}
+
+	// Check if audit sink must be closed
+	if auditSinkCloser != nil {
+		// Flush queued entries
+		err = auditSinkCloser.Close()
+		// Check error
+		if err != nil {
+			setupLog.Error(err, "unable to close audit sink")
+		}
+	}
 }
@@ -1,3 +1,20 @@
 package config
 
 const Finalizer = "finalizer.postgresql.easymile.com"
+
+// PlanModeAnnotation can be set to "true" on a resource to enable plan mode on it.
+// In plan mode, mutating statements are reported in status and events instead of being executed.
+const PlanModeAnnotation = "postgresql.easymile.com/plan-mode"
+
+// Operator namespace used to read cluster engine configuration secrets.
+var operatorNamespace string
+
+// SetOperatorNamespace will set operator namespace used to read cluster engine configuration secrets.
+func SetOperatorNamespace(namespace string) {
+	operatorNamespace = namespace
+}
+
+// GetOperatorNamespace will return operator namespace used to read cluster engine configuration secrets.
+func GetOperatorNamespace() string {
+	return operatorNamespace
+}
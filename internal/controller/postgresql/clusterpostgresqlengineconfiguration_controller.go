@@ -0,0 +1,71 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postgresql
+
+import (
+	"context"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+
+	postgresqlv1alpha1 "github.com/easymile/postgresql-operator/api/postgresql/v1alpha1"
+)
+
+// ClusterPostgresqlEngineConfigurationReconciler reconciles a ClusterPostgresqlEngineConfiguration object.
+// It shares the validation loop of PostgresqlEngineConfiguration reconciler.
+type ClusterPostgresqlEngineConfigurationReconciler struct {
+	PostgresqlEngineConfigurationReconciler
+}
+
+//+kubebuilder:rbac:groups=postgresql.easymile.com,resources=clusterpostgresqlengineconfigurations,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=postgresql.easymile.com,resources=clusterpostgresqlengineconfigurations/status,verbs=get;update;patch
+//+kubebuilder:rbac:groups=postgresql.easymile.com,resources=clusterpostgresqlengineconfigurations/finalizers,verbs=update
+//+kubebuilder:rbac:groups=core,resources=secrets,verbs=get;list;watch;update
+//+kubebuilder:rbac:groups=core,resources=events,verbs=create;patch
+
+// Reconcile is part of the main kubernetes reconciliation loop which aims to
+// move the current state of the cluster closer to the desired state.
+func (r *ClusterPostgresqlEngineConfigurationReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) { //nolint:wsl // it is like that
+	reqLogger := r.Log.WithValues("Request.Name", req.Name)
+	reqLogger.Info("Reconciling ClusterPostgresqlEngineConfiguration")
+
+	// Fetch the ClusterPostgresqlEngineConfiguration instance
+	instance := &postgresqlv1alpha1.ClusterPostgresqlEngineConfiguration{}
+
+	err := r.Get(ctx, req.NamespacedName, instance)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			// Request object not found, could have been deleted after reconcile request.
+			// Return and don't requeue
+			return ctrl.Result{}, nil
+		}
+		// Error reading the object - requeue the request.
+		return ctrl.Result{}, err
+	}
+
+	return r.reconcileWithTimeout(ctx, reqLogger, instance)
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *ClusterPostgresqlEngineConfigurationReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&postgresqlv1alpha1.ClusterPostgresqlEngineConfiguration{}).
+		Watches(&corev1.Secret{}, handler.EnqueueRequestsFromMapFunc(newEngineSecretMapFunc(mgr.GetClient(), true))).
+		Complete(r)
+}
@@ -0,0 +1,273 @@
+package postgresql
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/go-logr/logr"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/easymile/postgresql-operator/api/postgresql/common"
+	postgresqlv1alpha1 "github.com/easymile/postgresql-operator/api/postgresql/v1alpha1"
+	"github.com/easymile/postgresql-operator/internal/controller/config"
+	"github.com/easymile/postgresql-operator/internal/controller/postgresql/postgres"
+	"github.com/easymile/postgresql-operator/internal/controller/utils"
+)
+
+var _ = Describe("ClusterPostgresqlEngineConfiguration tests with fake engine", Label(fakeEngineLabel), func() {
+	It("should be used by databases", func() {
+		operatorNamespace := "operator"
+		config.SetOperatorNamespace(operatorNamespace)
+		defer config.SetOperatorNamespace("")
+
+		// Database linked to cluster engine configuration
+		pgdb := newFakePGDB()
+		pgdb.Spec.EngineConfiguration = &common.EngineCRLink{Kind: common.ClusterPostgresqlEngineConfigurationKind, Name: pgecName}
+
+		cl, fakePG, factory := setupFakeEnv(
+			pgdb,
+			&corev1.Secret{
+				ObjectMeta: v1.ObjectMeta{Name: pgecSecretName, Namespace: operatorNamespace},
+				Data: map[string][]byte{
+					"user":     []byte("cluster-user"),
+					"password": []byte("cluster-password"),
+				},
+			},
+			&postgresqlv1alpha1.ClusterPostgresqlEngineConfiguration{
+				ObjectMeta: v1.ObjectMeta{Name: pgecName},
+				Spec: postgresqlv1alpha1.PostgresqlEngineConfigurationSpec{
+					Host:                        "localhost",
+					URIArgs:                     "sslmode=disable",
+					SecretName:                  pgecSecretName,
+					WaitLinkedResourcesDeletion: true,
+				},
+			},
+		)
+		fakePG.Capabilities.AvailableExtensions = []string{pgdbExtensionName1}
+
+		// Save engine configurations and secret data given to factory
+		var engines []*postgresqlv1alpha1.PostgresqlEngineConfiguration
+
+		var secretData map[string][]byte
+
+		recordingFactory := func(l logr.Logger, data map[string][]byte, pgec *postgresqlv1alpha1.PostgresqlEngineConfiguration) postgres.PG {
+			engines = append(engines, pgec)
+			secretData = data
+
+			return factory(l, data, pgec)
+		}
+
+		r := &ClusterPostgresqlEngineConfigurationReconciler{
+			PostgresqlEngineConfigurationReconciler: PostgresqlEngineConfigurationReconciler{
+				Client:                              cl,
+				Scheme:                              cl.Scheme(),
+				Recorder:                            record.NewFakeRecorder(100),
+				Log:                                 logr.Discard(),
+				ControllerRuntimeDetailedErrorTotal: newFakeCounter(),
+				ControllerName:                      "clusterpostgresqlengineconfiguration",
+				ReconcileTimeout:                    10 * time.Second,
+				PgInstanceFactory:                   recordingFactory,
+			},
+		}
+
+		Expect(reconcileFakeUntilStable(r, pgecName, "")).To(Succeed())
+
+		item := &postgresqlv1alpha1.ClusterPostgresqlEngineConfiguration{}
+		Expect(cl.Get(context.TODO(), types.NamespacedName{Name: pgecName}, item)).To(Succeed())
+
+		// Checks
+		Expect(item.Finalizers).To(ContainElement(config.Finalizer))
+		Expect(item.Spec.Port).To(Equal(DefaultPGPort))
+		Expect(item.Status.Ready).To(BeTrue())
+		Expect(item.Status.Phase).To(Equal(postgresqlv1alpha1.EngineValidatedPhase))
+		Expect(secretData).To(HaveKeyWithValue("user", []byte("cluster-user")))
+		Expect(engines).NotTo(BeEmpty())
+		Expect(engines[len(engines)-1].Namespace).To(BeEmpty())
+		Expect(utils.CreateNameKeyForSavedPools(engines[len(engines)-1].Name, engines[len(engines)-1].Namespace)).
+			To(Equal(utils.CreateNameKeyForEngineLink(pgdb.Spec.EngineConfiguration, pgdbNamespace)))
+
+		// Database must be created with cluster engine configuration
+		dbr := newFakePGDBReconciler(cl, recordingFactory)
+		Expect(reconcileFakeUntilStable(dbr, pgdbName, pgdbNamespace)).To(Succeed())
+
+		db := &postgresqlv1alpha1.PostgresqlDatabase{}
+		Expect(cl.Get(context.TODO(), types.NamespacedName{Name: pgdbName, Namespace: pgdbNamespace}, db)).To(Succeed())
+		Expect(db.Status.Ready).To(BeTrue())
+		Expect(fakePG.Databases).To(HaveKey(pgdbDBName))
+		Expect(secretData).To(HaveKeyWithValue("user", []byte("cluster-user")))
+
+		// Deletion must be blocked by linked database
+		Expect(cl.Delete(context.TODO(), item)).To(Succeed())
+
+		_, err := r.Reconcile(context.TODO(), ctrl.Request{NamespacedName: types.NamespacedName{Name: pgecName}})
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("found database " + pgdbName))
+		Expect(cl.Get(context.TODO(), types.NamespacedName{Name: pgecName}, item)).To(Succeed())
+	})
+
+	It("should get credentials from exec credential source", func() {
+		cl, _, factory := setupFakeEnv(newFakeExecClusterPGEC(&postgresqlv1alpha1.ExecCredentialSource{
+			Command: "sh",
+			Args:    []string{"-c", `echo "{\"user\": \"$EXEC_USER\", \"password\": \"exec-password\"}"`},
+			Env:     []*postgresqlv1alpha1.ExecEnvVar{{Name: "EXEC_USER", Value: "exec-user"}},
+		}))
+
+		// Save secret data given to factory
+		var secretData map[string][]byte
+
+		r := newFakeClusterPGECReconciler(cl, func(l logr.Logger, data map[string][]byte, pgec *postgresqlv1alpha1.PostgresqlEngineConfiguration) postgres.PG {
+			secretData = data
+
+			return factory(l, data, pgec)
+		})
+
+		// Command isn't allowed
+		_ = reconcileFakeUntilStable(r, pgecName, "")
+
+		item := &postgresqlv1alpha1.ClusterPostgresqlEngineConfiguration{}
+		Expect(cl.Get(context.TODO(), types.NamespacedName{Name: pgecName}, item)).To(Succeed())
+		Expect(item.Status.Ready).To(BeFalse())
+		Expect(item.Status.Message).To(ContainSubstring(`exec credential command "sh" isn't allowed by operator`))
+
+		// Allow command
+		postgres.SetAllowedCredentialCommands([]string{"sh"})
+		defer postgres.SetAllowedCredentialCommands(nil)
+
+		// Environment variable isn't allowed
+		_ = reconcileFakeUntilStable(r, pgecName, "")
+
+		Expect(cl.Get(context.TODO(), types.NamespacedName{Name: pgecName}, item)).To(Succeed())
+		Expect(item.Status.Ready).To(BeFalse())
+		Expect(item.Status.Message).To(ContainSubstring(`exec credential environment variable "EXEC_USER" isn't allowed by operator`))
+
+		// Allow environment variable
+		postgres.SetAllowedCredentialEnvNames([]string{"EXEC_USER"})
+		defer postgres.SetAllowedCredentialEnvNames(nil)
+
+		Expect(reconcileFakeUntilStable(r, pgecName, "")).To(Succeed())
+
+		Expect(cl.Get(context.TODO(), types.NamespacedName{Name: pgecName}, item)).To(Succeed())
+		Expect(item.Status.Ready).To(BeTrue())
+		Expect(secretData).To(HaveKeyWithValue("user", []byte("exec-user")))
+		Expect(secretData).To(HaveKeyWithValue("password", []byte("exec-password")))
+	})
+
+	It("should requeue before exec credentials expiry and forget them on deletion", func() {
+		expiresAt := time.Now().Add(10 * time.Minute).UTC().Format(time.RFC3339)
+		cl, _, factory := setupFakeEnv(newFakeExecClusterPGEC(&postgresqlv1alpha1.ExecCredentialSource{
+			Command: "sh",
+			Args: []string{"-c", fmt.Sprintf(
+				`echo '{"user": "%s", "password": "%s", "expiresAt": "%s"}'`, postgresUser, postgresPassword, expiresAt,
+			)},
+		}))
+
+		r := newFakeClusterPGECReconciler(cl, factory)
+
+		// Allow command
+		postgres.SetAllowedCredentialCommands([]string{"sh"})
+		defer postgres.SetAllowedCredentialCommands(nil)
+
+		Expect(reconcileFakeUntilStable(r, pgecName, "")).To(Succeed())
+
+		// Force a full check
+		item := &postgresqlv1alpha1.ClusterPostgresqlEngineConfiguration{}
+		Expect(cl.Get(context.TODO(), types.NamespacedName{Name: pgecName}, item)).To(Succeed())
+		item.Status.LastValidatedTime = ""
+		Expect(cl.Status().Update(context.TODO(), item)).To(Succeed())
+
+		res, err := r.Reconcile(context.TODO(), ctrl.Request{NamespacedName: types.NamespacedName{Name: pgecName}})
+		Expect(err).NotTo(HaveOccurred())
+
+		// Requeue must be done before refresh time and not after check interval
+		Expect(res.RequeueAfter).To(BeNumerically("<=", 9*time.Minute))
+		Expect(res.RequeueAfter).To(BeNumerically(">", 8*time.Minute))
+
+		// Reconcile before check interval must keep delay
+		res, err = r.Reconcile(context.TODO(), ctrl.Request{NamespacedName: types.NamespacedName{Name: pgecName}})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(res.RequeueAfter).To(BeNumerically("<=", 9*time.Minute))
+
+		key := utils.CreateNameKeyForSavedPools(pgecName, "")
+		_, ok := postgres.GetExecCredentialsRefreshTime(key)
+		Expect(ok).To(BeTrue())
+
+		// Delete
+		Expect(cl.Get(context.TODO(), types.NamespacedName{Name: pgecName}, item)).To(Succeed())
+		Expect(cl.Delete(context.TODO(), item)).To(Succeed())
+
+		_, err = r.Reconcile(context.TODO(), ctrl.Request{NamespacedName: types.NamespacedName{Name: pgecName}})
+		Expect(err).NotTo(HaveOccurred())
+
+		// Cached credentials must be removed
+		_, ok = postgres.GetExecCredentialsRefreshTime(key)
+		Expect(ok).To(BeFalse())
+	})
+
+	It("should skip admin password rotation with exec credential source", func() {
+		execPGEC := newFakeExecClusterPGEC(&postgresqlv1alpha1.ExecCredentialSource{
+			Command: "sh",
+			Args:    []string{"-c", fmt.Sprintf(`echo '{"user": "%s", "password": "%s"}'`, postgresUser, postgresPassword)},
+		})
+		execPGEC.Spec.AdminPasswordRotation = &postgresqlv1alpha1.AdminPasswordRotation{Duration: "1h"}
+		execPGEC.Status.LastAdminPasswordChangedTime = time.Now().Add(-2 * time.Hour).UTC().Format(time.RFC3339)
+
+		cl, fakePG, factory := setupFakeEnv(execPGEC)
+		// Engine user password is the printed one
+		fakePG.Roles[postgresUser].Password = postgresPassword
+
+		r := newFakeClusterPGECReconciler(cl, factory)
+
+		// Allow command
+		postgres.SetAllowedCredentialCommands([]string{"sh"})
+		defer postgres.SetAllowedCredentialCommands(nil)
+
+		Expect(reconcileFakeUntilStable(r, pgecName, "")).To(Succeed())
+
+		// Checks
+		item := &postgresqlv1alpha1.ClusterPostgresqlEngineConfiguration{}
+		Expect(cl.Get(context.TODO(), types.NamespacedName{Name: pgecName}, item)).To(Succeed())
+		Expect(item.Status.Phase).To(Equal(postgresqlv1alpha1.EngineValidatedPhase))
+		Expect(fakePG.Calls).NotTo(ContainElement("UpdateCurrentUserPassword"))
+		Expect(fakePG.Roles[postgresUser].Password).To(Equal(postgresPassword))
+	})
+})
+
+// newFakeExecClusterPGEC will return a cluster engine configuration getting credentials from an exec plugin.
+func newFakeExecClusterPGEC(exec *postgresqlv1alpha1.ExecCredentialSource) *postgresqlv1alpha1.ClusterPostgresqlEngineConfiguration {
+	return &postgresqlv1alpha1.ClusterPostgresqlEngineConfiguration{
+		ObjectMeta: v1.ObjectMeta{Name: pgecName},
+		Spec: postgresqlv1alpha1.PostgresqlEngineConfigurationSpec{
+			Host:          "localhost",
+			URIArgs:       "sslmode=disable",
+			CheckInterval: "1h",
+			CredentialSource: &postgresqlv1alpha1.CredentialSource{
+				Type: postgresqlv1alpha1.ExecCredentialSourceType,
+				Exec: exec,
+			},
+		},
+	}
+}
+
+// newFakeClusterPGECReconciler will return a cluster engine configuration reconciler using fake engine.
+func newFakeClusterPGECReconciler(cl client.Client, factory utils.PgInstanceFactory) *ClusterPostgresqlEngineConfigurationReconciler {
+	return &ClusterPostgresqlEngineConfigurationReconciler{
+		PostgresqlEngineConfigurationReconciler: PostgresqlEngineConfigurationReconciler{
+			Client:                              cl,
+			Scheme:                              cl.Scheme(),
+			Recorder:                            record.NewFakeRecorder(100),
+			Log:                                 logr.Discard(),
+			ControllerRuntimeDetailedErrorTotal: newFakeCounter(),
+			ControllerName:                      "clusterpostgresqlengineconfiguration",
+			ReconcileTimeout:                    10 * time.Second,
+			PgInstanceFactory:                   factory,
+		},
+	}
+}
@@ -0,0 +1,462 @@
+package postgres
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/go-logr/logr"
+)
+
+const (
+	AuditOutcomeSuccess = "success"
+	AuditOutcomeError   = "error"
+
+	auditFilePermissions = 0o600
+
+	// Number of retries of a failed audit entry send before dropping it
+	auditHTTPMaxRetries = 3
+	// Delay before first retry, doubled on each retry
+	auditHTTPRetryInitialDelay = 500 * time.Millisecond
+)
+
+// AuditResource is the custom resource at the origin of statements.
+type AuditResource struct {
+	Kind        string `json:"kind"`
+	Namespace   string `json:"namespace"`
+	Name        string `json:"name"`
+	ReconcileID string `json:"reconcileID,omitempty"`
+}
+
+// AuditEntry is a mutating statement executed on an engine.
+type AuditEntry struct {
+	Time       time.Time      `json:"time"`
+	Resource   *AuditResource `json:"resource,omitempty"`
+	Engine     string         `json:"engine"`
+	Database   string         `json:"database"`
+	Statement  string         `json:"statement"`
+	Outcome    string         `json:"outcome"`
+	Error      string         `json:"error,omitempty"`
+	DurationMs int64          `json:"durationMs"`
+}
+
+// AuditSink is where audit entries are sent.
+type AuditSink interface {
+	Write(entry *AuditEntry) error
+}
+
+type auditResourceKey struct{}
+
+// Audit sink used by all PG instances.
+var (
+	auditSink      AuditSink
+	auditSinkMutex sync.RWMutex
+)
+
+// SetAuditSink will set the sink used for all PG instances. Nil disables audit.
+func SetAuditSink(sink AuditSink) {
+	auditSinkMutex.Lock()
+	defer auditSinkMutex.Unlock()
+
+	auditSink = sink
+}
+
+func getAuditSink() AuditSink {
+	auditSinkMutex.RLock()
+	defer auditSinkMutex.RUnlock()
+
+	return auditSink
+}
+
+// WithAuditResource will save the custom resource at the origin of statements in context.
+func WithAuditResource(ctx context.Context, resource *AuditResource) context.Context {
+	return context.WithValue(ctx, auditResourceKey{}, resource)
+}
+
+func auditResourceFromContext(ctx context.Context) *AuditResource {
+	// Get value
+	res, _ := ctx.Value(auditResourceKey{}).(*AuditResource)
+
+	return res
+}
+
+// audit will send an entry for an executed statement to the audit sink if there is one.
+func (c *pg) audit(ctx context.Context, statement string, start time.Time, err error) {
+	// Get sink
+	sink := getAuditSink()
+	// Check if audit is disabled
+	if sink == nil {
+		return
+	}
+
+	entry := &AuditEntry{
+		Time:       start.UTC(),
+		Resource:   auditResourceFromContext(ctx),
+		Engine:     c.name,
+		Database:   c.database,
+		Statement:  statement,
+		Outcome:    AuditOutcomeSuccess,
+		DurationMs: time.Since(start).Milliseconds(),
+	}
+
+	// Check error
+	if err != nil {
+		entry.Outcome = AuditOutcomeError
+		entry.Error = err.Error()
+	}
+
+	// Write
+	// ? Note: Audit failure mustn't fail the statement as it is already executed
+	err = sink.Write(entry)
+	if err != nil {
+		c.log.Error(err, "unable to write audit entry", "statement", statement)
+	}
+}
+
+// auditedTx will send an audit entry for each executed statement in transaction.
+type auditedTx struct {
+	sqlTx
+	c *pg
+}
+
+func (t *auditedTx) ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	start := time.Now()
+	// Execute
+	res, err := t.sqlTx.ExecContext(ctx, query, args...)
+	// Audit
+	t.c.audit(ctx, renderStatement(query, args), start, err)
+
+	return res, err
+}
+
+// LogAuditSink will write audit entries in a structured log stream.
+type LogAuditSink struct {
+	logger logr.Logger
+}
+
+func NewLogAuditSink(logger logr.Logger) *LogAuditSink {
+	return &LogAuditSink{logger: logger}
+}
+
+func (s *LogAuditSink) Write(entry *AuditEntry) error {
+	keysAndValues := []any{
+		"engine", entry.Engine,
+		"database", entry.Database,
+		"statement", entry.Statement,
+		"outcome", entry.Outcome,
+		"durationMs", entry.DurationMs,
+	}
+
+	// Check resource
+	if entry.Resource != nil {
+		keysAndValues = append(keysAndValues,
+			"resourceKind", entry.Resource.Kind,
+			"resourceNamespace", entry.Resource.Namespace,
+			"resourceName", entry.Resource.Name,
+			"reconcileID", entry.Resource.ReconcileID,
+		)
+	}
+
+	// Check error
+	if entry.Error != "" {
+		keysAndValues = append(keysAndValues, "error", entry.Error)
+	}
+
+	s.logger.Info("sql statement executed", keysAndValues...)
+
+	return nil
+}
+
+// FileAuditSink will write audit entries as json lines in a file rotated on size.
+type FileAuditSink struct {
+	file       *os.File
+	path       string
+	size       int64
+	maxSize    int64
+	maxBackups int
+	mutex      sync.Mutex
+}
+
+func NewFileAuditSink(path string, maxSize int64, maxBackups int) (*FileAuditSink, error) {
+	s := &FileAuditSink{
+		path:       path,
+		maxSize:    maxSize,
+		maxBackups: maxBackups,
+	}
+
+	// Open file
+	err := s.open()
+	if err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+func (s *FileAuditSink) open() error {
+	// Open in append mode
+	f, err := os.OpenFile(s.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, auditFilePermissions)
+	if err != nil {
+		return err
+	}
+
+	// Get current size
+	info, err := f.Stat()
+	if err != nil {
+		_ = f.Close()
+
+		return err
+	}
+
+	s.file = f
+	s.size = info.Size()
+
+	return nil
+}
+
+func (s *FileAuditSink) rotate() error {
+	// Close current file
+	err := s.file.Close()
+	if err != nil {
+		return err
+	}
+
+	// Shift backups, oldest one is overwritten
+	for i := s.maxBackups - 1; i > 0; i-- {
+		err = os.Rename(fmt.Sprintf("%s.%d", s.path, i), fmt.Sprintf("%s.%d", s.path, i+1))
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+
+	// Check if backups are enabled
+	if s.maxBackups > 0 {
+		err = os.Rename(s.path, s.path+".1")
+	} else {
+		err = os.Remove(s.path)
+	}
+	// Check error
+	if err != nil {
+		return err
+	}
+
+	return s.open()
+}
+
+func (s *FileAuditSink) Write(entry *AuditEntry) error {
+	// Marshal
+	b, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	// Add new line
+	b = append(b, '\n')
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	// Check if file must be rotated
+	if s.maxSize > 0 && s.size > 0 && s.size+int64(len(b)) > s.maxSize {
+		err = s.rotate()
+		if err != nil {
+			return err
+		}
+	}
+
+	// Write
+	n, err := s.file.Write(b)
+	s.size += int64(n)
+
+	return err
+}
+
+// Close will close the current file.
+func (s *FileAuditSink) Close() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return s.file.Close()
+}
+
+// HTTPAuditSink will send audit entries as json to an HTTP endpoint.
+// Entries are queued and sent by a background worker so that reconciles aren't slowed down by endpoint.
+// Failed sends are retried with a backoff.
+// When queue is full or when all retries failed, entries are dropped and counted in metrics.
+type HTTPAuditSink struct {
+	client     *http.Client
+	logger     logr.Logger
+	queue      chan *AuditEntry
+	done       chan struct{}
+	url        string
+	maxRetries int
+	retryDelay time.Duration
+	closed     bool
+	mutex      sync.RWMutex
+}
+
+// auditHTTPStatusError is raised when audit endpoint doesn't accept an entry.
+type auditHTTPStatusError struct {
+	statusCode int
+}
+
+func (e *auditHTTPStatusError) Error() string {
+	return fmt.Sprintf("audit endpoint returned status code %d", e.statusCode)
+}
+
+// isRetryableAuditSendError will return true if send can succeed later.
+// Client errors are considered permanent except for timeouts and rate limits.
+func isRetryableAuditSendError(err error) bool {
+	// Check if it isn't a status error
+	var statusErr *auditHTTPStatusError
+	if !errors.As(err, &statusErr) {
+		// Network errors and timeouts
+		return true
+	}
+
+	return statusErr.statusCode >= http.StatusInternalServerError ||
+		statusErr.statusCode == http.StatusRequestTimeout ||
+		statusErr.statusCode == http.StatusTooManyRequests
+}
+
+func NewHTTPAuditSink(url string, timeout time.Duration, queueSize int, logger logr.Logger) *HTTPAuditSink {
+	return newHTTPAuditSink(url, timeout, queueSize, auditHTTPMaxRetries, auditHTTPRetryInitialDelay, logger)
+}
+
+func newHTTPAuditSink(
+	url string,
+	timeout time.Duration,
+	queueSize, maxRetries int,
+	retryDelay time.Duration,
+	logger logr.Logger,
+) *HTTPAuditSink {
+	s := &HTTPAuditSink{
+		client:     &http.Client{Timeout: timeout},
+		logger:     logger,
+		queue:      make(chan *AuditEntry, queueSize),
+		done:       make(chan struct{}),
+		url:        url,
+		maxRetries: maxRetries,
+		retryDelay: retryDelay,
+	}
+
+	// Start worker
+	go s.run()
+
+	return s
+}
+
+func (s *HTTPAuditSink) Write(entry *AuditEntry) error {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	// Check if sink is closed
+	if s.closed {
+		return errors.New("audit sink is closed")
+	}
+
+	// Enqueue without blocking statement execution
+	select {
+	case s.queue <- entry:
+		auditMetrics.queueLength.WithLabelValues(AuditSinkHTTP).Set(float64(len(s.queue)))
+
+		return nil
+	default:
+		auditMetrics.droppedTotal.WithLabelValues(AuditSinkHTTP, AuditDropReasonQueueFull).Inc()
+
+		return errors.New("audit queue is full, entry dropped")
+	}
+}
+
+// Close will stop accepting entries and wait for queued ones to be sent.
+func (s *HTTPAuditSink) Close() error {
+	s.mutex.Lock()
+	// Check if sink is already closed
+	if !s.closed {
+		s.closed = true
+		close(s.queue)
+	}
+	s.mutex.Unlock()
+
+	// Wait for worker
+	<-s.done
+
+	return nil
+}
+
+func (s *HTTPAuditSink) run() {
+	defer close(s.done)
+
+	// Loop until queue is closed and empty
+	for entry := range s.queue {
+		auditMetrics.queueLength.WithLabelValues(AuditSinkHTTP).Set(float64(len(s.queue)))
+
+		// Send with retries
+		attempts, err := s.sendWithRetries(entry)
+		// Check error
+		if err != nil {
+			auditMetrics.droppedTotal.WithLabelValues(AuditSinkHTTP, AuditDropReasonSendError).Inc()
+			s.logger.Error(
+				err, "unable to send audit entry, entry dropped",
+				"attempts", attempts,
+				"engine", entry.Engine,
+				"database", entry.Database,
+				"statement", entry.Statement,
+			)
+
+			continue
+		}
+
+		auditMetrics.sentTotal.WithLabelValues(AuditSinkHTTP).Inc()
+	}
+}
+
+// sendWithRetries will send entry and retry with an exponential backoff when error is retryable.
+// Returns the number of attempts and the last error.
+func (s *HTTPAuditSink) sendWithRetries(entry *AuditEntry) (int, error) {
+	delay := s.retryDelay
+
+	for attempt := 1; ; attempt++ {
+		// Send
+		err := s.send(entry)
+		// Check if it is sent or if it cannot be retried
+		if err == nil || attempt > s.maxRetries || !isRetryableAuditSendError(err) {
+			return attempt, err
+		}
+
+		s.logger.Info("unable to send audit entry, retrying", "error", err.Error(), "attempt", attempt, "delay", delay.String())
+
+		// Wait before next attempt
+		time.Sleep(delay)
+		delay *= 2
+	}
+}
+
+func (s *HTTPAuditSink) send(entry *AuditEntry) error {
+	// Marshal
+	b, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	// Send
+	resp, err := s.client.Post(s.url, "application/json", bytes.NewReader(b)) //nolint:noctx // Timeout is managed by client
+	if err != nil {
+		return err
+	}
+
+	defer resp.Body.Close()
+
+	// Check status code
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		return &auditHTTPStatusError{statusCode: resp.StatusCode}
+	}
+
+	return nil
+}
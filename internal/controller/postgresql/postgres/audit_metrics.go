@@ -0,0 +1,75 @@
+package postgres
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	auditMetricsSubsystem = "audit"
+	auditSinkLabel        = "sink"
+	auditReasonLabel      = "reason"
+
+	AuditSinkHTTP = "http"
+
+	AuditDropReasonQueueFull = "queue_full"
+	AuditDropReasonSendError = "send_error"
+)
+
+// Audit metrics collector used by all asynchronous sinks.
+var auditMetrics = newAuditMetricsCollector()
+
+// auditMetricsCollector will export queue statistics of asynchronous audit sinks.
+type auditMetricsCollector struct {
+	sentTotal    *prometheus.CounterVec
+	droppedTotal *prometheus.CounterVec
+	queueLength  *prometheus.GaugeVec
+}
+
+func newAuditMetricsCollector() *auditMetricsCollector {
+	return &auditMetricsCollector{
+		sentTotal: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: poolMetricsNamespace,
+				Subsystem: auditMetricsSubsystem,
+				Name:      "sent_entries_total",
+				Help:      "Total number of audit entries sent by sink.",
+			},
+			[]string{auditSinkLabel},
+		),
+		droppedTotal: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: poolMetricsNamespace,
+				Subsystem: auditMetricsSubsystem,
+				Name:      "dropped_entries_total",
+				Help:      "Total number of audit entries dropped by sink because queue was full or sending failed after retries.",
+			},
+			[]string{auditSinkLabel, auditReasonLabel},
+		),
+		queueLength: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Namespace: poolMetricsNamespace,
+				Subsystem: auditMetricsSubsystem,
+				Name:      "queue_length",
+				Help:      "Number of audit entries waiting to be sent by sink.",
+			},
+			[]string{auditSinkLabel},
+		),
+	}
+}
+
+// GetAuditMetricsCollector will return the collector to register in order to export audit sink metrics.
+func GetAuditMetricsCollector() prometheus.Collector {
+	return auditMetrics
+}
+
+func (c *auditMetricsCollector) Describe(ch chan<- *prometheus.Desc) {
+	c.sentTotal.Describe(ch)
+	c.droppedTotal.Describe(ch)
+	c.queueLength.Describe(ch)
+}
+
+func (c *auditMetricsCollector) Collect(ch chan<- prometheus.Metric) {
+	c.sentTotal.Collect(ch)
+	c.droppedTotal.Collect(ch)
+	c.queueLength.Collect(ch)
+}
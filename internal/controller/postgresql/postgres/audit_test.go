@@ -0,0 +1,351 @@
+package postgres
+
+import (
+	"bufio"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-logr/logr"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+// memoryAuditSink will keep audit entries in memory.
+type memoryAuditSink struct {
+	entries []*AuditEntry
+	mutex   sync.Mutex
+}
+
+func (s *memoryAuditSink) Write(entry *AuditEntry) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.entries = append(s.entries, entry)
+
+	return nil
+}
+
+func (s *memoryAuditSink) getEntries() []*AuditEntry {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return append([]*AuditEntry{}, s.entries...)
+}
+
+// readAuditFile will return entries saved in an audit file.
+func readAuditFile(path string) []*AuditEntry {
+	f, err := os.Open(path)
+	Expect(err).NotTo(HaveOccurred())
+
+	defer f.Close()
+
+	res := []*AuditEntry{}
+	scanner := bufio.NewScanner(f)
+	// Loop over lines
+	for scanner.Scan() {
+		entry := &AuditEntry{}
+		Expect(json.Unmarshal(scanner.Bytes(), entry)).To(Succeed())
+
+		res = append(res, entry)
+	}
+
+	Expect(scanner.Err()).NotTo(HaveOccurred())
+
+	return res
+}
+
+var _ = Describe("audit", func() {
+	var sink *memoryAuditSink
+
+	BeforeEach(func() {
+		sink = &memoryAuditSink{}
+		SetAuditSink(sink)
+
+		// Store a pool without any engine
+		p := newTestPoolPG("audit-test", &PoolSettings{})
+		savInt, _ := poolManagerStorage.LoadOrStore(p.GetName(), newPoolSaved(p))
+		sav, _ := savInt.(*poolSaved)
+		sav.pools.Store(p.defaultDatabase, sql.OpenDB(&fakeConnector{}))
+	})
+
+	AfterEach(func() {
+		SetAuditSink(nil)
+		Expect(CloseAllSavedPoolsForName("audit-test")).To(Succeed())
+	})
+
+	It("should redact passwords in audited statements", func() {
+		p := newTestPoolPG("audit-test", &PoolSettings{})
+		ctx := WithAuditResource(context.TODO(), &AuditResource{Kind: "PostgresqlUserRole", Namespace: "ns", Name: "user"})
+
+		_, err := p.CreateUserRole(ctx, "role", "super-secret", &RoleAttributes{})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(p.UpdatePassword(ctx, "role", "super-secret")).To(Succeed())
+		Expect(p.UpdateCurrentUserPassword(ctx, "super-secret")).To(Succeed())
+
+		// Checks
+		entries := sink.getEntries()
+		Expect(entries).To(HaveLen(3))
+
+		for _, entry := range entries {
+			Expect(entry.Statement).To(ContainSubstring(RedactedValue))
+			Expect(entry.Statement).NotTo(ContainSubstring("super-secret"))
+			Expect(entry.Statement).NotTo(ContainSubstring("SCRAM-SHA-256$"))
+			Expect(entry.Engine).To(Equal("audit-test"))
+			Expect(entry.Database).To(Equal("postgres"))
+			Expect(entry.Outcome).To(Equal(AuditOutcomeSuccess))
+			Expect(entry.Resource).To(Equal(&AuditResource{Kind: "PostgresqlUserRole", Namespace: "ns", Name: "user"}))
+		}
+	})
+
+	It("should render bind parameters in audited statements", func() {
+		p := newTestPoolPG("audit-test", &PoolSettings{})
+
+		Expect(p.connect(p.defaultDatabase)).To(Succeed())
+
+		_, err := p.exec(context.TODO(), "COMMENT ON ROLE role IS $1", "it's")
+		Expect(err).NotTo(HaveOccurred())
+
+		entries := sink.getEntries()
+		Expect(entries).To(HaveLen(1))
+		Expect(entries[0].Statement).To(Equal("COMMENT ON ROLE role IS 'it''s'"))
+	})
+})
+
+var _ = Describe("FileAuditSink", func() {
+	var path string
+
+	// entryLength will return the size of an entry line in file.
+	entryLength := func(entry *AuditEntry) int64 {
+		b, err := json.Marshal(entry)
+		Expect(err).NotTo(HaveOccurred())
+
+		return int64(len(b) + 1)
+	}
+
+	BeforeEach(func() {
+		path = filepath.Join(GinkgoT().TempDir(), "audit.log")
+	})
+
+	It("should rotate file on size and keep backups", func() {
+		entry := &AuditEntry{Engine: "ns/engine", Database: "db", Statement: "DROP ROLE a", Outcome: AuditOutcomeSuccess}
+
+		// One entry per file
+		s, err := NewFileAuditSink(path, entryLength(entry)+1, 2)
+		Expect(err).NotTo(HaveOccurred())
+
+		defer s.Close()
+
+		for _, st := range []string{"DROP ROLE a", "DROP ROLE b", "DROP ROLE c", "DROP ROLE d"} {
+			entry.Statement = st
+			Expect(s.Write(entry)).To(Succeed())
+		}
+
+		// Checks
+		Expect(readAuditFile(path)).To(ConsistOf(HaveField("Statement", "DROP ROLE d")))
+		Expect(readAuditFile(path + ".1")).To(ConsistOf(HaveField("Statement", "DROP ROLE c")))
+		Expect(readAuditFile(path + ".2")).To(ConsistOf(HaveField("Statement", "DROP ROLE b")))
+		Expect(path + ".3").NotTo(BeAnExistingFile())
+	})
+
+	It("should truncate file when backups are disabled", func() {
+		entry := &AuditEntry{Statement: "DROP ROLE a", Outcome: AuditOutcomeSuccess}
+
+		s, err := NewFileAuditSink(path, entryLength(entry)+1, 0)
+		Expect(err).NotTo(HaveOccurred())
+
+		defer s.Close()
+
+		Expect(s.Write(entry)).To(Succeed())
+
+		entry.Statement = "DROP ROLE b"
+		Expect(s.Write(entry)).To(Succeed())
+
+		// Checks
+		Expect(readAuditFile(path)).To(ConsistOf(HaveField("Statement", "DROP ROLE b")))
+		Expect(path + ".1").NotTo(BeAnExistingFile())
+	})
+
+	It("should append to existing file and count its size for rotation", func() {
+		entry := &AuditEntry{Statement: "DROP ROLE a", Outcome: AuditOutcomeSuccess}
+
+		s, err := NewFileAuditSink(path, 2*entryLength(entry), 1)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(s.Write(entry)).To(Succeed())
+		Expect(s.Close()).To(Succeed())
+
+		// Reopen as after a restart
+		s, err = NewFileAuditSink(path, 2*entryLength(entry), 1)
+		Expect(err).NotTo(HaveOccurred())
+
+		defer s.Close()
+
+		entry.Statement = "DROP ROLE b"
+		Expect(s.Write(entry)).To(Succeed())
+		Expect(readAuditFile(path)).To(HaveLen(2))
+
+		// File is full
+		entry.Statement = "DROP ROLE c"
+		Expect(s.Write(entry)).To(Succeed())
+
+		// Checks
+		Expect(readAuditFile(path)).To(ConsistOf(HaveField("Statement", "DROP ROLE c")))
+		Expect(readAuditFile(path + ".1")).To(HaveLen(2))
+	})
+})
+
+var _ = Describe("HTTPAuditSink", func() {
+	var server *httptest.Server
+	var received chan *AuditEntry
+	var handler http.HandlerFunc
+
+	BeforeEach(func() {
+		received = make(chan *AuditEntry, 10)
+		handler = func(w http.ResponseWriter, r *http.Request) {
+			entry := &AuditEntry{}
+			Expect(json.NewDecoder(r.Body).Decode(entry)).To(Succeed())
+
+			received <- entry
+		}
+
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			defer GinkgoRecover()
+
+			handler(w, r)
+		}))
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	It("should send entries in background and flush them on close", func() {
+		sent := testutil.ToFloat64(auditMetrics.sentTotal.WithLabelValues(AuditSinkHTTP))
+
+		s := NewHTTPAuditSink(server.URL, time.Second, 10, logr.Discard())
+
+		Expect(s.Write(&AuditEntry{Statement: "DROP ROLE a"})).To(Succeed())
+		Expect(s.Write(&AuditEntry{Statement: "DROP ROLE b"})).To(Succeed())
+		Expect(s.Close()).To(Succeed())
+
+		// Checks
+		Expect(received).To(HaveLen(2))
+		Expect((<-received).Statement).To(Equal("DROP ROLE a"))
+		Expect((<-received).Statement).To(Equal("DROP ROLE b"))
+		Expect(testutil.ToFloat64(auditMetrics.sentTotal.WithLabelValues(AuditSinkHTTP))).To(Equal(sent + 2))
+		Expect(testutil.ToFloat64(auditMetrics.queueLength.WithLabelValues(AuditSinkHTTP))).To(BeZero())
+
+		// Closed sink must reject entries
+		Expect(s.Write(&AuditEntry{Statement: "DROP ROLE c"})).To(MatchError("audit sink is closed"))
+	})
+
+	It("should drop entries without blocking when queue is full", func() {
+		dropped := testutil.ToFloat64(auditMetrics.droppedTotal.WithLabelValues(AuditSinkHTTP, AuditDropReasonQueueFull))
+
+		// Block endpoint
+		unblock := make(chan struct{})
+		handler = func(w http.ResponseWriter, r *http.Request) {
+			entry := &AuditEntry{}
+			Expect(json.NewDecoder(r.Body).Decode(entry)).To(Succeed())
+
+			received <- entry
+			<-unblock
+		}
+
+		s := NewHTTPAuditSink(server.URL, 5*time.Second, 1, logr.Discard())
+
+		// First one is taken by worker
+		Expect(s.Write(&AuditEntry{Statement: "DROP ROLE a"})).To(Succeed())
+		Eventually(received).Should(Receive(HaveField("Statement", "DROP ROLE a")))
+
+		// Second one is queued
+		Expect(s.Write(&AuditEntry{Statement: "DROP ROLE b"})).To(Succeed())
+
+		// Third one is dropped
+		Expect(s.Write(&AuditEntry{Statement: "DROP ROLE c"})).To(MatchError("audit queue is full, entry dropped"))
+		Expect(testutil.ToFloat64(auditMetrics.droppedTotal.WithLabelValues(AuditSinkHTTP, AuditDropReasonQueueFull))).To(Equal(dropped + 1))
+
+		// Release endpoint
+		close(unblock)
+		Expect(s.Close()).To(Succeed())
+
+		Expect(received).To(Receive(HaveField("Statement", "DROP ROLE b")))
+		Expect(received).NotTo(Receive())
+	})
+
+	It("should count entries rejected by endpoint after retries", func() {
+		dropped := testutil.ToFloat64(auditMetrics.droppedTotal.WithLabelValues(AuditSinkHTTP, AuditDropReasonSendError))
+
+		var calls atomic.Int32
+		handler = func(w http.ResponseWriter, _ *http.Request) {
+			calls.Add(1)
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+
+		s := newHTTPAuditSink(server.URL, time.Second, 10, 2, time.Millisecond, logr.Discard())
+
+		Expect(s.Write(&AuditEntry{Statement: "DROP ROLE a"})).To(Succeed())
+		Expect(s.Close()).To(Succeed())
+
+		// Checks
+		Expect(calls.Load()).To(BeEquivalentTo(3))
+		Expect(testutil.ToFloat64(auditMetrics.droppedTotal.WithLabelValues(AuditSinkHTTP, AuditDropReasonSendError))).To(Equal(dropped + 1))
+	})
+
+	It("should send entries after transient endpoint errors", func() {
+		sent := testutil.ToFloat64(auditMetrics.sentTotal.WithLabelValues(AuditSinkHTTP))
+		dropped := testutil.ToFloat64(auditMetrics.droppedTotal.WithLabelValues(AuditSinkHTTP, AuditDropReasonSendError))
+
+		// Fail twice before accepting
+		var calls atomic.Int32
+		handler = func(w http.ResponseWriter, r *http.Request) {
+			if calls.Add(1) <= 2 {
+				w.WriteHeader(http.StatusServiceUnavailable)
+
+				return
+			}
+
+			entry := &AuditEntry{}
+			Expect(json.NewDecoder(r.Body).Decode(entry)).To(Succeed())
+
+			received <- entry
+		}
+
+		s := newHTTPAuditSink(server.URL, time.Second, 10, 3, time.Millisecond, logr.Discard())
+
+		Expect(s.Write(&AuditEntry{Statement: "DROP ROLE a"})).To(Succeed())
+		Expect(s.Close()).To(Succeed())
+
+		// Checks
+		Expect(received).To(Receive(HaveField("Statement", "DROP ROLE a")))
+		Expect(testutil.ToFloat64(auditMetrics.sentTotal.WithLabelValues(AuditSinkHTTP))).To(Equal(sent + 1))
+		Expect(testutil.ToFloat64(auditMetrics.droppedTotal.WithLabelValues(AuditSinkHTTP, AuditDropReasonSendError))).To(Equal(dropped))
+	})
+
+	It("shouldn't retry entries refused by endpoint", func() {
+		dropped := testutil.ToFloat64(auditMetrics.droppedTotal.WithLabelValues(AuditSinkHTTP, AuditDropReasonSendError))
+
+		var calls atomic.Int32
+		handler = func(w http.ResponseWriter, _ *http.Request) {
+			calls.Add(1)
+			w.WriteHeader(http.StatusBadRequest)
+		}
+
+		s := newHTTPAuditSink(server.URL, time.Second, 10, 3, time.Millisecond, logr.Discard())
+
+		Expect(s.Write(&AuditEntry{Statement: "DROP ROLE a"})).To(Succeed())
+		Expect(s.Close()).To(Succeed())
+
+		// Checks
+		Expect(calls.Load()).To(BeEquivalentTo(1))
+		Expect(testutil.ToFloat64(auditMetrics.droppedTotal.WithLabelValues(AuditSinkHTTP, AuditDropReasonSendError))).To(Equal(dropped + 1))
+	})
+})
@@ -5,17 +5,25 @@ import (
 	"fmt"
 
 	"github.com/lib/pq"
+
+	"github.com/easymile/postgresql-operator/api/postgresql/v1alpha1"
 )
 
 const (
-	CreateDBWithoutOwnerSQLTemplate = `CREATE DATABASE "%s"`
-	AlterDBOwnerSQLTemplate         = `ALTER DATABASE "%s" OWNER TO "%s"`
+	CreateDBWithoutOwnerSQLTemplate = `CREATE DATABASE %s`
+	AlterDBOwnerSQLTemplate         = `ALTER DATABASE %s OWNER TO %s`
 )
 
 type awspg struct {
 	pg
 }
 
+func init() {
+	RegisterProvider(v1alpha1.AWSProvider, &ProviderCapabilities{MembershipToReassign: true}, newAWSPG)
+	// Generic restricted admin is derived from AWS behaviour
+	RegisterProvider(v1alpha1.RestrictedAdminProvider, &ProviderCapabilities{MembershipToReassign: true}, newAWSPG)
+}
+
 func newAWSPG(postgres *pg) PG {
 	return &awspg{
 		*postgres,
@@ -23,6 +31,11 @@ func newAWSPG(postgres *pg) PG {
 }
 
 func (c *awspg) AlterDefaultLoginRole(ctx context.Context, role, setRole string) error {
+	// Check if membership isn't needed
+	if !c.provider.MembershipToReassign {
+		return c.pg.AlterDefaultLoginRole(ctx, role, setRole)
+	}
+
 	// On AWS RDS the postgres user isn't really superuser so he doesn't have permissions
 	// to ALTER USER unless he belongs to both roles
 	err := c.GrantRole(ctx, role, c.user, false)
@@ -41,13 +54,18 @@ func (c *awspg) AlterDefaultLoginRole(ctx context.Context, role, setRole string)
 	return c.pg.AlterDefaultLoginRole(ctx, role, setRole)
 }
 
-func (c *awspg) CreateDB(ctx context.Context, dbname, role string) error {
+func (c *awspg) CreateDB(ctx context.Context, dbname, role string, options *DatabaseCreationOptions) error {
+	// Check if database can be created with owner directly
+	if c.provider.CreateDBWithOwner {
+		return c.pg.CreateDB(ctx, dbname, role, options)
+	}
+
 	err := c.connect(c.defaultDatabase)
 	if err != nil {
 		return err
 	}
 
-	_, err = c.db.ExecContext(ctx, fmt.Sprintf(CreateDBWithoutOwnerSQLTemplate, dbname))
+	_, err = c.exec(ctx, buildCreateDBWithoutOwnerSQL(dbname, options))
 	if err != nil {
 		// eat DUPLICATE DATABASE ERROR
 		// Try to cast error
@@ -57,7 +75,7 @@ func (c *awspg) CreateDB(ctx context.Context, dbname, role string) error {
 		}
 	}
 
-	_, err = c.db.ExecContext(ctx, fmt.Sprintf(AlterDBOwnerSQLTemplate, dbname, role))
+	_, err = c.exec(ctx, fmt.Sprintf(AlterDBOwnerSQLTemplate, pq.QuoteIdentifier(dbname), pq.QuoteIdentifier(role)))
 	if err != nil {
 		return err
 	}
@@ -66,6 +84,11 @@ func (c *awspg) CreateDB(ctx context.Context, dbname, role string) error {
 }
 
 func (c *awspg) DropRoleAndDropAndChangeOwnedBy(ctx context.Context, role, newOwner, database string) error {
+	// Check if membership isn't needed
+	if !c.provider.MembershipToReassign {
+		return c.pg.DropRoleAndDropAndChangeOwnedBy(ctx, role, newOwner, database)
+	}
+
 	// On AWS RDS the postgres user isn't really superuser so he doesn't have permissions
 	// to REASSIGN OWNED BY unless he belongs to both roles
 	err := c.GrantRole(ctx, role, c.user, false)
@@ -118,6 +141,11 @@ func (c *awspg) DropRoleAndDropAndChangeOwnedBy(ctx context.Context, role, newOw
 }
 
 func (c *awspg) ChangeAndDropOwnedBy(ctx context.Context, role, newOwner, database string) error {
+	// Check if membership isn't needed
+	if !c.provider.MembershipToReassign {
+		return c.pg.ChangeAndDropOwnedBy(ctx, role, newOwner, database)
+	}
+
 	// On AWS RDS the postgres user isn't really superuser so he doesn't have permissions
 	// to REASSIGN OWNED BY unless he belongs to both roles
 	err := c.GrantRole(ctx, role, c.user, false)
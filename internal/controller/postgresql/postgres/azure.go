@@ -2,8 +2,9 @@ package postgres
 
 import (
 	"context"
-	"fmt"
 	"strings"
+
+	"github.com/easymile/postgresql-operator/api/postgresql/v1alpha1"
 )
 
 type azurepg struct {
@@ -11,10 +12,14 @@ type azurepg struct {
 	pg
 }
 
+func init() {
+	RegisterProvider(v1alpha1.AzureProvider, &ProviderCapabilities{CreateDBWithOwner: true, UsernameSuffixSeparator: "@"}, newAzurePG)
+}
+
 const MinUserSplit = 1
 
 func newAzurePG(postgres *pg) PG {
-	splitUser := strings.Split(postgres.user, "@")
+	splitUser := strings.Split(postgres.user, postgres.provider.UsernameSuffixSeparator)
 	serverName := ""
 
 	if len(splitUser) > MinUserSplit {
@@ -33,11 +38,11 @@ func (azpg *azurepg) CreateUserRole(ctx context.Context, role, password string,
 		return "", err
 	}
 
-	return fmt.Sprintf("%s@%s", returnedRole, azpg.serverName), nil
+	return returnedRole + azpg.provider.UsernameSuffixSeparator + azpg.serverName, nil
 }
 
 func (azpg *azurepg) GetRoleForLogin(login string) string {
-	splitUser := strings.Split(azpg.user, "@")
+	splitUser := strings.Split(azpg.user, azpg.provider.UsernameSuffixSeparator)
 	if len(splitUser) > MinUserSplit {
 		return splitUser[0]
 	}
@@ -45,12 +50,12 @@ func (azpg *azurepg) GetRoleForLogin(login string) string {
 	return login
 }
 
-func (azpg *azurepg) CreateDB(ctx context.Context, dbname, role string) error {
+func (azpg *azurepg) CreateDB(ctx context.Context, dbname, role string, options *DatabaseCreationOptions) error {
 	// Have to add the master role to the group role before we can transfer the database owner
 	err := azpg.GrantRole(ctx, role, azpg.GetRoleForLogin(azpg.user), false)
 	if err != nil {
 		return err
 	}
 
-	return azpg.pg.CreateDB(ctx, dbname, role)
+	return azpg.pg.CreateDB(ctx, dbname, role, options)
 }
@@ -0,0 +1,72 @@
+package postgres
+
+import (
+	"context"
+)
+
+const (
+	GetEngineCapabilitiesSQLTemplate  = `SELECT current_setting('server_version_num')::int, current_setting('wal_level'), current_setting('max_connections')::int, r.rolsuper, r.rolcreatedb, r.rolcreaterole, r.rolreplication FROM pg_catalog.pg_roles r WHERE r.rolname = current_user` //nolint:lll // Because
+	GetAvailableExtensionsSQLTemplate = `SELECT name FROM pg_catalog.pg_available_extensions ORDER BY name`
+)
+
+type EngineCapabilities struct {
+	ServerVersionNum    int
+	WalLevel            string
+	MaxConnections      int
+	Superuser           bool
+	CreateDB            bool
+	CreateRole          bool
+	Replication         bool
+	AvailableExtensions []string
+}
+
+func (c *pg) GetEngineCapabilities(ctx context.Context) (*EngineCapabilities, error) {
+	err := c.connect(c.defaultDatabase)
+	if err != nil {
+		return nil, err
+	}
+
+	res := &EngineCapabilities{}
+
+	err = c.db.QueryRowContext(ctx, GetEngineCapabilitiesSQLTemplate).Scan(
+		&res.ServerVersionNum,
+		&res.WalLevel,
+		&res.MaxConnections,
+		&res.Superuser,
+		&res.CreateDB,
+		&res.CreateRole,
+		&res.Replication,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := c.db.QueryContext(ctx, GetAvailableExtensionsSQLTemplate)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	res.AvailableExtensions = make([]string, 0)
+
+	for rows.Next() {
+		var name string
+		// Scan
+		err = rows.Scan(&name)
+		// Check error
+		if err != nil {
+			return nil, err
+		}
+
+		res.AvailableExtensions = append(res.AvailableExtensions, name)
+	}
+
+	// Rows error
+	err = rows.Err()
+	if err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
@@ -0,0 +1,101 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/lib/pq"
+)
+
+const (
+	AlterDatabaseConnectionLimitSQLTemplate  = `ALTER DATABASE %s CONNECTION LIMIT %d`
+	AlterDatabaseAllowConnectionsSQLTemplate = `ALTER DATABASE %s ALLOW_CONNECTIONS %t`
+	GetDatabaseConnectionsSQLTemplate        = `SELECT d.datconnlimit, d.datallowconn, (SELECT count(*) FROM pg_catalog.pg_stat_activity a WHERE a.datname = d.datname)
+FROM pg_catalog.pg_database d
+WHERE d.datname = $1`
+	TerminateDatabaseSessionsSQLTemplate = `SELECT pg_catalog.pg_terminate_backend(pid) FROM pg_catalog.pg_stat_activity WHERE datname = $1 AND pid <> pg_catalog.pg_backend_pid()`
+
+	// Connection limit value for unlimited connections
+	UnlimitedConnectionLimit = -1
+)
+
+// DatabaseConnections is the connection configuration and usage of a database.
+type DatabaseConnections struct {
+	// Connection limit (-1 means no limit)
+	ConnectionLimit int
+	// Are connections allowed ?
+	AllowConnections bool
+	// Number of sessions connected to database (pg_stat_activity)
+	Count int
+}
+
+// GetDatabaseConnections will return connection configuration and current connection count of database.
+// Nil is returned if database doesn't exist.
+func (c *pg) GetDatabaseConnections(ctx context.Context, dbname string) (*DatabaseConnections, error) {
+	err := c.connect(c.defaultDatabase)
+	if err != nil {
+		return nil, err
+	}
+
+	res := &DatabaseConnections{}
+
+	err = c.db.QueryRowContext(ctx, GetDatabaseConnectionsSQLTemplate, dbname).Scan(&res.ConnectionLimit, &res.AllowConnections, &res.Count)
+	// Check error
+	if err != nil {
+		// Check if database doesn't exist
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	return res, nil
+}
+
+func (c *pg) SetDatabaseConnectionLimit(ctx context.Context, dbname string, limit int) error {
+	err := c.connect(c.defaultDatabase)
+	if err != nil {
+		return err
+	}
+
+	_, err = c.exec(ctx, fmt.Sprintf(AlterDatabaseConnectionLimitSQLTemplate, pq.QuoteIdentifier(dbname), limit))
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (c *pg) SetDatabaseAllowConnections(ctx context.Context, dbname string, allow bool) error {
+	err := c.connect(c.defaultDatabase)
+	if err != nil {
+		return err
+	}
+
+	_, err = c.exec(ctx, fmt.Sprintf(AlterDatabaseAllowConnectionsSQLTemplate, pq.QuoteIdentifier(dbname), allow))
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// TerminateDatabaseSessions will terminate all sessions connected to database except the current one.
+func (c *pg) TerminateDatabaseSessions(ctx context.Context, dbname string) error {
+	err := c.connect(c.defaultDatabase)
+	if err != nil {
+		return err
+	}
+
+	_, err = c.exec(ctx, TerminateDatabaseSessionsSQLTemplate, dbname)
+	if err != nil {
+		return err
+	}
+
+	c.log.Info(fmt.Sprintf("Terminated sessions on database %s", dbname))
+
+	return nil
+}
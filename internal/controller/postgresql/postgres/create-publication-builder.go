@@ -3,15 +3,19 @@ package postgres
 import (
 	"fmt"
 	"strings"
+
+	"github.com/lib/pq"
 )
 
 type CreatePublicationBuilder struct {
-	name       string
-	tablesPart string
-	allTables  string
-	withPart   string
-	tables     []string
-	schemaList []string
+	name                    string
+	tablesPart              string
+	allTables               string
+	withPart                string
+	publish                 string
+	tables                  []string
+	schemaList              []string
+	publishViaPartitionRoot *bool
 }
 
 func NewCreatePublicationBuilder() *CreatePublicationBuilder {
@@ -41,7 +45,7 @@ func (b *CreatePublicationBuilder) Build() {
 			res += ", "
 		}
 
-		res += "TABLES IN SCHEMA " + strings.Join(b.schemaList, ", ")
+		res += "TABLES IN SCHEMA " + strings.Join(quoteIdentifiers(b.schemaList), ", ")
 	}
 
 	// Save
@@ -49,11 +53,11 @@ func (b *CreatePublicationBuilder) Build() {
 }
 
 func (b *CreatePublicationBuilder) AddTable(name string, columns *[]string, additionalWhere *string) *CreatePublicationBuilder {
-	res := name
+	res := quoteQualifiedIdentifier(name)
 
 	// Manage columns
 	if columns != nil {
-		res += " (" + strings.Join(*columns, ", ") + ")"
+		res += " (" + strings.Join(quoteIdentifiers(*columns), ", ") + ")"
 	}
 
 	// Add where is set
@@ -86,10 +90,14 @@ func (b *CreatePublicationBuilder) SetName(n string) *CreatePublicationBuilder {
 }
 
 func (b *CreatePublicationBuilder) SetWith(publish string, publishViaPartitionRoot *bool) *CreatePublicationBuilder {
+	// Save raw values
+	b.publish = publish
+	b.publishViaPartitionRoot = publishViaPartitionRoot
+
 	var with string
 	// Check if publish is set
 	if publish != "" {
-		with += "publish = '" + publish + "'"
+		with += "publish = " + pq.QuoteLiteral(publish)
 	}
 	// Check publish via partition root
 	if publishViaPartitionRoot != nil {
@@ -111,3 +119,56 @@ func (b *CreatePublicationBuilder) SetWith(publish string, publishViaPartitionRo
 
 	return b
 }
+
+// GetName will return publication name.
+func (b *CreatePublicationBuilder) GetName() string {
+	return b.name
+}
+
+// IsForAllTables will return true if publication is for all tables.
+func (b *CreatePublicationBuilder) IsForAllTables() bool {
+	return b.allTables != ""
+}
+
+// GetTables will return quoted tables with their columns and where clauses.
+func (b *CreatePublicationBuilder) GetTables() []string {
+	return b.tables
+}
+
+// GetTablesInSchema will return schema list of tables in schema.
+func (b *CreatePublicationBuilder) GetTablesInSchema() []string {
+	return b.schemaList
+}
+
+// GetPublish will return publish option.
+func (b *CreatePublicationBuilder) GetPublish() string {
+	return b.publish
+}
+
+// GetPublishViaPartitionRoot will return publish via partition root option.
+func (b *CreatePublicationBuilder) GetPublishViaPartitionRoot() *bool {
+	return b.publishViaPartitionRoot
+}
+
+// quoteQualifiedIdentifier will quote an identifier that can be prefixed by its schema (schema.name).
+// ? Note: The first dot is considered as the schema separator.
+func quoteQualifiedIdentifier(name string) string {
+	// Split schema and name
+	schema, n, found := strings.Cut(name, ".")
+	// Check if schema isn't present
+	if !found {
+		return pq.QuoteIdentifier(name)
+	}
+
+	return pq.QuoteIdentifier(schema) + "." + pq.QuoteIdentifier(n)
+}
+
+func quoteIdentifiers(list []string) []string {
+	res := make([]string, 0, len(list))
+	// Loop over list
+	for _, it := range list {
+		res = append(res, pq.QuoteIdentifier(it))
+	}
+
+	return res
+}
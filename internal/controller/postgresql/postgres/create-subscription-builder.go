@@ -0,0 +1,108 @@
+package postgres
+
+import (
+	"strings"
+
+	"github.com/lib/pq"
+)
+
+type CreateSubscriptionBuilder struct {
+	name        string
+	connInfo    string
+	publication string
+	slotName    string
+	withPart    string
+	enabled     *bool
+	copyData    *bool
+}
+
+func NewCreateSubscriptionBuilder() *CreateSubscriptionBuilder {
+	return &CreateSubscriptionBuilder{}
+}
+
+func (b *CreateSubscriptionBuilder) Build() {
+	// Replication slot is managed by the publication side, never create it
+	res := []string{"create_slot = false", "slot_name = " + pq.QuoteLiteral(b.slotName)}
+
+	// Check enabled
+	if b.enabled != nil {
+		res = append(res, "enabled = "+boolToSQL(*b.enabled))
+	}
+
+	// Check copy data
+	if b.copyData != nil {
+		res = append(res, "copy_data = "+boolToSQL(*b.copyData))
+	}
+
+	// Save
+	b.withPart = strings.Join(res, ", ")
+}
+
+func (b *CreateSubscriptionBuilder) SetName(n string) *CreateSubscriptionBuilder {
+	b.name = n
+
+	return b
+}
+
+func (b *CreateSubscriptionBuilder) SetConnectionInfo(connInfo string) *CreateSubscriptionBuilder {
+	b.connInfo = connInfo
+
+	return b
+}
+
+func (b *CreateSubscriptionBuilder) SetPublication(publication string) *CreateSubscriptionBuilder {
+	b.publication = publication
+
+	return b
+}
+
+func (b *CreateSubscriptionBuilder) SetReplicationSlot(slotName string) *CreateSubscriptionBuilder {
+	b.slotName = slotName
+
+	return b
+}
+
+func (b *CreateSubscriptionBuilder) SetWith(enabled, copyData *bool) *CreateSubscriptionBuilder {
+	b.enabled = enabled
+	b.copyData = copyData
+
+	return b
+}
+
+// GetName will return subscription name.
+func (b *CreateSubscriptionBuilder) GetName() string {
+	return b.name
+}
+
+// GetConnectionInfo will return connection info.
+func (b *CreateSubscriptionBuilder) GetConnectionInfo() string {
+	return b.connInfo
+}
+
+// GetPublication will return publication name.
+func (b *CreateSubscriptionBuilder) GetPublication() string {
+	return b.publication
+}
+
+// GetReplicationSlot will return replication slot name.
+func (b *CreateSubscriptionBuilder) GetReplicationSlot() string {
+	return b.slotName
+}
+
+// GetEnabled will return enabled option.
+func (b *CreateSubscriptionBuilder) GetEnabled() *bool {
+	return b.enabled
+}
+
+// GetCopyData will return copy data option.
+func (b *CreateSubscriptionBuilder) GetCopyData() *bool {
+	return b.copyData
+}
+
+func boolToSQL(b bool) string {
+	if b {
+		return "true"
+	}
+
+	return "false"
+}
@@ -0,0 +1,238 @@
+package postgres
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"sync"
+	"time"
+
+	"github.com/samber/lo"
+)
+
+const (
+	DefaultExecCredentialRefreshBefore = time.Minute
+	DefaultExecCredentialTimeout       = 30 * time.Second
+	execCredentialWaitDelay            = time.Second
+)
+
+// ExecCredentialPlugin is the command printing engine credentials as JSON.
+type ExecCredentialPlugin struct {
+	// Command to execute
+	Command string
+	// Command arguments
+	Args []string
+	// Environment variables added to operator ones
+	// Names must be allowed by operator
+	Env map[string]string
+	// Credentials are refreshed this duration before expiry
+	RefreshBefore time.Duration
+}
+
+// Credentials are the credentials printed by exec credential plugins.
+type Credentials struct {
+	User      string     `json:"user"`
+	Password  string     `json:"password"`
+	ExpiresAt *time.Time `json:"expiresAt,omitempty"`
+}
+
+type cachedCredentials struct {
+	plugin      *ExecCredentialPlugin
+	credentials *Credentials
+}
+
+// Operator wide lists of commands and environment variable names allowed for exec credential plugins
+// and exec credential plugins timeout.
+var (
+	allowedCredentialCommands []string
+	allowedCredentialEnvNames []string
+	execCredentialTimeout     = DefaultExecCredentialTimeout
+	execCredentialConfigMutex sync.RWMutex
+)
+
+// Exec credentials cache per engine.
+var execCredentialsCache = sync.Map{}
+
+// SetAllowedCredentialCommands will set operator wide list of commands allowed for exec credential plugins.
+// Empty list disables exec credential plugins.
+func SetAllowedCredentialCommands(commands []string) {
+	execCredentialConfigMutex.Lock()
+	defer execCredentialConfigMutex.Unlock()
+
+	allowedCredentialCommands = commands
+}
+
+// SetAllowedCredentialEnvNames will set operator wide list of environment variable names that exec credential plugins can set.
+// Empty list disables environment variables coming from engine configurations.
+func SetAllowedCredentialEnvNames(names []string) {
+	execCredentialConfigMutex.Lock()
+	defer execCredentialConfigMutex.Unlock()
+
+	allowedCredentialEnvNames = names
+}
+
+// SetExecCredentialTimeout will set operator wide timeout of exec credential plugins.
+func SetExecCredentialTimeout(timeout time.Duration) {
+	execCredentialConfigMutex.Lock()
+	defer execCredentialConfigMutex.Unlock()
+
+	execCredentialTimeout = timeout
+}
+
+func isCredentialCommandAllowed(command string) bool {
+	execCredentialConfigMutex.RLock()
+	defer execCredentialConfigMutex.RUnlock()
+
+	return lo.Contains(allowedCredentialCommands, command)
+}
+
+func isCredentialEnvNameAllowed(name string) bool {
+	execCredentialConfigMutex.RLock()
+	defer execCredentialConfigMutex.RUnlock()
+
+	return lo.Contains(allowedCredentialEnvNames, name)
+}
+
+func getExecCredentialTimeout() time.Duration {
+	execCredentialConfigMutex.RLock()
+	defer execCredentialConfigMutex.RUnlock()
+
+	return execCredentialTimeout
+}
+
+// GetExecCredentials will return cached credentials for engine or execute plugin when they are missing or about to expire.
+func GetExecCredentials(ctx context.Context, name string, plugin *ExecCredentialPlugin) (*Credentials, error) {
+	// Check if command is allowed
+	if !isCredentialCommandAllowed(plugin.Command) {
+		return nil, fmt.Errorf("exec credential command %q isn't allowed by operator", plugin.Command)
+	}
+
+	// Check if environment variables are allowed
+	// ? Note: Variables like LD_PRELOAD or PATH would allow to run something else than the allowed command
+	for _, k := range lo.Keys(plugin.Env) {
+		if !isCredentialEnvNameAllowed(k) {
+			return nil, fmt.Errorf("exec credential environment variable %q isn't allowed by operator", k)
+		}
+	}
+
+	// Check if there are cached credentials
+	cInt, ok := execCredentialsCache.Load(name)
+	// Check if they are found
+	if ok {
+		// Cast
+		c, _ := cInt.(*cachedCredentials)
+		// Check if plugin haven't changed and if credentials are still valid
+		if isSameExecCredentialPlugin(c.plugin, plugin) && !c.credentials.needRefresh(plugin.RefreshBefore) {
+			return c.credentials, nil
+		}
+	}
+
+	// Execute plugin
+	res, err := execCredentialPlugin(ctx, plugin)
+	// Check error
+	if err != nil {
+		return nil, err
+	}
+
+	// Save in cache
+	execCredentialsCache.Store(name, &cachedCredentials{plugin: plugin, credentials: res})
+
+	return res, nil
+}
+
+// DeleteExecCredentials will remove cached credentials of engine.
+func DeleteExecCredentials(name string) {
+	execCredentialsCache.Delete(name)
+}
+
+// GetExecCredentialsRefreshTime will return the time when cached credentials of engine must be refreshed.
+// False is returned when there aren't any cached credentials or when they never expire.
+func GetExecCredentialsRefreshTime(name string) (time.Time, bool) {
+	// Get cached credentials
+	cInt, ok := execCredentialsCache.Load(name)
+	// Check if they aren't found
+	if !ok {
+		return time.Time{}, false
+	}
+
+	// Cast
+	c, _ := cInt.(*cachedCredentials)
+	// Check if credentials never expire
+	if c.credentials.ExpiresAt == nil {
+		return time.Time{}, false
+	}
+
+	return c.credentials.ExpiresAt.Add(-c.plugin.RefreshBefore), true
+}
+
+// needRefresh will return true if credentials expire in less than refresh before duration.
+func (c *Credentials) needRefresh(refreshBefore time.Duration) bool {
+	// Check if credentials never expire
+	if c.ExpiresAt == nil {
+		return false
+	}
+
+	return time.Now().Add(refreshBefore).After(*c.ExpiresAt)
+}
+
+func isSameExecCredentialPlugin(a, b *ExecCredentialPlugin) bool {
+	// Marshal both
+	aBytes, _ := json.Marshal(a)
+	bBytes, _ := json.Marshal(b)
+
+	return bytes.Equal(aBytes, bBytes)
+}
+
+func execCredentialPlugin(ctx context.Context, plugin *ExecCredentialPlugin) (*Credentials, error) {
+	// Get timeout
+	timeout := getExecCredentialTimeout()
+	// Create timeout in ctx
+	timeoutCtx, cancel := context.WithTimeout(ctx, timeout)
+	// Defer cancel
+	defer cancel()
+
+	// Prepare command
+	cmd := exec.CommandContext(timeoutCtx, plugin.Command, plugin.Args...) //nolint:gosec // Command is checked against allowed list
+	// Don't wait for outputs closing after kill as children processes can keep them opened
+	cmd.WaitDelay = execCredentialWaitDelay
+	// Add environment
+	cmd.Env = os.Environ()
+	for k, v := range plugin.Env {
+		cmd.Env = append(cmd.Env, k+"="+v)
+	}
+
+	// Prepare outputs
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	// Run
+	err := cmd.Run()
+	// Check if command have been killed because of plugin timeout
+	if ctx.Err() == nil && errors.Is(timeoutCtx.Err(), context.DeadlineExceeded) {
+		return nil, fmt.Errorf("exec credential command %q timed out after %s", plugin.Command, timeout)
+	}
+	// Check error
+	if err != nil {
+		return nil, fmt.Errorf("exec credential command %q failed: %w: %s", plugin.Command, err, stderr.String())
+	}
+
+	// Parse output
+	res := &Credentials{}
+	err = json.Unmarshal(stdout.Bytes(), res)
+	// Check error
+	if err != nil {
+		return nil, fmt.Errorf("exec credential command %q output is invalid: %w", plugin.Command, err)
+	}
+
+	// Check values
+	if res.User == "" || res.Password == "" {
+		return nil, fmt.Errorf("exec credential command %q output must contain \"user\" and \"password\" values", plugin.Command)
+	}
+
+	return res, nil
+}
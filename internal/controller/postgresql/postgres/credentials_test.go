@@ -0,0 +1,84 @@
+package postgres
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("GetExecCredentials", func() {
+	BeforeEach(func() {
+		SetAllowedCredentialCommands([]string{"/bin/sh"})
+		SetAllowedCredentialEnvNames([]string{"PLUGIN_USER"})
+		SetExecCredentialTimeout(DefaultExecCredentialTimeout)
+
+		DeleteExecCredentials("exec-test")
+	})
+
+	AfterEach(func() {
+		SetAllowedCredentialCommands(nil)
+		SetAllowedCredentialEnvNames(nil)
+		SetExecCredentialTimeout(DefaultExecCredentialTimeout)
+
+		DeleteExecCredentials("exec-test")
+	})
+
+	It("should return credentials printed by plugin with allowed environment", func() {
+		res, err := GetExecCredentials(context.TODO(), "exec-test", &ExecCredentialPlugin{
+			Command: "/bin/sh",
+			Args:    []string{"-c", `echo "{\"user\": \"$PLUGIN_USER\", \"password\": \"secret\"}"`},
+			Env:     map[string]string{"PLUGIN_USER": "plugin-user"},
+		})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(res.User).To(Equal("plugin-user"))
+		Expect(res.Password).To(Equal("secret"))
+	})
+
+	It("should reject commands not allowed by operator", func() {
+		_, err := GetExecCredentials(context.TODO(), "exec-test", &ExecCredentialPlugin{Command: "/bin/bash"})
+		Expect(err).To(MatchError(`exec credential command "/bin/bash" isn't allowed by operator`))
+	})
+
+	It("should reject environment variables not allowed by operator", func() {
+		_, err := GetExecCredentials(context.TODO(), "exec-test", &ExecCredentialPlugin{
+			Command: "/bin/sh",
+			Args:    []string{"-c", `echo '{"user": "u", "password": "p"}'`},
+			Env:     map[string]string{"LD_PRELOAD": "/tmp/evil.so"},
+		})
+		Expect(err).To(MatchError(`exec credential environment variable "LD_PRELOAD" isn't allowed by operator`))
+	})
+
+	It("should kill plugin after timeout", func() {
+		SetExecCredentialTimeout(100 * time.Millisecond)
+
+		start := time.Now()
+		_, err := GetExecCredentials(context.TODO(), "exec-test", &ExecCredentialPlugin{
+			Command: "/bin/sh",
+			Args:    []string{"-c", "sleep 10"},
+		})
+		Expect(err).To(MatchError(`exec credential command "/bin/sh" timed out after 100ms`))
+		Expect(time.Since(start)).To(BeNumerically("<", 5*time.Second))
+	})
+
+	It("should return refresh time of cached credentials and forget them", func() {
+		expiresAt := time.Now().Add(10 * time.Minute).UTC().Truncate(time.Second)
+
+		_, err := GetExecCredentials(context.TODO(), "exec-test", &ExecCredentialPlugin{
+			Command:       "/bin/sh",
+			Args:          []string{"-c", `echo '{"user": "u", "password": "p", "expiresAt": "` + expiresAt.Format(time.RFC3339) + `"}'`},
+			RefreshBefore: time.Minute,
+		})
+		Expect(err).NotTo(HaveOccurred())
+
+		refreshTime, ok := GetExecCredentialsRefreshTime("exec-test")
+		Expect(ok).To(BeTrue())
+		Expect(refreshTime).To(BeTemporally("==", expiresAt.Add(-time.Minute)))
+
+		DeleteExecCredentials("exec-test")
+
+		_, ok = GetExecCredentialsRefreshTime("exec-test")
+		Expect(ok).To(BeFalse())
+	})
+})
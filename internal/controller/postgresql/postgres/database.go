@@ -2,6 +2,8 @@ package postgres
 
 import (
 	"context"
+	"database/sql"
+	"errors"
 	"fmt"
 
 	"github.com/lib/pq"
@@ -10,38 +12,142 @@ import (
 const (
 	CascadeKeyword                 = "CASCADE"
 	RestrictKeyword                = "RESTRICT"
-	CreateDBSQLTemplate            = `CREATE DATABASE "%s" WITH OWNER = "%s"`
-	ChangeDBOwnerSQLTemplate       = `ALTER DATABASE "%s" OWNER TO "%s"`
-	IsDatabaseExistSQLTemplate     = `SELECT 1 FROM pg_database WHERE datname='%s'`
-	RenameDatabaseSQLTemplate      = `ALTER DATABASE "%s" RENAME TO "%s"`
-	CreateSchemaSQLTemplate        = `CREATE SCHEMA IF NOT EXISTS "%s" AUTHORIZATION "%s"`
-	CreateExtensionSQLTemplate     = `CREATE EXTENSION IF NOT EXISTS "%s"`
-	DropDatabaseSQLTemplate        = `DROP DATABASE "%s"`
-	DropExtensionSQLTemplate       = `DROP EXTENSION IF EXISTS "%s" %s`
-	DropSchemaSQLTemplate          = `DROP SCHEMA IF EXISTS "%s" %s`
-	GrantUsageSchemaSQLTemplate    = `GRANT USAGE ON SCHEMA "%s" TO "%s"`
-	GrantAllTablesSQLTemplate      = `GRANT %s ON ALL TABLES IN SCHEMA "%s" TO "%s"`
-	DefaultPrivsSchemaSQLTemplate  = `ALTER DEFAULT PRIVILEGES FOR ROLE "%s" IN SCHEMA "%s" GRANT %s ON TABLES TO "%s"`
-	GetTablesFromSchemaSQLTemplate = `SELECT tablename,tableowner FROM pg_tables WHERE schemaname = '%s'`
-	ChangeTableOwnerSQLTemplate    = `ALTER TABLE IF EXISTS "%s" OWNER TO "%s"`
-	ChangeTypeOwnerSQLTemplate     = `ALTER TYPE "%s"."%s" OWNER TO "%s"`
+	CreateDBSQLTemplate            = `CREATE DATABASE %s WITH OWNER = %s`
+	ChangeDBOwnerSQLTemplate       = `ALTER DATABASE %s OWNER TO %s`
+	IsDatabaseExistSQLTemplate     = `SELECT 1 FROM pg_database WHERE datname = $1`
+	RenameDatabaseSQLTemplate      = `ALTER DATABASE %s RENAME TO %s`
+	CreateSchemaSQLTemplate        = `CREATE SCHEMA IF NOT EXISTS %s AUTHORIZATION %s`
+	CreateExtensionSQLTemplate     = `CREATE EXTENSION IF NOT EXISTS %s`
+	DropDatabaseSQLTemplate        = `DROP DATABASE %s`
+	DropExtensionSQLTemplate       = `DROP EXTENSION IF EXISTS %s %s`
+	DropSchemaSQLTemplate          = `DROP SCHEMA IF EXISTS %s %s`
+	GrantUsageSchemaSQLTemplate    = `GRANT USAGE ON SCHEMA %s TO %s`
+	GrantAllTablesSQLTemplate      = `GRANT %s ON ALL TABLES IN SCHEMA %s TO %s`
+	DefaultPrivsSchemaSQLTemplate  = `ALTER DEFAULT PRIVILEGES FOR ROLE %s IN SCHEMA %s GRANT %s ON TABLES TO %s`
+	GetTablesFromSchemaSQLTemplate = `SELECT tablename,tableowner FROM pg_tables WHERE schemaname = $1`
+	ChangeTableOwnerSQLTemplate    = `ALTER TABLE IF EXISTS %s OWNER TO %s`
+	ChangeTypeOwnerSQLTemplate     = `ALTER TYPE %s.%s OWNER TO %s`
+	// Sequences, functions and types privileges
+	GrantAllSequencesSQLTemplate = `GRANT %s ON ALL SEQUENCES IN SCHEMA %s TO %s`
+	GrantAllFunctionsSQLTemplate = `GRANT %s ON ALL FUNCTIONS IN SCHEMA %s TO %s`
+	GrantTypeSQLTemplate         = `GRANT %s ON TYPE %s.%s TO %s`
+	// Object type is one of TABLES, SEQUENCES, FUNCTIONS or TYPES
+	DefaultPrivsSchemaObjectsSQLTemplate = `ALTER DEFAULT PRIVILEGES FOR ROLE %s IN SCHEMA %s GRANT %s ON %s TO %s`
+	// Object type is one of TABLES, SEQUENCES or FUNCTIONS
+	RevokeAllObjectsSQLTemplate = `REVOKE %s ON ALL %s IN SCHEMA %s FROM %s`
+	RevokeTypeSQLTemplate       = `REVOKE %s ON TYPE %s.%s FROM %s`
+	// Object type is one of TABLES, SEQUENCES, FUNCTIONS or TYPES
+	RevokeDefaultPrivsSchemaObjectsSQLTemplate = `ALTER DEFAULT PRIVILEGES FOR ROLE %s IN SCHEMA %s REVOKE %s ON %s FROM %s`
+
 	// Got and edited from : https://stackoverflow.com/questions/3660787/how-to-list-custom-types-using-postgres-information-schema
 	GetTypesFromSchemaSQLTemplate = `SELECT      t.typname as type, pg_catalog.pg_get_userbyid(t.typowner) as owner
 FROM        pg_type t
 LEFT JOIN   pg_catalog.pg_namespace n ON n.oid = t.typnamespace
 WHERE       (t.typrelid = 0 OR (SELECT c.relkind = 'c' FROM pg_catalog.pg_class c WHERE c.oid = t.typrelid))
 AND     NOT EXISTS(SELECT 1 FROM pg_catalog.pg_type el WHERE el.oid = t.typelem AND el.typarray = t.oid)
-AND     n.nspname = '%s';`
+AND     n.nspname = $1;`
+	// Provider and ICU locale columns depend on version (datlocprovider and daticulocale since 15, datlocale since 17)
+	GetDatabaseCreationOptionsSQLTemplate = `SELECT pg_encoding_to_char(d.encoding), COALESCE(d.datcollate, ''), COALESCE(d.datctype, ''),
+COALESCE(to_jsonb(d)->>'datlocprovider', ''), COALESCE(to_jsonb(d)->>'daticulocale', to_jsonb(d)->>'datlocale', ''), t.spcname
+FROM pg_database d
+JOIN pg_tablespace t ON t.oid = d.dattablespace
+WHERE d.datname = $1`
 	DuplicateDatabaseErrorCode = "42P04"
+	UndefinedDatabaseErrorCode = "3D000"
+
+	LibcLocaleProvider    = "libc"
+	ICULocaleProvider     = "icu"
+	BuiltinLocaleProvider = "builtin"
 )
 
+// Locale provider codes in pg_database.datlocprovider.
+var localeProviderCodes = map[string]string{
+	"c": LibcLocaleProvider,
+	"i": ICULocaleProvider,
+	"b": BuiltinLocaleProvider,
+}
+
+// DatabaseCreationOptions are the options used in CREATE DATABASE.
+// Empty values are ignored and engine defaults are used.
+type DatabaseCreationOptions struct {
+	Encoding       string
+	Locale         string
+	LocaleProvider string
+	ICULocale      string
+	Template       string
+	Tablespace     string
+}
+
+// SchemaPrivileges are privileges granted to a role on objects of a schema, for existing and future objects.
+// Empty values are ignored.
+type SchemaPrivileges struct {
+	// Privileges on tables (like SELECT,INSERT)
+	Tables string
+	// Privileges on sequences (like USAGE,SELECT,UPDATE)
+	Sequences string
+	// Privileges on functions (EXECUTE)
+	Functions string
+	// Privileges on types (USAGE)
+	Types string
+}
+
+// buildCreateDBOptionsSQL will return creation options SQL part starting with a space.
+func buildCreateDBOptionsSQL(options *DatabaseCreationOptions) string {
+	// Check if there isn't any option
+	if options == nil {
+		return ""
+	}
+
+	res := ""
+	// Check template
+	if options.Template != "" {
+		res += " TEMPLATE = " + pq.QuoteIdentifier(options.Template)
+	}
+	// Check encoding
+	if options.Encoding != "" {
+		res += " ENCODING = " + pq.QuoteLiteral(options.Encoding)
+	}
+	// Check locale
+	// ? Note: LC_COLLATE and LC_CTYPE are used instead of LOCALE to support versions before 13
+	if options.Locale != "" {
+		res += " LC_COLLATE = " + pq.QuoteLiteral(options.Locale) + " LC_CTYPE = " + pq.QuoteLiteral(options.Locale)
+	}
+	// Check locale provider
+	if options.LocaleProvider != "" {
+		res += " LOCALE_PROVIDER = " + pq.QuoteLiteral(options.LocaleProvider)
+	}
+	// Check ICU locale
+	if options.ICULocale != "" {
+		res += " ICU_LOCALE = " + pq.QuoteLiteral(options.ICULocale)
+	}
+	// Check tablespace
+	if options.Tablespace != "" {
+		res += " TABLESPACE = " + pq.QuoteIdentifier(options.Tablespace)
+	}
+
+	return res
+}
+
+// buildCreateDBWithoutOwnerSQL will return CREATE DATABASE statement without owner.
+func buildCreateDBWithoutOwnerSQL(dbname string, options *DatabaseCreationOptions) string {
+	res := fmt.Sprintf(CreateDBWithoutOwnerSQLTemplate, pq.QuoteIdentifier(dbname))
+	// Get options
+	opts := buildCreateDBOptionsSQL(options)
+	// Check if there are options
+	if opts != "" {
+		res += " WITH" + opts
+	}
+
+	return res
+}
+
 func (c *pg) IsDatabaseExist(ctx context.Context, dbname string) (bool, error) {
 	err := c.connect(c.defaultDatabase)
 	if err != nil {
 		return false, err
 	}
 
-	res, err := c.db.ExecContext(ctx, fmt.Sprintf(IsDatabaseExistSQLTemplate, dbname))
+	res, err := c.db.ExecContext(ctx, IsDatabaseExistSQLTemplate, dbname)
 	if err != nil {
 		return false, err
 	}
@@ -60,7 +166,7 @@ func (c *pg) RenameDatabase(ctx context.Context, oldname, newname string) error
 		return err
 	}
 
-	_, err = c.db.ExecContext(ctx, fmt.Sprintf(RenameDatabaseSQLTemplate, oldname, newname))
+	_, err = c.exec(ctx, fmt.Sprintf(RenameDatabaseSQLTemplate, pq.QuoteIdentifier(oldname), pq.QuoteIdentifier(newname)))
 	if err != nil {
 		return err
 	}
@@ -68,13 +174,13 @@ func (c *pg) RenameDatabase(ctx context.Context, oldname, newname string) error
 	return nil
 }
 
-func (c *pg) CreateDB(ctx context.Context, dbname, role string) error {
+func (c *pg) CreateDB(ctx context.Context, dbname, role string, options *DatabaseCreationOptions) error {
 	err := c.connect(c.defaultDatabase)
 	if err != nil {
 		return err
 	}
 
-	_, err = c.db.ExecContext(ctx, fmt.Sprintf(CreateDBSQLTemplate, dbname, role))
+	_, err = c.exec(ctx, fmt.Sprintf(CreateDBSQLTemplate, pq.QuoteIdentifier(dbname), pq.QuoteIdentifier(role))+buildCreateDBOptionsSQL(options))
 	if err != nil {
 		// eat DUPLICATE DATABASE ERROR
 		// Try to cast error
@@ -87,13 +193,55 @@ func (c *pg) CreateDB(ctx context.Context, dbname, role string) error {
 	return nil
 }
 
+// GetDatabaseCreationOptions will return creation options of an existing database as reported by pg_database.
+// Template isn't returned as engine doesn't keep it. Nil is returned if database doesn't exist.
+func (c *pg) GetDatabaseCreationOptions(ctx context.Context, dbname string) (*DatabaseCreationOptions, error) {
+	err := c.connect(c.defaultDatabase)
+	if err != nil {
+		return nil, err
+	}
+
+	res := &DatabaseCreationOptions{}
+	localeCollate, localeCtype, localeProvider := "", "", ""
+
+	err = c.db.QueryRowContext(ctx, GetDatabaseCreationOptionsSQLTemplate, dbname).Scan(
+		&res.Encoding,
+		&localeCollate,
+		&localeCtype,
+		&localeProvider,
+		&res.ICULocale,
+		&res.Tablespace,
+	)
+	// Check error
+	if err != nil {
+		// Check if database doesn't exist
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	// Locale is only reported when collate and ctype are the same
+	if localeCollate == localeCtype {
+		res.Locale = localeCollate
+	} else {
+		res.Locale = fmt.Sprintf("%s/%s", localeCollate, localeCtype)
+	}
+
+	// Convert locale provider code
+	res.LocaleProvider = localeProviderCodes[localeProvider]
+
+	return res, nil
+}
+
 func (c *pg) ChangeDBOwner(ctx context.Context, dbname, owner string) error {
 	err := c.connect(c.defaultDatabase)
 	if err != nil {
 		return err
 	}
 
-	_, err = c.db.ExecContext(ctx, fmt.Sprintf(ChangeDBOwnerSQLTemplate, dbname, owner))
+	_, err = c.exec(ctx, fmt.Sprintf(ChangeDBOwnerSQLTemplate, pq.QuoteIdentifier(dbname), pq.QuoteIdentifier(owner)))
 	if err != nil {
 		return err
 	}
@@ -107,7 +255,7 @@ func (c *pg) CreateSchema(ctx context.Context, db, role, schema string) error {
 		return err
 	}
 
-	_, err = c.db.ExecContext(ctx, fmt.Sprintf(CreateSchemaSQLTemplate, schema, role))
+	_, err = c.exec(ctx, fmt.Sprintf(CreateSchemaSQLTemplate, pq.QuoteIdentifier(schema), pq.QuoteIdentifier(role)))
 	if err != nil {
 		return err
 	}
@@ -121,8 +269,13 @@ func (c *pg) GetTablesInSchema(ctx context.Context, db, schema string) ([]*Table
 		return nil, err
 	}
 
-	rows, err := c.db.QueryContext(ctx, fmt.Sprintf(GetTablesFromSchemaSQLTemplate, schema))
+	rows, err := c.db.QueryContext(ctx, GetTablesFromSchemaSQLTemplate, schema)
 	if err != nil {
+		// Check if database creation have only been planned
+		if c.isPlannedMissingDatabaseError(err) {
+			return []*TableOwnership{}, nil
+		}
+
 		return nil, err
 	}
 
@@ -158,7 +311,7 @@ func (c *pg) ChangeTableOwner(ctx context.Context, db, table, owner string) erro
 		return err
 	}
 
-	_, err = c.db.ExecContext(ctx, fmt.Sprintf(ChangeTableOwnerSQLTemplate, table, owner))
+	_, err = c.exec(ctx, fmt.Sprintf(ChangeTableOwnerSQLTemplate, pq.QuoteIdentifier(table), pq.QuoteIdentifier(owner)))
 	if err != nil {
 		return err
 	}
@@ -172,8 +325,13 @@ func (c *pg) GetTypesInSchema(ctx context.Context, db, schema string) ([]*TypeOw
 		return nil, err
 	}
 
-	rows, err := c.db.QueryContext(ctx, fmt.Sprintf(GetTypesFromSchemaSQLTemplate, schema))
+	rows, err := c.db.QueryContext(ctx, GetTypesFromSchemaSQLTemplate, schema)
 	if err != nil {
+		// Check if database creation have only been planned
+		if c.isPlannedMissingDatabaseError(err) {
+			return []*TypeOwnership{}, nil
+		}
+
 		return nil, err
 	}
 
@@ -209,7 +367,7 @@ func (c *pg) ChangeTypeOwnerInSchema(ctx context.Context, db, schema, typeName,
 		return err
 	}
 
-	_, err = c.db.ExecContext(ctx, fmt.Sprintf(ChangeTypeOwnerSQLTemplate, schema, typeName, owner))
+	_, err = c.exec(ctx, fmt.Sprintf(ChangeTypeOwnerSQLTemplate, pq.QuoteIdentifier(schema), pq.QuoteIdentifier(typeName), pq.QuoteIdentifier(owner)))
 	if err != nil {
 		return err
 	}
@@ -223,7 +381,7 @@ func (c *pg) DropDatabase(ctx context.Context, database string) error {
 		return err
 	}
 
-	_, err = c.db.ExecContext(ctx, fmt.Sprintf(DropDatabaseSQLTemplate, database))
+	_, err = c.exec(ctx, fmt.Sprintf(DropDatabaseSQLTemplate, pq.QuoteIdentifier(database)))
 	// Error code 3D000 is returned if database doesn't exist
 	if err != nil {
 		// Try to cast error
@@ -249,7 +407,7 @@ func (c *pg) DropExtension(ctx context.Context, database, extension string, casc
 		param = CascadeKeyword
 	}
 
-	_, err = c.db.ExecContext(ctx, fmt.Sprintf(DropExtensionSQLTemplate, extension, param))
+	_, err = c.exec(ctx, fmt.Sprintf(DropExtensionSQLTemplate, pq.QuoteIdentifier(extension), param))
 	if err != nil {
 		return err
 	}
@@ -270,7 +428,7 @@ func (c *pg) DropSchema(ctx context.Context, database, schema string, cascade bo
 		param = CascadeKeyword
 	}
 
-	_, err = c.db.ExecContext(ctx, fmt.Sprintf(DropSchemaSQLTemplate, schema, param))
+	_, err = c.exec(ctx, fmt.Sprintf(DropSchemaSQLTemplate, pq.QuoteIdentifier(schema), param))
 	if err != nil {
 		return err
 	}
@@ -286,7 +444,7 @@ func (c *pg) CreateExtension(ctx context.Context, db, extension string) error {
 		return err
 	}
 
-	_, err = c.db.ExecContext(ctx, fmt.Sprintf(CreateExtensionSQLTemplate, extension))
+	_, err = c.exec(ctx, fmt.Sprintf(CreateExtensionSQLTemplate, pq.QuoteIdentifier(extension)))
 	if err != nil {
 		return err
 	}
@@ -294,29 +452,170 @@ func (c *pg) CreateExtension(ctx context.Context, db, extension string) error {
 	return nil
 }
 
-func (c *pg) SetSchemaPrivileges(ctx context.Context, db, creator, role, schema, privs string) error {
+func (c *pg) SetSchemaPrivileges(ctx context.Context, db, creator, role, schema string, privs *SchemaPrivileges) error {
 	err := c.connect(db)
 	if err != nil {
 		return err
 	}
 
 	// Grant role usage on schema
-	_, err = c.db.ExecContext(ctx, fmt.Sprintf(GrantUsageSchemaSQLTemplate, schema, role))
+	_, err = c.exec(ctx, fmt.Sprintf(GrantUsageSchemaSQLTemplate, pq.QuoteIdentifier(schema), pq.QuoteIdentifier(role)))
 	if err != nil {
 		return err
 	}
 
-	// Grant role privs on existing tables in schema
-	_, err = c.db.ExecContext(ctx, fmt.Sprintf(GrantAllTablesSQLTemplate, privs, schema, role))
-	if err != nil {
-		return err
+	// Check if there are privileges on tables
+	if privs.Tables != "" {
+		// Grant role privs on existing tables in schema
+		_, err = c.exec(ctx, fmt.Sprintf(GrantAllTablesSQLTemplate, privs.Tables, pq.QuoteIdentifier(schema), pq.QuoteIdentifier(role)))
+		if err != nil {
+			return err
+		}
+
+		// Grant role privs on future tables in schema
+		_, err = c.exec(ctx, fmt.Sprintf(DefaultPrivsSchemaSQLTemplate, pq.QuoteIdentifier(creator), pq.QuoteIdentifier(schema), privs.Tables, pq.QuoteIdentifier(role)))
+		if err != nil {
+			return err
+		}
+	}
+
+	// Check if there are privileges on sequences
+	if privs.Sequences != "" {
+		// Grant role privs on existing sequences in schema
+		_, err = c.exec(ctx, fmt.Sprintf(GrantAllSequencesSQLTemplate, privs.Sequences, pq.QuoteIdentifier(schema), pq.QuoteIdentifier(role)))
+		if err != nil {
+			return err
+		}
+
+		// Grant role privs on future sequences in schema
+		_, err = c.exec(ctx, fmt.Sprintf(
+			DefaultPrivsSchemaObjectsSQLTemplate,
+			pq.QuoteIdentifier(creator), pq.QuoteIdentifier(schema), privs.Sequences, "SEQUENCES", pq.QuoteIdentifier(role),
+		))
+		if err != nil {
+			return err
+		}
+	}
+
+	// Check if there are privileges on functions
+	if privs.Functions != "" {
+		// Grant role privs on existing functions in schema
+		_, err = c.exec(ctx, fmt.Sprintf(GrantAllFunctionsSQLTemplate, privs.Functions, pq.QuoteIdentifier(schema), pq.QuoteIdentifier(role)))
+		if err != nil {
+			return err
+		}
+
+		// Grant role privs on future functions in schema
+		_, err = c.exec(ctx, fmt.Sprintf(
+			DefaultPrivsSchemaObjectsSQLTemplate,
+			pq.QuoteIdentifier(creator), pq.QuoteIdentifier(schema), privs.Functions, "FUNCTIONS", pq.QuoteIdentifier(role),
+		))
+		if err != nil {
+			return err
+		}
+	}
+
+	// Check if there are privileges on types
+	if privs.Types != "" {
+		// Get existing types in schema
+		// ? Note: There isn't any GRANT ON ALL TYPES statement
+		types, err := c.GetTypesInSchema(ctx, db, schema)
+		if err != nil {
+			return err
+		}
+
+		// Grant role privs on existing types in schema
+		for _, it := range types {
+			_, err = c.exec(ctx, fmt.Sprintf(
+				GrantTypeSQLTemplate,
+				privs.Types, pq.QuoteIdentifier(schema), pq.QuoteIdentifier(it.TypeName), pq.QuoteIdentifier(role),
+			))
+			if err != nil {
+				return err
+			}
+		}
+
+		// Grant role privs on future types in schema
+		_, err = c.exec(ctx, fmt.Sprintf(
+			DefaultPrivsSchemaObjectsSQLTemplate,
+			pq.QuoteIdentifier(creator), pq.QuoteIdentifier(schema), privs.Types, "TYPES", pq.QuoteIdentifier(role),
+		))
+		if err != nil {
+			return err
+		}
 	}
 
-	// Grant role privs on future tables in schema
-	_, err = c.db.ExecContext(ctx, fmt.Sprintf(DefaultPrivsSchemaSQLTemplate, creator, schema, privs, role))
+	return nil
+}
+
+// RevokeSchemaPrivileges will revoke privileges from role on existing and future objects of schema.
+func (c *pg) RevokeSchemaPrivileges(ctx context.Context, db, creator, role, schema string, privs *SchemaPrivileges) error {
+	err := c.connect(db)
 	if err != nil {
 		return err
 	}
 
+	// Privileges by object type
+	objectPrivs := []struct {
+		objectType string
+		privs      string
+	}{
+		{objectType: "TABLES", privs: privs.Tables},
+		{objectType: "SEQUENCES", privs: privs.Sequences},
+		{objectType: "FUNCTIONS", privs: privs.Functions},
+	}
+
+	// Loop over them
+	for _, it := range objectPrivs {
+		// Ignore empty privileges
+		if it.privs == "" {
+			continue
+		}
+
+		// Revoke role privs on existing objects in schema
+		_, err = c.exec(ctx, fmt.Sprintf(RevokeAllObjectsSQLTemplate, it.privs, it.objectType, pq.QuoteIdentifier(schema), pq.QuoteIdentifier(role)))
+		if err != nil {
+			return err
+		}
+
+		// Revoke role privs on future objects in schema
+		_, err = c.exec(ctx, fmt.Sprintf(
+			RevokeDefaultPrivsSchemaObjectsSQLTemplate,
+			pq.QuoteIdentifier(creator), pq.QuoteIdentifier(schema), it.privs, it.objectType, pq.QuoteIdentifier(role),
+		))
+		if err != nil {
+			return err
+		}
+	}
+
+	// Check if there are privileges on types
+	if privs.Types != "" {
+		// Get existing types in schema
+		types, err := c.GetTypesInSchema(ctx, db, schema)
+		if err != nil {
+			return err
+		}
+
+		// Revoke role privs on existing types in schema
+		for _, it := range types {
+			_, err = c.exec(ctx, fmt.Sprintf(
+				RevokeTypeSQLTemplate,
+				privs.Types, pq.QuoteIdentifier(schema), pq.QuoteIdentifier(it.TypeName), pq.QuoteIdentifier(role),
+			))
+			if err != nil {
+				return err
+			}
+		}
+
+		// Revoke role privs on future types in schema
+		_, err = c.exec(ctx, fmt.Sprintf(
+			RevokeDefaultPrivsSchemaObjectsSQLTemplate,
+			pq.QuoteIdentifier(creator), pq.QuoteIdentifier(schema), privs.Types, "TYPES", pq.QuoteIdentifier(role),
+		))
+		if err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
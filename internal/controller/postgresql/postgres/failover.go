@@ -0,0 +1,86 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/lib/pq"
+)
+
+const (
+	GetServerIdentitySQLTemplate   = `SELECT pg_is_in_recovery(), COALESCE(host(inet_server_addr()), '')`
+	GetSystemIdentifierSQLTemplate = `SELECT system_identifier::text FROM pg_control_system()`
+
+	ReadOnlySQLTransactionErrorCode = "25006"
+)
+
+// ServerIdentity identifies the server behind engine host.
+type ServerIdentity struct {
+	// System identifier of the cluster (pg_control_system()). Empty when it cannot be read.
+	SystemIdentifier string
+	// Result of pg_is_in_recovery()
+	InRecovery bool
+	// Result of inet_server_addr(). Empty for unix socket connections.
+	ServerAddress string
+}
+
+// String will return a readable identity.
+func (s *ServerIdentity) String() string {
+	return fmt.Sprintf("system identifier %q, address %q, in recovery %t", s.SystemIdentifier, s.ServerAddress, s.InRecovery)
+}
+
+// IsSameServer will return true if both identities are pointing to the same server in the same state.
+// System identifiers are only compared when both are known.
+func (s *ServerIdentity) IsSameServer(other *ServerIdentity) bool {
+	// Check system identifier
+	if s.SystemIdentifier != "" && other.SystemIdentifier != "" && s.SystemIdentifier != other.SystemIdentifier {
+		return false
+	}
+
+	return s.InRecovery == other.InRecovery && s.ServerAddress == other.ServerAddress
+}
+
+// IsReadOnlySQLTransactionError will return true if error is a "cannot execute ... in a read-only transaction" one.
+// This happens when connections are still opened on an old primary after a failover.
+func IsReadOnlySQLTransactionError(err error) bool {
+	var pqErr *pq.Error
+	// Try to find pq error
+	// ? Note: Errors must be wrapped to keep pq error reachable
+	return errors.As(err, &pqErr) && pqErr.Code == ReadOnlySQLTransactionErrorCode
+}
+
+// GetServerIdentity will return identity of the server currently behind engine host.
+// A dedicated connection is used as pooled connections can still be opened on a previous server.
+func (c *pg) GetServerIdentity(ctx context.Context) (*ServerIdentity, error) {
+	// Open a dedicated connection
+	db, err := c.openDedicatedConnection(&Endpoint{Host: c.host, Port: c.port, URIArgs: c.args}, c.GetPassword())
+	// Check error
+	if err != nil {
+		return nil, err
+	}
+	// Close it at the end
+	defer db.Close()
+
+	// Limit query duration
+	identityCtx, cancel := context.WithTimeout(ctx, DefaultEndpointProbeTimeout)
+	defer cancel()
+
+	res := &ServerIdentity{}
+	// Get recovery status and address
+	err = db.QueryRowContext(identityCtx, GetServerIdentitySQLTemplate).Scan(&res.InRecovery, &res.ServerAddress)
+	// Check error
+	if err != nil {
+		return nil, err
+	}
+
+	// Get system identifier
+	err = db.QueryRowContext(identityCtx, GetSystemIdentifierSQLTemplate).Scan(&res.SystemIdentifier)
+	// Check error
+	if err != nil {
+		// pg_control_system() is restricted to superusers by default
+		c.log.V(1).Info(fmt.Sprintf("cannot get system identifier: %s", err))
+	}
+
+	return res, nil
+}
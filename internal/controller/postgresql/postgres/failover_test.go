@@ -0,0 +1,21 @@
+package postgres
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/lib/pq"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = DescribeTable("IsReadOnlySQLTransactionError",
+	func(err error, expected bool) {
+		Expect(IsReadOnlySQLTransactionError(err)).To(Equal(expected))
+	},
+	Entry("nil error", nil, false),
+	Entry("read only pq error", &pq.Error{Code: ReadOnlySQLTransactionErrorCode}, true),
+	Entry("wrapped read only pq error", fmt.Errorf("cannot create database: %w", &pq.Error{Code: ReadOnlySQLTransactionErrorCode}), true),
+	Entry("other pq error", &pq.Error{Code: InsufficientPrivilegeErrorCode}, false),
+	Entry("error with same message without pq error", errors.New("cannot execute CREATE DATABASE in a read-only transaction"), false),
+)
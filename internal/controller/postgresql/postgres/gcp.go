@@ -0,0 +1,249 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/lib/pq"
+	"github.com/samber/lo"
+
+	"github.com/easymile/postgresql-operator/api/postgresql/v1alpha1"
+)
+
+type gcppg struct {
+	pg
+}
+
+func init() {
+	RegisterProvider(v1alpha1.GCPProvider, &ProviderCapabilities{MembershipToReassign: true}, newGCPPG)
+}
+
+func newGCPPG(postgres *pg) PG {
+	return &gcppg{
+		*postgres,
+	}
+}
+
+// grantTemporaryMembership will grant role to engine user if it isn't already a member.
+// Returned function must be called to revoke the membership when it has been granted.
+func (c *gcppg) grantTemporaryMembership(ctx context.Context, role string) (func(), error) {
+	// Check if it is the engine user
+	if role == c.user {
+		return func() {}, nil
+	}
+
+	// Get current membership
+	membership, err := c.GetRoleMembership(ctx, c.user)
+	// Check error
+	if err != nil {
+		return nil, err
+	}
+
+	// Check if user is already a member
+	if lo.Contains(membership, role) {
+		return func() {}, nil
+	}
+
+	// On Cloud SQL, the user is a member of cloudsqlsuperuser and isn't a real superuser
+	// so it must belong to roles to act on their objects
+	err = c.GrantRole(ctx, role, c.user, false)
+	// Check error
+	if err != nil {
+		return nil, err
+	}
+
+	return func() {
+		err := c.RevokeRole(ctx, role, c.user)
+		// Check error
+		if err != nil {
+			c.log.Error(err, "error in revoke role")
+		}
+	}, nil
+}
+
+// grantTemporaryMemberships will grant all roles to engine user and return a function revoking them.
+// Roles that don't exist are ignored.
+func (c *gcppg) grantTemporaryMemberships(ctx context.Context, roles ...string) (func(), error) {
+	revokes := make([]func(), 0)
+	// Revoke all
+	revokeAll := func() {
+		// Loop over revokes in reverse order
+		for i := len(revokes) - 1; i >= 0; i-- {
+			revokes[i]()
+		}
+	}
+
+	// Loop over roles
+	for _, role := range roles {
+		revoke, err := c.grantTemporaryMembership(ctx, role)
+		// Check error
+		if err != nil {
+			// Try to cast error
+			pqErr, ok := err.(*pq.Error)
+			// Ignore roles that don't exist
+			if ok && pqErr.Code == RoleNotFoundErrorCode {
+				c.log.Info(fmt.Sprintf("not granting %s to %s as %s does not exist", role, c.user, role))
+
+				continue
+			}
+
+			// Revoke already granted ones
+			revokeAll()
+
+			return nil, err
+		}
+
+		revokes = append(revokes, revoke)
+	}
+
+	return revokeAll, nil
+}
+
+// grantTemporaryReplication will add replication attribute to engine user if it doesn't have it.
+// Returned function must be called to remove the attribute when it has been added.
+func (c *gcppg) grantTemporaryReplication(ctx context.Context) (func(), error) {
+	// Get current attributes
+	attributes, err := c.GetRoleAttributes(ctx, c.user)
+	// Check error
+	if err != nil {
+		return nil, err
+	}
+
+	// Check if user already has replication
+	if *attributes.Replication {
+		return func() {}, nil
+	}
+
+	// On Cloud SQL, cloudsqlsuperuser members don't have replication attribute by default but can set it
+	err = c.AlterRoleAttributes(ctx, c.user, &RoleAttributes{Replication: lo.ToPtr(true)})
+	// Check error
+	if err != nil {
+		return nil, err
+	}
+
+	return func() {
+		err := c.AlterRoleAttributes(ctx, c.user, &RoleAttributes{Replication: lo.ToPtr(false)})
+		// Check error
+		if err != nil {
+			c.log.Error(err, "error in remove replication attribute")
+		}
+	}, nil
+}
+
+func (c *gcppg) AlterDefaultLoginRole(ctx context.Context, role, setRole string) error {
+	// User must belong to role in order to alter it
+	revoke, err := c.grantTemporaryMembership(ctx, role)
+	// Check error
+	if err != nil {
+		return err
+	}
+
+	defer revoke()
+
+	return c.pg.AlterDefaultLoginRole(ctx, role, setRole)
+}
+
+func (c *gcppg) CreateDB(ctx context.Context, dbname, role string, options *DatabaseCreationOptions) error {
+	// User must belong to owner role in order to create a database owned by it
+	revoke, err := c.grantTemporaryMembership(ctx, role)
+	// Check error
+	if err != nil {
+		return err
+	}
+
+	defer revoke()
+
+	err = c.connect(c.defaultDatabase)
+	if err != nil {
+		return err
+	}
+
+	_, err = c.exec(ctx, buildCreateDBWithoutOwnerSQL(dbname, options))
+	if err != nil {
+		// eat DUPLICATE DATABASE ERROR
+		// Try to cast error
+		pqErr, ok := err.(*pq.Error)
+		if !ok || pqErr.Code != DuplicateDatabaseErrorCode {
+			return err
+		}
+	}
+
+	_, err = c.exec(ctx, fmt.Sprintf(AlterDBOwnerSQLTemplate, pq.QuoteIdentifier(dbname), pq.QuoteIdentifier(role)))
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (c *gcppg) ChangeDBOwner(ctx context.Context, dbname, owner string) error {
+	// User must belong to new owner role in order to give it the database
+	revoke, err := c.grantTemporaryMembership(ctx, owner)
+	// Check error
+	if err != nil {
+		return err
+	}
+
+	defer revoke()
+
+	return c.pg.ChangeDBOwner(ctx, dbname, owner)
+}
+
+func (c *gcppg) DropRoleAndDropAndChangeOwnedBy(ctx context.Context, role, newOwner, database string) error {
+	// User must belong to both roles in order to REASSIGN OWNED BY
+	revoke, err := c.grantTemporaryMemberships(ctx, role, newOwner)
+	// Check error
+	if err != nil {
+		return err
+	}
+
+	// Change and drop owned by
+	err = c.pg.ChangeAndDropOwnedBy(ctx, role, newOwner, database)
+	// Revoke memberships before role deletion
+	revoke()
+	// Check error
+	if err != nil {
+		return err
+	}
+
+	return c.DropRole(ctx, role)
+}
+
+func (c *gcppg) ChangeAndDropOwnedBy(ctx context.Context, role, newOwner, database string) error {
+	// User must belong to both roles in order to REASSIGN OWNED BY
+	revoke, err := c.grantTemporaryMemberships(ctx, role, newOwner)
+	// Check error
+	if err != nil {
+		return err
+	}
+
+	defer revoke()
+
+	return c.pg.ChangeAndDropOwnedBy(ctx, role, newOwner, database)
+}
+
+func (c *gcppg) CreateReplicationSlot(ctx context.Context, dbname, name, plugin string) error {
+	// User must have replication attribute in order to create a replication slot
+	revoke, err := c.grantTemporaryReplication(ctx)
+	// Check error
+	if err != nil {
+		return err
+	}
+
+	defer revoke()
+
+	return c.pg.CreateReplicationSlot(ctx, dbname, name, plugin)
+}
+
+func (c *gcppg) DropReplicationSlot(ctx context.Context, name string) error {
+	// User must have replication attribute in order to drop a replication slot
+	revoke, err := c.grantTemporaryReplication(ctx)
+	// Check error
+	if err != nil {
+		return err
+	}
+
+	defer revoke()
+
+	return c.pg.DropReplicationSlot(ctx, name)
+}
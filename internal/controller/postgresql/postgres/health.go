@@ -0,0 +1,139 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net"
+	"strconv"
+	"time"
+)
+
+const (
+	GetEndpointRecoveryStatusSQLTemplate = `SELECT pg_is_in_recovery(), CASE WHEN pg_is_in_recovery() THEN COALESCE((EXTRACT(EPOCH FROM (now() - pg_last_xact_replay_timestamp())) * 1000)::bigint, 0) ELSE 0 END` //nolint:lll // Because
+
+	DefaultEndpointProbeTimeout = 5 * time.Second
+)
+
+// Endpoint is a user connection endpoint probed with engine user.
+type Endpoint struct {
+	Host    string
+	Port    int
+	URIArgs string
+}
+
+// String will return endpoint address.
+func (e *Endpoint) String() string {
+	return net.JoinHostPort(e.Host, strconv.Itoa(e.Port))
+}
+
+// EndpointHealth is the result of an endpoint probe.
+type EndpointHealth struct {
+	// Connection and ping latency
+	Latency time.Duration
+	// Result of pg_is_in_recovery(). Nil when it cannot be read (like on a pg bouncer admin console).
+	InRecovery *bool
+	// Time since last replayed transaction when endpoint is in recovery. Nil when it cannot be read.
+	ReplicationLag *time.Duration
+}
+
+// openDedicatedConnection will open a single connection database handle to endpoint with engine user and provided password.
+// It doesn't use pools so it can be used to check new credentials or other hosts.
+func (c *pg) openDedicatedConnection(endpoint *Endpoint, password string) (*sql.DB, error) {
+	// Get connection args with TLS overrides
+	args, err := c.getConnectionArgsFrom(endpoint.URIArgs)
+	// Check error
+	if err != nil {
+		return nil, err
+	}
+
+	// Generate url
+	pgURL := TemplatePostgresqlURLWithArgs(
+		endpoint.Host,
+		c.GetUser(),
+		password,
+		args,
+		c.defaultDatabase,
+		endpoint.Port,
+	)
+
+	// Open
+	db, err := sql.Open("postgres", pgURL)
+	// Check error
+	if err != nil {
+		return nil, err
+	}
+
+	// Only one connection is needed
+	db.SetMaxOpenConns(1)
+
+	return db, nil
+}
+
+// CheckLogin will open a fresh connection on engine host with engine user and provided password.
+// An error is returned if login fails.
+func (c *pg) CheckLogin(ctx context.Context, password string) error {
+	// Open a dedicated connection to avoid any reuse of already opened pool connections
+	db, err := c.openDedicatedConnection(&Endpoint{Host: c.host, Port: c.port, URIArgs: c.args}, password)
+	// Check error
+	if err != nil {
+		return err
+	}
+	// Close it at the end
+	defer db.Close()
+
+	// Limit check duration
+	checkCtx, cancel := context.WithTimeout(ctx, DefaultEndpointProbeTimeout)
+	defer cancel()
+
+	return db.PingContext(checkCtx)
+}
+
+// ProbeEndpoint will connect to endpoint with engine user without using pools
+// and return its latency, recovery status and replication lag.
+// An error is returned when endpoint isn't reachable.
+func (c *pg) ProbeEndpoint(ctx context.Context, endpoint *Endpoint) (*EndpointHealth, error) {
+	// Open a dedicated connection as endpoint can be a replica or a bouncer
+	db, err := c.openDedicatedConnection(endpoint, c.GetPassword())
+	// Check error
+	if err != nil {
+		return nil, err
+	}
+	// Close it at the end
+	defer db.Close()
+
+	// Limit probe duration
+	probeCtx, cancel := context.WithTimeout(ctx, DefaultEndpointProbeTimeout)
+	defer cancel()
+
+	// Save start time
+	start := time.Now()
+	// Try to connect
+	err = db.PingContext(probeCtx)
+	// Check error
+	if err != nil {
+		return nil, fmt.Errorf("endpoint %s isn't reachable: %w", endpoint, err)
+	}
+
+	res := &EndpointHealth{Latency: time.Since(start)}
+
+	// Get recovery status and replication lag
+	inRecovery := false
+	lagMs := int64(0)
+	err = db.QueryRowContext(probeCtx, GetEndpointRecoveryStatusSQLTemplate).Scan(&inRecovery, &lagMs)
+	// Check error
+	if err != nil {
+		// Endpoint is reachable, only recovery status is unknown
+		c.log.V(1).Info(fmt.Sprintf("cannot get recovery status of endpoint %s: %s", endpoint, err))
+
+		return res, nil
+	}
+
+	// Compute lag
+	lag := time.Duration(lagMs) * time.Millisecond
+
+	res.InRecovery = &inRecovery
+	res.ReplicationLag = &lag
+
+	return res, nil
+}
@@ -0,0 +1,131 @@
+package postgres
+
+import (
+	"strconv"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	endpointMetricsSubsystem = "engine_endpoint"
+	endpointTypeLabel        = "type"
+	endpointHostLabel        = "host"
+	endpointPortLabel        = "port"
+)
+
+// Endpoint health metrics collector used by all engines.
+var endpointMetrics = newEndpointMetricsCollector()
+
+// endpointMetricsCollector will export results of last user connection endpoint probes.
+type endpointMetricsCollector struct {
+	up             *prometheus.GaugeVec
+	latency        *prometheus.GaugeVec
+	inRecovery     *prometheus.GaugeVec
+	replicationLag *prometheus.GaugeVec
+}
+
+func newEndpointMetricsCollector() *endpointMetricsCollector {
+	labels := []string{engineLabel, endpointTypeLabel, endpointHostLabel, endpointPortLabel}
+
+	return &endpointMetricsCollector{
+		up: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Namespace: poolMetricsNamespace,
+				Subsystem: endpointMetricsSubsystem,
+				Name:      "up",
+				Help:      "1 if user connection endpoint was reachable during last probe, 0 otherwise.",
+			},
+			labels,
+		),
+		latency: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Namespace: poolMetricsNamespace,
+				Subsystem: endpointMetricsSubsystem,
+				Name:      "latency_seconds",
+				Help:      "Connection and ping latency of user connection endpoint during last probe.",
+			},
+			labels,
+		),
+		inRecovery: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Namespace: poolMetricsNamespace,
+				Subsystem: endpointMetricsSubsystem,
+				Name:      "in_recovery",
+				Help:      "1 if user connection endpoint is in recovery (pg_is_in_recovery()), 0 otherwise.",
+			},
+			labels,
+		),
+		replicationLag: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Namespace: poolMetricsNamespace,
+				Subsystem: endpointMetricsSubsystem,
+				Name:      "replication_lag_seconds",
+				Help:      "Time since last replayed transaction of user connection endpoint in recovery.",
+			},
+			labels,
+		),
+	}
+}
+
+// GetEndpointMetricsCollector will return the collector to register in order to export endpoint health metrics.
+func GetEndpointMetricsCollector() prometheus.Collector {
+	return endpointMetrics
+}
+
+// SetEndpointMetrics will save endpoint probe result for engine. Nil health means endpoint is unreachable.
+func SetEndpointMetrics(engine, endpointType string, endpoint *Endpoint, health *EndpointHealth) {
+	labels := []string{engine, endpointType, endpoint.Host, strconv.Itoa(endpoint.Port)}
+
+	// Check if endpoint is unreachable
+	if health == nil {
+		endpointMetrics.up.WithLabelValues(labels...).Set(0)
+		// Remove values that cannot be known anymore
+		endpointMetrics.latency.DeleteLabelValues(labels...)
+		endpointMetrics.inRecovery.DeleteLabelValues(labels...)
+		endpointMetrics.replicationLag.DeleteLabelValues(labels...)
+
+		return
+	}
+
+	endpointMetrics.up.WithLabelValues(labels...).Set(1)
+	endpointMetrics.latency.WithLabelValues(labels...).Set(health.Latency.Seconds())
+
+	// Check if recovery status is known
+	if health.InRecovery != nil {
+		v := 0.0
+		if *health.InRecovery {
+			v = 1
+		}
+
+		endpointMetrics.inRecovery.WithLabelValues(labels...).Set(v)
+	}
+
+	// Check if replication lag is known
+	if health.ReplicationLag != nil {
+		endpointMetrics.replicationLag.WithLabelValues(labels...).Set(health.ReplicationLag.Seconds())
+	}
+}
+
+// DeleteEndpointMetrics will remove all endpoint metrics of engine.
+func DeleteEndpointMetrics(engine string) {
+	labels := prometheus.Labels{engineLabel: engine}
+
+	endpointMetrics.up.DeletePartialMatch(labels)
+	endpointMetrics.latency.DeletePartialMatch(labels)
+	endpointMetrics.inRecovery.DeletePartialMatch(labels)
+	endpointMetrics.replicationLag.DeletePartialMatch(labels)
+}
+
+func (c *endpointMetricsCollector) Describe(ch chan<- *prometheus.Desc) {
+	c.up.Describe(ch)
+	c.latency.Describe(ch)
+	c.inRecovery.Describe(ch)
+	c.replicationLag.Describe(ch)
+}
+
+func (c *endpointMetricsCollector) Collect(ch chan<- prometheus.Metric) {
+	c.up.Collect(ch)
+	c.latency.Collect(ch)
+	c.inRecovery.Collect(ch)
+	c.replicationLag.Collect(ch)
+}
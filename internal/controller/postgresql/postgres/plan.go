@@ -0,0 +1,171 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"regexp"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// RedactedValue is used instead of secret values in recorded statements.
+const RedactedValue = "<redacted>"
+
+var bindParameterRegexp = regexp.MustCompile(`\$(\d+)`)
+
+// sqlExecutor is the common interface between a database pool, a transaction and a plan recorder.
+type sqlExecutor interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+}
+
+// sqlTx is the common interface between a real transaction and a planned one.
+type sqlTx interface {
+	sqlExecutor
+	Commit() error
+	Rollback() error
+}
+
+// planRecorder will save statements instead of executing them.
+type planRecorder struct {
+	statements []string
+	mutex      sync.Mutex
+}
+
+func (p *planRecorder) ExecContext(_ context.Context, query string, args ...any) (sql.Result, error) {
+	p.record(renderStatement(query, args))
+
+	return driver.RowsAffected(0), nil
+}
+
+func (*planRecorder) Commit() error {
+	return nil
+}
+
+func (*planRecorder) Rollback() error {
+	return nil
+}
+
+func (p *planRecorder) record(statement string) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	p.statements = append(p.statements, statement)
+}
+
+func (p *planRecorder) getStatements() []string {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	// Copy to avoid any side effect
+	res := make([]string, len(p.statements))
+	copy(res, p.statements)
+
+	return res
+}
+
+// renderStatement will replace bind parameters with quoted literals in order to have a readable statement.
+func renderStatement(query string, args []any) string {
+	// Check if there isn't any argument
+	if len(args) == 0 {
+		return query
+	}
+
+	return bindParameterRegexp.ReplaceAllStringFunc(query, func(s string) string {
+		// Parse index
+		i, err := strconv.Atoi(s[1:])
+		// Check if index is valid
+		if err != nil || i < 1 || i > len(args) {
+			return s
+		}
+
+		return pq.QuoteLiteral(fmt.Sprint(args[i-1]))
+	})
+}
+
+// EnablePlanMode will record mutating statements instead of executing them.
+// Each call starts a new plan.
+func (c *pg) EnablePlanMode() {
+	c.plan = &planRecorder{}
+}
+
+func (c *pg) IsPlanMode() bool {
+	return c.plan != nil
+}
+
+func (c *pg) GetPlannedStatements() []string {
+	// Check if plan mode isn't enabled
+	if c.plan == nil {
+		return nil
+	}
+
+	return c.plan.getStatements()
+}
+
+// exec will execute a mutating statement or record it in plan mode.
+func (c *pg) exec(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	// Check if plan mode is enabled
+	if c.plan != nil {
+		return c.plan.ExecContext(ctx, query, args...)
+	}
+
+	start := time.Now()
+	// Execute
+	res, err := c.db.ExecContext(ctx, query, args...)
+	// Audit
+	c.audit(ctx, renderStatement(query, args), start, err)
+
+	return res, err
+}
+
+// execSensitive will execute a mutating statement containing a secret value.
+// In plan mode, the redacted statement is recorded instead.
+func (c *pg) execSensitive(ctx context.Context, query, redactedQuery string) (sql.Result, error) {
+	// Check if plan mode is enabled
+	if c.plan != nil {
+		return c.plan.ExecContext(ctx, redactedQuery)
+	}
+
+	start := time.Now()
+	// Execute
+	res, err := c.db.ExecContext(ctx, query)
+	// Audit with redacted statement
+	c.audit(ctx, redactedQuery, start, err)
+
+	return res, err
+}
+
+// begin will start a transaction or a planned one in plan mode.
+func (c *pg) begin(ctx context.Context) (sqlTx, error) {
+	// Check if plan mode is enabled
+	if c.plan != nil {
+		return c.plan, nil
+	}
+
+	// Begin transaction
+	tx, err := c.db.BeginTx(ctx, nil)
+	// Check error
+	if err != nil {
+		return nil, err
+	}
+
+	return &auditedTx{sqlTx: tx, c: c}, nil
+}
+
+// isPlannedMissingDatabaseError will return true if error is due to a database that doesn't exist yet in plan mode.
+// In plan mode, database creation is only recorded, so read only queries on it will fail.
+func (c *pg) isPlannedMissingDatabaseError(err error) bool {
+	// Check if plan mode isn't enabled
+	if c.plan == nil {
+		return false
+	}
+
+	// Try to cast error
+	pqErr, ok := err.(*pq.Error)
+
+	return ok && pqErr.Code == UndefinedDatabaseErrorCode
+}
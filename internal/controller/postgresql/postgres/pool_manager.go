@@ -1,132 +1,414 @@
 package postgres
 
 import (
+	"context"
 	"database/sql"
+	"database/sql/driver"
+	"errors"
 	"sync"
 	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/lib/pq"
 )
 
 const (
-	maxOpenConnections = 5
-	maxIdleConnections = 1
-	maxLifeTimeSecond  = 60 * time.Second //nolint:revive // Continue with the suffix Second
+	defaultMaxOpenConnections = 5
+	defaultMaxIdleConnections = 1
+	defaultConnMaxLifetime    = 60 * time.Second
+	defaultIdleTimeout        = 10 * time.Minute
 )
 
+// PoolSettings are the connection pool settings used for an engine.
+type PoolSettings struct {
+	// Maximum number of open connections per database
+	MaxOpenConnections int
+	// Maximum number of idle connections per database
+	MaxIdleConnections int
+	// Maximum lifetime of a connection. 0 means no limit.
+	ConnMaxLifetime time.Duration
+	// Database pools unused for this duration are closed by janitor. 0 disables eviction.
+	IdleTimeout time.Duration
+	// Maximum number of open connections across all database pools of an engine. 0 means no limit.
+	MaxTotalConnections int
+}
+
+// Operator wide pool settings.
+// ? Note: This is protected by defaultPoolSettingsMutex.
+var defaultPoolSettings = &PoolSettings{
+	// Operator shouldn't take too much slots
+	MaxOpenConnections: defaultMaxOpenConnections,
+	// Operator shouldn't take too much slots
+	MaxIdleConnections: defaultMaxIdleConnections,
+	// Force connections to 60s max lifetime because operator shouldn't take a slot too longer
+	ConnMaxLifetime: defaultConnMaxLifetime,
+	IdleTimeout:     defaultIdleTimeout,
+}
+
+// This mutex protects operator wide pool settings.
+var defaultPoolSettingsMutex sync.RWMutex
+
+// SetDefaultPoolSettings will set operator wide pool settings used when an engine doesn't override them.
+// Existing pools using other settings are recreated on their next usage.
+func SetDefaultPoolSettings(settings *PoolSettings) {
+	// Save a copy to avoid changes from caller
+	res := *settings
+
+	defaultPoolSettingsMutex.Lock()
+	defer defaultPoolSettingsMutex.Unlock()
+
+	defaultPoolSettings = &res
+}
+
+// GetDefaultPoolSettings will return a copy of operator wide pool settings.
+func GetDefaultPoolSettings() *PoolSettings {
+	defaultPoolSettingsMutex.RLock()
+	defer defaultPoolSettingsMutex.RUnlock()
+
+	res := *defaultPoolSettings
+
+	return &res
+}
+
 // Pool saved structure per postgres engine configuration.
 type poolSaved struct {
+	// This mutex protects pool opening and closing for this engine
+	mutex sync.Mutex
 	// This map will save all pools per database
 	pools *sync.Map
+	// This map will save last usage time per database
+	lastUsed *sync.Map
+	// Pool settings used to create pools
+	settings *PoolSettings
+	// Connection slots shared by all pools when a maximum is set
+	connections chan struct{}
 	// Username and password are saved because this comes from secret
 	username string
 	password string
+	// TLS hash is saved because certificates come from secret
+	tlsHash string
 }
 
 // Pool manager map per pgec.
 var poolManagerStorage = sync.Map{}
 
+func newPoolSaved(p *pg) *poolSaved {
+	// Get settings
+	settings := p.getPoolSettings()
+
+	res := &poolSaved{
+		username: p.GetUser(),
+		password: p.GetPassword(),
+		tlsHash:  p.getTLSHash(),
+		settings: settings,
+		pools:    &sync.Map{},
+		lastUsed: &sync.Map{},
+	}
+
+	// Check if total connections are limited
+	if settings.MaxTotalConnections > 0 {
+		res.connections = make(chan struct{}, settings.MaxTotalConnections)
+	}
+
+	return res
+}
+
 func getOrOpenPool(p *pg, database string) (*sql.DB, error) {
-	// Create result
-	var db *sql.DB
+	// Get saved pool for this engine
+	sav, err := getOrRenewPoolSaved(p)
+	// Check error
+	if err != nil {
+		return nil, err
+	}
 
-	// Check if there is a saved pool in the storage
-	savInt, ok := poolManagerStorage.Load(p.GetName())
-	// Check if this is found
-	if !ok {
-		// Open connection
-		sqlDB, err := openConnection(p, database)
-		// Check error
-		if err != nil {
-			return nil, err
+	// Lock to avoid concurrent opening of the same pool and closing by janitor
+	sav.mutex.Lock()
+	defer sav.mutex.Unlock()
+
+	// Save last usage for janitor
+	// ? Note: This is done before returning the pool so janitor won't close it in between
+	sav.lastUsed.Store(database, time.Now())
+
+	// Check if we can found a pool for this database
+	sqlDBInt, ok := sav.pools.Load(database)
+	// Check if it is found
+	if ok {
+		// Cast
+		db, _ := sqlDBInt.(*sql.DB)
+
+		return db, nil
+	}
+
+	// Open connection
+	db, err := openConnection(p, sav, database)
+	// Check error
+	if err != nil {
+		// Clean last usage as there isn't any pool
+		sav.lastUsed.Delete(database)
+
+		return nil, err
+	}
+
+	// Save it in pool manager storage
+	sav.pools.Store(database, db)
+
+	return db, nil
+}
+
+// getOrRenewPoolSaved will return saved pool for engine and replace it when credentials or pool settings have changed.
+func getOrRenewPoolSaved(p *pg) (*poolSaved, error) {
+	// Get settings
+	settings := p.getPoolSettings()
+
+	for {
+		// Check if there is a saved pool in the storage
+		savInt, ok := poolManagerStorage.Load(p.GetName())
+		// Check if this isn't found
+		if !ok {
+			// Store a new one if nobody did it in between
+			// ? Note: Saved pool doesn't hold any connection, so the one not stored can be forgotten
+			savInt, _ = poolManagerStorage.LoadOrStore(p.GetName(), newPoolSaved(p))
 		}
 
-		// Create saved pool map with the selected database
-		psMap := &sync.Map{}
-		psMap.Store(database, sqlDB)
-		// Add it to storage
-		poolManagerStorage.Store(p.GetName(), &poolSaved{
-			username: p.GetUser(),
-			password: p.GetPassword(),
-			pools:    psMap,
-		})
-
-		// Result
-		db = sqlDB
-	} else {
 		// Cast saved pool object
 		sav, _ := savInt.(*poolSaved)
-		// Check if username and password haven't changed, if yes, close pools and recreate current
-		if sav.username != p.GetUser() || sav.password != p.GetPassword() {
-			// Close all pools
-			err := CloseAllSavedPoolsForName(p.GetName())
-			// Check error
-			if err != nil {
-				return nil, err
-			}
+		// Check if username, password and TLS data have changed
+		credentialsChanged := sav.username != p.GetUser() || sav.password != p.GetPassword() || sav.tlsHash != p.getTLSHash()
+		// Check if nothing has changed
+		// ? Note: Pool settings are applied on pool opening, so pools must be recreated to use new ones
+		if !credentialsChanged && *sav.settings == *settings {
+			return sav, nil
 		}
-		// Check if we can found a pool for this database
-		sqlDBInt, ok := sav.pools.Load(database)
-		// Check if it isn't found
-		if !ok {
-			// Open connection
-			sqlDB, err := openConnection(p, database)
-			// Check error
-			if err != nil {
-				return nil, err
-			}
-
-			// Save it in pool manager storage
-			sav.pools.Store(database, sqlDB)
-
-			// Result
-			db = sqlDB
-		} else {
-			// Result
-			db, _ = sqlDBInt.(*sql.DB)
+
+		// Recreate saved pool with new credentials and settings
+		// ? Note: Only one caller can replace it, others will loop and use the new one
+		if !poolManagerStorage.CompareAndSwap(p.GetName(), sav, newPoolSaved(p)) {
+			continue
 		}
-	}
 
-	return db, nil
+		// Close all pools of the old saved pool
+		err := sav.closeAll()
+		// Check error
+		if err != nil {
+			return nil, err
+		}
+
+		// Check if credentials have changed
+		if credentialsChanged {
+			// Increase metric
+			poolMetrics.credentialChangeClosesTotal.WithLabelValues(p.GetName()).Inc()
+		}
+	}
 }
 
-func openConnection(p *pg, database string) (*sql.DB, error) {
+func openConnection(p *pg, sav *poolSaved, database string) (*sql.DB, error) {
+	// Get connection args
+	args, err := p.getConnectionArgs()
+	// Check error
+	if err != nil {
+		return nil, err
+	}
+
 	// Generate url
 	pgURL := TemplatePostgresqlURLWithArgs(
 		p.GetHost(),
 		p.GetUser(),
 		p.GetPassword(),
-		p.GetArgs(),
+		args,
 		database,
 		p.GetPort(),
 	)
+
+	var db *sql.DB
+
+	// Check if total connections are limited
+	if sav.connections != nil {
+		// Create connector
+		connector, err := pq.NewConnector(pgURL)
+		// Check error
+		if err != nil {
+			return nil, err
+		}
+		// Open with a connector sharing connection slots of all engine pools
+		db = sql.OpenDB(&limitedConnector{Connector: connector, slots: sav.connections})
+	} else {
+		// Connect
+		sqlDB, err := sql.Open("postgres", pgURL)
+		// Check error
+		if err != nil {
+			return nil, err
+		}
+
+		db = sqlDB
+	}
+
+	// Increase metric
+	poolMetrics.creationsTotal.WithLabelValues(p.GetName(), database).Inc()
+
+	// Set sql parameters
+	db.SetConnMaxLifetime(sav.settings.ConnMaxLifetime)
+	db.SetMaxIdleConns(sav.settings.MaxIdleConnections)
+	db.SetMaxOpenConns(sav.settings.MaxOpenConnections)
+
+	return db, nil
+}
+
+// pqConn is the list of driver interfaces implemented by pq connections.
+type pqConn interface {
+	driver.Conn
+	driver.ConnBeginTx
+	driver.ConnPrepareContext
+	driver.ExecerContext
+	driver.QueryerContext
+	driver.Pinger
+	driver.SessionResetter
+	driver.Validator
+}
+
+// limitedConnector will wait for a free connection slot before opening a connection.
+type limitedConnector struct {
+	driver.Connector
+	slots chan struct{}
+}
+
+func (c *limitedConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	// Wait for a free slot
+	select {
+	case c.slots <- struct{}{}:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+
 	// Connect
-	db, err := sql.Open("postgres", pgURL)
+	conn, err := c.Connector.Connect(ctx)
 	// Check error
 	if err != nil {
+		// Release slot
+		<-c.slots
+
 		return nil, err
 	}
 
-	// Set sql parameters
-	// Force connections to 60s max lifetime because operator shouldn't take a slot too longer
-	db.SetConnMaxLifetime(maxLifeTimeSecond)
-	// Operator shouldn't take too much slots
-	db.SetMaxIdleConns(maxIdleConnections)
-	// Operator shouldn't take too much slots
-	db.SetMaxOpenConns(maxOpenConnections)
+	// Cast connection
+	pqc, ok := conn.(pqConn)
+	// Check if cast isn't possible
+	if !ok {
+		_ = conn.Close()
+		// Release slot
+		<-c.slots
 
-	return db, nil
+		return nil, errors.New("unsupported driver connection")
+	}
+
+	return &limitedConn{pqConn: pqc, slots: c.slots}, nil
 }
 
-func CloseDatabaseSavedPoolsForName(name, database string) error {
-	// Get pool saved
-	psInt, ok := poolManagerStorage.Load(name)
-	// Check if it exists
-	if !ok {
-		return nil
+// limitedConn will release its connection slot on close.
+type limitedConn struct {
+	pqConn
+	slots chan struct{}
+	once  sync.Once
+}
+
+func (c *limitedConn) Close() error {
+	// Close
+	err := c.pqConn.Close()
+	// Release slot only once
+	c.once.Do(func() { <-c.slots })
+
+	return err
+}
+
+// RunPoolJanitor will close database pools unused for their engine idle timeout, until context is done.
+func RunPoolJanitor(ctx context.Context, interval time.Duration, logger logr.Logger) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			closeIdlePools(logger)
+		}
 	}
+}
 
-	// Cast pool saved
-	ps, _ := psInt.(*poolSaved)
+func closeIdlePools(logger logr.Logger) {
+	now := time.Now()
+
+	// Loop over engines
+	poolManagerStorage.Range(func(k, val interface{}) bool {
+		// Cast
+		name, _ := k.(string)
+		ps, _ := val.(*poolSaved)
+
+		// Check if eviction is disabled
+		if ps.settings.IdleTimeout == 0 {
+			return true
+		}
+
+		// Close idle pools of engine
+		ps.closeIdle(logger, name, now)
+
+		// Default
+		return true
+	})
+}
+
+// closeIdle will close pools unused for idle timeout.
+func (ps *poolSaved) closeIdle(logger logr.Logger, name string, now time.Time) {
+	// Lock to avoid closing a pool that is being returned
+	ps.mutex.Lock()
+	defer ps.mutex.Unlock()
 
+	// Loop over databases
+	ps.lastUsed.Range(func(k, val interface{}) bool {
+		// Cast
+		database, _ := k.(string)
+		lastUsed, _ := val.(time.Time)
+
+		// Check if pool have been used recently
+		if now.Sub(lastUsed) < ps.settings.IdleTimeout {
+			return true
+		}
+
+		// Get pool
+		dbInt, ok := ps.pools.Load(database)
+		// Check if it doesn't exist anymore
+		if !ok {
+			ps.lastUsed.Delete(database)
+
+			return true
+		}
+
+		// Cast
+		db, _ := dbInt.(*sql.DB)
+		// Ignore pools with connections in use
+		if db.Stats().InUse > 0 {
+			return true
+		}
+
+		// Close pool
+		err := ps.closePool(database)
+		// Check error
+		if err != nil {
+			logger.Error(err, "unable to close idle pool", "engine", name, "database", database)
+
+			return true
+		}
+
+		// Increase metric
+		poolMetrics.idleClosesTotal.WithLabelValues(name, database).Inc()
+
+		// Default
+		return true
+	})
+}
+
+// closePool will close database pool.
+// Caller must hold the lock.
+func (ps *poolSaved) closePool(database string) error {
 	// Get entry
 	enInt, ok := ps.pools.Load(database)
 	// Check if it isn't present
@@ -144,22 +426,18 @@ func CloseDatabaseSavedPoolsForName(name, database string) error {
 		return err
 	}
 
-	// Clean entry
+	// Clean entries
 	ps.pools.Delete(database)
+	ps.lastUsed.Delete(database)
 
 	return nil
 }
 
-func CloseAllSavedPoolsForName(name string) error {
-	// Get pool saved
-	psInt, ok := poolManagerStorage.Load(name)
-	// Check if it exists
-	if !ok {
-		return nil
-	}
-
-	// Cast pool saved
-	ps, _ := psInt.(*poolSaved)
+// closeAll will close all database pools.
+func (ps *poolSaved) closeAll() error {
+	// Lock to avoid concurrent opening
+	ps.mutex.Lock()
+	defer ps.mutex.Unlock()
 
 	// Save all keys to be removed
 	keysToBeRemoved := make([]interface{}, 0)
@@ -184,16 +462,52 @@ func CloseAllSavedPoolsForName(name string) error {
 	})
 	// Loop over keys to remove
 	for _, v := range keysToBeRemoved {
-		// Delete key
+		// Delete keys
 		ps.pools.Delete(v)
+		ps.lastUsed.Delete(v)
 	}
+
+	return err
+}
+
+func CloseDatabaseSavedPoolsForName(name, database string) error {
+	// Get pool saved
+	psInt, ok := poolManagerStorage.Load(name)
+	// Check if it exists
+	if !ok {
+		return nil
+	}
+
+	// Cast pool saved
+	ps, _ := psInt.(*poolSaved)
+
+	// Lock to avoid concurrent opening
+	ps.mutex.Lock()
+	defer ps.mutex.Unlock()
+
+	return ps.closePool(database)
+}
+
+func CloseAllSavedPoolsForName(name string) error {
+	// Get pool saved
+	psInt, ok := poolManagerStorage.Load(name)
+	// Check if it exists
+	if !ok {
+		return nil
+	}
+
+	// Cast pool saved
+	ps, _ := psInt.(*poolSaved)
+
+	// Close all pools
+	err := ps.closeAll()
 	// Check error
 	if err != nil {
 		return err
 	}
 
-	// Clean main entry
-	poolManagerStorage.Delete(name)
+	// Clean main entry only if it hasn't been replaced in between
+	poolManagerStorage.CompareAndDelete(name, ps)
 
 	return nil
 }
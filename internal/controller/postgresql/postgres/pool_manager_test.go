@@ -0,0 +1,353 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/go-logr/logr"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+// fakeConnector is a driver connector counting opened connections without any engine.
+type fakeConnector struct {
+	err         error
+	unsupported bool
+	mutex       sync.Mutex
+	opened      int
+}
+
+func (c *fakeConnector) Connect(_ context.Context) (driver.Conn, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	// Check error
+	if c.err != nil {
+		return nil, c.err
+	}
+
+	c.opened++
+
+	// Check if connection must not implement all pq interfaces
+	if c.unsupported {
+		return &fakeBasicConn{}, nil
+	}
+
+	return &fakeConn{}, nil
+}
+
+func (*fakeConnector) Driver() driver.Driver { return nil }
+
+// fakeBasicConn only implements driver.Conn.
+type fakeBasicConn struct{}
+
+func (*fakeBasicConn) Prepare(_ string) (driver.Stmt, error) {
+	return nil, errors.New("not implemented")
+}
+func (*fakeBasicConn) Close() error              { return nil }
+func (*fakeBasicConn) Begin() (driver.Tx, error) { return nil, errors.New("not implemented") }
+
+// fakeConn implements all driver interfaces of a pq connection.
+type fakeConn struct {
+	fakeBasicConn
+}
+
+func (*fakeConn) BeginTx(_ context.Context, _ driver.TxOptions) (driver.Tx, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (*fakeConn) PrepareContext(_ context.Context, _ string) (driver.Stmt, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (*fakeConn) ExecContext(_ context.Context, _ string, _ []driver.NamedValue) (driver.Result, error) {
+	return driver.RowsAffected(0), nil
+}
+
+func (*fakeConn) QueryContext(_ context.Context, _ string, _ []driver.NamedValue) (driver.Rows, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (*fakeConn) Ping(_ context.Context) error               { return nil }
+func (*fakeConn) ResetSession(_ context.Context) error       { return nil }
+func (*fakeConn) IsValid() bool                              { return true }
+func (*fakeConn) CheckNamedValue(_ *driver.NamedValue) error { return nil }
+
+// newTestPoolPG will return a pg instance using the given pool settings without any engine.
+func newTestPoolPG(name string, settings *PoolSettings) *pg {
+	return &pg{
+		log:             logr.Discard(),
+		host:            "localhost",
+		port:            5432,
+		user:            "postgres",
+		pass:            "postgres",
+		args:            "sslmode=disable",
+		defaultDatabase: "postgres",
+		name:            name,
+		pool:            settings,
+	}
+}
+
+var _ = Describe("limitedConnector", func() {
+	It("should take a slot on connect and release it only once on close", func() {
+		slots := make(chan struct{}, 1)
+		c := &limitedConnector{Connector: &fakeConnector{}, slots: slots}
+
+		conn, err := c.Connect(context.TODO())
+		Expect(err).NotTo(HaveOccurred())
+		Expect(slots).To(HaveLen(1))
+
+		// Close twice
+		Expect(conn.Close()).To(Succeed())
+		Expect(conn.Close()).To(Succeed())
+
+		Expect(slots).To(BeEmpty())
+	})
+
+	It("should wait for a free slot until context is done", func() {
+		slots := make(chan struct{}, 1)
+		inner := &fakeConnector{}
+		c := &limitedConnector{Connector: inner, slots: slots}
+
+		conn, err := c.Connect(context.TODO())
+		Expect(err).NotTo(HaveOccurred())
+
+		// Try to open another one
+		ctx, cancel := context.WithTimeout(context.TODO(), 50*time.Millisecond)
+		defer cancel()
+
+		_, err = c.Connect(ctx)
+		Expect(err).To(MatchError(context.DeadlineExceeded))
+		Expect(inner.opened).To(Equal(1))
+
+		// Release first one and retry
+		Expect(conn.Close()).To(Succeed())
+
+		conn, err = c.Connect(context.TODO())
+		Expect(err).NotTo(HaveOccurred())
+		Expect(inner.opened).To(Equal(2))
+		Expect(conn.Close()).To(Succeed())
+	})
+
+	It("should release slot when connection fails", func() {
+		slots := make(chan struct{}, 1)
+		c := &limitedConnector{Connector: &fakeConnector{err: errors.New("connection refused")}, slots: slots}
+
+		_, err := c.Connect(context.TODO())
+		Expect(err).To(MatchError("connection refused"))
+		Expect(slots).To(BeEmpty())
+	})
+
+	It("should release slot when connection isn't supported", func() {
+		slots := make(chan struct{}, 1)
+		c := &limitedConnector{Connector: &fakeConnector{unsupported: true}, slots: slots}
+
+		_, err := c.Connect(context.TODO())
+		Expect(err).To(MatchError("unsupported driver connection"))
+		Expect(slots).To(BeEmpty())
+	})
+
+	It("should share slots between pools", func() {
+		slots := make(chan struct{}, 1)
+		db1 := sql.OpenDB(&limitedConnector{Connector: &fakeConnector{}, slots: slots})
+		db2 := sql.OpenDB(&limitedConnector{Connector: &fakeConnector{}, slots: slots})
+
+		defer db1.Close()
+		defer db2.Close()
+
+		conn, err := db1.Conn(context.TODO())
+		Expect(err).NotTo(HaveOccurred())
+
+		// Second pool can't get a connection while first one holds the only slot
+		ctx, cancel := context.WithTimeout(context.TODO(), 50*time.Millisecond)
+		defer cancel()
+
+		_, err = db2.Conn(ctx)
+		Expect(err).To(MatchError(context.DeadlineExceeded))
+
+		// Release
+		Expect(conn.Close()).To(Succeed())
+		Expect(db1.Close()).To(Succeed())
+
+		conn, err = db2.Conn(context.TODO())
+		Expect(err).NotTo(HaveOccurred())
+		Expect(conn.Close()).To(Succeed())
+	})
+})
+
+var _ = Describe("closeIdlePools", func() {
+	// storeTestPool will save a pool opened with a fake connector for engine and database.
+	storeTestPool := func(name, database string, settings *PoolSettings, lastUsed time.Time) *sql.DB {
+		savInt, _ := poolManagerStorage.LoadOrStore(name, newPoolSaved(newTestPoolPG(name, settings)))
+		sav, _ := savInt.(*poolSaved)
+
+		db := sql.OpenDB(&fakeConnector{})
+		sav.pools.Store(database, db)
+		sav.lastUsed.Store(database, lastUsed)
+
+		return db
+	}
+
+	// hasPool will return true if a pool is saved for engine and database.
+	hasPool := func(name, database string) bool {
+		savInt, ok := poolManagerStorage.Load(name)
+		if !ok {
+			return false
+		}
+
+		sav, _ := savInt.(*poolSaved)
+		_, ok = sav.pools.Load(database)
+
+		return ok
+	}
+
+	AfterEach(func() {
+		Expect(CloseAllSavedPoolsForName("idle-test")).To(Succeed())
+	})
+
+	It("should close pools unused for idle timeout", func() {
+		settings := &PoolSettings{IdleTimeout: time.Minute}
+		storeTestPool("idle-test", "old", settings, time.Now().Add(-2*time.Minute))
+		storeTestPool("idle-test", "recent", settings, time.Now())
+
+		closeIdlePools(logr.Discard())
+
+		Expect(hasPool("idle-test", "old")).To(BeFalse())
+		Expect(hasPool("idle-test", "recent")).To(BeTrue())
+	})
+
+	It("shouldn't close pools when eviction is disabled", func() {
+		storeTestPool("idle-test", "old", &PoolSettings{}, time.Now().Add(-time.Hour))
+
+		closeIdlePools(logr.Discard())
+
+		Expect(hasPool("idle-test", "old")).To(BeTrue())
+	})
+
+	It("shouldn't close pools with connections in use", func() {
+		db := storeTestPool("idle-test", "old", &PoolSettings{IdleTimeout: time.Minute}, time.Now().Add(-2*time.Minute))
+
+		// Take a connection
+		conn, err := db.Conn(context.TODO())
+		Expect(err).NotTo(HaveOccurred())
+
+		closeIdlePools(logr.Discard())
+
+		Expect(hasPool("idle-test", "old")).To(BeTrue())
+
+		// Release it
+		Expect(conn.Close()).To(Succeed())
+
+		closeIdlePools(logr.Discard())
+
+		Expect(hasPool("idle-test", "old")).To(BeFalse())
+	})
+
+	It("shouldn't close a pool that have just been returned", func() {
+		settings := &PoolSettings{IdleTimeout: time.Minute, MaxOpenConnections: 1, MaxIdleConnections: 1}
+		db := storeTestPool("idle-test", "old", settings, time.Now().Add(-2*time.Minute))
+
+		// Get pool as a reconcile would do
+		res, err := getOrOpenPool(newTestPoolPG("idle-test", settings), "old")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(res).To(BeIdenticalTo(db))
+
+		closeIdlePools(logr.Discard())
+
+		Expect(hasPool("idle-test", "old")).To(BeTrue())
+	})
+})
+
+var _ = Describe("getOrOpenPool", func() {
+	AfterEach(func() {
+		Expect(CloseAllSavedPoolsForName("open-test")).To(Succeed())
+	})
+
+	It("should open only one pool on concurrent first opens", func() {
+		settings := &PoolSettings{MaxOpenConnections: 1, MaxIdleConnections: 1, MaxTotalConnections: 2}
+
+		res := make([]*sql.DB, 10)
+
+		var wg sync.WaitGroup
+		// Open concurrently
+		for i := range res {
+			wg.Add(1)
+
+			go func(i int) {
+				defer GinkgoRecover()
+				defer wg.Done()
+
+				db, err := getOrOpenPool(newTestPoolPG("open-test", settings), "db")
+				Expect(err).NotTo(HaveOccurred())
+
+				res[i] = db
+			}(i)
+		}
+
+		wg.Wait()
+
+		// Checks
+		for _, db := range res {
+			Expect(db).To(BeIdenticalTo(res[0]))
+		}
+	})
+
+	It("should recreate pools when credentials change", func() {
+		settings := &PoolSettings{MaxOpenConnections: 1, MaxIdleConnections: 1}
+
+		db1, err := getOrOpenPool(newTestPoolPG("open-test", settings), "db")
+		Expect(err).NotTo(HaveOccurred())
+
+		// Change password
+		p := newTestPoolPG("open-test", settings)
+		p.pass = "new-password"
+
+		db2, err := getOrOpenPool(p, "db")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(db2).NotTo(BeIdenticalTo(db1))
+
+		// Old pool must be closed
+		Expect(db1.PingContext(context.TODO())).To(MatchError("sql: database is closed"))
+	})
+
+	It("should recreate pools when settings change", func() {
+		db1, err := getOrOpenPool(newTestPoolPG("open-test", &PoolSettings{MaxOpenConnections: 1, MaxIdleConnections: 1}), "db")
+		Expect(err).NotTo(HaveOccurred())
+
+		// Same settings in another object must reuse pool
+		db2, err := getOrOpenPool(newTestPoolPG("open-test", &PoolSettings{MaxOpenConnections: 1, MaxIdleConnections: 1}), "db")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(db2).To(BeIdenticalTo(db1))
+
+		// Change settings
+		db3, err := getOrOpenPool(newTestPoolPG("open-test", &PoolSettings{MaxOpenConnections: 3, MaxIdleConnections: 1}), "db")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(db3).NotTo(BeIdenticalTo(db1))
+		Expect(db3.Stats().MaxOpenConnections).To(Equal(3))
+
+		// Old pool must be closed
+		Expect(db1.PingContext(context.TODO())).To(MatchError("sql: database is closed"))
+	})
+
+	It("should recreate pools using operator wide settings when they change", func() {
+		saved := GetDefaultPoolSettings()
+		defer SetDefaultPoolSettings(saved)
+
+		SetDefaultPoolSettings(&PoolSettings{MaxOpenConnections: 1, MaxIdleConnections: 1})
+
+		db1, err := getOrOpenPool(newTestPoolPG("open-test", nil), "db")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(db1.Stats().MaxOpenConnections).To(Equal(1))
+
+		SetDefaultPoolSettings(&PoolSettings{MaxOpenConnections: 2, MaxIdleConnections: 1})
+
+		db2, err := getOrOpenPool(newTestPoolPG("open-test", nil), "db")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(db2).NotTo(BeIdenticalTo(db1))
+		Expect(db2.Stats().MaxOpenConnections).To(Equal(2))
+	})
+})
@@ -0,0 +1,155 @@
+package postgres
+
+import (
+	"database/sql"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	poolMetricsNamespace = "postgresql_operator"
+	poolMetricsSubsystem = "pool"
+	engineLabel          = "engine"
+	databaseLabel        = "database"
+)
+
+// Pool metrics collector used by all pools.
+var poolMetrics = newPoolMetricsCollector()
+
+// poolMetricsCollector will export statistics of all saved pools and pool manager counters.
+type poolMetricsCollector struct {
+	creationsTotal              *prometheus.CounterVec
+	credentialChangeClosesTotal *prometheus.CounterVec
+	idleClosesTotal             *prometheus.CounterVec
+	maxOpenConnections          *prometheus.Desc
+	openConnections             *prometheus.Desc
+	inUseConnections            *prometheus.Desc
+	idleConnections             *prometheus.Desc
+	waitCount                   *prometheus.Desc
+	waitDuration                *prometheus.Desc
+	maxLifetimeClosed           *prometheus.Desc
+}
+
+func newPoolMetricsCollector() *poolMetricsCollector {
+	labels := []string{engineLabel, databaseLabel}
+
+	return &poolMetricsCollector{
+		creationsTotal: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: poolMetricsNamespace,
+				Subsystem: poolMetricsSubsystem,
+				Name:      "creations_total",
+				Help:      "Total number of pools created per engine and database.",
+			},
+			labels,
+		),
+		credentialChangeClosesTotal: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: poolMetricsNamespace,
+				Subsystem: poolMetricsSubsystem,
+				Name:      "credential_change_closes_total",
+				Help:      "Total number of forced closes of all engine pools caused by a credential change.",
+			},
+			[]string{engineLabel},
+		),
+		idleClosesTotal: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: poolMetricsNamespace,
+				Subsystem: poolMetricsSubsystem,
+				Name:      "idle_closes_total",
+				Help:      "Total number of pools closed by janitor because they were unused.",
+			},
+			labels,
+		),
+		maxOpenConnections: prometheus.NewDesc(
+			prometheus.BuildFQName(poolMetricsNamespace, poolMetricsSubsystem, "max_open_connections"),
+			"Maximum number of open connections of the pool.",
+			labels, nil,
+		),
+		openConnections: prometheus.NewDesc(
+			prometheus.BuildFQName(poolMetricsNamespace, poolMetricsSubsystem, "open_connections"),
+			"Number of established connections both in use and idle.",
+			labels, nil,
+		),
+		inUseConnections: prometheus.NewDesc(
+			prometheus.BuildFQName(poolMetricsNamespace, poolMetricsSubsystem, "in_use_connections"),
+			"Number of connections currently in use.",
+			labels, nil,
+		),
+		idleConnections: prometheus.NewDesc(
+			prometheus.BuildFQName(poolMetricsNamespace, poolMetricsSubsystem, "idle_connections"),
+			"Number of idle connections.",
+			labels, nil,
+		),
+		waitCount: prometheus.NewDesc(
+			prometheus.BuildFQName(poolMetricsNamespace, poolMetricsSubsystem, "wait_count_total"),
+			"Total number of connections waited for.",
+			labels, nil,
+		),
+		waitDuration: prometheus.NewDesc(
+			prometheus.BuildFQName(poolMetricsNamespace, poolMetricsSubsystem, "wait_duration_seconds_total"),
+			"Total time blocked waiting for a new connection.",
+			labels, nil,
+		),
+		maxLifetimeClosed: prometheus.NewDesc(
+			prometheus.BuildFQName(poolMetricsNamespace, poolMetricsSubsystem, "max_lifetime_closed_total"),
+			"Total number of connections closed due to max connection lifetime.",
+			labels, nil,
+		),
+	}
+}
+
+// GetPoolMetricsCollector will return the collector to register in order to export pool metrics.
+func GetPoolMetricsCollector() prometheus.Collector {
+	return poolMetrics
+}
+
+func (c *poolMetricsCollector) Describe(ch chan<- *prometheus.Desc) {
+	c.creationsTotal.Describe(ch)
+	c.credentialChangeClosesTotal.Describe(ch)
+	c.idleClosesTotal.Describe(ch)
+	ch <- c.maxOpenConnections
+	ch <- c.openConnections
+	ch <- c.inUseConnections
+	ch <- c.idleConnections
+	ch <- c.waitCount
+	ch <- c.waitDuration
+	ch <- c.maxLifetimeClosed
+}
+
+func (c *poolMetricsCollector) Collect(ch chan<- prometheus.Metric) {
+	c.creationsTotal.Collect(ch)
+	c.credentialChangeClosesTotal.Collect(ch)
+	c.idleClosesTotal.Collect(ch)
+
+	// Loop over engines
+	poolManagerStorage.Range(func(k, val interface{}) bool {
+		// Cast
+		engine, _ := k.(string)
+		ps, _ := val.(*poolSaved)
+
+		// Loop over databases
+		ps.pools.Range(func(k, val interface{}) bool {
+			// Cast
+			database, _ := k.(string)
+			db, _ := val.(*sql.DB)
+
+			// Get stats
+			stats := db.Stats()
+
+			ch <- prometheus.MustNewConstMetric(c.maxOpenConnections, prometheus.GaugeValue, float64(stats.MaxOpenConnections), engine, database)
+			ch <- prometheus.MustNewConstMetric(c.openConnections, prometheus.GaugeValue, float64(stats.OpenConnections), engine, database)
+			ch <- prometheus.MustNewConstMetric(c.inUseConnections, prometheus.GaugeValue, float64(stats.InUse), engine, database)
+			ch <- prometheus.MustNewConstMetric(c.idleConnections, prometheus.GaugeValue, float64(stats.Idle), engine, database)
+			ch <- prometheus.MustNewConstMetric(c.waitCount, prometheus.CounterValue, float64(stats.WaitCount), engine, database)
+			ch <- prometheus.MustNewConstMetric(c.waitDuration, prometheus.CounterValue, stats.WaitDuration.Seconds(), engine, database)
+			ch <- prometheus.MustNewConstMetric(c.maxLifetimeClosed, prometheus.CounterValue, float64(stats.MaxLifetimeClosed), engine, database)
+
+			// Default
+			return true
+		})
+
+		// Default
+		return true
+	})
+}
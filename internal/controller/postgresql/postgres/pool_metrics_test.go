@@ -0,0 +1,157 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/go-logr/logr"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+// gatherPoolMetrics will return values of a pool metric per database for an engine.
+func gatherPoolMetrics(name, engine string) map[string]float64 {
+	reg := prometheus.NewPedanticRegistry()
+	Expect(reg.Register(poolMetrics)).To(Succeed())
+
+	families, err := reg.Gather()
+	Expect(err).NotTo(HaveOccurred())
+
+	res := map[string]float64{}
+	// Loop over families
+	for _, family := range families {
+		// Check name
+		if family.GetName() != name {
+			continue
+		}
+
+		// Loop over metrics
+		for _, m := range family.GetMetric() {
+			labels := map[string]string{}
+			for _, l := range m.GetLabel() {
+				labels[l.GetName()] = l.GetValue()
+			}
+
+			// Check engine
+			if labels[engineLabel] != engine {
+				continue
+			}
+
+			// Check if it is a counter
+			if m.GetCounter() != nil {
+				res[labels[databaseLabel]] = m.GetCounter().GetValue()
+			} else {
+				res[labels[databaseLabel]] = m.GetGauge().GetValue()
+			}
+		}
+	}
+
+	return res
+}
+
+var _ = Describe("poolMetricsCollector", func() {
+	// storeTestPool will save a pool opened with a fake connector for engine and database.
+	storeTestPool := func(name, database string) *sql.DB {
+		savInt, _ := poolManagerStorage.LoadOrStore(name, newPoolSaved(newTestPoolPG(name, &PoolSettings{})))
+		sav, _ := savInt.(*poolSaved)
+
+		db := sql.OpenDB(&fakeConnector{})
+		sav.pools.Store(database, db)
+		sav.lastUsed.Store(database, time.Now())
+
+		return db
+	}
+
+	AfterEach(func() {
+		Expect(CloseAllSavedPoolsForName("metrics-test")).To(Succeed())
+		Expect(CloseAllSavedPoolsForName("metrics-other")).To(Succeed())
+	})
+
+	It("should be consistent and follow metric naming rules", func() {
+		storeTestPool("metrics-test", "db1")
+
+		problems, err := testutil.CollectAndLint(poolMetrics)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(problems).To(BeEmpty())
+	})
+
+	It("should export pool statistics with engine and database labels", func() {
+		db1 := storeTestPool("metrics-test", "db1")
+		db1.SetMaxOpenConns(5)
+		storeTestPool("metrics-test", "db2")
+		storeTestPool("metrics-other", "db1")
+
+		// Take a connection on first pool
+		conn, err := db1.Conn(context.TODO())
+		Expect(err).NotTo(HaveOccurred())
+
+		defer conn.Close()
+
+		// Checks
+		Expect(gatherPoolMetrics("postgresql_operator_pool_max_open_connections", "metrics-test")).To(Equal(map[string]float64{"db1": 5, "db2": 0}))
+		Expect(gatherPoolMetrics("postgresql_operator_pool_in_use_connections", "metrics-test")).To(Equal(map[string]float64{"db1": 1, "db2": 0}))
+		Expect(gatherPoolMetrics("postgresql_operator_pool_open_connections", "metrics-test")).To(Equal(map[string]float64{"db1": 1, "db2": 0}))
+		Expect(gatherPoolMetrics("postgresql_operator_pool_idle_connections", "metrics-other")).To(Equal(map[string]float64{"db1": 0}))
+	})
+
+	It("should remove pool statistics when pool is closed", func() {
+		storeTestPool("metrics-test", "db1")
+		storeTestPool("metrics-test", "db2")
+
+		Expect(CloseDatabaseSavedPoolsForName("metrics-test", "db1")).To(Succeed())
+
+		Expect(gatherPoolMetrics("postgresql_operator_pool_open_connections", "metrics-test")).To(Equal(map[string]float64{"db2": 0}))
+
+		Expect(CloseAllSavedPoolsForName("metrics-test")).To(Succeed())
+
+		Expect(gatherPoolMetrics("postgresql_operator_pool_open_connections", "metrics-test")).To(BeEmpty())
+	})
+
+	It("should count pool creations per engine and database", func() {
+		settings := &PoolSettings{MaxOpenConnections: 1, MaxIdleConnections: 1}
+		created := testutil.ToFloat64(poolMetrics.creationsTotal.WithLabelValues("metrics-test", "db1"))
+
+		_, err := getOrOpenPool(newTestPoolPG("metrics-test", settings), "db1")
+		Expect(err).NotTo(HaveOccurred())
+		// Reuse
+		_, err = getOrOpenPool(newTestPoolPG("metrics-test", settings), "db1")
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(testutil.ToFloat64(poolMetrics.creationsTotal.WithLabelValues("metrics-test", "db1"))).To(Equal(created + 1))
+	})
+
+	It("should count forced closes on credential change per engine", func() {
+		settings := &PoolSettings{MaxOpenConnections: 1, MaxIdleConnections: 1}
+		closes := testutil.ToFloat64(poolMetrics.credentialChangeClosesTotal.WithLabelValues("metrics-test"))
+
+		_, err := getOrOpenPool(newTestPoolPG("metrics-test", settings), "db1")
+		Expect(err).NotTo(HaveOccurred())
+
+		// Change password
+		p := newTestPoolPG("metrics-test", settings)
+		p.pass = "new-password"
+
+		_, err = getOrOpenPool(p, "db1")
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(testutil.ToFloat64(poolMetrics.credentialChangeClosesTotal.WithLabelValues("metrics-test"))).To(Equal(closes + 1))
+	})
+
+	It("should count idle closes per engine and database", func() {
+		closes := testutil.ToFloat64(poolMetrics.idleClosesTotal.WithLabelValues("metrics-test", "old"))
+
+		// Store an old unused pool with eviction enabled
+		savInt, _ := poolManagerStorage.LoadOrStore("metrics-test", newPoolSaved(newTestPoolPG("metrics-test", &PoolSettings{IdleTimeout: time.Minute})))
+		sav, _ := savInt.(*poolSaved)
+		sav.pools.Store("old", sql.OpenDB(&fakeConnector{}))
+		sav.lastUsed.Store("old", time.Now().Add(-2*time.Minute))
+
+		closeIdlePools(logr.Discard())
+
+		Expect(testutil.ToFloat64(poolMetrics.idleClosesTotal.WithLabelValues("metrics-test", "old"))).To(Equal(closes + 1))
+		Expect(gatherPoolMetrics("postgresql_operator_pool_open_connections", "metrics-test")).To(BeEmpty())
+	})
+})
@@ -4,6 +4,7 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"net/url"
 
 	"github.com/easymile/postgresql-operator/api/postgresql/v1alpha1"
 	"github.com/go-logr/logr"
@@ -27,8 +28,16 @@ type TypeOwnership struct {
 }
 
 type PG interface { //nolint:interfacebloat // This is needed
-	CreateDB(ctx context.Context, dbname, username string) error
+	CreateDB(ctx context.Context, dbname, username string, options *DatabaseCreationOptions) error
+	GetDatabaseCreationOptions(ctx context.Context, dbname string) (*DatabaseCreationOptions, error)
 	ChangeDBOwner(ctx context.Context, dbname, owner string) error
+	GetDatabaseSettings(ctx context.Context, dbname, role string) (map[string]string, error)
+	AlterDatabaseSetting(ctx context.Context, dbname, role, name, value string) error
+	ResetDatabaseSetting(ctx context.Context, dbname, role, name string) error
+	GetDatabaseConnections(ctx context.Context, dbname string) (*DatabaseConnections, error)
+	SetDatabaseConnectionLimit(ctx context.Context, dbname string, limit int) error
+	SetDatabaseAllowConnections(ctx context.Context, dbname string, allow bool) error
+	TerminateDatabaseSessions(ctx context.Context, dbname string) error
 	IsDatabaseExist(ctx context.Context, dbname string) (bool, error)
 	RenameDatabase(ctx context.Context, oldname, newname string) error
 	CreateSchema(ctx context.Context, db, role, schema string) error
@@ -37,11 +46,15 @@ type PG interface { //nolint:interfacebloat // This is needed
 	CreateUserRole(ctx context.Context, role, password string, attributes *RoleAttributes) (string, error)
 	AlterRoleAttributes(ctx context.Context, role string, attributes *RoleAttributes) error
 	GetRoleAttributes(ctx context.Context, role string) (*RoleAttributes, error)
+	DoesRolePasswordMethodMatch(ctx context.Context, role string) (bool, error)
 	IsRoleExist(ctx context.Context, role string) (bool, error)
 	RenameRole(ctx context.Context, oldname, newname string) error
 	UpdatePassword(ctx context.Context, role, password string) error
+	UpdateCurrentUserPassword(ctx context.Context, password string) error
+	CheckLogin(ctx context.Context, password string) error
 	GrantRole(ctx context.Context, role, grantee string, withAdminOption bool) error
-	SetSchemaPrivileges(ctx context.Context, db, creator, role, schema, privs string) error
+	SetSchemaPrivileges(ctx context.Context, db, creator, role, schema string, privs *SchemaPrivileges) error
+	RevokeSchemaPrivileges(ctx context.Context, db, creator, role, schema string, privs *SchemaPrivileges) error
 	RevokeRole(ctx context.Context, role, userRole string) error
 	AlterDefaultLoginRole(ctx context.Context, role, setRole string) error
 	AlterDefaultLoginRoleOnDatabase(ctx context.Context, role, setRole, database string) error
@@ -64,27 +77,44 @@ type PG interface { //nolint:interfacebloat // This is needed
 	GetPublication(ctx context.Context, dbname, name string) (*PublicationResult, error)
 	CreatePublication(ctx context.Context, dbname string, builder *CreatePublicationBuilder) error
 	UpdatePublication(ctx context.Context, dbname, publicationName string, builder *UpdatePublicationBuilder) error
+	SetPublicationTablesSelectPrivilege(ctx context.Context, dbname, role, publication string) error
 	DropReplicationSlot(ctx context.Context, name string) error
 	CreateReplicationSlot(ctx context.Context, dbname, name, plugin string) error
 	GetReplicationSlot(ctx context.Context, name string) (*ReplicationSlotResult, error)
+	CreateSubscription(ctx context.Context, dbname string, builder *CreateSubscriptionBuilder) error
+	AlterSubscription(ctx context.Context, dbname, subscriptionName string, builder *UpdateSubscriptionBuilder) error
+	DropSubscription(ctx context.Context, dbname, name string) error
+	GetSubscription(ctx context.Context, dbname, name string) (*SubscriptionResult, error)
 	GetUser() string
 	GetHost() string
 	GetPort() int
 	GetDefaultDatabase() string
 	GetArgs() string
 	Ping(ctx context.Context) error
+	GetEngineCapabilities(ctx context.Context) (*EngineCapabilities, error)
+	ProbeEndpoint(ctx context.Context, endpoint *Endpoint) (*EndpointHealth, error)
+	GetServerIdentity(ctx context.Context) (*ServerIdentity, error)
+	EnablePlanMode()
+	IsPlanMode() bool
+	GetPlannedStatements() []string
 }
 
 type pg struct {
-	db              *sql.DB
-	log             logr.Logger
-	host            string
-	user            string
-	pass            string
-	args            string
-	defaultDatabase string
-	name            string
-	port            int
+	db               *sql.DB
+	plan             *planRecorder
+	pool             *PoolSettings
+	passwordSettings *PasswordSettings
+	tls              *TLSSettings
+	provider         *ProviderCapabilities
+	database         string
+	log              logr.Logger
+	host             string
+	user             string
+	pass             string
+	args             string
+	defaultDatabase  string
+	name             string
+	port             int
 }
 
 func NewPG(
@@ -95,28 +125,37 @@ func NewPG(
 	args,
 	defaultDatabase string,
 	port int,
+	poolSettings *PoolSettings,
+	passwordSettings *PasswordSettings,
+	tlsSettings *TLSSettings,
 	cloudType v1alpha1.ProviderType,
 	logger logr.Logger,
 ) PG {
 	postgres := &pg{
-		log:             logger,
-		host:            host,
-		port:            port,
-		user:            user,
-		pass:            password,
-		args:            args,
-		defaultDatabase: defaultDatabase,
-		name:            name,
+		log:              logger,
+		host:             host,
+		port:             port,
+		user:             user,
+		pass:             password,
+		args:             args,
+		defaultDatabase:  defaultDatabase,
+		name:             name,
+		pool:             poolSettings,
+		passwordSettings: passwordSettings,
+		tls:              tlsSettings,
 	}
 
-	switch cloudType {
-	case v1alpha1.AWSProvider:
-		return newAWSPG(postgres)
-	case v1alpha1.AzureProvider:
-		return newAzurePG(postgres)
-	default:
-		return postgres
+	// Get provider registration
+	provider, ok := getProviderRegistration(cloudType)
+	// Check if it isn't registered
+	if !ok {
+		// ? Note: Engine reconcile will report this error, use base implementation here
+		logger.Error(nil, "provider isn't registered, no provider will be used", "provider", cloudType)
 	}
+
+	postgres.provider = provider.capabilities
+
+	return provider.decorate(postgres)
 }
 
 func (c *pg) GetUser() string {
@@ -147,6 +186,16 @@ func (c *pg) GetPassword() string {
 	return c.pass
 }
 
+// getPoolSettings will return pool settings of this engine or operator wide ones if not set.
+func (c *pg) getPoolSettings() *PoolSettings {
+	// Check if settings aren't set
+	if c.pool == nil {
+		return GetDefaultPoolSettings()
+	}
+
+	return c.pool
+}
+
 func (c *pg) connect(database string) error {
 	// Open or create pool
 	db, err := getOrOpenPool(c, database)
@@ -156,6 +205,8 @@ func (c *pg) connect(database string) error {
 	}
 	// Save db
 	c.db = db
+	// Save database name for audit
+	c.database = database
 
 	return nil
 }
@@ -175,9 +226,26 @@ func (c *pg) Ping(ctx context.Context) error {
 }
 
 func TemplatePostgresqlURLWithArgs(host, user, password, uriArgs, database string, port int) string {
-	return fmt.Sprintf("postgresql://%s:%s@%s:%d/%s?%s", user, password, host, port, database, uriArgs)
+	// Build url without args
+	u := templatePostgresqlURL(host, user, password, database, port)
+	// Force query in order to keep "?" even if args are empty
+	u.ForceQuery = true
+	// Args are already in the query format
+	u.RawQuery = uriArgs
+
+	return u.String()
 }
 
 func TemplatePostgresqlURL(host, user, password, database string, port int) string {
-	return fmt.Sprintf("postgresql://%s:%s@%s:%d/%s", user, password, host, port, database)
+	return templatePostgresqlURL(host, user, password, database, port).String()
+}
+
+func templatePostgresqlURL(host, user, password, database string, port int) *url.URL {
+	// ? Note: url structure is used in order to escape user, password and database
+	return &url.URL{
+		Scheme: "postgresql",
+		User:   url.UserPassword(user, password),
+		Host:   fmt.Sprintf("%s:%d", host, port),
+		Path:   "/" + database,
+	}
 }
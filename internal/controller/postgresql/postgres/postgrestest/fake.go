@@ -0,0 +1,1966 @@
+// Package postgrestest provides an in-memory fake PG engine for controller tests.
+package postgrestest
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/easymile/postgresql-operator/internal/controller/postgresql/postgres"
+	"github.com/lib/pq"
+	"github.com/samber/lo"
+)
+
+const (
+	DuplicateObjectErrorCode   = "42710"
+	UndefinedObjectErrorCode   = "42704"
+	InvalidSchemaNameErrorCode = "3F000"
+	DependentObjectsErrorCode  = "2BP01"
+)
+
+// Check that fake implements PG interface.
+var _ postgres.PG = &FakePG{}
+
+// FakeRole represents a role saved in the fake PG engine.
+type FakeRole struct {
+	Password string
+	// Method used to store password (PasswordMethodScram for created or updated passwords)
+	PasswordMethod  string
+	ConnectionLimit int
+	Login           bool
+	Replication     bool
+	BypassRLS       bool
+}
+
+// FakeSchemaPrivilege represents privileges granted on a schema in the fake PG engine.
+type FakeSchemaPrivilege struct {
+	Creator    string
+	Privileges postgres.SchemaPrivileges
+}
+
+// FakePublication represents a publication saved in the fake PG engine.
+type FakePublication struct {
+	Tables                  []string
+	TablesInSchema          []string
+	Publish                 string
+	AllTables               bool
+	PublishViaPartitionRoot bool
+}
+
+// FakeSubscription represents a subscription saved in the fake PG engine.
+type FakeSubscription struct {
+	ConnectionInfo string
+	Publication    string
+	SlotName       string
+	ReceivedLSN    string
+	Enabled        bool
+	CopyData       bool
+	WorkerRunning  bool
+}
+
+// FakeDatabase represents a database saved in the fake PG engine.
+type FakeDatabase struct {
+	Owner string
+	// Effective creation options (template isn't kept like in pg_database)
+	CreationOptions *postgres.DatabaseCreationOptions
+	// Setting name => value set with ALTER DATABASE SET
+	Settings map[string]string
+	// Role => setting name => value set with ALTER ROLE IN DATABASE SET
+	RoleSettings map[string]map[string]string
+	// Connection limit (-1 means no limit)
+	ConnectionLimit int
+	// Are connections allowed ?
+	AllowConnections bool
+	// Number of connected sessions
+	Sessions int
+	// Schema name => owner
+	Schemas map[string]string
+	// Extension name => present
+	Extensions map[string]bool
+	// Schema name => table name => owner
+	Tables map[string]map[string]string
+	// Schema name => type name => owner
+	Types map[string]map[string]string
+	// Schema name => role => privileges
+	SchemaPrivileges map[string]map[string]*FakeSchemaPrivilege
+	// Publication name => publication
+	Publications map[string]*FakePublication
+	// Role => publication name on which tables select is granted
+	PublicationSelectGrants map[string]string
+	// Subscription name => subscription
+	Subscriptions map[string]*FakeSubscription
+}
+
+// FakePG is a stateful in-memory implementation of the PG interface.
+// This is designed to test reconcile logic without any PostgreSQL engine.
+type FakePG struct {
+	// Database name => database
+	Databases map[string]*FakeDatabase
+	// Role name => role
+	Roles map[string]*FakeRole
+	// Member => group role => with admin option
+	Memberships map[string]map[string]bool
+	// Role => database ("" for all databases) => role set on login
+	RoleSettings map[string]map[string]string
+	// Replication slot name => replication slot
+	ReplicationSlots map[string]*postgres.ReplicationSlotResult
+	// Role name => has active session
+	ActiveSessions map[string]bool
+	// Capabilities returned by engine discovery
+	Capabilities *postgres.EngineCapabilities
+	// Endpoint address => health returned by probes (healthy primary when not set)
+	EndpointHealths map[string]*postgres.EndpointHealth
+	// Endpoint address => unreachable
+	UnreachableEndpoints map[string]bool
+	// Identity of server behind engine host
+	ServerIdentity *postgres.ServerIdentity
+	// Method name => error to return
+	injectedErrors map[string]error
+	// List of called methods
+	Calls []string
+	// List of mutating calls recorded in plan mode
+	PlannedStatements []string
+	host              string
+	user              string
+	args              string
+	defaultDatabase   string
+	port              int
+	mutex             sync.Mutex
+	planMode          bool
+}
+
+// NewFakePG will create a fake PG engine containing the default database and the admin user.
+func NewFakePG(host, user, args, defaultDatabase string, port int) *FakePG {
+	f := &FakePG{
+		Databases:        map[string]*FakeDatabase{},
+		Roles:            map[string]*FakeRole{},
+		Memberships:      map[string]map[string]bool{},
+		RoleSettings:     map[string]map[string]string{},
+		ReplicationSlots: map[string]*postgres.ReplicationSlotResult{},
+		ActiveSessions:   map[string]bool{},
+		Capabilities: &postgres.EngineCapabilities{
+			ServerVersionNum: 150000, //nolint:gomnd // PostgreSQL 15
+			WalLevel:         "logical",
+			MaxConnections:   100, //nolint:gomnd // PostgreSQL default
+			Superuser:        true,
+			CreateDB:         true,
+			CreateRole:       true,
+			Replication:      true,
+		},
+		EndpointHealths:      map[string]*postgres.EndpointHealth{},
+		UnreachableEndpoints: map[string]bool{},
+		ServerIdentity:       &postgres.ServerIdentity{SystemIdentifier: "7000000000000000001", ServerAddress: "127.0.0.1"},
+		injectedErrors:       map[string]error{},
+		Calls:                []string{},
+		host:                 host,
+		user:                 user,
+		args:                 args,
+		defaultDatabase:      defaultDatabase,
+		port:                 port,
+	}
+
+	// Add admin user
+	f.Roles[user] = &FakeRole{Login: true, ConnectionLimit: postgres.DefaultAttributeConnectionLimit}
+	// Add default database
+	f.Databases[defaultDatabase] = newFakeDatabase(user)
+
+	return f
+}
+
+func newFakeDatabase(owner string) *FakeDatabase {
+	return &FakeDatabase{
+		Owner: owner,
+		CreationOptions: &postgres.DatabaseCreationOptions{
+			Encoding:       "UTF8",
+			Locale:         "en_US.utf8",
+			LocaleProvider: postgres.LibcLocaleProvider,
+			Tablespace:     "pg_default",
+		},
+		ConnectionLimit:  postgres.UnlimitedConnectionLimit,
+		AllowConnections: true,
+		Settings:         map[string]string{},
+		RoleSettings:     map[string]map[string]string{},
+		Schemas:          map[string]string{"public": owner},
+		Extensions:       map[string]bool{},
+		Tables:           map[string]map[string]string{},
+		Types:            map[string]map[string]string{},
+		SchemaPrivileges: map[string]map[string]*FakeSchemaPrivilege{},
+		Publications:     map[string]*FakePublication{},
+		Subscriptions:    map[string]*FakeSubscription{},
+
+		PublicationSelectGrants: map[string]string{},
+	}
+}
+
+// NewFakePqError will create a pq error with the given code.
+func NewFakePqError(code pq.ErrorCode, msg string) *pq.Error {
+	return &pq.Error{Code: code, Message: msg}
+}
+
+// InjectError will force the given method to return the provided error until errors are cleared.
+func (f *FakePG) InjectError(method string, err error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	f.injectedErrors[method] = err
+}
+
+// InjectPqError will force the given method to return a pq error with the provided code until errors are cleared.
+func (f *FakePG) InjectPqError(method string, code pq.ErrorCode) {
+	f.InjectError(method, NewFakePqError(code, "injected error"))
+}
+
+// ClearInjectedErrors will remove all injected errors.
+func (f *FakePG) ClearInjectedErrors() {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	f.injectedErrors = map[string]error{}
+}
+
+// SetActiveSession will flag role as having an active session or not.
+func (f *FakePG) SetActiveSession(role string, active bool) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	f.ActiveSessions[role] = active
+}
+
+// SetEndpointHealth will set health returned by probes of endpoint.
+func (f *FakePG) SetEndpointHealth(host string, port int, health *postgres.EndpointHealth) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	f.EndpointHealths[(&postgres.Endpoint{Host: host, Port: port}).String()] = health
+}
+
+// SetEndpointUnreachable will flag endpoint as unreachable or not.
+func (f *FakePG) SetEndpointUnreachable(host string, port int, unreachable bool) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	f.UnreachableEndpoints[(&postgres.Endpoint{Host: host, Port: port}).String()] = unreachable
+}
+
+// AddTable will add a table in schema of database.
+func (f *FakePG) AddTable(db, schema, table, owner string) error {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	d, err := f.getDatabase(db)
+	if err != nil {
+		return err
+	}
+
+	if d.Tables[schema] == nil {
+		d.Tables[schema] = map[string]string{}
+	}
+
+	d.Tables[schema][table] = owner
+
+	return nil
+}
+
+// AddType will add a type in schema of database.
+func (f *FakePG) AddType(db, schema, typeName, owner string) error {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	d, err := f.getDatabase(db)
+	if err != nil {
+		return err
+	}
+
+	if d.Types[schema] == nil {
+		d.Types[schema] = map[string]string{}
+	}
+
+	d.Types[schema][typeName] = owner
+
+	return nil
+}
+
+// HasCall will return true if method have been called.
+func (f *FakePG) HasCall(method string) bool {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	for _, it := range f.Calls {
+		if it == method {
+			return true
+		}
+	}
+
+	return false
+}
+
+// start will lock fake, save call and return injected error if exists.
+// Caller must unlock.
+func (f *FakePG) start(method string) error {
+	f.mutex.Lock()
+
+	// Save call
+	f.Calls = append(f.Calls, method)
+
+	return f.injectedErrors[method]
+}
+
+// startMutation will act as start for mutating methods.
+// In plan mode, call is recorded as a statement and planned is true: caller must return without any change.
+// Caller must unlock.
+func (f *FakePG) startMutation(method string, args ...any) (planned bool, err error) {
+	err = f.start(method)
+	// Check error or plan mode disabled
+	if err != nil || !f.planMode {
+		return false, err
+	}
+
+	// Build statement
+	strArgs := make([]string, 0, len(args))
+	for _, it := range args {
+		strArgs = append(strArgs, fmt.Sprint(it))
+	}
+
+	f.PlannedStatements = append(f.PlannedStatements, fmt.Sprintf("%s(%s)", method, strings.Join(strArgs, ", ")))
+
+	return true, nil
+}
+
+// isPlannedMissingDatabaseError will return true if error is due to a database that doesn't exist yet in plan mode.
+func (f *FakePG) isPlannedMissingDatabaseError(err error) bool {
+	// Try to cast error
+	pqErr, ok := err.(*pq.Error)
+
+	return f.planMode && ok && pqErr.Code == postgres.UndefinedDatabaseErrorCode
+}
+
+func (f *FakePG) EnablePlanMode() {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	// Start a new plan
+	f.planMode = true
+	f.PlannedStatements = []string{}
+}
+
+// DisablePlanMode will execute mutating methods again.
+func (f *FakePG) DisablePlanMode() {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	f.planMode = false
+}
+
+func (f *FakePG) IsPlanMode() bool {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	return f.planMode
+}
+
+func (f *FakePG) GetPlannedStatements() []string {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	// Copy to avoid any side effect
+	res := make([]string, len(f.PlannedStatements))
+	copy(res, f.PlannedStatements)
+
+	return res
+}
+
+func (f *FakePG) getDatabase(name string) (*FakeDatabase, error) {
+	d, ok := f.Databases[name]
+	if !ok {
+		return nil, NewFakePqError(postgres.UndefinedDatabaseErrorCode, fmt.Sprintf("database \"%s\" does not exist", name))
+	}
+
+	return d, nil
+}
+
+func (f *FakePG) getRole(name string) (*FakeRole, error) {
+	r, ok := f.Roles[name]
+	if !ok {
+		return nil, NewFakePqError(UndefinedObjectErrorCode, fmt.Sprintf("role \"%s\" does not exist", name))
+	}
+
+	return r, nil
+}
+
+func (f *FakePG) GetUser() string {
+	return f.user
+}
+
+func (f *FakePG) GetHost() string {
+	return f.host
+}
+
+func (f *FakePG) GetArgs() string {
+	return f.args
+}
+
+func (f *FakePG) GetPort() int {
+	return f.port
+}
+
+func (f *FakePG) GetDefaultDatabase() string {
+	return f.defaultDatabase
+}
+
+func (f *FakePG) Ping(_ context.Context) error {
+	defer f.mutex.Unlock()
+
+	return f.start("Ping")
+}
+
+func (f *FakePG) GetEngineCapabilities(_ context.Context) (*postgres.EngineCapabilities, error) {
+	defer f.mutex.Unlock()
+
+	if err := f.start("GetEngineCapabilities"); err != nil {
+		return nil, err
+	}
+
+	// Copy to avoid any side effect
+	res := *f.Capabilities
+
+	return &res, nil
+}
+
+func (f *FakePG) GetServerIdentity(_ context.Context) (*postgres.ServerIdentity, error) {
+	defer f.mutex.Unlock()
+
+	if err := f.start("GetServerIdentity"); err != nil {
+		return nil, err
+	}
+
+	// Copy to avoid any side effect
+	res := *f.ServerIdentity
+
+	return &res, nil
+}
+
+func (f *FakePG) ProbeEndpoint(_ context.Context, endpoint *postgres.Endpoint) (*postgres.EndpointHealth, error) {
+	defer f.mutex.Unlock()
+
+	if err := f.start("ProbeEndpoint"); err != nil {
+		return nil, err
+	}
+
+	// Check if endpoint is unreachable
+	if f.UnreachableEndpoints[endpoint.String()] {
+		return nil, fmt.Errorf("endpoint %s isn't reachable: connection refused", endpoint)
+	}
+
+	// Check if health is set
+	if h, ok := f.EndpointHealths[endpoint.String()]; ok {
+		// Copy to avoid any side effect
+		res := *h
+
+		return &res, nil
+	}
+
+	// Default to a healthy primary
+	inRecovery := false
+	lag := time.Duration(0)
+
+	return &postgres.EndpointHealth{Latency: time.Millisecond, InRecovery: &inRecovery, ReplicationLag: &lag}, nil
+}
+
+func (f *FakePG) IsDatabaseExist(_ context.Context, dbname string) (bool, error) {
+	defer f.mutex.Unlock()
+
+	if err := f.start("IsDatabaseExist"); err != nil {
+		return false, err
+	}
+
+	_, ok := f.Databases[dbname]
+
+	return ok, nil
+}
+
+func (f *FakePG) CreateDB(_ context.Context, dbname, username string, options *postgres.DatabaseCreationOptions) error {
+	defer f.mutex.Unlock()
+
+	// Options are only part of planned statement when set
+	args := []any{dbname, username}
+	if options != nil {
+		args = append(args, *options)
+	}
+
+	if planned, err := f.startMutation("CreateDB", args...); planned || err != nil {
+		return err
+	}
+
+	// Check role
+	if _, err := f.getRole(username); err != nil {
+		return err
+	}
+
+	// Duplicate database error is ignored
+	if _, ok := f.Databases[dbname]; ok {
+		return nil
+	}
+
+	d := newFakeDatabase(username)
+	// Apply options
+	if options != nil {
+		opts := *options
+		// Template isn't kept
+		opts.Template = ""
+		// Use defaults for missing values
+		if opts.Encoding == "" {
+			opts.Encoding = d.CreationOptions.Encoding
+		}
+
+		if opts.Locale == "" {
+			opts.Locale = d.CreationOptions.Locale
+		}
+
+		if opts.LocaleProvider == "" {
+			opts.LocaleProvider = d.CreationOptions.LocaleProvider
+		}
+
+		if opts.Tablespace == "" {
+			opts.Tablespace = d.CreationOptions.Tablespace
+		}
+
+		d.CreationOptions = &opts
+	}
+
+	f.Databases[dbname] = d
+
+	return nil
+}
+
+func (f *FakePG) GetDatabaseCreationOptions(_ context.Context, dbname string) (*postgres.DatabaseCreationOptions, error) {
+	defer f.mutex.Unlock()
+
+	if err := f.start("GetDatabaseCreationOptions"); err != nil {
+		return nil, err
+	}
+
+	d, ok := f.Databases[dbname]
+	// Check if database doesn't exist
+	if !ok {
+		return nil, nil
+	}
+
+	// Copy to avoid any side effect
+	res := *d.CreationOptions
+
+	return &res, nil
+}
+
+func (f *FakePG) GetDatabaseSettings(_ context.Context, dbname, role string) (map[string]string, error) {
+	defer f.mutex.Unlock()
+
+	if err := f.start("GetDatabaseSettings"); err != nil {
+		return nil, err
+	}
+
+	res := map[string]string{}
+
+	d, ok := f.Databases[dbname]
+	// Check if database doesn't exist
+	if !ok {
+		return res, nil
+	}
+
+	// Select settings
+	settings := d.Settings
+	if role != "" {
+		settings = d.RoleSettings[role]
+	}
+
+	// Copy to avoid any side effect
+	for k, v := range settings {
+		res[k] = v
+	}
+
+	return res, nil
+}
+
+func (f *FakePG) AlterDatabaseSetting(_ context.Context, dbname, role, name, value string) error {
+	defer f.mutex.Unlock()
+
+	if planned, err := f.startMutation("AlterDatabaseSetting", dbname, role, name, value); planned || err != nil {
+		return err
+	}
+
+	d, err := f.getDatabase(dbname)
+	if err != nil {
+		return err
+	}
+
+	// Check if it is a database setting
+	if role == "" {
+		d.Settings[name] = value
+
+		return nil
+	}
+
+	if _, err = f.getRole(role); err != nil {
+		return err
+	}
+
+	if _, ok := d.RoleSettings[role]; !ok {
+		d.RoleSettings[role] = map[string]string{}
+	}
+
+	d.RoleSettings[role][name] = value
+
+	return nil
+}
+
+func (f *FakePG) ResetDatabaseSetting(_ context.Context, dbname, role, name string) error {
+	defer f.mutex.Unlock()
+
+	if planned, err := f.startMutation("ResetDatabaseSetting", dbname, role, name); planned || err != nil {
+		return err
+	}
+
+	d, err := f.getDatabase(dbname)
+	if err != nil {
+		return err
+	}
+
+	// Check if it is a database setting
+	if role == "" {
+		delete(d.Settings, name)
+
+		return nil
+	}
+
+	delete(d.RoleSettings[role], name)
+
+	return nil
+}
+
+func (f *FakePG) GetDatabaseConnections(_ context.Context, dbname string) (*postgres.DatabaseConnections, error) {
+	defer f.mutex.Unlock()
+
+	if err := f.start("GetDatabaseConnections"); err != nil {
+		return nil, err
+	}
+
+	d, ok := f.Databases[dbname]
+	// Check if database doesn't exist
+	if !ok {
+		return nil, nil
+	}
+
+	return &postgres.DatabaseConnections{
+		ConnectionLimit:  d.ConnectionLimit,
+		AllowConnections: d.AllowConnections,
+		Count:            d.Sessions,
+	}, nil
+}
+
+func (f *FakePG) SetDatabaseConnectionLimit(_ context.Context, dbname string, limit int) error {
+	defer f.mutex.Unlock()
+
+	if planned, err := f.startMutation("SetDatabaseConnectionLimit", dbname, limit); planned || err != nil {
+		return err
+	}
+
+	d, err := f.getDatabase(dbname)
+	if err != nil {
+		return err
+	}
+
+	d.ConnectionLimit = limit
+
+	return nil
+}
+
+func (f *FakePG) SetDatabaseAllowConnections(_ context.Context, dbname string, allow bool) error {
+	defer f.mutex.Unlock()
+
+	if planned, err := f.startMutation("SetDatabaseAllowConnections", dbname, allow); planned || err != nil {
+		return err
+	}
+
+	d, err := f.getDatabase(dbname)
+	if err != nil {
+		return err
+	}
+
+	d.AllowConnections = allow
+
+	return nil
+}
+
+func (f *FakePG) TerminateDatabaseSessions(_ context.Context, dbname string) error {
+	defer f.mutex.Unlock()
+
+	if planned, err := f.startMutation("TerminateDatabaseSessions", dbname); planned || err != nil {
+		return err
+	}
+
+	d, err := f.getDatabase(dbname)
+	if err != nil {
+		return err
+	}
+
+	d.Sessions = 0
+
+	return nil
+}
+
+func (f *FakePG) ChangeDBOwner(_ context.Context, dbname, owner string) error {
+	defer f.mutex.Unlock()
+
+	if planned, err := f.startMutation("ChangeDBOwner", dbname, owner); planned || err != nil {
+		return err
+	}
+
+	d, err := f.getDatabase(dbname)
+	if err != nil {
+		return err
+	}
+
+	if _, err = f.getRole(owner); err != nil {
+		return err
+	}
+
+	d.Owner = owner
+
+	return nil
+}
+
+func (f *FakePG) RenameDatabase(_ context.Context, oldname, newname string) error {
+	defer f.mutex.Unlock()
+
+	if planned, err := f.startMutation("RenameDatabase", oldname, newname); planned || err != nil {
+		return err
+	}
+
+	d, err := f.getDatabase(oldname)
+	if err != nil {
+		return err
+	}
+
+	if _, ok := f.Databases[newname]; ok {
+		return NewFakePqError(postgres.DuplicateDatabaseErrorCode, fmt.Sprintf("database \"%s\" already exists", newname))
+	}
+
+	delete(f.Databases, oldname)
+	f.Databases[newname] = d
+
+	// Move role settings
+	for _, settings := range f.RoleSettings {
+		if v, ok := settings[oldname]; ok {
+			delete(settings, oldname)
+			settings[newname] = v
+		}
+	}
+
+	return nil
+}
+
+func (f *FakePG) DropDatabase(_ context.Context, db string) error {
+	defer f.mutex.Unlock()
+
+	if planned, err := f.startMutation("DropDatabase", db); planned || err != nil {
+		return err
+	}
+
+	delete(f.Databases, db)
+
+	// Clean role settings
+	for _, settings := range f.RoleSettings {
+		delete(settings, db)
+	}
+
+	return nil
+}
+
+func (f *FakePG) CreateSchema(_ context.Context, db, role, schema string) error {
+	defer f.mutex.Unlock()
+
+	if planned, err := f.startMutation("CreateSchema", db, role, schema); planned || err != nil {
+		return err
+	}
+
+	d, err := f.getDatabase(db)
+	if err != nil {
+		return err
+	}
+
+	if _, err = f.getRole(role); err != nil {
+		return err
+	}
+
+	// If not exists
+	if _, ok := d.Schemas[schema]; !ok {
+		d.Schemas[schema] = role
+	}
+
+	return nil
+}
+
+func (f *FakePG) DropSchema(_ context.Context, database, schema string, cascade bool) error {
+	defer f.mutex.Unlock()
+
+	if planned, err := f.startMutation("DropSchema", database, schema, cascade); planned || err != nil {
+		return err
+	}
+
+	d, err := f.getDatabase(database)
+	if err != nil {
+		return err
+	}
+
+	// Check dependent objects
+	if !cascade && (len(d.Tables[schema]) != 0 || len(d.Types[schema]) != 0) {
+		return NewFakePqError(DependentObjectsErrorCode, fmt.Sprintf("cannot drop schema %s because other objects depend on it", schema))
+	}
+
+	delete(d.Schemas, schema)
+	delete(d.Tables, schema)
+	delete(d.Types, schema)
+	delete(d.SchemaPrivileges, schema)
+
+	return nil
+}
+
+func (f *FakePG) CreateExtension(_ context.Context, db, extension string) error {
+	defer f.mutex.Unlock()
+
+	if planned, err := f.startMutation("CreateExtension", db, extension); planned || err != nil {
+		return err
+	}
+
+	d, err := f.getDatabase(db)
+	if err != nil {
+		return err
+	}
+
+	d.Extensions[extension] = true
+
+	return nil
+}
+
+func (f *FakePG) DropExtension(_ context.Context, database, extension string, _ bool) error {
+	defer f.mutex.Unlock()
+
+	if planned, err := f.startMutation("DropExtension", database, extension); planned || err != nil {
+		return err
+	}
+
+	d, err := f.getDatabase(database)
+	if err != nil {
+		return err
+	}
+
+	delete(d.Extensions, extension)
+
+	return nil
+}
+
+func (f *FakePG) SetSchemaPrivileges(_ context.Context, db, creator, role, schema string, privs *postgres.SchemaPrivileges) error {
+	defer f.mutex.Unlock()
+
+	if planned, err := f.startMutation("SetSchemaPrivileges", db, creator, role, schema, *privs); planned || err != nil {
+		return err
+	}
+
+	d, err := f.getDatabase(db)
+	if err != nil {
+		return err
+	}
+
+	if _, ok := d.Schemas[schema]; !ok {
+		return NewFakePqError(InvalidSchemaNameErrorCode, fmt.Sprintf("schema \"%s\" does not exist", schema))
+	}
+
+	if _, err = f.getRole(role); err != nil {
+		return err
+	}
+
+	if d.SchemaPrivileges[schema] == nil {
+		d.SchemaPrivileges[schema] = map[string]*FakeSchemaPrivilege{}
+	}
+
+	// Grants are added to existing ones
+	current := d.SchemaPrivileges[schema][role]
+	if current == nil {
+		current = &FakeSchemaPrivilege{}
+		d.SchemaPrivileges[schema][role] = current
+	}
+
+	current.Creator = creator
+	current.Privileges = postgres.SchemaPrivileges{
+		Tables:    mergeFakePrivileges(current.Privileges.Tables, privs.Tables, false),
+		Sequences: mergeFakePrivileges(current.Privileges.Sequences, privs.Sequences, false),
+		Functions: mergeFakePrivileges(current.Privileges.Functions, privs.Functions, false),
+		Types:     mergeFakePrivileges(current.Privileges.Types, privs.Types, false),
+	}
+
+	return nil
+}
+
+func (f *FakePG) RevokeSchemaPrivileges(_ context.Context, db, creator, role, schema string, privs *postgres.SchemaPrivileges) error {
+	defer f.mutex.Unlock()
+
+	if planned, err := f.startMutation("RevokeSchemaPrivileges", db, creator, role, schema, *privs); planned || err != nil {
+		return err
+	}
+
+	d, err := f.getDatabase(db)
+	if err != nil {
+		return err
+	}
+
+	current := d.SchemaPrivileges[schema][role]
+	// Check if there isn't any privilege
+	if current == nil {
+		return nil
+	}
+
+	current.Privileges = postgres.SchemaPrivileges{
+		Tables:    mergeFakePrivileges(current.Privileges.Tables, privs.Tables, true),
+		Sequences: mergeFakePrivileges(current.Privileges.Sequences, privs.Sequences, true),
+		Functions: mergeFakePrivileges(current.Privileges.Functions, privs.Functions, true),
+		Types:     mergeFakePrivileges(current.Privileges.Types, privs.Types, true),
+	}
+
+	return nil
+}
+
+// mergeFakePrivileges will add or remove comma separated privileges from current ones keeping order.
+func mergeFakePrivileges(current, privs string, remove bool) string {
+	// Split values
+	currentList := lo.Compact(strings.Split(current, ","))
+	privsList := lo.Compact(strings.Split(privs, ","))
+
+	// Check if privileges must be removed
+	if remove {
+		res, _ := lo.Difference(currentList, privsList)
+
+		return strings.Join(res, ",")
+	}
+
+	return strings.Join(lo.Union(currentList, privsList), ",")
+}
+
+func (f *FakePG) GetTablesInSchema(_ context.Context, db, schema string) ([]*postgres.TableOwnership, error) {
+	defer f.mutex.Unlock()
+
+	if err := f.start("GetTablesInSchema"); err != nil {
+		return nil, err
+	}
+
+	d, err := f.getDatabase(db)
+	if err != nil {
+		// Check if database creation have only been planned
+		if f.isPlannedMissingDatabaseError(err) {
+			return []*postgres.TableOwnership{}, nil
+		}
+
+		return nil, err
+	}
+
+	res := []*postgres.TableOwnership{}
+
+	for _, k := range sortedKeys(d.Tables[schema]) {
+		res = append(res, &postgres.TableOwnership{TableName: k, Owner: d.Tables[schema][k]})
+	}
+
+	return res, nil
+}
+
+func (f *FakePG) ChangeTableOwner(_ context.Context, db, table, owner string) error {
+	defer f.mutex.Unlock()
+
+	if planned, err := f.startMutation("ChangeTableOwner", db, table, owner); planned || err != nil {
+		return err
+	}
+
+	d, err := f.getDatabase(db)
+	if err != nil {
+		return err
+	}
+
+	// Table is found like with the search path: public schema first
+	for _, schema := range append([]string{"public"}, sortedKeys(d.Tables)...) {
+		if _, ok := d.Tables[schema][table]; ok {
+			d.Tables[schema][table] = owner
+
+			return nil
+		}
+	}
+
+	// If exists
+	return nil
+}
+
+func (f *FakePG) GetTypesInSchema(_ context.Context, db, schema string) ([]*postgres.TypeOwnership, error) {
+	defer f.mutex.Unlock()
+
+	if err := f.start("GetTypesInSchema"); err != nil {
+		return nil, err
+	}
+
+	d, err := f.getDatabase(db)
+	if err != nil {
+		// Check if database creation have only been planned
+		if f.isPlannedMissingDatabaseError(err) {
+			return []*postgres.TypeOwnership{}, nil
+		}
+
+		return nil, err
+	}
+
+	res := []*postgres.TypeOwnership{}
+
+	for _, k := range sortedKeys(d.Types[schema]) {
+		res = append(res, &postgres.TypeOwnership{TypeName: k, Owner: d.Types[schema][k]})
+	}
+
+	return res, nil
+}
+
+func (f *FakePG) ChangeTypeOwnerInSchema(_ context.Context, db, schema, typeName, owner string) error {
+	defer f.mutex.Unlock()
+
+	if planned, err := f.startMutation("ChangeTypeOwnerInSchema", db, schema, typeName, owner); planned || err != nil {
+		return err
+	}
+
+	d, err := f.getDatabase(db)
+	if err != nil {
+		return err
+	}
+
+	if _, ok := d.Types[schema][typeName]; !ok {
+		return NewFakePqError(UndefinedObjectErrorCode, fmt.Sprintf("type \"%s.%s\" does not exist", schema, typeName))
+	}
+
+	d.Types[schema][typeName] = owner
+
+	return nil
+}
+
+func (f *FakePG) CreateGroupRole(_ context.Context, role string) error {
+	defer f.mutex.Unlock()
+
+	if planned, err := f.startMutation("CreateGroupRole", role); planned || err != nil {
+		return err
+	}
+
+	// Duplicate role error is ignored
+	if _, ok := f.Roles[role]; ok {
+		return nil
+	}
+
+	f.Roles[role] = &FakeRole{ConnectionLimit: postgres.DefaultAttributeConnectionLimit}
+
+	return nil
+}
+
+func (f *FakePG) CreateUserRole(_ context.Context, role, password string, attributes *postgres.RoleAttributes) (string, error) {
+	defer f.mutex.Unlock()
+
+	planned, err := f.startMutation("CreateUserRole", role, postgres.RedactedValue)
+	if err != nil {
+		return "", err
+	}
+
+	if planned {
+		return role, nil
+	}
+
+	if _, ok := f.Roles[role]; ok {
+		return "", NewFakePqError(postgres.DuplicateRoleErrorCode, fmt.Sprintf("role \"%s\" already exists", role))
+	}
+
+	r := &FakeRole{Login: true, Password: password, PasswordMethod: postgres.PasswordMethodScram, ConnectionLimit: postgres.DefaultAttributeConnectionLimit}
+	// Apply attributes
+	applyFakeRoleAttributes(r, attributes)
+
+	f.Roles[role] = r
+
+	return role, nil
+}
+
+func applyFakeRoleAttributes(r *FakeRole, attributes *postgres.RoleAttributes) {
+	// Check nil
+	if attributes == nil {
+		return
+	}
+
+	if attributes.ConnectionLimit != nil {
+		r.ConnectionLimit = *attributes.ConnectionLimit
+	}
+
+	if attributes.Replication != nil {
+		r.Replication = *attributes.Replication
+	}
+
+	if attributes.BypassRLS != nil {
+		r.BypassRLS = *attributes.BypassRLS
+	}
+}
+
+func (f *FakePG) AlterRoleAttributes(_ context.Context, role string, attributes *postgres.RoleAttributes) error {
+	defer f.mutex.Unlock()
+
+	if planned, err := f.startMutation("AlterRoleAttributes", role); planned || err != nil {
+		return err
+	}
+
+	r, err := f.getRole(role)
+	if err != nil {
+		return err
+	}
+
+	applyFakeRoleAttributes(r, attributes)
+
+	return nil
+}
+
+func (f *FakePG) GetRoleAttributes(_ context.Context, role string) (*postgres.RoleAttributes, error) {
+	defer f.mutex.Unlock()
+
+	res := &postgres.RoleAttributes{
+		ConnectionLimit: new(int),
+		Replication:     new(bool),
+		BypassRLS:       new(bool),
+	}
+
+	if err := f.start("GetRoleAttributes"); err != nil {
+		return res, err
+	}
+
+	r, ok := f.Roles[role]
+	if !ok {
+		return res, nil
+	}
+
+	*res.ConnectionLimit = r.ConnectionLimit
+	*res.Replication = r.Replication
+	*res.BypassRLS = r.BypassRLS
+
+	return res, nil
+}
+
+func (f *FakePG) DoesRolePasswordMethodMatch(_ context.Context, role string) (bool, error) {
+	defer f.mutex.Unlock()
+
+	if err := f.start("DoesRolePasswordMethodMatch"); err != nil {
+		return false, err
+	}
+
+	r, ok := f.Roles[role]
+	if !ok {
+		return true, nil
+	}
+
+	return r.PasswordMethod == postgres.PasswordMethodScram, nil
+}
+
+func (f *FakePG) IsRoleExist(_ context.Context, role string) (bool, error) {
+	defer f.mutex.Unlock()
+
+	if err := f.start("IsRoleExist"); err != nil {
+		return false, err
+	}
+
+	_, ok := f.Roles[role]
+
+	return ok, nil
+}
+
+func (f *FakePG) RenameRole(_ context.Context, oldname, newname string) error {
+	defer f.mutex.Unlock()
+
+	if planned, err := f.startMutation("RenameRole", oldname, newname); planned || err != nil {
+		return err
+	}
+
+	r, err := f.getRole(oldname)
+	if err != nil {
+		return err
+	}
+
+	if _, ok := f.Roles[newname]; ok {
+		return NewFakePqError(postgres.DuplicateRoleErrorCode, fmt.Sprintf("role \"%s\" already exists", newname))
+	}
+
+	// Move role
+	delete(f.Roles, oldname)
+	f.Roles[newname] = r
+
+	// Move memberships as member
+	if v, ok := f.Memberships[oldname]; ok {
+		delete(f.Memberships, oldname)
+		f.Memberships[newname] = v
+	}
+
+	// Move memberships as group
+	for _, groups := range f.Memberships {
+		if v, ok := groups[oldname]; ok {
+			delete(groups, oldname)
+			groups[newname] = v
+		}
+	}
+
+	// Move settings
+	if v, ok := f.RoleSettings[oldname]; ok {
+		delete(f.RoleSettings, oldname)
+		f.RoleSettings[newname] = v
+	}
+
+	// Move ownerships
+	f.reassignOwned(oldname, newname, "")
+
+	return nil
+}
+
+func (f *FakePG) UpdatePassword(_ context.Context, role, password string) error {
+	defer f.mutex.Unlock()
+
+	if planned, err := f.startMutation("UpdatePassword", role, postgres.RedactedValue); planned || err != nil {
+		return err
+	}
+
+	r, err := f.getRole(role)
+	if err != nil {
+		return err
+	}
+
+	r.Password = password
+	r.PasswordMethod = postgres.PasswordMethodScram
+
+	return nil
+}
+
+func (f *FakePG) UpdateCurrentUserPassword(_ context.Context, password string) error {
+	defer f.mutex.Unlock()
+
+	if planned, err := f.startMutation("UpdateCurrentUserPassword", postgres.RedactedValue); planned || err != nil {
+		return err
+	}
+
+	r, err := f.getRole(f.user)
+	if err != nil {
+		return err
+	}
+
+	r.Password = password
+	r.PasswordMethod = postgres.PasswordMethodScram
+
+	return nil
+}
+
+func (f *FakePG) CheckLogin(_ context.Context, password string) error {
+	defer f.mutex.Unlock()
+
+	if err := f.start("CheckLogin"); err != nil {
+		return err
+	}
+
+	r, err := f.getRole(f.user)
+	if err != nil {
+		return err
+	}
+
+	// Check password
+	if r.Password != password {
+		return NewFakePqError(postgres.InvalidPasswordErrorCode, fmt.Sprintf("password authentication failed for user %q", f.user))
+	}
+
+	return nil
+}
+
+func (f *FakePG) GrantRole(_ context.Context, role, grantee string, withAdminOption bool) error {
+	defer f.mutex.Unlock()
+
+	if planned, err := f.startMutation("GrantRole", role, grantee, withAdminOption); planned || err != nil {
+		return err
+	}
+
+	if _, err := f.getRole(role); err != nil {
+		return err
+	}
+
+	if _, err := f.getRole(grantee); err != nil {
+		return err
+	}
+
+	if f.Memberships[grantee] == nil {
+		f.Memberships[grantee] = map[string]bool{}
+	}
+
+	f.Memberships[grantee][role] = f.Memberships[grantee][role] || withAdminOption
+
+	return nil
+}
+
+func (f *FakePG) RevokeRole(_ context.Context, role, userRole string) error {
+	defer f.mutex.Unlock()
+
+	if planned, err := f.startMutation("RevokeRole", role, userRole); planned || err != nil {
+		return err
+	}
+
+	delete(f.Memberships[userRole], role)
+
+	return nil
+}
+
+func (f *FakePG) GetRoleMembership(_ context.Context, role string) ([]string, error) {
+	defer f.mutex.Unlock()
+
+	res := make([]string, 0)
+
+	if err := f.start("GetRoleMembership"); err != nil {
+		return res, err
+	}
+
+	// Only login roles are considered
+	r, ok := f.Roles[role]
+	if !ok || !r.Login {
+		return res, nil
+	}
+
+	res = append(res, sortedKeys(f.Memberships[role])...)
+
+	return res, nil
+}
+
+func (f *FakePG) AlterDefaultLoginRole(_ context.Context, role, setRole string) error {
+	defer f.mutex.Unlock()
+
+	if planned, err := f.startMutation("AlterDefaultLoginRole", role, setRole); planned || err != nil {
+		return err
+	}
+
+	return f.setRoleSetting(role, setRole, "")
+}
+
+func (f *FakePG) AlterDefaultLoginRoleOnDatabase(_ context.Context, role, setRole, database string) error {
+	defer f.mutex.Unlock()
+
+	if planned, err := f.startMutation("AlterDefaultLoginRoleOnDatabase", role, setRole, database); planned || err != nil {
+		return err
+	}
+
+	if _, err := f.getDatabase(database); err != nil {
+		return err
+	}
+
+	return f.setRoleSetting(role, setRole, database)
+}
+
+func (f *FakePG) setRoleSetting(role, setRole, database string) error {
+	if _, err := f.getRole(role); err != nil {
+		return err
+	}
+
+	if f.RoleSettings[role] == nil {
+		f.RoleSettings[role] = map[string]string{}
+	}
+
+	f.RoleSettings[role][database] = setRole
+
+	return nil
+}
+
+func (f *FakePG) RevokeUserSetRoleOnDatabase(_ context.Context, role, database string) error {
+	defer f.mutex.Unlock()
+
+	if planned, err := f.startMutation("RevokeUserSetRoleOnDatabase", role, database); planned || err != nil {
+		return err
+	}
+
+	if _, err := f.getRole(role); err != nil {
+		return err
+	}
+
+	if _, err := f.getDatabase(database); err != nil {
+		return err
+	}
+
+	delete(f.RoleSettings[role], database)
+
+	return nil
+}
+
+func (f *FakePG) GetSetRoleOnDatabasesRoleSettings(_ context.Context, role string) ([]*postgres.SetRoleOnDatabaseRoleSetting, error) {
+	defer f.mutex.Unlock()
+
+	res := make([]*postgres.SetRoleOnDatabaseRoleSetting, 0)
+
+	if err := f.start("GetSetRoleOnDatabasesRoleSettings"); err != nil {
+		return res, err
+	}
+
+	// Only login roles are considered
+	r, ok := f.Roles[role]
+	if !ok || !r.Login {
+		return res, nil
+	}
+
+	for _, db := range sortedKeys(f.RoleSettings[role]) {
+		// Ignore settings that aren't linked to a database
+		if db == "" {
+			continue
+		}
+
+		res = append(res, &postgres.SetRoleOnDatabaseRoleSetting{Role: f.RoleSettings[role][db], Database: db})
+	}
+
+	return res, nil
+}
+
+func (f *FakePG) DoesRoleHaveActiveSession(_ context.Context, role string) (bool, error) {
+	defer f.mutex.Unlock()
+
+	if err := f.start("DoesRoleHaveActiveSession"); err != nil {
+		return false, err
+	}
+
+	return f.ActiveSessions[role], nil
+}
+
+// reassignOwned will change owner of objects owned by role. Database filter is ignored if empty.
+func (f *FakePG) reassignOwned(role, newOwner, database string) {
+	for dbName, d := range f.Databases {
+		// Databases are shared objects
+		if d.Owner == role {
+			d.Owner = newOwner
+		}
+
+		// Check database filter
+		if database != "" && dbName != database {
+			continue
+		}
+
+		for k, v := range d.Schemas {
+			if v == role {
+				d.Schemas[k] = newOwner
+			}
+		}
+
+		for _, tables := range d.Tables {
+			for k, v := range tables {
+				if v == role {
+					tables[k] = newOwner
+				}
+			}
+		}
+
+		for _, types := range d.Types {
+			for k, v := range types {
+				if v == role {
+					types[k] = newOwner
+				}
+			}
+		}
+	}
+}
+
+func (f *FakePG) ChangeAndDropOwnedBy(_ context.Context, role, newOwner, database string) error {
+	defer f.mutex.Unlock()
+
+	if planned, err := f.startMutation("ChangeAndDropOwnedBy", role, newOwner, database); planned || err != nil {
+		return err
+	}
+
+	return f.changeAndDropOwnedBy(role, newOwner, database)
+}
+
+func (f *FakePG) changeAndDropOwnedBy(role, newOwner, database string) error {
+	d, err := f.getDatabase(database)
+	if err != nil {
+		return err
+	}
+
+	// Role not found errors are ignored
+	if _, ok := f.Roles[role]; !ok {
+		return nil
+	}
+
+	if _, err = f.getRole(newOwner); err != nil {
+		return err
+	}
+
+	// Reassign
+	f.reassignOwned(role, newOwner, database)
+
+	// Drop privileges
+	for _, privs := range d.SchemaPrivileges {
+		delete(privs, role)
+	}
+
+	delete(d.PublicationSelectGrants, role)
+
+	return nil
+}
+
+func (f *FakePG) DropRole(_ context.Context, role string) error {
+	defer f.mutex.Unlock()
+
+	if planned, err := f.startMutation("DropRole", role); planned || err != nil {
+		return err
+	}
+
+	return f.dropRole(role)
+}
+
+func (f *FakePG) dropRole(role string) error {
+	// Role not found errors are ignored
+	if _, ok := f.Roles[role]; !ok {
+		return nil
+	}
+
+	// Check that role doesn't own a database
+	for name, d := range f.Databases {
+		if d.Owner == role {
+			return NewFakePqError(
+				DependentObjectsErrorCode,
+				fmt.Sprintf("role \"%s\" cannot be dropped because some objects depend on it: owner of database %s", role, name),
+			)
+		}
+	}
+
+	delete(f.Roles, role)
+	delete(f.Memberships, role)
+	delete(f.RoleSettings, role)
+	delete(f.ActiveSessions, role)
+
+	for _, groups := range f.Memberships {
+		delete(groups, role)
+	}
+
+	return nil
+}
+
+func (f *FakePG) DropRoleAndDropAndChangeOwnedBy(_ context.Context, role, newOwner, database string) error {
+	defer f.mutex.Unlock()
+
+	if planned, err := f.startMutation("DropRoleAndDropAndChangeOwnedBy", role, newOwner, database); planned || err != nil {
+		return err
+	}
+
+	err := f.changeAndDropOwnedBy(role, newOwner, database)
+	if err != nil {
+		return err
+	}
+
+	return f.dropRole(role)
+}
+
+func (f *FakePG) CreatePublication(_ context.Context, dbname string, builder *postgres.CreatePublicationBuilder) error {
+	defer f.mutex.Unlock()
+
+	if planned, err := f.startMutation("CreatePublication", dbname, builder.GetName()); planned || err != nil {
+		return err
+	}
+
+	d, err := f.getDatabase(dbname)
+	if err != nil {
+		return err
+	}
+
+	if _, ok := d.Publications[builder.GetName()]; ok {
+		return NewFakePqError(DuplicateObjectErrorCode, fmt.Sprintf("publication \"%s\" already exists", builder.GetName()))
+	}
+
+	pub := &FakePublication{
+		AllTables:      builder.IsForAllTables(),
+		Tables:         builder.GetTables(),
+		TablesInSchema: builder.GetTablesInSchema(),
+		Publish:        builder.GetPublish(),
+	}
+
+	if builder.GetPublishViaPartitionRoot() != nil {
+		pub.PublishViaPartitionRoot = *builder.GetPublishViaPartitionRoot()
+	}
+
+	d.Publications[builder.GetName()] = pub
+
+	return nil
+}
+
+func (f *FakePG) SetPublicationTablesSelectPrivilege(_ context.Context, dbname, role, publication string) error {
+	defer f.mutex.Unlock()
+
+	if planned, err := f.startMutation("SetPublicationTablesSelectPrivilege", dbname, role, publication); planned || err != nil {
+		return err
+	}
+
+	d, err := f.getDatabase(dbname)
+	if err != nil {
+		return err
+	}
+
+	if _, err = f.getRole(role); err != nil {
+		return err
+	}
+
+	if _, ok := d.Publications[publication]; !ok {
+		return NewFakePqError(UndefinedObjectErrorCode, fmt.Sprintf("publication \"%s\" does not exist", publication))
+	}
+
+	d.PublicationSelectGrants[role] = publication
+
+	return nil
+}
+
+func (f *FakePG) UpdatePublication(_ context.Context, dbname, publicationName string, builder *postgres.UpdatePublicationBuilder) error {
+	defer f.mutex.Unlock()
+
+	if planned, err := f.startMutation("UpdatePublication", dbname, publicationName); planned || err != nil {
+		return err
+	}
+
+	d, err := f.getDatabase(dbname)
+	if err != nil {
+		return err
+	}
+
+	pub, ok := d.Publications[publicationName]
+	if !ok {
+		return NewFakePqError(UndefinedObjectErrorCode, fmt.Sprintf("publication \"%s\" does not exist", publicationName))
+	}
+
+	// Check rename
+	if builder.GetNewName() != "" {
+		if _, ok := d.Publications[builder.GetNewName()]; ok {
+			return NewFakePqError(DuplicateObjectErrorCode, fmt.Sprintf("publication \"%s\" already exists", builder.GetNewName()))
+		}
+	}
+
+	// Manage with options
+	if builder.GetPublish() != "" {
+		pub.Publish = builder.GetPublish()
+	}
+
+	if builder.GetPublishViaPartitionRoot() != nil {
+		pub.PublishViaPartitionRoot = *builder.GetPublishViaPartitionRoot()
+	}
+
+	// Manage tables
+	if len(builder.GetTables()) != 0 || len(builder.GetTablesInSchema()) != 0 {
+		pub.Tables = builder.GetTables()
+		pub.TablesInSchema = builder.GetTablesInSchema()
+	}
+
+	// Manage rename
+	if builder.GetNewName() != "" {
+		delete(d.Publications, publicationName)
+		d.Publications[builder.GetNewName()] = pub
+	}
+
+	return nil
+}
+
+func (f *FakePG) GetPublication(_ context.Context, dbname, name string) (*postgres.PublicationResult, error) {
+	defer f.mutex.Unlock()
+
+	if err := f.start("GetPublication"); err != nil {
+		return nil, err
+	}
+
+	d, err := f.getDatabase(dbname)
+	if err != nil {
+		// Check if database creation have only been planned
+		if f.isPlannedMissingDatabaseError(err) {
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	pub, ok := d.Publications[name]
+	if !ok {
+		return nil, nil
+	}
+
+	res := &postgres.PublicationResult{
+		AllTables:          pub.AllTables,
+		PublicationViaRoot: pub.PublishViaPartitionRoot,
+	}
+
+	// Default publish is everything
+	if pub.Publish == "" {
+		res.Insert = true
+		res.Update = true
+		res.Delete = true
+		res.Truncate = true
+	} else {
+		for _, it := range strings.Split(pub.Publish, ",") {
+			switch strings.ToLower(strings.TrimSpace(it)) {
+			case "insert":
+				res.Insert = true
+			case "update":
+				res.Update = true
+			case "delete":
+				res.Delete = true
+			case "truncate":
+				res.Truncate = true
+			}
+		}
+	}
+
+	return res, nil
+}
+
+func (f *FakePG) DropPublication(_ context.Context, dbname, name string) error {
+	defer f.mutex.Unlock()
+
+	if planned, err := f.startMutation("DropPublication", dbname, name); planned || err != nil {
+		return err
+	}
+
+	d, ok := f.Databases[dbname]
+	// Database not found errors are ignored
+	if !ok {
+		return nil
+	}
+
+	if _, ok := d.Publications[name]; !ok {
+		return NewFakePqError(UndefinedObjectErrorCode, fmt.Sprintf("publication \"%s\" does not exist", name))
+	}
+
+	delete(d.Publications, name)
+
+	return nil
+}
+
+func (f *FakePG) RenamePublication(_ context.Context, dbname, oldname, newname string) error {
+	defer f.mutex.Unlock()
+
+	if planned, err := f.startMutation("RenamePublication", dbname, oldname, newname); planned || err != nil {
+		return err
+	}
+
+	d, err := f.getDatabase(dbname)
+	if err != nil {
+		return err
+	}
+
+	pub, ok := d.Publications[oldname]
+	if !ok {
+		return NewFakePqError(UndefinedObjectErrorCode, fmt.Sprintf("publication \"%s\" does not exist", oldname))
+	}
+
+	if _, ok := d.Publications[newname]; ok {
+		return NewFakePqError(DuplicateObjectErrorCode, fmt.Sprintf("publication \"%s\" already exists", newname))
+	}
+
+	delete(d.Publications, oldname)
+	d.Publications[newname] = pub
+
+	return nil
+}
+
+func (f *FakePG) CreateReplicationSlot(_ context.Context, dbname, name, plugin string) error {
+	defer f.mutex.Unlock()
+
+	if planned, err := f.startMutation("CreateReplicationSlot", dbname, name, plugin); planned || err != nil {
+		return err
+	}
+
+	if _, err := f.getDatabase(dbname); err != nil {
+		return err
+	}
+
+	if _, ok := f.ReplicationSlots[name]; ok {
+		return NewFakePqError(DuplicateObjectErrorCode, fmt.Sprintf("replication slot \"%s\" already exists", name))
+	}
+
+	f.ReplicationSlots[name] = &postgres.ReplicationSlotResult{SlotName: name, Plugin: plugin, Database: dbname}
+
+	return nil
+}
+
+func (f *FakePG) GetReplicationSlot(_ context.Context, name string) (*postgres.ReplicationSlotResult, error) {
+	defer f.mutex.Unlock()
+
+	if err := f.start("GetReplicationSlot"); err != nil {
+		return nil, err
+	}
+
+	slot, ok := f.ReplicationSlots[name]
+	if !ok {
+		return nil, nil
+	}
+
+	// Copy to avoid side effects
+	res := *slot
+
+	return &res, nil
+}
+
+func (f *FakePG) DropReplicationSlot(_ context.Context, name string) error {
+	defer f.mutex.Unlock()
+
+	if planned, err := f.startMutation("DropReplicationSlot", name); planned || err != nil {
+		return err
+	}
+
+	if _, ok := f.ReplicationSlots[name]; !ok {
+		return NewFakePqError(UndefinedObjectErrorCode, fmt.Sprintf("replication slot \"%s\" does not exist", name))
+	}
+
+	delete(f.ReplicationSlots, name)
+
+	return nil
+}
+
+func (f *FakePG) CreateSubscription(_ context.Context, dbname string, builder *postgres.CreateSubscriptionBuilder) error {
+	defer f.mutex.Unlock()
+
+	if planned, err := f.startMutation("CreateSubscription", dbname, builder.GetName()); planned || err != nil {
+		return err
+	}
+
+	d, err := f.getDatabase(dbname)
+	if err != nil {
+		return err
+	}
+
+	if _, ok := d.Subscriptions[builder.GetName()]; ok {
+		return NewFakePqError(DuplicateObjectErrorCode, fmt.Sprintf("subscription \"%s\" already exists", builder.GetName()))
+	}
+
+	sub := &FakeSubscription{
+		ConnectionInfo: builder.GetConnectionInfo(),
+		Publication:    builder.GetPublication(),
+		SlotName:       builder.GetReplicationSlot(),
+		Enabled:        true,
+		CopyData:       true,
+	}
+
+	if builder.GetEnabled() != nil {
+		sub.Enabled = *builder.GetEnabled()
+	}
+
+	if builder.GetCopyData() != nil {
+		sub.CopyData = *builder.GetCopyData()
+	}
+
+	d.Subscriptions[builder.GetName()] = sub
+
+	return nil
+}
+
+func (f *FakePG) AlterSubscription(_ context.Context, dbname, subscriptionName string, builder *postgres.UpdateSubscriptionBuilder) error {
+	defer f.mutex.Unlock()
+
+	if planned, err := f.startMutation("AlterSubscription", dbname, subscriptionName); planned || err != nil {
+		return err
+	}
+
+	d, err := f.getDatabase(dbname)
+	if err != nil {
+		return err
+	}
+
+	sub, ok := d.Subscriptions[subscriptionName]
+	if !ok {
+		return NewFakePqError(UndefinedObjectErrorCode, fmt.Sprintf("subscription \"%s\" does not exist", subscriptionName))
+	}
+
+	// Build
+	builder.Build()
+
+	if builder.GetNewName() != "" {
+		if _, ok := d.Subscriptions[builder.GetNewName()]; ok {
+			return NewFakePqError(DuplicateObjectErrorCode, fmt.Sprintf("subscription \"%s\" already exists", builder.GetNewName()))
+		}
+	}
+
+	if builder.GetReplicationSlot() != "" {
+		sub.SlotName = builder.GetReplicationSlot()
+	}
+
+	if builder.GetConnectionInfo() != "" {
+		sub.ConnectionInfo = builder.GetConnectionInfo()
+	}
+
+	if builder.GetPublication() != "" {
+		sub.Publication = builder.GetPublication()
+	}
+
+	if builder.GetEnabledPart() != "" {
+		sub.Enabled = builder.GetEnabledPart() == postgres.EnableSubscriptionKeyword
+	}
+
+	if builder.GetNewName() != "" {
+		delete(d.Subscriptions, subscriptionName)
+		d.Subscriptions[builder.GetNewName()] = sub
+	}
+
+	return nil
+}
+
+func (f *FakePG) DropSubscription(_ context.Context, dbname, name string) error {
+	defer f.mutex.Unlock()
+
+	if planned, err := f.startMutation("DropSubscription", dbname, name); planned || err != nil {
+		return err
+	}
+
+	d, err := f.getDatabase(dbname)
+	if err != nil {
+		return err
+	}
+
+	if _, ok := d.Subscriptions[name]; !ok {
+		return NewFakePqError(UndefinedObjectErrorCode, fmt.Sprintf("subscription \"%s\" does not exist", name))
+	}
+
+	delete(d.Subscriptions, name)
+
+	return nil
+}
+
+func (f *FakePG) GetSubscription(_ context.Context, dbname, name string) (*postgres.SubscriptionResult, error) {
+	defer f.mutex.Unlock()
+
+	if err := f.start("GetSubscription"); err != nil {
+		return nil, err
+	}
+
+	d, err := f.getDatabase(dbname)
+	if err != nil {
+		// Check if database creation have only been planned
+		if f.isPlannedMissingDatabaseError(err) {
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	sub, ok := d.Subscriptions[name]
+	if !ok {
+		return nil, nil
+	}
+
+	return &postgres.SubscriptionResult{
+		Enabled:       sub.Enabled,
+		SlotName:      sub.SlotName,
+		WorkerRunning: sub.Enabled && sub.WorkerRunning,
+		ReceivedLSN:   sub.ReceivedLSN,
+	}, nil
+}
+
+func sortedKeys[T any](m map[string]T) []string {
+	res := make([]string, 0, len(m))
+
+	for k := range m {
+		res = append(res, k)
+	}
+
+	sort.Strings(res)
+
+	return res
+}
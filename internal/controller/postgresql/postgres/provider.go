@@ -0,0 +1,87 @@
+package postgres
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/easymile/postgresql-operator/api/postgresql/v1alpha1"
+)
+
+// ProviderCapabilities are the flags describing the behaviour of a managed service provider.
+type ProviderCapabilities struct {
+	// Engine user can create a database owned by another role directly
+	CreateDBWithOwner bool
+	// Engine user must be a member of roles to reassign or drop their objects
+	MembershipToReassign bool
+	// Separator used to add engine user suffix to created logins (like "@server" on Azure). Empty means no suffix.
+	UsernameSuffixSeparator string
+}
+
+// providerDecorator will wrap base pg to manage provider quirks.
+type providerDecorator func(postgres *pg) PG
+
+type providerRegistration struct {
+	capabilities *ProviderCapabilities
+	decorate     providerDecorator
+}
+
+// Provider registry.
+// Each provider registers a decorator over the base pg and its capabilities from its file init function.
+var providerRegistry = map[v1alpha1.ProviderType]*providerRegistration{}
+
+func init() {
+	// Base implementation used without any provider
+	RegisterProvider(v1alpha1.NoProvider, &ProviderCapabilities{CreateDBWithOwner: true}, func(postgres *pg) PG { return postgres })
+}
+
+// RegisterProvider will register a provider decorator and its capabilities.
+// It must be called from init functions and panics if provider is already registered.
+func RegisterProvider(name v1alpha1.ProviderType, capabilities *ProviderCapabilities, decorate providerDecorator) {
+	// Check if provider is already registered
+	if _, ok := providerRegistry[name]; ok {
+		panic(fmt.Sprintf("provider %q is already registered", name))
+	}
+
+	providerRegistry[name] = &providerRegistration{capabilities: capabilities, decorate: decorate}
+}
+
+// GetRegisteredProviders will return sorted registered provider names.
+func GetRegisteredProviders() []v1alpha1.ProviderType {
+	res := make([]v1alpha1.ProviderType, 0, len(providerRegistry))
+	// Loop over registry
+	for k := range providerRegistry {
+		res = append(res, k)
+	}
+
+	// Sort
+	sort.Slice(res, func(i, j int) bool { return res[i] < res[j] })
+
+	return res
+}
+
+// GetProviderCapabilities will return a copy of provider capabilities.
+// Nil is returned if provider isn't registered.
+func GetProviderCapabilities(name v1alpha1.ProviderType) *ProviderCapabilities {
+	// Get registration
+	reg, ok := providerRegistry[name]
+	// Check if it isn't found
+	if !ok {
+		return nil
+	}
+
+	res := *reg.capabilities
+
+	return &res
+}
+
+// getProviderRegistration will return provider registration or the default one if provider isn't registered.
+func getProviderRegistration(name v1alpha1.ProviderType) (*providerRegistration, bool) {
+	// Get registration
+	reg, ok := providerRegistry[name]
+	// Check if it isn't found
+	if !ok {
+		return providerRegistry[v1alpha1.NoProvider], false
+	}
+
+	return reg, true
+}
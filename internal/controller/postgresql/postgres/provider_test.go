@@ -0,0 +1,28 @@
+package postgres
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/easymile/postgresql-operator/api/postgresql/v1alpha1"
+)
+
+var _ = Describe("provider registry", func() {
+	It("should contain providers registered by provider files", func() {
+		Expect(GetRegisteredProviders()).To(Equal([]v1alpha1.ProviderType{
+			v1alpha1.NoProvider,
+			v1alpha1.AWSProvider,
+			v1alpha1.AzureProvider,
+			v1alpha1.GCPProvider,
+			v1alpha1.RestrictedAdminProvider,
+		}))
+		Expect(GetProviderCapabilities(v1alpha1.AzureProvider)).To(Equal(&ProviderCapabilities{CreateDBWithOwner: true, UsernameSuffixSeparator: "@"}))
+		Expect(GetProviderCapabilities("UNKNOWN")).To(BeNil())
+	})
+
+	It("should refuse to register a provider twice", func() {
+		Expect(func() {
+			RegisterProvider(v1alpha1.AWSProvider, &ProviderCapabilities{}, newAWSPG)
+		}).To(PanicWith(`provider "AWS" is already registered`))
+	})
+})
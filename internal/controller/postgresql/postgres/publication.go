@@ -6,20 +6,27 @@ import (
 	"fmt"
 
 	"github.com/lib/pq"
+	"github.com/samber/lo"
 )
 
 const (
-	CreatePublicationSQLTemplate                = `CREATE PUBLICATION "%s" %s %s`
-	DropPublicationSQLTemplate                  = `DROP PUBLICATION "%s"`
-	AlterPublicationRenameSQLTemplate           = `ALTER PUBLICATION "%s" RENAME TO "%s"`
-	AlterPublicationGeneralOperationSQLTemplate = `ALTER PUBLICATION "%s" SET %s`
+	CreatePublicationSQLTemplate                = `CREATE PUBLICATION %s %s %s`
+	DropPublicationSQLTemplate                  = `DROP PUBLICATION %s`
+	AlterPublicationRenameSQLTemplate           = `ALTER PUBLICATION %s RENAME TO %s`
+	AlterPublicationGeneralOperationSQLTemplate = `ALTER PUBLICATION %s SET %s`
 	GetPublicationSQLTemplate                   = `SELECT
   puballtables, pubinsert, pubupdate, pubdelete, pubtruncate, pubviaroot
 FROM pg_catalog.pg_publication
-WHERE pubname = '%s';`
-	GetReplicationSlotSQLTemplate    = `SELECT slot_name,plugin,database FROM pg_replication_slots WHERE slot_name = '%s'`
-	CreateReplicationSlotSQLTemplate = `SELECT pg_create_logical_replication_slot('%s', '%s')`
-	DropReplicationSlotSQLTemplate   = `SELECT pg_drop_replication_slot('%s')`
+WHERE pubname = $1;`
+	GetReplicationSlotSQLTemplate    = `SELECT slot_name,plugin,database FROM pg_replication_slots WHERE slot_name = $1`
+	CreateReplicationSlotSQLTemplate = `SELECT pg_create_logical_replication_slot($1, $2)`
+	DropReplicationSlotSQLTemplate   = `SELECT pg_drop_replication_slot($1)`
+	GetPublicationTablesSQLTemplate  = `SELECT schemaname, tablename FROM pg_catalog.pg_publication_tables WHERE pubname = $1`
+	GetRoleSelectTablesSQLTemplate   = `SELECT table_schema, table_name
+FROM information_schema.role_table_grants
+WHERE grantee = $1 AND privilege_type = 'SELECT'`
+	GrantSelectOnTableSQLTemplate  = `GRANT SELECT ON TABLE %s.%s TO %s`
+	RevokeSelectOnTableSQLTemplate = `REVOKE SELECT ON TABLE %s.%s FROM %s`
 )
 
 type PublicationResult struct {
@@ -50,7 +57,7 @@ func (c *pg) DropReplicationSlot(ctx context.Context, name string) error {
 		return err
 	}
 
-	_, err = c.db.ExecContext(ctx, fmt.Sprintf(DropReplicationSlotSQLTemplate, name))
+	_, err = c.exec(ctx, DropReplicationSlotSQLTemplate, name)
 	if err != nil {
 		return err
 	}
@@ -65,7 +72,7 @@ func (c *pg) CreateReplicationSlot(ctx context.Context, dbname, name, plugin str
 		return err
 	}
 
-	_, err = c.db.ExecContext(ctx, fmt.Sprintf(CreateReplicationSlotSQLTemplate, name, plugin))
+	_, err = c.exec(ctx, CreateReplicationSlotSQLTemplate, name, plugin)
 	if err != nil {
 		return err
 	}
@@ -81,7 +88,7 @@ func (c *pg) GetReplicationSlot(ctx context.Context, name string) (*ReplicationS
 	}
 
 	// Get rows
-	rows, err := c.db.QueryContext(ctx, fmt.Sprintf(GetReplicationSlotSQLTemplate, name))
+	rows, err := c.db.QueryContext(ctx, GetReplicationSlotSQLTemplate, name)
 	if err != nil {
 		return nil, err
 	}
@@ -129,7 +136,7 @@ func (c *pg) UpdatePublication(ctx context.Context, dbname, publicationName stri
 	// Build
 	builder.Build()
 
-	tx, err := c.db.Begin()
+	tx, err := c.begin(ctx)
 	if err != nil {
 		return err
 	}
@@ -144,7 +151,7 @@ func (c *pg) UpdatePublication(ctx context.Context, dbname, publicationName stri
 
 	// Manage with options
 	if builder.withPart != "" {
-		_, err = tx.ExecContext(ctx, fmt.Sprintf(AlterPublicationGeneralOperationSQLTemplate, publicationName, builder.withPart))
+		_, err = tx.ExecContext(ctx, fmt.Sprintf(AlterPublicationGeneralOperationSQLTemplate, pq.QuoteIdentifier(publicationName), builder.withPart))
 		if err != nil {
 			return err
 		}
@@ -152,7 +159,7 @@ func (c *pg) UpdatePublication(ctx context.Context, dbname, publicationName stri
 
 	// Manage tables
 	if builder.tablesPart != "" {
-		_, err = tx.ExecContext(ctx, fmt.Sprintf(AlterPublicationGeneralOperationSQLTemplate, publicationName, builder.tablesPart))
+		_, err = tx.ExecContext(ctx, fmt.Sprintf(AlterPublicationGeneralOperationSQLTemplate, pq.QuoteIdentifier(publicationName), builder.tablesPart))
 		if err != nil {
 			return err
 		}
@@ -162,7 +169,7 @@ func (c *pg) UpdatePublication(ctx context.Context, dbname, publicationName stri
 	// ? Note: this should be the last step
 	if builder.newName != "" {
 		// Rename have to be done
-		_, err = tx.ExecContext(ctx, fmt.Sprintf(AlterPublicationRenameSQLTemplate, publicationName, builder.newName))
+		_, err = tx.ExecContext(ctx, fmt.Sprintf(AlterPublicationRenameSQLTemplate, pq.QuoteIdentifier(publicationName), pq.QuoteIdentifier(builder.newName)))
 		if err != nil {
 			return err
 		}
@@ -188,7 +195,7 @@ func (c *pg) CreatePublication(ctx context.Context, dbname string, builder *Crea
 	// Build
 	builder.Build()
 
-	_, err = c.db.ExecContext(ctx, fmt.Sprintf(CreatePublicationSQLTemplate, builder.name, builder.tablesPart, builder.withPart))
+	_, err = c.exec(ctx, fmt.Sprintf(CreatePublicationSQLTemplate, pq.QuoteIdentifier(builder.name), builder.tablesPart, builder.withPart))
 	if err != nil {
 		return err
 	}
@@ -204,8 +211,13 @@ func (c *pg) GetPublication(ctx context.Context, dbname, name string) (*Publicat
 	}
 
 	// Get rows
-	rows, err := c.db.QueryContext(ctx, fmt.Sprintf(GetPublicationSQLTemplate, name))
+	rows, err := c.db.QueryContext(ctx, GetPublicationSQLTemplate, name)
 	if err != nil {
+		// Check if database creation have only been planned
+		if c.isPlannedMissingDatabaseError(err) {
+			return nil, nil
+		}
+
 		return nil, err
 	}
 
@@ -248,7 +260,7 @@ func (c *pg) DropPublication(ctx context.Context, dbname, name string) error {
 		return err
 	}
 
-	_, err = c.db.ExecContext(ctx, fmt.Sprintf(DropPublicationSQLTemplate, name))
+	_, err = c.exec(ctx, fmt.Sprintf(DropPublicationSQLTemplate, pq.QuoteIdentifier(name)))
 	// Error code 3D000 is returned if database doesn't exist
 	if err != nil {
 		// Try to cast error
@@ -267,10 +279,102 @@ func (c *pg) RenamePublication(ctx context.Context, dbname, oldname, newname str
 		return err
 	}
 
-	_, err = c.db.ExecContext(ctx, fmt.Sprintf(AlterPublicationRenameSQLTemplate, oldname, newname))
+	_, err = c.exec(ctx, fmt.Sprintf(AlterPublicationRenameSQLTemplate, pq.QuoteIdentifier(oldname), pq.QuoteIdentifier(newname)))
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (c *pg) SetPublicationTablesSelectPrivilege(ctx context.Context, dbname, role, publication string) error {
+	err := c.connect(dbname)
+	if err != nil {
+		return err
+	}
+
+	// Get published tables
+	published, err := c.getSchemaTables(ctx, GetPublicationTablesSQLTemplate, publication)
+	if err != nil {
+		return err
+	}
+
+	// Get tables on which role have already select privilege
+	granted, err := c.getSchemaTables(ctx, GetRoleSelectTablesSQLTemplate, role)
 	if err != nil {
 		return err
 	}
 
+	// Grant usage on schemas of published tables as select is useless without it
+	// ? Note: Grant is idempotent so this is done on all of them
+	for _, schema := range lo.Uniq(lo.Map(published, func(it [2]string, _ int) string { return it[0] })) {
+		_, err = c.exec(ctx, fmt.Sprintf(GrantUsageSchemaSQLTemplate, pq.QuoteIdentifier(schema), pq.QuoteIdentifier(role)))
+		// Check error
+		if err != nil {
+			return err
+		}
+	}
+
+	// Grant select on published tables
+	for _, it := range published {
+		_, err = c.exec(ctx, fmt.Sprintf(GrantSelectOnTableSQLTemplate, pq.QuoteIdentifier(it[0]), pq.QuoteIdentifier(it[1]), pq.QuoteIdentifier(role)))
+		// Check error
+		if err != nil {
+			return err
+		}
+	}
+
+	// Revoke select on tables that aren't published anymore
+	for _, it := range granted {
+		// Check if table is still published
+		if lo.Contains(published, it) {
+			continue
+		}
+
+		_, err = c.exec(ctx, fmt.Sprintf(RevokeSelectOnTableSQLTemplate, pq.QuoteIdentifier(it[0]), pq.QuoteIdentifier(it[1]), pq.QuoteIdentifier(role)))
+		// Check error
+		if err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
+
+// getSchemaTables will run query and return a list of schema and table name couples.
+func (c *pg) getSchemaTables(ctx context.Context, query string, args ...interface{}) ([][2]string, error) {
+	rows, err := c.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		// Check if database creation have only been planned
+		if c.isPlannedMissingDatabaseError(err) {
+			return [][2]string{}, nil
+		}
+
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	res := [][2]string{}
+
+	for rows.Next() {
+		var it [2]string
+		// Scan
+		err = rows.Scan(&it[0], &it[1])
+		// Check error
+		if err != nil {
+			return nil, err
+		}
+		// Save
+		res = append(res, it)
+	}
+
+	// Rows error
+	err = rows.Err()
+	// Check error
+	if err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
@@ -9,31 +9,33 @@ import (
 )
 
 const (
-	CreateGroupRoleSQLTemplate             = `CREATE ROLE "%s"`
-	CreateUserRoleSQLTemplate              = `CREATE ROLE "%s" WITH LOGIN PASSWORD '%s' %s`
-	GrantRoleSQLTemplate                   = `GRANT "%s" TO "%s"`
-	GrantRoleWithAdminOptionSQLTemplate    = `GRANT "%s" TO "%s" WITH ADMIN OPTION`
-	AlterUserSetRoleSQLTemplate            = `ALTER USER "%s" SET ROLE "%s"`
-	AlterUserSetRoleOnDatabaseSQLTemplate  = `ALTER ROLE "%s" IN DATABASE "%s" SET ROLE "%s"`
-	RevokeUserSetRoleOnDatabaseSQLTemplate = `ALTER ROLE "%s" IN DATABASE "%s" RESET role`
-	RevokeRoleSQLTemplate                  = `REVOKE "%s" FROM "%s"`
-	UpdatePasswordSQLTemplate              = `ALTER ROLE "%s" WITH PASSWORD '%s'` // #nosec
-	DropRoleSQLTemplate                    = `DROP ROLE "%s"`
-	DropOwnedBySQLTemplate                 = `DROP OWNED BY "%s"`
-	ReassignObjectsSQLTemplate             = `REASSIGN OWNED BY "%s" TO "%s"`
-	IsRoleExistSQLTemplate                 = `SELECT 1 FROM pg_roles WHERE rolname='%s'`
-	RenameRoleSQLTemplate                  = `ALTER ROLE "%s" RENAME TO "%s"`
-	AlterRoleWithOptionSQLTemplate         = `ALTER ROLE "%s" WITH %s`
+	CreateGroupRoleSQLTemplate             = `CREATE ROLE %s`
+	CreateUserRoleSQLTemplate              = `CREATE ROLE %s WITH LOGIN PASSWORD %s %s`
+	GrantRoleSQLTemplate                   = `GRANT %s TO %s`
+	GrantRoleWithAdminOptionSQLTemplate    = `GRANT %s TO %s WITH ADMIN OPTION`
+	AlterUserSetRoleSQLTemplate            = `ALTER USER %s SET ROLE %s`
+	AlterUserSetRoleOnDatabaseSQLTemplate  = `ALTER ROLE %s IN DATABASE %s SET ROLE %s`
+	RevokeUserSetRoleOnDatabaseSQLTemplate = `ALTER ROLE %s IN DATABASE %s RESET role`
+	RevokeRoleSQLTemplate                  = `REVOKE %s FROM %s`
+	UpdatePasswordSQLTemplate              = `ALTER ROLE %s WITH PASSWORD %s`           // #nosec
+	UpdateCurrentUserPasswordSQLTemplate   = `ALTER ROLE CURRENT_USER WITH PASSWORD %s` // #nosec
+	DropRoleSQLTemplate                    = `DROP ROLE %s`
+	DropOwnedBySQLTemplate                 = `DROP OWNED BY %s`
+	ReassignObjectsSQLTemplate             = `REASSIGN OWNED BY %s TO %s`
+	IsRoleExistSQLTemplate                 = `SELECT 1 FROM pg_roles WHERE rolname = $1`
+	RenameRoleSQLTemplate                  = `ALTER ROLE %s RENAME TO %s`
+	AlterRoleWithOptionSQLTemplate         = `ALTER ROLE %s WITH %s`
 	// Source: https://dba.stackexchange.com/questions/136858/postgresql-display-role-members
-	GetRoleMembershipSQLTemplate = `SELECT r1.rolname as "role" FROM pg_catalog.pg_roles r JOIN pg_catalog.pg_auth_members m ON (m.member = r.oid) JOIN pg_roles r1 ON (m.roleid=r1.oid) WHERE r.rolcanlogin AND r.rolname='%s'`
-	GetRoleAttributesSQLTemplate = `select rolconnlimit, rolreplication, rolbypassrls FROM pg_roles WHERE rolname = '%s'`
+	GetRoleMembershipSQLTemplate = `SELECT r1.rolname as "role" FROM pg_catalog.pg_roles r JOIN pg_catalog.pg_auth_members m ON (m.member = r.oid) JOIN pg_roles r1 ON (m.roleid=r1.oid) WHERE r.rolcanlogin AND r.rolname = $1`
+	GetRoleAttributesSQLTemplate = `select rolconnlimit, rolreplication, rolbypassrls FROM pg_roles WHERE rolname = $1`
 	// DO NOT TOUCH THIS
 	// Cannot filter on compute value so... cf line before.
-	GetRoleSettingsSQLTemplate           = `SELECT pg_catalog.split_part(pg_catalog.unnest(setconfig), '=', 1) as parameter_type, pg_catalog.split_part(pg_catalog.unnest(setconfig), '=', 2) as parameter_value, d.datname as database FROM pg_catalog.pg_roles r JOIN pg_catalog.pg_db_role_setting c ON (c.setrole = r.oid) JOIN pg_catalog.pg_database d ON (d.oid = c.setdatabase) WHERE r.rolcanlogin AND r.rolname='%s'` //nolint:lll//Because
-	DoesRoleHaveActiveSessionSQLTemplate = `SELECT 1 from pg_stat_activity WHERE usename = '%s' group by usename`
+	GetRoleSettingsSQLTemplate           = `SELECT pg_catalog.split_part(pg_catalog.unnest(setconfig), '=', 1) as parameter_type, pg_catalog.split_part(pg_catalog.unnest(setconfig), '=', 2) as parameter_value, d.datname as database FROM pg_catalog.pg_roles r JOIN pg_catalog.pg_db_role_setting c ON (c.setrole = r.oid) JOIN pg_catalog.pg_database d ON (d.oid = c.setdatabase) WHERE r.rolcanlogin AND r.rolname = $1` //nolint:lll//Because
+	DoesRoleHaveActiveSessionSQLTemplate = `SELECT 1 from pg_stat_activity WHERE usename = $1 group by usename`
 	DuplicateRoleErrorCode               = "42710"
 	RoleNotFoundErrorCode                = "42704"
 	InvalidGrantOperationErrorCode       = "0LP01"
+	InvalidPasswordErrorCode             = "28P01"
 )
 
 var (
@@ -95,7 +97,7 @@ func (c *pg) AlterRoleAttributes(ctx context.Context, role string, attributes *R
 		return err
 	}
 
-	_, err = c.db.ExecContext(ctx, fmt.Sprintf(AlterRoleWithOptionSQLTemplate, role, attributesSQLStr))
+	_, err = c.exec(ctx, fmt.Sprintf(AlterRoleWithOptionSQLTemplate, pq.QuoteIdentifier(role), attributesSQLStr))
 	if err != nil {
 		return err
 	}
@@ -115,7 +117,7 @@ func (c *pg) GetRoleAttributes(ctx context.Context, role string) (*RoleAttribute
 		return res, err
 	}
 
-	rows, err := c.db.QueryContext(ctx, fmt.Sprintf(GetRoleAttributesSQLTemplate, role))
+	rows, err := c.db.QueryContext(ctx, GetRoleAttributesSQLTemplate, role)
 	if err != nil {
 		return res, err
 	}
@@ -149,7 +151,7 @@ func (c *pg) GetRoleMembership(ctx context.Context, role string) ([]string, erro
 		return res, err
 	}
 
-	rows, err := c.db.QueryContext(ctx, fmt.Sprintf(GetRoleMembershipSQLTemplate, role))
+	rows, err := c.db.QueryContext(ctx, GetRoleMembershipSQLTemplate, role)
 	if err != nil {
 		return res, err
 	}
@@ -185,7 +187,7 @@ func (c *pg) CreateGroupRole(ctx context.Context, role string) error {
 		return err
 	}
 
-	_, err = c.db.ExecContext(ctx, fmt.Sprintf(CreateGroupRoleSQLTemplate, role))
+	_, err = c.exec(ctx, fmt.Sprintf(CreateGroupRoleSQLTemplate, pq.QuoteIdentifier(role)))
 	if err != nil {
 		// Try to cast error
 		pqErr, ok := err.(*pq.Error)
@@ -209,7 +211,17 @@ func (c *pg) CreateUserRole(ctx context.Context, role, password string, attribut
 	// Build attributes sql
 	attributesSQLStr := c.buildAttributesString(attributes)
 
-	_, err = c.db.ExecContext(ctx, fmt.Sprintf(CreateUserRoleSQLTemplate, role, password, attributesSQLStr))
+	// Encrypt password
+	encryptedPassword, err := c.encryptPassword(password)
+	if err != nil {
+		return "", err
+	}
+
+	_, err = c.execSensitive(
+		ctx,
+		fmt.Sprintf(CreateUserRoleSQLTemplate, pq.QuoteIdentifier(role), pq.QuoteLiteral(encryptedPassword), attributesSQLStr),
+		fmt.Sprintf(CreateUserRoleSQLTemplate, pq.QuoteIdentifier(role), pq.QuoteLiteral(RedactedValue), attributesSQLStr),
+	)
 	if err != nil {
 		return "", err
 	}
@@ -229,7 +241,7 @@ func (c *pg) GrantRole(ctx context.Context, role, grantee string, withAdminOptio
 		tpl = GrantRoleWithAdminOptionSQLTemplate
 	}
 
-	_, err = c.db.ExecContext(ctx, fmt.Sprintf(tpl, role, grantee))
+	_, err = c.exec(ctx, fmt.Sprintf(tpl, pq.QuoteIdentifier(role), pq.QuoteIdentifier(grantee)))
 	if err != nil {
 		return err
 	}
@@ -243,7 +255,7 @@ func (c *pg) AlterDefaultLoginRole(ctx context.Context, role, setRole string) er
 		return err
 	}
 
-	_, err = c.db.ExecContext(ctx, fmt.Sprintf(AlterUserSetRoleSQLTemplate, role, setRole))
+	_, err = c.exec(ctx, fmt.Sprintf(AlterUserSetRoleSQLTemplate, pq.QuoteIdentifier(role), pq.QuoteIdentifier(setRole)))
 	if err != nil {
 		return err
 	}
@@ -257,7 +269,7 @@ func (c *pg) AlterDefaultLoginRoleOnDatabase(ctx context.Context, role, setRole,
 		return err
 	}
 
-	_, err = c.db.ExecContext(ctx, fmt.Sprintf(AlterUserSetRoleOnDatabaseSQLTemplate, role, database, setRole))
+	_, err = c.exec(ctx, fmt.Sprintf(AlterUserSetRoleOnDatabaseSQLTemplate, pq.QuoteIdentifier(role), pq.QuoteIdentifier(database), pq.QuoteIdentifier(setRole)))
 	if err != nil {
 		return err
 	}
@@ -271,7 +283,7 @@ func (c *pg) RevokeUserSetRoleOnDatabase(ctx context.Context, role, database str
 		return err
 	}
 
-	_, err = c.db.ExecContext(ctx, fmt.Sprintf(RevokeUserSetRoleOnDatabaseSQLTemplate, role, database))
+	_, err = c.exec(ctx, fmt.Sprintf(RevokeUserSetRoleOnDatabaseSQLTemplate, pq.QuoteIdentifier(role), pq.QuoteIdentifier(database)))
 	if err != nil {
 		return err
 	}
@@ -288,7 +300,7 @@ func (c *pg) GetSetRoleOnDatabasesRoleSettings(ctx context.Context, role string)
 		return res, err
 	}
 
-	rows, err := c.db.QueryContext(ctx, fmt.Sprintf(GetRoleSettingsSQLTemplate, role))
+	rows, err := c.db.QueryContext(ctx, GetRoleSettingsSQLTemplate, role)
 	if err != nil {
 		return res, err
 	}
@@ -335,7 +347,7 @@ func (c *pg) RevokeRole(ctx context.Context, role, revoked string) error {
 		return err
 	}
 
-	_, err = c.db.ExecContext(ctx, fmt.Sprintf(RevokeRoleSQLTemplate, role, revoked))
+	_, err = c.exec(ctx, fmt.Sprintf(RevokeRoleSQLTemplate, pq.QuoteIdentifier(role), pq.QuoteIdentifier(revoked)))
 	// Check if error exists and if different from "ROLE NOT FOUND" => 42704
 	if err != nil {
 		// Try to cast error
@@ -355,7 +367,7 @@ func (c *pg) ChangeAndDropOwnedBy(ctx context.Context, role, newOwner, database
 		return err
 	}
 
-	_, err = c.db.ExecContext(ctx, fmt.Sprintf(ReassignObjectsSQLTemplate, role, newOwner))
+	_, err = c.exec(ctx, fmt.Sprintf(ReassignObjectsSQLTemplate, pq.QuoteIdentifier(role), pq.QuoteIdentifier(newOwner)))
 	// Check if error exists and if different from "ROLE NOT FOUND" => 42704
 	if err != nil {
 		// Try to cast error
@@ -366,7 +378,7 @@ func (c *pg) ChangeAndDropOwnedBy(ctx context.Context, role, newOwner, database
 	}
 
 	// We previously assigned all objects to the operator's role so DROP OWNED BY will drop privileges of role
-	_, err = c.db.ExecContext(ctx, fmt.Sprintf(DropOwnedBySQLTemplate, role))
+	_, err = c.exec(ctx, fmt.Sprintf(DropOwnedBySQLTemplate, pq.QuoteIdentifier(role)))
 	// Check if error exists and if different from "ROLE NOT FOUND" => 42704
 	if err != nil {
 		// Try to cast error
@@ -386,7 +398,7 @@ func (c *pg) DropRole(ctx context.Context, role string) error {
 		return err
 	}
 
-	_, err = c.db.ExecContext(ctx, fmt.Sprintf(DropRoleSQLTemplate, role))
+	_, err = c.exec(ctx, fmt.Sprintf(DropRoleSQLTemplate, pq.QuoteIdentifier(role)))
 	// Check if error exists and if different from "ROLE NOT FOUND" => 42704
 	if err != nil {
 		// Try to cast error
@@ -414,7 +426,43 @@ func (c *pg) UpdatePassword(ctx context.Context, role, password string) error {
 		return err
 	}
 
-	_, err = c.db.ExecContext(ctx, fmt.Sprintf(UpdatePasswordSQLTemplate, role, password))
+	// Encrypt password
+	encryptedPassword, err := c.encryptPassword(password)
+	if err != nil {
+		return err
+	}
+
+	_, err = c.execSensitive(
+		ctx,
+		fmt.Sprintf(UpdatePasswordSQLTemplate, pq.QuoteIdentifier(role), pq.QuoteLiteral(encryptedPassword)),
+		fmt.Sprintf(UpdatePasswordSQLTemplate, pq.QuoteIdentifier(role), pq.QuoteLiteral(RedactedValue)),
+	)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// UpdateCurrentUserPassword will change password of engine user.
+// CURRENT_USER is used because login name can be different from role name (like "user@server" on Azure).
+func (c *pg) UpdateCurrentUserPassword(ctx context.Context, password string) error {
+	err := c.connect(c.defaultDatabase)
+	if err != nil {
+		return err
+	}
+
+	// Encrypt password
+	encryptedPassword, err := c.encryptPassword(password)
+	if err != nil {
+		return err
+	}
+
+	_, err = c.execSensitive(
+		ctx,
+		fmt.Sprintf(UpdateCurrentUserPasswordSQLTemplate, pq.QuoteLiteral(encryptedPassword)),
+		fmt.Sprintf(UpdateCurrentUserPasswordSQLTemplate, pq.QuoteLiteral(RedactedValue)),
+	)
 	if err != nil {
 		return err
 	}
@@ -428,7 +476,7 @@ func (c *pg) IsRoleExist(ctx context.Context, role string) (bool, error) {
 		return false, err
 	}
 
-	res, err := c.db.ExecContext(ctx, fmt.Sprintf(IsRoleExistSQLTemplate, role))
+	res, err := c.db.ExecContext(ctx, IsRoleExistSQLTemplate, role)
 	if err != nil {
 		return false, err
 	}
@@ -447,7 +495,7 @@ func (c *pg) DoesRoleHaveActiveSession(ctx context.Context, role string) (bool,
 		return false, err
 	}
 
-	res, err := c.db.ExecContext(ctx, fmt.Sprintf(DoesRoleHaveActiveSessionSQLTemplate, role))
+	res, err := c.db.ExecContext(ctx, DoesRoleHaveActiveSessionSQLTemplate, role)
 	if err != nil {
 		return false, err
 	}
@@ -466,7 +514,7 @@ func (c *pg) RenameRole(ctx context.Context, oldname, newname string) error {
 		return err
 	}
 
-	_, err = c.db.ExecContext(ctx, fmt.Sprintf(RenameRoleSQLTemplate, oldname, newname))
+	_, err = c.exec(ctx, fmt.Sprintf(RenameRoleSQLTemplate, pq.QuoteIdentifier(oldname), pq.QuoteIdentifier(newname)))
 	if err != nil {
 		return err
 	}
@@ -0,0 +1,201 @@
+package postgres
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"strings"
+
+	"github.com/easymile/postgresql-operator/api/postgresql/v1alpha1"
+	"github.com/lib/pq"
+)
+
+const (
+	// Source: https://github.com/postgres/postgres/blob/master/src/include/common/scram-common.h
+	DefaultScramIterations = 4096
+	scramSaltLength        = 16
+	scramKeyLength         = sha256.Size
+
+	PasswordMethodScram = "scram-sha-256"
+	PasswordMethodMD5   = "md5"
+	PasswordMethodNone  = ""
+
+	GetRolePasswordSQLTemplate     = `SELECT rolpassword FROM pg_catalog.pg_authid WHERE rolname = $1`
+	InsufficientPrivilegeErrorCode = "42501"
+)
+
+// PasswordSettings are the password settings used for roles created or updated on an engine.
+type PasswordSettings struct {
+	// Password encryption done by operator
+	Encryption v1alpha1.PasswordEncryptionType
+	// Iteration count used for SCRAM-SHA-256 verifiers
+	ScramIterations int
+}
+
+// Operator wide SCRAM-SHA-256 iteration count.
+var defaultScramIterations = DefaultScramIterations
+
+// SetDefaultScramIterations will set operator wide iteration count used when an engine doesn't override it.
+// This must be called before any password update.
+func SetDefaultScramIterations(iterations int) {
+	defaultScramIterations = iterations
+}
+
+// GetDefaultScramIterations will return operator wide iteration count.
+func GetDefaultScramIterations() int {
+	return defaultScramIterations
+}
+
+// getPasswordSettings will return password settings of this engine or defaults if not set.
+func (c *pg) getPasswordSettings() *PasswordSettings {
+	res := &PasswordSettings{Encryption: v1alpha1.ScramPasswordEncryption, ScramIterations: defaultScramIterations}
+
+	// Check if settings are set
+	if c.passwordSettings != nil {
+		// Override encryption
+		if c.passwordSettings.Encryption != "" {
+			res.Encryption = c.passwordSettings.Encryption
+		}
+		// Override iterations
+		if c.passwordSettings.ScramIterations > 0 {
+			res.ScramIterations = c.passwordSettings.ScramIterations
+		}
+	}
+
+	return res
+}
+
+// encryptPassword will return the value to send to engine for this password depending on encryption settings.
+func (c *pg) encryptPassword(password string) (string, error) {
+	// Get settings
+	settings := c.getPasswordSettings()
+
+	// Check if plaintext is wanted
+	if settings.Encryption == v1alpha1.PlaintextPasswordEncryption {
+		return password, nil
+	}
+
+	return ScramSHA256Verifier(password, settings.ScramIterations)
+}
+
+// DoesRolePasswordMethodMatch will return true if password stored for role is encrypted with the wanted method.
+// When password method cannot be read (not enough privileges) or is decided by engine (plaintext), true is returned.
+func (c *pg) DoesRolePasswordMethodMatch(ctx context.Context, role string) (bool, error) {
+	// Check if engine decides the method
+	if c.getPasswordSettings().Encryption == v1alpha1.PlaintextPasswordEncryption {
+		return true, nil
+	}
+
+	err := c.connect(c.defaultDatabase)
+	if err != nil {
+		return false, err
+	}
+
+	var rolpassword sql.NullString
+
+	err = c.db.QueryRowContext(ctx, GetRolePasswordSQLTemplate, role).Scan(&rolpassword)
+	if err != nil {
+		// Try to cast error
+		pqErr, ok := err.(*pq.Error)
+		// Check if it is a permission error (managed engines don't allow to read pg_authid)
+		if ok && pqErr.Code == InsufficientPrivilegeErrorCode {
+			return true, nil
+		}
+		// Check if role doesn't exist
+		if err == sql.ErrNoRows {
+			return true, nil
+		}
+
+		return false, err
+	}
+
+	return GetPasswordMethod(rolpassword.String) == PasswordMethodScram, nil
+}
+
+// GetPasswordMethod will return the method used to store a password in pg_authid.
+func GetPasswordMethod(rolpassword string) string {
+	// Check if there isn't any password
+	if rolpassword == "" {
+		return PasswordMethodNone
+	}
+
+	// Check scram
+	if strings.HasPrefix(rolpassword, "SCRAM-SHA-256$") {
+		return PasswordMethodScram
+	}
+
+	// Otherwise, it is md5 (plaintext isn't stored anymore since PostgreSQL 10)
+	return PasswordMethodMD5
+}
+
+// ScramSHA256Verifier will compute a SCRAM-SHA-256 verifier with a random salt in the format stored by PostgreSQL.
+// Source: https://github.com/postgres/postgres/blob/master/src/common/scram-common.c
+//
+// Note: Password isn't normalized with SASLprep. PostgreSQL uses the raw password when normalization fails,
+// so this is the same for ASCII passwords like generated ones.
+func ScramSHA256Verifier(password string, iterations int) (string, error) {
+	// Generate salt
+	salt := make([]byte, scramSaltLength)
+
+	_, err := rand.Read(salt)
+	if err != nil {
+		return "", err
+	}
+
+	return scramSHA256VerifierWithSalt(password, salt, iterations), nil
+}
+
+func scramSHA256VerifierWithSalt(password string, salt []byte, iterations int) string {
+	// Compute salted password
+	saltedPassword := pbkdf2SHA256([]byte(password), salt, iterations)
+
+	// Compute keys
+	clientKey := hmacSHA256(saltedPassword, []byte("Client Key"))
+	storedKey := sha256.Sum256(clientKey)
+	serverKey := hmacSHA256(saltedPassword, []byte("Server Key"))
+
+	return fmt.Sprintf(
+		"SCRAM-SHA-256$%d:%s$%s:%s",
+		iterations,
+		base64.StdEncoding.EncodeToString(salt),
+		base64.StdEncoding.EncodeToString(storedKey[:]),
+		base64.StdEncoding.EncodeToString(serverKey),
+	)
+}
+
+func hmacSHA256(key, data []byte) []byte {
+	h := hmac.New(sha256.New, key)
+	// ? Note: hash write never returns an error
+	_, _ = h.Write(data)
+
+	return h.Sum(nil)
+}
+
+// pbkdf2SHA256 will compute PBKDF2 with HMAC-SHA-256 for one block as key length is the hash length.
+// Source: https://www.rfc-editor.org/rfc/rfc8018#section-5.2
+func pbkdf2SHA256(password, salt []byte, iterations int) []byte {
+	// First block index
+	blockIndex := make([]byte, 4) //nolint:gomnd // Block index is a 32 bits integer
+	binary.BigEndian.PutUint32(blockIndex, 1)
+
+	// U1
+	u := hmacSHA256(password, append(append([]byte{}, salt...), blockIndex...))
+	res := make([]byte, scramKeyLength)
+	copy(res, u)
+
+	// U2 to Uc
+	for i := 1; i < iterations; i++ {
+		u = hmacSHA256(password, u)
+		// Xor
+		for j := range res {
+			res[j] ^= u[j]
+		}
+	}
+
+	return res
+}
@@ -0,0 +1,97 @@
+package postgres
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+// RFC 7677 section 3 exchange.
+// Source: https://www.rfc-editor.org/rfc/rfc7677#section-3
+const (
+	rfc7677Password        = "pencil"
+	rfc7677Salt            = "W22ZaJ0SNY7soEsUEjb6gQ=="
+	rfc7677Iterations      = 4096
+	rfc7677AuthMessage     = "n=user,r=rOprNGfwEbeRWgbNEkqO," + "r=rOprNGfwEbeRWgbNEkqO%hvYDpWUa2RaTCAfuxFIlj)hNlF$k0,s=W22ZaJ0SNY7soEsUEjb6gQ==,i=4096," + "c=biws,r=rOprNGfwEbeRWgbNEkqO%hvYDpWUa2RaTCAfuxFIlj)hNlF$k0"
+	rfc7677ClientProof     = "dHzbZapWIk4jUhN+Ute9ytag9zjfMHgsqmmiz7AndVQ="
+	rfc7677ServerSignature = "6rriTRBi23WpRR/wtup+mMhUZUn/dB5nLTJRsjl95G4="
+)
+
+func mustDecodeBase64(s string) []byte {
+	res, err := base64.StdEncoding.DecodeString(s)
+	Expect(err).NotTo(HaveOccurred())
+
+	return res
+}
+
+func mustDecodeHex(s string) []byte {
+	res, err := hex.DecodeString(s)
+	Expect(err).NotTo(HaveOccurred())
+
+	return res
+}
+
+var _ = Describe("SCRAM-SHA-256", func() {
+	// Known answers from RFC 7914 section 11 and published PBKDF2-HMAC-SHA256 vectors
+	DescribeTable("pbkdf2SHA256",
+		func(password, salt string, iterations int, expected string) {
+			Expect(pbkdf2SHA256([]byte(password), []byte(salt), iterations)).To(Equal(mustDecodeHex(expected)))
+		},
+		Entry("one iteration", "password", "salt", 1, "120fb6cffcf8b32c43e7225256c4f837a86548c92ccc35480805987cb70be17b"),
+		Entry("two iterations", "password", "salt", 2, "ae4d0c95af6b46d32d0adff928f06dd02a303f8ef3c251dfd6e2d85a95474c43"),
+		Entry("default iterations", "password", "salt", DefaultScramIterations, "c5e478d59288c841aa530db6845c4c8d962893a001ce4e11a4963873aa98134a"),
+		Entry("RFC 7914 first block", "passwd", "salt", 1, "55ac046e56e3089fec1691c22544b605f94185216dde0465e68b9d57c20dacbc"),
+	)
+
+	// Known answers computed with an independent implementation (python hashlib) using PostgreSQL format
+	DescribeTable("scramSHA256VerifierWithSalt",
+		func(password string, salt []byte, iterations int, expected string) {
+			Expect(scramSHA256VerifierWithSalt(password, salt, iterations)).To(Equal(expected))
+		},
+		Entry("RFC 7677 password and salt", rfc7677Password, mustDecodeBase64(rfc7677Salt), rfc7677Iterations,
+			"SCRAM-SHA-256$4096:W22ZaJ0SNY7soEsUEjb6gQ==$WG5d8oPm3OtcPnkdi4Uo7BkeZkBFzpcXkuLmtbsT4qY=:wfPLwcE6nTWhTAmQ7tl2KeoiWGPlZqQxSrmfPwDl2dU="),
+		Entry("RFC 7677 password and salt with one iteration", rfc7677Password, mustDecodeBase64(rfc7677Salt), 1,
+			"SCRAM-SHA-256$1:W22ZaJ0SNY7soEsUEjb6gQ==$bzcn5wYzlcMpEXczzDM1iuyLhni5BVbqsm82vjMHWXI=:fg/vS0Y425LcbLGWSqdzrFlRn9451QblzgpwLQYoXCI="),
+		Entry("custom iterations", "k3yp4ssw0rd", []byte{0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15}, 10000,
+			"SCRAM-SHA-256$10000:AAECAwQFBgcICQoLDA0ODw==$l3+A4WBmUY3x4zVp2gljqiSkHmhnP6/EY11tdy0bp/Q=:vxBRptQwKnpPvjwQyjvNKnDASZ9n+62sMMNoKGYlU0Q="),
+	)
+
+	It("should produce a verifier accepting RFC 7677 exchange", func() {
+		verifier := scramSHA256VerifierWithSalt(rfc7677Password, mustDecodeBase64(rfc7677Salt), rfc7677Iterations)
+
+		// Parse SCRAM-SHA-256$<iterations>:<salt>$<StoredKey>:<ServerKey>
+		parts := strings.Split(verifier, "$")
+		Expect(parts).To(HaveLen(3))
+		keys := strings.Split(parts[2], ":")
+		Expect(keys).To(HaveLen(2))
+		storedKey := mustDecodeBase64(keys[0])
+		serverKey := mustDecodeBase64(keys[1])
+
+		// Server side check of client proof: H(ClientProof XOR HMAC(StoredKey, AuthMessage)) == StoredKey
+		clientKey := mustDecodeBase64(rfc7677ClientProof)
+		clientSignature := hmacSHA256(storedKey, []byte(rfc7677AuthMessage))
+		for i := range clientKey {
+			clientKey[i] ^= clientSignature[i]
+		}
+		computedStoredKey := sha256.Sum256(clientKey)
+		Expect(computedStoredKey[:]).To(Equal(storedKey))
+
+		// Server signature
+		Expect(hmacSHA256(serverKey, []byte(rfc7677AuthMessage))).To(Equal(mustDecodeBase64(rfc7677ServerSignature)))
+	})
+
+	It("should generate random salts", func() {
+		v1, err := ScramSHA256Verifier(rfc7677Password, DefaultScramIterations)
+		Expect(err).NotTo(HaveOccurred())
+		v2, err := ScramSHA256Verifier(rfc7677Password, DefaultScramIterations)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(v1).To(HavePrefix("SCRAM-SHA-256$4096:"))
+		Expect(GetPasswordMethod(v1)).To(Equal(PasswordMethodScram))
+		Expect(v1).NotTo(Equal(v2))
+	})
+})
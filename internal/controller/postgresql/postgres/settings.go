@@ -0,0 +1,182 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/lib/pq"
+)
+
+const (
+	AlterDatabaseSetSQLTemplate         = `ALTER DATABASE %s SET %s = %s`
+	AlterDatabaseResetSQLTemplate       = `ALTER DATABASE %s RESET %s`
+	AlterRoleInDatabaseSetSQLTemplate   = `ALTER ROLE %s IN DATABASE %s SET %s = %s`
+	AlterRoleInDatabaseResetSQLTemplate = `ALTER ROLE %s IN DATABASE %s RESET %s`
+	// Database settings have setrole = 0 which doesn't exist in pg_roles
+	GetDatabaseSettingsSQLTemplate = `SELECT pg_catalog.unnest(s.setconfig)
+FROM pg_catalog.pg_db_role_setting s
+JOIN pg_catalog.pg_database d ON d.oid = s.setdatabase
+LEFT JOIN pg_catalog.pg_roles r ON r.oid = s.setrole
+WHERE d.datname = $1 AND COALESCE(r.rolname, '') = $2`
+)
+
+// Setting names are lower case identifiers with an optional prefix for custom settings (like myapp.feature).
+var settingNameRegexp = regexp.MustCompile(`^[a-z_][a-z0-9_]*(\.[a-z_][a-z0-9_]*)*$`)
+
+// IsValidSettingName will return true if setting name can be used in SET statements.
+func IsValidSettingName(name string) bool {
+	return settingNameRegexp.MatchString(name)
+}
+
+// Settings with a list value. Each element must be quoted alone, otherwise value is a single element.
+var listSettings = map[string]bool{
+	"search_path":               true,
+	"temp_tablespaces":          true,
+	"session_preload_libraries": true,
+	"local_preload_libraries":   true,
+}
+
+// splitSettingList will return elements of a list setting value without spaces and double quotes around them.
+// ? Note: Engine adds double quotes around elements that need them (like "$user").
+func splitSettingList(value string) []string {
+	res := make([]string, 0)
+	// Loop over elements
+	for _, it := range strings.Split(value, ",") {
+		// Trim spaces and double quotes
+		it = strings.Trim(strings.TrimSpace(it), `"`)
+		// Ignore empty elements
+		if it != "" {
+			res = append(res, it)
+		}
+	}
+
+	return res
+}
+
+// formatSettingValue will return setting value quoted for SET statements.
+func formatSettingValue(name, value string) string {
+	// Check if it isn't a list setting
+	if !listSettings[name] {
+		return pq.QuoteLiteral(value)
+	}
+
+	// Quote each element
+	elements := splitSettingList(value)
+	for i, it := range elements {
+		elements[i] = pq.QuoteLiteral(it)
+	}
+
+	// Check if list is empty
+	if len(elements) == 0 {
+		return pq.QuoteLiteral("")
+	}
+
+	return strings.Join(elements, ", ")
+}
+
+// IsSameSettingValue will return true if setting values are the same for engine.
+// List settings are stored by engine with a normalized separator.
+func IsSameSettingValue(name, value1, value2 string) bool {
+	// Check if it isn't a list setting
+	if !listSettings[name] {
+		return value1 == value2
+	}
+
+	return strings.Join(splitSettingList(value1), ",") == strings.Join(splitSettingList(value2), ",")
+}
+
+// GetDatabaseSettings will return settings set on database with ALTER DATABASE SET when role is empty
+// or with ALTER ROLE IN DATABASE SET for role.
+func (c *pg) GetDatabaseSettings(ctx context.Context, dbname, role string) (map[string]string, error) {
+	// Prepare result
+	res := map[string]string{}
+
+	err := c.connect(c.defaultDatabase)
+	if err != nil {
+		return res, err
+	}
+
+	rows, err := c.db.QueryContext(ctx, GetDatabaseSettingsSQLTemplate, dbname, role)
+	if err != nil {
+		return res, err
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		setting := ""
+		// Scan
+		err = rows.Scan(&setting)
+		// Check error
+		if err != nil {
+			return res, err
+		}
+
+		// Split name and value
+		name, value, _ := strings.Cut(setting, "=")
+		// Save
+		res[name] = value
+	}
+
+	// Rows error
+	err = rows.Err()
+	// Check error
+	if err != nil {
+		return res, err
+	}
+
+	return res, nil
+}
+
+// AlterDatabaseSetting will set a setting on database when role is empty or for role in database.
+func (c *pg) AlterDatabaseSetting(ctx context.Context, dbname, role, name, value string) error {
+	err := c.connect(c.defaultDatabase)
+	if err != nil {
+		return err
+	}
+
+	// Build statement
+	// ? Note: Setting name is validated before and cannot be quoted as it can contain a dot
+	sqlStr := fmt.Sprintf(AlterDatabaseSetSQLTemplate, pq.QuoteIdentifier(dbname), name, formatSettingValue(name, value))
+	// Check if it is for a role
+	if role != "" {
+		sqlStr = fmt.Sprintf(
+			AlterRoleInDatabaseSetSQLTemplate,
+			pq.QuoteIdentifier(role),
+			pq.QuoteIdentifier(dbname),
+			name,
+			formatSettingValue(name, value),
+		)
+	}
+
+	_, err = c.exec(ctx, sqlStr)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// ResetDatabaseSetting will reset a setting on database when role is empty or for role in database.
+func (c *pg) ResetDatabaseSetting(ctx context.Context, dbname, role, name string) error {
+	err := c.connect(c.defaultDatabase)
+	if err != nil {
+		return err
+	}
+
+	// Build statement
+	sqlStr := fmt.Sprintf(AlterDatabaseResetSQLTemplate, pq.QuoteIdentifier(dbname), name)
+	// Check if it is for a role
+	if role != "" {
+		sqlStr = fmt.Sprintf(AlterRoleInDatabaseResetSQLTemplate, pq.QuoteIdentifier(role), pq.QuoteIdentifier(dbname), name)
+	}
+
+	_, err = c.exec(ctx, sqlStr)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
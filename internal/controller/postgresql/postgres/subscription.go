@@ -0,0 +1,231 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/lib/pq"
+)
+
+const (
+	CreateSubscriptionSQLTemplate                = `CREATE SUBSCRIPTION %s CONNECTION %s PUBLICATION %s WITH (%s)`
+	DropSubscriptionSQLTemplate                  = `DROP SUBSCRIPTION IF EXISTS %s`
+	AlterSubscriptionRenameSQLTemplate           = `ALTER SUBSCRIPTION %s RENAME TO %s`
+	AlterSubscriptionConnectionSQLTemplate       = `ALTER SUBSCRIPTION %s CONNECTION %s`
+	AlterSubscriptionSetPublicationSQLTemplate   = `ALTER SUBSCRIPTION %s SET PUBLICATION %s WITH (refresh = false)`
+	AlterSubscriptionGeneralOperationSQLTemplate = `ALTER SUBSCRIPTION %s %s`
+	GetSubscriptionSQLTemplate                   = `SELECT
+  s.subenabled, COALESCE(s.subslotname, ''), st.pid IS NOT NULL, COALESCE(st.received_lsn::text, '')
+FROM pg_catalog.pg_subscription s
+LEFT JOIN pg_catalog.pg_stat_subscription st ON (st.subid = s.oid AND st.relid IS NULL)
+WHERE s.subname = $1 AND s.subdbid = (SELECT oid FROM pg_catalog.pg_database WHERE datname = current_database());`
+	DisableSubscriptionKeyword    = "DISABLE"
+	EnableSubscriptionKeyword     = "ENABLE"
+	DetachSubscriptionSlotKeyword = "SET (slot_name = NONE)"
+)
+
+type SubscriptionResult struct {
+	Enabled       bool
+	SlotName      string
+	WorkerRunning bool
+	ReceivedLSN   string
+}
+
+// BuildSubscriptionConnectionInfo will build a libpq key/value connection string
+// from the provided values. URI args are expected in the URL query format (a=b&c=d).
+func BuildSubscriptionConnectionInfo(host, user, password, uriArgs, database string, port int) string {
+	res := []string{
+		"host=" + quoteConnectionInfoValue(host),
+		fmt.Sprintf("port=%d", port),
+		"user=" + quoteConnectionInfoValue(user),
+		"password=" + quoteConnectionInfoValue(password),
+		"dbname=" + quoteConnectionInfoValue(database),
+	}
+
+	// Loop over uri args
+	for _, it := range strings.Split(uriArgs, "&") {
+		// Ignore empty items
+		if it == "" {
+			continue
+		}
+
+		// Split key and value
+		k, v, _ := strings.Cut(it, "=")
+		// Save
+		res = append(res, k+"="+quoteConnectionInfoValue(v))
+	}
+
+	return strings.Join(res, " ")
+}
+
+func quoteConnectionInfoValue(v string) string {
+	// See here: https://www.postgresql.org/docs/current/libpq-connect.html#LIBPQ-CONNSTRING-KEYWORD-VALUE
+	v = strings.ReplaceAll(v, `\`, `\\`)
+	v = strings.ReplaceAll(v, `'`, `\'`)
+
+	return "'" + v + "'"
+}
+
+func (c *pg) CreateSubscription(ctx context.Context, dbname string, builder *CreateSubscriptionBuilder) error {
+	// Connect to db
+	err := c.connect(dbname)
+	if err != nil {
+		return err
+	}
+
+	// Build
+	builder.Build()
+
+	// ? Note: CREATE SUBSCRIPTION cannot be executed inside a transaction block
+	// ? Note: Connection info contains the password
+	_, err = c.execSensitive(
+		ctx,
+		fmt.Sprintf(CreateSubscriptionSQLTemplate, pq.QuoteIdentifier(builder.name), pq.QuoteLiteral(builder.connInfo), pq.QuoteIdentifier(builder.publication), builder.withPart),
+		fmt.Sprintf(CreateSubscriptionSQLTemplate, pq.QuoteIdentifier(builder.name), pq.QuoteLiteral(RedactedValue), pq.QuoteIdentifier(builder.publication), builder.withPart),
+	)
+	if err != nil {
+		return err
+	}
+
+	// Default
+	return nil
+}
+
+func (c *pg) AlterSubscription(ctx context.Context, dbname, subscriptionName string, builder *UpdateSubscriptionBuilder) error {
+	// Connect to db
+	err := c.connect(dbname)
+	if err != nil {
+		return err
+	}
+
+	// Build
+	builder.Build()
+
+	// Loop over statements
+	// ? Note: Some ALTER SUBSCRIPTION cannot be executed inside a transaction block, so no transaction here
+	for _, st := range builder.statements {
+		_, err = c.exec(ctx, fmt.Sprintf(AlterSubscriptionGeneralOperationSQLTemplate, pq.QuoteIdentifier(subscriptionName), st))
+		if err != nil {
+			return err
+		}
+	}
+
+	// Check connection
+	if builder.connInfo != "" {
+		// ? Note: Connection info contains the password
+		_, err = c.execSensitive(
+			ctx,
+			fmt.Sprintf(AlterSubscriptionConnectionSQLTemplate, pq.QuoteIdentifier(subscriptionName), pq.QuoteLiteral(builder.connInfo)),
+			fmt.Sprintf(AlterSubscriptionConnectionSQLTemplate, pq.QuoteIdentifier(subscriptionName), pq.QuoteLiteral(RedactedValue)),
+		)
+		if err != nil {
+			return err
+		}
+	}
+
+	// Check publication
+	if builder.publication != "" {
+		_, err = c.exec(ctx, fmt.Sprintf(AlterSubscriptionSetPublicationSQLTemplate, pq.QuoteIdentifier(subscriptionName), pq.QuoteIdentifier(builder.publication)))
+		if err != nil {
+			return err
+		}
+	}
+
+	// Check final enable state
+	if builder.enabledPart != "" {
+		_, err = c.exec(ctx, fmt.Sprintf(AlterSubscriptionGeneralOperationSQLTemplate, pq.QuoteIdentifier(subscriptionName), builder.enabledPart))
+		if err != nil {
+			return err
+		}
+	}
+
+	// Check rename
+	// ? Note: this should be the last step
+	if builder.newName != "" {
+		_, err = c.exec(ctx, fmt.Sprintf(AlterSubscriptionRenameSQLTemplate, pq.QuoteIdentifier(subscriptionName), pq.QuoteIdentifier(builder.newName)))
+		if err != nil {
+			return err
+		}
+	}
+
+	// Default
+	return nil
+}
+
+func (c *pg) DropSubscription(ctx context.Context, dbname, name string) error {
+	err := c.connect(dbname)
+	if err != nil {
+		return err
+	}
+
+	// Replication slot is owned by the publication side and mustn't be dropped with subscription
+	// So subscription must be disabled and detached from the slot before drop.
+	_, err = c.exec(ctx, fmt.Sprintf(AlterSubscriptionGeneralOperationSQLTemplate, pq.QuoteIdentifier(name), DisableSubscriptionKeyword))
+	if err != nil {
+		return err
+	}
+
+	_, err = c.exec(ctx, fmt.Sprintf(AlterSubscriptionGeneralOperationSQLTemplate, pq.QuoteIdentifier(name), DetachSubscriptionSlotKeyword))
+	if err != nil {
+		return err
+	}
+
+	_, err = c.exec(ctx, fmt.Sprintf(DropSubscriptionSQLTemplate, pq.QuoteIdentifier(name)))
+	if err != nil {
+		return err
+	}
+
+	// Default
+	return nil
+}
+
+func (c *pg) GetSubscription(ctx context.Context, dbname, name string) (*SubscriptionResult, error) {
+	err := c.connect(dbname)
+	if err != nil {
+		return nil, err
+	}
+
+	// Get rows
+	rows, err := c.db.QueryContext(ctx, GetSubscriptionSQLTemplate, name)
+	if err != nil {
+		// Check if database creation have only been planned
+		if c.isPlannedMissingDatabaseError(err) {
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	var res SubscriptionResult
+
+	var foundOne bool
+
+	for rows.Next() {
+		// Scan
+		err = rows.Scan(&res.Enabled, &res.SlotName, &res.WorkerRunning, &res.ReceivedLSN)
+		// Check error
+		if err != nil {
+			return nil, err
+		}
+
+		// Update marker
+		foundOne = true
+	}
+
+	// Rows error
+	err = rows.Err()
+	// Check error
+	if err != nil {
+		return nil, err
+	}
+
+	// Check if found marker isn't set
+	if !foundOne {
+		return nil, nil
+	}
+
+	return &res, nil
+}
@@ -0,0 +1,14 @@
+package postgres
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestPostgres(t *testing.T) {
+	RegisterFailHandler(Fail)
+
+	RunSpecs(t, "Postgres Suite")
+}
@@ -0,0 +1,179 @@
+package postgres
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	DefaultTLSSSLMode = "verify-full"
+
+	tlsCertificateFileName = "tls.crt"
+	tlsKeyFileName         = "tls.key"
+	tlsCAFileName          = "ca.crt"
+	tlsDirectoryMode       = 0o700
+	tlsFileMode            = 0o600
+)
+
+// TLSSettings are the client certificate settings used for engine connections.
+type TLSSettings struct {
+	// SSL mode used for connections
+	SSLMode string
+	// PEM encoded client certificate
+	Certificate []byte
+	// PEM encoded client key
+	Key []byte
+	// PEM encoded CA bundle
+	CA []byte
+}
+
+// Operator wide directory used to materialize TLS files.
+var tlsDirectory = filepath.Join(os.TempDir(), "postgresql-operator-tls")
+
+// SetTLSDirectory will set operator wide directory used to materialize TLS files for lib/pq.
+// This must be called before any pool creation.
+func SetTLSDirectory(dir string) {
+	tlsDirectory = dir
+}
+
+// getTLSHash will return a hash of TLS data in order to detect changes. Empty string is returned without TLS.
+func (c *pg) getTLSHash() string {
+	// Check if TLS is disabled
+	if c.tls == nil {
+		return ""
+	}
+
+	h := sha256.New()
+	// ? Note: hash write never returns an error
+	_, _ = h.Write([]byte(c.tls.SSLMode))
+	_, _ = h.Write(c.tls.Certificate)
+	_, _ = h.Write(c.tls.Key)
+	_, _ = h.Write(c.tls.CA)
+
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// getConnectionArgs will return uri args used by operator connections.
+// When TLS is enabled, files are materialized and TLS args override the ones coming from engine configuration.
+func (c *pg) getConnectionArgs() (string, error) {
+	return c.getConnectionArgsFrom(c.args)
+}
+
+// getConnectionArgsFrom will return uri args used by operator connections based on provided args.
+// When TLS is enabled, files are materialized and TLS args override the provided ones.
+func (c *pg) getConnectionArgsFrom(args string) (string, error) {
+	// Check if TLS is disabled
+	if c.tls == nil {
+		return args, nil
+	}
+
+	// Parse args
+	values, err := url.ParseQuery(args)
+	// Check error
+	if err != nil {
+		return "", err
+	}
+
+	// Compute directory for this engine
+	dir := filepath.Join(tlsDirectory, strings.ReplaceAll(c.name, "/", "_"))
+	// Create directory
+	err = os.MkdirAll(dir, tlsDirectoryMode)
+	// Check error
+	if err != nil {
+		return "", err
+	}
+
+	// Write certificate
+	certPath, err := writeTLSFile(dir, tlsCertificateFileName, c.tls.Certificate)
+	// Check error
+	if err != nil {
+		return "", err
+	}
+	// Write key
+	keyPath, err := writeTLSFile(dir, tlsKeyFileName, c.tls.Key)
+	// Check error
+	if err != nil {
+		return "", err
+	}
+
+	// Compute ssl mode
+	sslMode := c.tls.SSLMode
+	if sslMode == "" {
+		sslMode = DefaultTLSSSLMode
+	}
+
+	values.Set("sslmode", sslMode)
+	values.Set("sslcert", certPath)
+	values.Set("sslkey", keyPath)
+
+	// Check if there is a CA bundle
+	if len(c.tls.CA) != 0 {
+		// Write CA
+		caPath, err := writeTLSFile(dir, tlsCAFileName, c.tls.CA)
+		// Check error
+		if err != nil {
+			return "", err
+		}
+
+		values.Set("sslrootcert", caPath)
+	}
+
+	return values.Encode(), nil
+}
+
+// writeTLSFile will write file atomically with permissions accepted by lib/pq for keys.
+func writeTLSFile(dir, name string, data []byte) (string, error) {
+	// Create temporary file
+	f, err := os.CreateTemp(dir, name+".*")
+	// Check error
+	if err != nil {
+		return "", err
+	}
+	// Save temporary path
+	tmpPath := f.Name()
+
+	// Write data
+	_, err = f.Write(data)
+	// Check error
+	if err != nil {
+		_ = f.Close()
+		_ = os.Remove(tmpPath)
+
+		return "", err
+	}
+
+	// Close file
+	err = f.Close()
+	// Check error
+	if err != nil {
+		_ = os.Remove(tmpPath)
+
+		return "", err
+	}
+
+	// Set permissions
+	err = os.Chmod(tmpPath, tlsFileMode)
+	// Check error
+	if err != nil {
+		_ = os.Remove(tmpPath)
+
+		return "", err
+	}
+
+	// Final path
+	res := filepath.Join(dir, name)
+	// Replace file
+	err = os.Rename(tmpPath, res)
+	// Check error
+	if err != nil {
+		_ = os.Remove(tmpPath)
+
+		return "", err
+	}
+
+	return res, nil
+}
@@ -3,14 +3,18 @@ package postgres
 import (
 	"fmt"
 	"strings"
+
+	"github.com/lib/pq"
 )
 
 type UpdatePublicationBuilder struct {
-	newName    string
-	withPart   string
-	tablesPart string
-	tables     []string
-	schemaList []string
+	newName                 string
+	withPart                string
+	publish                 string
+	tablesPart              string
+	tables                  []string
+	schemaList              []string
+	publishViaPartitionRoot *bool
 }
 
 func NewUpdatePublicationBuilder() *UpdatePublicationBuilder {
@@ -34,7 +38,7 @@ func (b *UpdatePublicationBuilder) Build() {
 			res += ", "
 		}
 
-		res += "TABLES IN SCHEMA " + strings.Join(b.schemaList, ", ")
+		res += "TABLES IN SCHEMA " + strings.Join(quoteIdentifiers(b.schemaList), ", ")
 	}
 
 	// Save
@@ -42,11 +46,11 @@ func (b *UpdatePublicationBuilder) Build() {
 }
 
 func (b *UpdatePublicationBuilder) AddSetTable(name string, columns *[]string, additionalWhere *string) *UpdatePublicationBuilder {
-	res := name
+	res := quoteQualifiedIdentifier(name)
 
 	// Manage columns
 	if columns != nil {
-		res += " (" + strings.Join(*columns, ", ") + ")"
+		res += " (" + strings.Join(quoteIdentifiers(*columns), ", ") + ")"
 	}
 
 	// Add where is set
@@ -73,10 +77,14 @@ func (b *UpdatePublicationBuilder) RenameTo(newName string) *UpdatePublicationBu
 }
 
 func (b *UpdatePublicationBuilder) SetWith(publish string, publishViaPartitionRoot *bool) *UpdatePublicationBuilder {
+	// Save raw values
+	b.publish = publish
+	b.publishViaPartitionRoot = publishViaPartitionRoot
+
 	var with string
 	// Check if publish is set
 	if publish != "" {
-		with += "publish = '" + publish + "'"
+		with += "publish = " + pq.QuoteLiteral(publish)
 	}
 	// Check publish via partition root
 	if publishViaPartitionRoot != nil {
@@ -98,3 +106,28 @@ func (b *UpdatePublicationBuilder) SetWith(publish string, publishViaPartitionRo
 
 	return b
 }
+
+// GetNewName will return new publication name.
+func (b *UpdatePublicationBuilder) GetNewName() string {
+	return b.newName
+}
+
+// GetTables will return quoted tables with their columns and where clauses.
+func (b *UpdatePublicationBuilder) GetTables() []string {
+	return b.tables
+}
+
+// GetTablesInSchema will return schema list of tables in schema.
+func (b *UpdatePublicationBuilder) GetTablesInSchema() []string {
+	return b.schemaList
+}
+
+// GetPublish will return publish option.
+func (b *UpdatePublicationBuilder) GetPublish() string {
+	return b.publish
+}
+
+// GetPublishViaPartitionRoot will return publish via partition root option.
+func (b *UpdatePublicationBuilder) GetPublishViaPartitionRoot() *bool {
+	return b.publishViaPartitionRoot
+}
@@ -0,0 +1,98 @@
+package postgres
+
+import "github.com/lib/pq"
+
+type UpdateSubscriptionBuilder struct {
+	newName     string
+	connInfo    string
+	publication string
+	slotName    string
+	enabled     *bool
+	enabledPart string
+	statements  []string
+}
+
+func NewUpdateSubscriptionBuilder() *UpdateSubscriptionBuilder {
+	return &UpdateSubscriptionBuilder{}
+}
+
+func (b *UpdateSubscriptionBuilder) Build() {
+	// Reset
+	b.statements = []string{}
+	b.enabledPart = ""
+
+	// Check if replication slot must be changed
+	// ? Note: Subscription must be disabled to change replication slot
+	if b.slotName != "" {
+		b.statements = append(b.statements, DisableSubscriptionKeyword, "SET (slot_name = "+pq.QuoteLiteral(b.slotName)+")")
+
+		// Check if subscription must be re-enabled
+		if b.enabled == nil || *b.enabled {
+			b.enabledPart = EnableSubscriptionKeyword
+		}
+	}
+
+	// Check enabled
+	if b.enabled != nil {
+		if *b.enabled {
+			b.enabledPart = EnableSubscriptionKeyword
+		} else {
+			b.enabledPart = DisableSubscriptionKeyword
+		}
+	}
+}
+
+func (b *UpdateSubscriptionBuilder) RenameTo(newName string) *UpdateSubscriptionBuilder {
+	b.newName = newName
+
+	return b
+}
+
+func (b *UpdateSubscriptionBuilder) SetConnectionInfo(connInfo string) *UpdateSubscriptionBuilder {
+	b.connInfo = connInfo
+
+	return b
+}
+
+func (b *UpdateSubscriptionBuilder) SetPublication(publication string) *UpdateSubscriptionBuilder {
+	b.publication = publication
+
+	return b
+}
+
+func (b *UpdateSubscriptionBuilder) SetReplicationSlot(slotName string) *UpdateSubscriptionBuilder {
+	b.slotName = slotName
+
+	return b
+}
+
+func (b *UpdateSubscriptionBuilder) SetEnabled(enabled *bool) *UpdateSubscriptionBuilder {
+	b.enabled = enabled
+
+	return b
+}
+
+// GetNewName will return new subscription name.
+func (b *UpdateSubscriptionBuilder) GetNewName() string {
+	return b.newName
+}
+
+// GetConnectionInfo will return new connection info.
+func (b *UpdateSubscriptionBuilder) GetConnectionInfo() string {
+	return b.connInfo
+}
+
+// GetPublication will return new publication name.
+func (b *UpdateSubscriptionBuilder) GetPublication() string {
+	return b.publication
+}
+
+// GetReplicationSlot will return new replication slot name.
+func (b *UpdateSubscriptionBuilder) GetReplicationSlot() string {
+	return b.slotName
+}
+
+// GetEnabledPart will return enable or disable keyword computed by build.
+func (b *UpdateSubscriptionBuilder) GetEnabledPart() string {
+	return b.enabledPart
+}
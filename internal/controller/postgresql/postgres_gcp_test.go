@@ -0,0 +1,158 @@
+package postgresql
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/go-logr/logr"
+	"github.com/lib/pq"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	postgresqlv1alpha1 "github.com/easymile/postgresql-operator/api/postgresql/v1alpha1"
+	"github.com/easymile/postgresql-operator/internal/controller/postgresql/postgres"
+)
+
+// Cloud SQL like restricted admin
+// This user isn't a superuser but a member of cloudsqlsuperuser with CREATEDB and CREATEROLE.
+// Replication attribute is given because only a superuser can set it on a local engine.
+var gcpAdminGroupRole = "cloudsqlsuperuser"
+var gcpAdminUser = "gcp-admin"
+var gcpAdminPassword = "gcp-admin"
+var gcpDBName = "gcp-db"
+var gcpOwnerRole = "gcp-db-owner"
+var gcpOwnerRole2 = "gcp-db-owner2"
+var gcpLoginRole = "gcp-login"
+var gcpReplicationSlotName = "gcp_slot"
+
+func newGCPPG() postgres.PG {
+	return postgres.NewPG(
+		"gcp",
+		"localhost",
+		gcpAdminUser,
+		gcpAdminPassword,
+		"sslmode=disable",
+		"postgres",
+		5432,
+		nil,
+		nil,
+		nil,
+		postgresqlv1alpha1.GCPProvider,
+		logr.Discard(),
+	)
+}
+
+func getSQLDatabaseOwner(dbName string) (string, error) {
+	// Connect
+	db, err := sql.Open("postgres", postgresUrl)
+	// Check error
+	if err != nil {
+		return "", err
+	}
+
+	defer db.Close()
+
+	res := ""
+	err = db.QueryRow(`SELECT pg_catalog.pg_get_userbyid(datdba) FROM pg_catalog.pg_database WHERE datname = $1`, dbName).Scan(&res)
+
+	return res, err
+}
+
+var _ = Describe("PG GCP provider", func() {
+	BeforeEach(func() {
+		Expect(rawSQLQueryOnDB("postgres", fmt.Sprintf("CREATE ROLE %s", pq.QuoteIdentifier(gcpAdminGroupRole)))).To(Succeed())
+		Expect(rawSQLQueryOnDB("postgres", fmt.Sprintf(
+			"CREATE ROLE %s WITH LOGIN PASSWORD %s CREATEDB CREATEROLE REPLICATION IN ROLE %s",
+			pq.QuoteIdentifier(gcpAdminUser),
+			pq.QuoteLiteral(gcpAdminPassword),
+			pq.QuoteIdentifier(gcpAdminGroupRole),
+		))).To(Succeed())
+	})
+
+	AfterEach(func() {
+		// Close pools to be able to drop database and user
+		Expect(postgres.CloseAllSavedPoolsForName("gcp")).To(Succeed())
+
+		Expect(rawSQLQueryOnDB("postgres", fmt.Sprintf("DROP DATABASE IF EXISTS %s", pq.QuoteIdentifier(gcpDBName)))).To(Succeed())
+
+		for _, it := range []string{gcpLoginRole, gcpOwnerRole, gcpOwnerRole2, gcpAdminUser, gcpAdminGroupRole} {
+			Expect(rawSQLQueryOnDB("postgres", fmt.Sprintf("DROP ROLE IF EXISTS %s", pq.QuoteIdentifier(it)))).To(Succeed())
+		}
+	})
+
+	It("should create a database and change its owner with temporary memberships", func() {
+		pg := newGCPPG()
+
+		Expect(pg.CreateGroupRole(ctx, gcpOwnerRole)).To(Succeed())
+		Expect(pg.CreateGroupRole(ctx, gcpOwnerRole2)).To(Succeed())
+
+		// Create database
+		Expect(pg.CreateDB(ctx, gcpDBName, gcpOwnerRole, nil)).To(Succeed())
+
+		owner, err := getSQLDatabaseOwner(gcpDBName)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(owner).To(Equal(gcpOwnerRole))
+
+		// Change owner
+		Expect(pg.ChangeDBOwner(ctx, gcpDBName, gcpOwnerRole2)).To(Succeed())
+
+		owner, err = getSQLDatabaseOwner(gcpDBName)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(owner).To(Equal(gcpOwnerRole2))
+
+		// Temporary memberships must have been revoked
+		membership, err := pg.GetRoleMembership(ctx, gcpAdminUser)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(membership).To(ConsistOf(gcpAdminGroupRole))
+	})
+
+	It("should drop a role and reassign its objects with temporary memberships", func() {
+		pg := newGCPPG()
+
+		Expect(pg.CreateGroupRole(ctx, gcpOwnerRole)).To(Succeed())
+		Expect(pg.CreateDB(ctx, gcpDBName, gcpOwnerRole, nil)).To(Succeed())
+
+		_, err := pg.CreateUserRole(ctx, gcpLoginRole, "password", nil)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(pg.GrantRole(ctx, gcpOwnerRole, gcpLoginRole, false)).To(Succeed())
+
+		// Create an object owned by login role
+		Expect(rawSQLQueryOnDB(gcpDBName, fmt.Sprintf(
+			"CREATE TABLE gcp_table (id int); ALTER TABLE gcp_table OWNER TO %s",
+			pq.QuoteIdentifier(gcpLoginRole),
+		))).To(Succeed())
+
+		// Drop role
+		Expect(pg.DropRoleAndDropAndChangeOwnedBy(ctx, gcpLoginRole, gcpOwnerRole, gcpDBName)).To(Succeed())
+
+		exists, err := isSQLRoleExists(gcpLoginRole)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(exists).To(BeFalse())
+
+		// Temporary memberships must have been revoked
+		membership, err := pg.GetRoleMembership(ctx, gcpAdminUser)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(membership).To(ConsistOf(gcpAdminGroupRole))
+	})
+
+	It("should create and drop a replication slot", func() {
+		pg := newGCPPG()
+
+		Expect(pg.CreateGroupRole(ctx, gcpOwnerRole)).To(Succeed())
+		Expect(pg.CreateDB(ctx, gcpDBName, gcpOwnerRole, nil)).To(Succeed())
+
+		// Create replication slot
+		Expect(pg.CreateReplicationSlot(ctx, gcpDBName, gcpReplicationSlotName, "pgoutput")).To(Succeed())
+
+		slot, err := pg.GetReplicationSlot(ctx, gcpReplicationSlotName)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(slot).NotTo(BeNil())
+
+		// Drop replication slot
+		Expect(pg.DropReplicationSlot(ctx, gcpReplicationSlotName)).To(Succeed())
+
+		slot, err = pg.GetReplicationSlot(ctx, gcpReplicationSlotName)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(slot).To(BeNil())
+	})
+})
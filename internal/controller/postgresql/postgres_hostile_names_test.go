@@ -0,0 +1,321 @@
+package postgresql
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/go-logr/logr"
+	"github.com/lib/pq"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	postgresqlv1alpha1 "github.com/easymile/postgresql-operator/api/postgresql/v1alpha1"
+	"github.com/easymile/postgresql-operator/internal/controller/postgresql/postgres"
+)
+
+// Hostile names corpus
+// All of these must be valid identifiers once quoted and must be kept as is in catalogs.
+var hostileNamesCorpus = map[string]string{
+	"double quote":         `with"double"quote`,
+	"single quote":         `with'single'quote`,
+	"sql injection":        `x"; DROP TABLE pg_class; --`,
+	"literal injection":    `x' OR '1'='1`,
+	"backslash":            `back\slash\`,
+	"bind placeholder":     `$1`,
+	"mixed case and space": `Mixed Case Name`,
+	"unicode":              `ünïcødé-名前-🐘`,
+	"63 bytes":             strings.Repeat("a", postgres.MaxIdentifierLength),
+	"63 bytes multibyte":   strings.Repeat("é", 31) + "a",
+}
+
+func newHostileNamesPG() postgres.PG {
+	return postgres.NewPG(
+		"hostile-names",
+		"localhost",
+		postgresUser,
+		postgresPassword,
+		"sslmode=disable",
+		"postgres",
+		5432,
+		nil,
+		nil,
+		nil,
+		postgresqlv1alpha1.NoProvider,
+		logr.Discard(),
+	)
+}
+
+// suffixHostileName will add suffix to name and keep the result under the identifier length limit.
+func suffixHostileName(name, suffix string) string {
+	res := []rune(name)
+	// Remove runes until it fits
+	for len(string(res))+len(suffix) > postgres.MaxIdentifierLength {
+		res = res[:len(res)-1]
+	}
+
+	return string(res) + suffix
+}
+
+func rawSQLQueryOnDB(dbName, raw string) error {
+	// Connect
+	db, err := sql.Open("postgres", postgres.TemplatePostgresqlURLWithArgs("localhost", postgresUser, postgresPassword, "sslmode=disable", dbName, 5432))
+	// Check error
+	if err != nil {
+		return err
+	}
+
+	defer db.Close()
+
+	_, err = db.Exec(raw)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+var _ = Describe("PG hostile names", func() {
+	It("should have a corpus with valid identifier length", func() {
+		for _, name := range hostileNamesCorpus {
+			Expect(len(name)).To(BeNumerically("<=", postgres.MaxIdentifierLength))
+		}
+	})
+
+	for k, v := range hostileNamesCorpus {
+		// Copy for closures
+		desc := k
+		name := v
+
+		Describe(desc, func() {
+			It("should round-trip roles", func() {
+				pg := newHostileNamesPG()
+				password := `pa'ss"wo\rd` + name
+
+				defer func() {
+					Expect(pg.DropRole(ctx, name)).To(Succeed())
+				}()
+
+				// Group role
+				Expect(pg.CreateGroupRole(ctx, name)).To(Succeed())
+
+				exists, err := pg.IsRoleExist(ctx, name)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(exists).To(BeTrue())
+
+				// Check that role have been created with the exact name
+				exists, err = isSQLRoleExists(name)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(exists).To(BeTrue())
+
+				// Login role with hostile password
+				login := suffixHostileName(name, "2")
+
+				defer func() {
+					Expect(pg.DropRole(ctx, login)).To(Succeed())
+				}()
+
+				_, err = pg.CreateUserRole(ctx, login, password, nil)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(pg.GrantRole(ctx, name, login, false)).To(Succeed())
+
+				members, err := pg.GetRoleMembership(ctx, login)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(members).To(Equal([]string{name}))
+
+				Expect(pg.AlterDefaultLoginRole(ctx, login, name)).To(Succeed())
+				Expect(pg.UpdatePassword(ctx, login, password+"'")).To(Succeed())
+
+				attrs, err := pg.GetRoleAttributes(ctx, login)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(*attrs.ConnectionLimit).To(Equal(postgres.DefaultAttributeConnectionLimit))
+
+				active, err := pg.DoesRoleHaveActiveSession(ctx, login)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(active).To(BeFalse())
+
+				Expect(pg.RevokeRole(ctx, name, login)).To(Succeed())
+
+				members, err = pg.GetRoleMembership(ctx, login)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(members).To(BeEmpty())
+			})
+
+			It("should round-trip databases, schemas and publications", func() {
+				pg := newHostileNamesPG()
+				owner := postgresUser
+
+				defer func() {
+					// Close pools on this database before dropping it
+					Expect(postgres.CloseDatabaseSavedPoolsForName("hostile-names", name)).To(Succeed())
+					Expect(pg.DropDatabase(ctx, name)).To(Succeed())
+
+					exists, err := pg.IsDatabaseExist(ctx, name)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(exists).To(BeFalse())
+				}()
+
+				Expect(pg.CreateDB(ctx, name, owner, nil)).To(Succeed())
+
+				exists, err := pg.IsDatabaseExist(ctx, name)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(exists).To(BeTrue())
+
+				exists, err = isSQLDBExists(name)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(exists).To(BeTrue())
+
+				// Schema
+				Expect(pg.CreateSchema(ctx, name, owner, name)).To(Succeed())
+				Expect(pg.SetSchemaPrivileges(ctx, name, owner, owner, name, &postgres.SchemaPrivileges{Tables: "SELECT"})).To(Succeed())
+
+				tables, err := pg.GetTablesInSchema(ctx, name, name)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(tables).To(BeEmpty())
+
+				// Create a table and a type inside the schema
+				// ? Note: Type name must be different from table name as a table creates a type with the same name
+				typeName := suffixHostileName(name, "_t")
+
+				Expect(rawSQLQueryOnDB(name, fmt.Sprintf(
+					"CREATE TABLE %[1]s.%[1]s (%[1]s int); CREATE TYPE %[1]s.%[2]s AS (%[1]s int);",
+					pq.QuoteIdentifier(name), pq.QuoteIdentifier(typeName),
+				))).To(Succeed())
+
+				tables, err = pg.GetTablesInSchema(ctx, name, name)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(tables).To(Equal([]*postgres.TableOwnership{{TableName: name, Owner: owner}}))
+
+				types, err := pg.GetTypesInSchema(ctx, name, name)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(types).To(Equal([]*postgres.TypeOwnership{{TypeName: typeName, Owner: owner}}))
+				Expect(pg.ChangeTypeOwnerInSchema(ctx, name, name, types[0].TypeName, owner)).To(Succeed())
+
+				// Grant privileges on existing tables, sequences, functions and types
+				Expect(pg.SetSchemaPrivileges(ctx, name, owner, owner, name, &postgres.SchemaPrivileges{
+					Tables:    "SELECT",
+					Sequences: "USAGE,SELECT,UPDATE",
+					Functions: "EXECUTE",
+					Types:     "USAGE",
+				})).To(Succeed())
+
+				// Publication on table in schema
+				Expect(pg.CreatePublication(ctx, name, postgres.NewCreatePublicationBuilder().
+					SetName(name).
+					AddTable(name+"."+name, &[]string{name}, nil).
+					SetWith("insert, update", nil),
+				)).To(Succeed())
+
+				pub, err := pg.GetPublication(ctx, name, name)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(pub).NotTo(BeNil())
+				Expect(pub.Insert).To(BeTrue())
+				Expect(pub.Delete).To(BeFalse())
+
+				Expect(pg.UpdatePublication(ctx, name, name, postgres.NewUpdatePublicationBuilder().
+					SetTablesInSchema([]string{name}).
+					SetWith("delete", nil),
+				)).To(Succeed())
+
+				pub, err = pg.GetPublication(ctx, name, name)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(pub).NotTo(BeNil())
+				Expect(pub.Delete).To(BeTrue())
+
+				Expect(pg.DropPublication(ctx, name, name)).To(Succeed())
+
+				pub, err = pg.GetPublication(ctx, name, name)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(pub).To(BeNil())
+
+				Expect(pg.DropSchema(ctx, name, name, true)).To(Succeed())
+
+				tables, err = pg.GetTablesInSchema(ctx, name, name)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(tables).To(BeEmpty())
+			})
+		})
+	}
+
+	It("should round-trip extensions", func() {
+		pg := newHostileNamesPG()
+
+		Expect(createSQLDB(pgdbDBName, postgresUser)).To(Succeed())
+
+		defer func() {
+			Expect(postgres.CloseDatabaseSavedPoolsForName("hostile-names", pgdbDBName)).To(Succeed())
+			Expect(deleteSQLDBs(pgdbDBName)).To(Succeed())
+		}()
+
+		Expect(pg.CreateExtension(ctx, pgdbDBName, pgdbExtensionName1)).To(Succeed())
+
+		exists, err := isSQLExtensionExists(pgdbExtensionName1)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(exists).To(BeTrue())
+
+		Expect(pg.DropExtension(ctx, pgdbDBName, pgdbExtensionName1, false)).To(Succeed())
+
+		exists, err = isSQLExtensionExists(pgdbExtensionName1)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(exists).To(BeFalse())
+
+		// Hostile extension names must be rejected and not as syntax errors
+		for _, it := range hostileNamesCorpus {
+			err = pg.CreateExtension(ctx, pgdbDBName, it)
+			Expect(err).To(HaveOccurred())
+
+			pqErr, ok := err.(*pq.Error)
+			Expect(ok).To(BeTrue())
+			Expect(pqErr.Code.Name()).NotTo(Equal("syntax_error"))
+		}
+	})
+
+	It("should round-trip replication slots", func() {
+		pg := newHostileNamesPG()
+		// Replication slot names can only contain lower case letters, numbers and underscore
+		name := strings.Repeat("s", postgres.MaxIdentifierLength)
+
+		Expect(createSQLDB(pgdbDBName, postgresUser)).To(Succeed())
+
+		defer func() {
+			Expect(postgres.CloseDatabaseSavedPoolsForName("hostile-names", pgdbDBName)).To(Succeed())
+			Expect(deleteSQLDBs(pgdbDBName)).To(Succeed())
+		}()
+
+		Expect(pg.CreateReplicationSlot(ctx, pgdbDBName, name, DefaultReplicationSlotPlugin)).To(Succeed())
+
+		slot, err := pg.GetReplicationSlot(ctx, name)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(slot).To(Equal(&postgres.ReplicationSlotResult{
+			SlotName: name,
+			Plugin:   DefaultReplicationSlotPlugin,
+			Database: pgdbDBName,
+		}))
+
+		Expect(pg.DropReplicationSlot(ctx, name)).To(Succeed())
+
+		slot, err = pg.GetReplicationSlot(ctx, name)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(slot).To(BeNil())
+
+		// Hostile names must be rejected as invalid names and not as syntax errors
+		for _, it := range hostileNamesCorpus {
+			err = pg.CreateReplicationSlot(ctx, pgdbDBName, it, DefaultReplicationSlotPlugin)
+			// Ignore valid names
+			if err == nil {
+				Expect(pg.DropReplicationSlot(ctx, it)).To(Succeed())
+
+				continue
+			}
+
+			pqErr, ok := err.(*pq.Error)
+			Expect(ok).To(BeTrue())
+			Expect(pqErr.Code).To(Equal(pq.ErrorCode("42602")))
+
+			slot, err = pg.GetReplicationSlot(ctx, it)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(slot).To(BeNil())
+		}
+	})
+})
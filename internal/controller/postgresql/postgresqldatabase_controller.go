@@ -20,14 +20,19 @@ import (
 	"context"
 	"fmt"
 	"reflect"
+	"sort"
+	"strings"
 	"time"
 
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
 
 	postgresqlv1alpha1 "github.com/easymile/postgresql-operator/api/postgresql/v1alpha1"
 	"github.com/easymile/postgresql-operator/internal/controller/config"
@@ -39,9 +44,30 @@ import (
 )
 
 const (
-	readerPrivs               = "SELECT"
-	writerPrivs               = "SELECT,INSERT,DELETE,UPDATE"
 	defaultPGPublicSchemaName = "public"
+	// Status reason of databases reconciled partially because connections aren't allowed.
+	ConnectionsNotAllowedReason = "ConnectionsNotAllowed"
+)
+
+var (
+	readerPrivs = &postgres.SchemaPrivileges{
+		Tables:    "SELECT",
+		Sequences: "SELECT",
+		Functions: "EXECUTE",
+		Types:     "USAGE",
+	}
+	writerPrivs = &postgres.SchemaPrivileges{
+		Tables:    "SELECT,INSERT,DELETE,UPDATE",
+		Sequences: "USAGE,SELECT,UPDATE",
+		Functions: "EXECUTE",
+		Types:     "USAGE",
+	}
+	// All privileges that can be set in privilege profiles
+	allProfileTablePrivs    = []string{"SELECT", "INSERT", "UPDATE", "DELETE", "TRUNCATE", "REFERENCES", "TRIGGER"}
+	allProfileSequencePrivs = []string{"USAGE", "SELECT", "UPDATE"}
+	allProfileFunctionPrivs = []string{"EXECUTE"}
+	// Profile names reserved for built-in roles
+	reservedPrivilegeProfileNames = []string{"owner", "reader", "writer"}
 )
 
 // PostgresqlDatabaseReconciler reconciles a PostgresqlDatabase object.
@@ -53,12 +79,15 @@ type PostgresqlDatabaseReconciler struct {
 	Log                                 logr.Logger
 	ControllerName                      string
 	ReconcileTimeout                    time.Duration
+	PgInstanceFactory                   utils.PgInstanceFactory
+	PlanMode                            bool
 }
 
 //+kubebuilder:rbac:groups=postgresql.easymile.com,resources=postgresqldatabases,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups=postgresql.easymile.com,resources=postgresqldatabases/status,verbs=get;update;patch
 //+kubebuilder:rbac:groups=postgresql.easymile.com,resources=postgresqldatabases/finalizers,verbs=update
 //+kubebuilder:rbac:groups=core,resources=events,verbs=create;patch
+//+kubebuilder:rbac:groups=core,resources=namespaces,verbs=get;list;watch
 
 // Reconcile is part of the main kubernetes reconciliation loop which aims to
 // move the current state of the cluster closer to the desired state.
@@ -100,6 +129,14 @@ func (r *PostgresqlDatabaseReconciler) Reconcile(ctx context.Context, req ctrl.R
 	// Defer cancel
 	defer cancel()
 
+	// Save resource at the origin of statements for audit
+	timeoutCtx = postgres.WithAuditResource(timeoutCtx, &postgres.AuditResource{
+		Kind:        "PostgresqlDatabase",
+		Namespace:   req.Namespace,
+		Name:        req.Name,
+		ReconcileID: string(controller.ReconcileIDFromContext(ctx)),
+	})
+
 	// Init result
 	var res ctrl.Result
 
@@ -143,11 +180,24 @@ func (r *PostgresqlDatabaseReconciler) mainReconcile(
 		}
 		// Check if should delete database is flagged
 		if shouldDelete {
+			// Check if plan mode is enabled
+			planMode := utils.IsPlanModeEnabled(r.PlanMode, instance)
+			// In plan mode, work on a copy in order to keep status untouched
+			dropInstance := instance
+			if planMode {
+				dropInstance = instance.DeepCopy()
+			}
+
 			// Drop database
-			err = r.manageDropDatabase(ctx, reqLogger, instance)
+			statements, err := r.manageDropDatabase(ctx, reqLogger, dropInstance, planMode)
 			if err != nil {
 				return r.manageError(ctx, reqLogger, instance, originalPatch, err)
 			}
+			// Check if there are pending statements in plan mode
+			// In this case, finalizer is kept until plan mode is disabled
+			if len(statements) != 0 {
+				return r.managePlan(ctx, reqLogger, instance, originalPatch, statements)
+			}
 		} else {
 			// Close saved pools
 			err = utils.CloseDatabaseSavedPoolsForName(instance, instance.Spec.Database)
@@ -184,6 +234,27 @@ func (r *PostgresqlDatabaseReconciler) mainReconcile(
 		return ctrl.Result{}, nil
 	}
 
+	// Validate settings
+	err = validateDatabaseSettings(instance)
+	// Check error
+	if err != nil {
+		return r.manageError(ctx, reqLogger, instance, originalPatch, err)
+	}
+
+	// Validate with engine capabilities
+	err = r.validateWithEngineCapabilities(instance, pgEngCfg)
+	// Check error
+	if err != nil {
+		return r.manageError(ctx, reqLogger, instance, originalPatch, err)
+	}
+
+	// Check engine quotas
+	err = checkDatabaseQuota(ctx, r.Client, pgEngCfg, instance)
+	// Check error
+	if err != nil {
+		return r.manageError(ctx, reqLogger, instance, originalPatch, err)
+	}
+
 	// Get secret linked to PostgresqlEngineConfiguration CR
 	secret, err := utils.FindSecretPgEngineCfg(ctx, r.Client, pgEngCfg)
 	if err != nil {
@@ -202,7 +273,7 @@ func (r *PostgresqlDatabaseReconciler) mainReconcile(
 	}
 
 	// Create PG instance
-	pg := utils.CreatePgInstance(reqLogger, secret.Data, pgEngCfg)
+	pg := r.PgInstanceFactory.CreatePgInstance(reqLogger, secret.Data, pgEngCfg)
 
 	// Create all identifiers now to check length
 	owner := instance.Spec.MasterRole
@@ -232,43 +303,321 @@ func (r *PostgresqlDatabaseReconciler) mainReconcile(
 		return r.manageError(ctx, reqLogger, instance, originalPatch, errors.NewBadRequest(errStr))
 	}
 
+	for _, schema := range instance.Spec.Schemas.List {
+		if len(schema) > postgres.MaxIdentifierLength {
+			errStr := fmt.Sprintf("identifier too long, must be <= 63, %s is %d character, must reduce schema name length", schema, len(schema))
+
+			return r.manageError(ctx, reqLogger, instance, originalPatch, errors.NewBadRequest(errStr))
+		}
+	}
+
+	for _, profile := range instance.Spec.PrivilegeProfiles {
+		// Check reserved names
+		if funk.ContainsString(reservedPrivilegeProfileNames, profile.Name) {
+			errStr := fmt.Sprintf("privilege profile name %s is reserved for built-in roles", profile.Name)
+
+			return r.manageError(ctx, reqLogger, instance, originalPatch, errors.NewBadRequest(errStr))
+		}
+
+		profileRole := getPrivilegeProfileRole(instance.Spec.Database, profile.Name)
+		if len(profileRole) > postgres.MaxIdentifierLength {
+			errStr := fmt.Sprintf(
+				"identifier too long, must be <= 63, %s is %d character, must reduce database or privilege profile name length",
+				profileRole, len(profileRole),
+			)
+
+			return r.manageError(ctx, reqLogger, instance, originalPatch, errors.NewBadRequest(errStr))
+		}
+	}
+
+	// Check if plan mode is enabled
+	if utils.IsPlanModeEnabled(r.PlanMode, instance) {
+		// Record statements instead of executing them
+		pg.EnablePlanMode()
+
+		// Manage engine on a copy in order to keep status untouched
+		err = r.manageEngine(ctx, pg, instance.DeepCopy(), owner, reader, writer, pgEngCfg.Spec.AllowGrantAdminOption)
+		if err != nil {
+			return r.manageError(ctx, reqLogger, instance, originalPatch, err)
+		}
+
+		return r.managePlan(ctx, reqLogger, instance, originalPatch, pg.GetPlannedStatements())
+	}
+
+	// Manage engine
+	err = r.manageEngine(ctx, pg, instance, owner, reader, writer, pgEngCfg.Spec.AllowGrantAdminOption)
+	if err != nil {
+		return r.manageError(ctx, reqLogger, instance, originalPatch, err)
+	}
+
+	return r.manageSuccess(ctx, reqLogger, instance, originalPatch)
+}
+
+func (r *PostgresqlDatabaseReconciler) manageEngine(
+	ctx context.Context,
+	pg postgres.PG,
+	instance *postgresqlv1alpha1.PostgresqlDatabase,
+	owner, reader, writer string,
+	allowGrantAdminOption bool,
+) error {
 	// Create owner role
-	err = r.manageOwnerRole(ctx, pg, owner, instance, pgEngCfg.Spec.AllowGrantAdminOption)
+	err := r.manageOwnerRole(ctx, pg, owner, instance, allowGrantAdminOption)
 	if err != nil {
-		return r.manageError(ctx, reqLogger, instance, originalPatch, errors.NewInternalError(err))
+		return newInternalError(err)
 	}
 
 	// Create or update database
 	err = r.manageDBCreationOrUpdate(ctx, pg, instance, owner)
 	if err != nil {
-		return r.manageError(ctx, reqLogger, instance, originalPatch, errors.NewInternalError(err))
+		return newInternalError(err)
+	}
+
+	// Manage connection limit and allowed connections
+	err = r.manageConnections(ctx, pg, instance)
+	if err != nil {
+		return newInternalError(err)
 	}
 
 	// Create reader role
-	err = r.manageReaderRole(ctx, pg, reader, instance, pgEngCfg.Spec.AllowGrantAdminOption)
+	err = r.manageReaderRole(ctx, pg, reader, instance, allowGrantAdminOption)
 	if err != nil {
-		return r.manageError(ctx, reqLogger, instance, originalPatch, errors.NewInternalError(err))
+		return newInternalError(err)
 	}
 
 	// Create writer role
-	err = r.manageWriterRole(ctx, pg, writer, instance, pgEngCfg.Spec.AllowGrantAdminOption)
+	err = r.manageWriterRole(ctx, pg, writer, instance, allowGrantAdminOption)
 	if err != nil {
-		return r.manageError(ctx, reqLogger, instance, originalPatch, errors.NewInternalError(err))
+		return newInternalError(err)
 	}
 
-	// Manage extensions
-	err = r.manageExtensions(ctx, pg, instance)
+	// Create privilege profile roles
+	err = r.managePrivilegeProfileRoles(ctx, pg, instance, allowGrantAdminOption)
 	if err != nil {
-		return r.manageError(ctx, reqLogger, instance, originalPatch, errors.NewInternalError(err))
+		return newInternalError(err)
 	}
 
-	// Manage schema
-	err = r.manageSchemas(ctx, pg, instance)
+	// Check if connections are allowed
+	// ? Note: Extensions and schemas need a connection on database
+	if instance.Spec.AllowConnections == nil || *instance.Spec.AllowConnections {
+		// Manage extensions
+		err = r.manageExtensions(ctx, pg, instance)
+		if err != nil {
+			return newInternalError(err)
+		}
+
+		// Manage schema
+		err = r.manageSchemas(ctx, pg, instance)
+		if err != nil {
+			return newInternalError(err)
+		}
+	}
+
+	// Manage settings
+	err = r.manageSettings(ctx, pg, instance)
 	if err != nil {
-		return r.manageError(ctx, reqLogger, instance, originalPatch, errors.NewInternalError(err))
+		return newInternalError(err)
 	}
 
-	return r.manageSuccess(ctx, reqLogger, instance, originalPatch)
+	return nil
+}
+
+func (*PostgresqlDatabaseReconciler) manageConnections(ctx context.Context, pg postgres.PG, instance *postgresqlv1alpha1.PostgresqlDatabase) error {
+	// Get current connections
+	conns, err := pg.GetDatabaseConnections(ctx, instance.Spec.Database)
+	// Check error
+	if err != nil {
+		return err
+	}
+	// Check if database isn't found
+	// ? Note: Database doesn't exist in plan mode, engine defaults are used
+	planned := conns == nil
+	if planned {
+		conns = &postgres.DatabaseConnections{ConnectionLimit: postgres.UnlimitedConnectionLimit, AllowConnections: true}
+	}
+
+	// Compute wanted connection limit
+	// ? Note: Engine default is restored when it is removed from spec
+	connectionLimit := postgres.UnlimitedConnectionLimit
+	if instance.Spec.ConnectionLimit != nil {
+		connectionLimit = *instance.Spec.ConnectionLimit
+	}
+
+	// Check connection limit
+	if connectionLimit != conns.ConnectionLimit {
+		err = pg.SetDatabaseConnectionLimit(ctx, instance.Spec.Database, connectionLimit)
+		// Check error
+		if err != nil {
+			return err
+		}
+
+		conns.ConnectionLimit = connectionLimit
+	}
+
+	// Compute wanted allowed connections
+	// ? Note: Engine default is restored when it is removed from spec
+	allowConnections := true
+	if instance.Spec.AllowConnections != nil {
+		allowConnections = *instance.Spec.AllowConnections
+	}
+
+	// Check allowed connections
+	if allowConnections != conns.AllowConnections {
+		err = pg.SetDatabaseAllowConnections(ctx, instance.Spec.Database, allowConnections)
+		// Check error
+		if err != nil {
+			return err
+		}
+
+		conns.AllowConnections = allowConnections
+	}
+
+	// Check if existing sessions must be terminated
+	// ? Note: This is done after disabling connections to avoid new ones
+	if !conns.AllowConnections && instance.Spec.TerminateExistingSessions && conns.Count != 0 {
+		// Close saved pools as their connections will be terminated
+		err = utils.CloseDatabaseSavedPoolsForName(instance, instance.Spec.Database)
+		// Check error
+		if err != nil {
+			return err
+		}
+
+		err = pg.TerminateDatabaseSessions(ctx, instance.Spec.Database)
+		// Check error
+		if err != nil {
+			return err
+		}
+
+		conns.Count = 0
+	}
+
+	// Check if database doesn't exist yet
+	if planned {
+		return nil
+	}
+
+	// Update status
+	instance.Status.Connections = &postgresqlv1alpha1.DatabaseConnectionsStatus{
+		ConnectionLimit:  conns.ConnectionLimit,
+		AllowConnections: conns.AllowConnections,
+		Count:            conns.Count,
+	}
+
+	return nil
+}
+
+func (*PostgresqlDatabaseReconciler) manageSettings(ctx context.Context, pg postgres.PG, instance *postgresqlv1alpha1.PostgresqlDatabase) error {
+	// Manage database settings
+	applied, err := manageSettingsOnEngine(ctx, pg, instance.Spec.Database, "", instance.Spec.Settings, instance.Status.Settings)
+	// Check error
+	if err != nil {
+		return err
+	}
+	// Update status
+	instance.Status.Settings = applied
+
+	// Get wanted and already applied role settings
+	wanted := instance.Spec.RoleSettings
+	if wanted == nil {
+		wanted = &postgresqlv1alpha1.DatabaseRoleSettings{}
+	}
+
+	previous := instance.Status.RoleSettings
+	if previous == nil {
+		previous = &postgresqlv1alpha1.DatabaseRoleSettings{}
+	}
+
+	res := &postgresqlv1alpha1.DatabaseRoleSettings{}
+
+	// Manage owner settings
+	res.Owner, err = manageSettingsOnEngine(ctx, pg, instance.Spec.Database, instance.Status.Roles.Owner, wanted.Owner, previous.Owner)
+	// Check error
+	if err != nil {
+		return err
+	}
+	// Manage reader settings
+	res.Reader, err = manageSettingsOnEngine(ctx, pg, instance.Spec.Database, instance.Status.Roles.Reader, wanted.Reader, previous.Reader)
+	// Check error
+	if err != nil {
+		return err
+	}
+	// Manage writer settings
+	res.Writer, err = manageSettingsOnEngine(ctx, pg, instance.Spec.Database, instance.Status.Roles.Writer, wanted.Writer, previous.Writer)
+	// Check error
+	if err != nil {
+		return err
+	}
+
+	// Update status
+	instance.Status.RoleSettings = nil
+	if res.Owner != nil || res.Reader != nil || res.Writer != nil {
+		instance.Status.RoleSettings = res
+	}
+
+	return nil
+}
+
+// manageSettingsOnEngine will apply wanted settings on database (or for role in database when set)
+// and reset previously applied settings that aren't wanted anymore.
+// Settings set outside of operator and not in previously applied ones are kept.
+// Applied settings are returned.
+func manageSettingsOnEngine(
+	ctx context.Context,
+	pg postgres.PG,
+	dbname, role string,
+	wanted, previous map[string]string,
+) (map[string]string, error) {
+	// Check if there is nothing to do
+	if len(wanted) == 0 && len(previous) == 0 {
+		return nil, nil
+	}
+
+	// Get current settings
+	current, err := pg.GetDatabaseSettings(ctx, dbname, role)
+	// Check error
+	if err != nil {
+		return nil, err
+	}
+
+	// Reset removed settings
+	for k := range previous {
+		// Check if it is still wanted
+		if _, ok := wanted[k]; ok {
+			continue
+		}
+
+		// Check if it is still set on engine
+		if _, ok := current[k]; !ok {
+			continue
+		}
+
+		err = pg.ResetDatabaseSetting(ctx, dbname, role, k)
+		// Check error
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Check if there isn't any wanted setting
+	if len(wanted) == 0 {
+		return nil, nil
+	}
+
+	res := map[string]string{}
+	// Set wanted settings
+	for k, v := range wanted {
+		// Check if it is already set
+		if cv, ok := current[k]; !ok || !postgres.IsSameSettingValue(k, cv, v) {
+			err = pg.AlterDatabaseSetting(ctx, dbname, role, k, v)
+			// Check error
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		res[k] = v
+	}
+
+	return res, nil
 }
 
 func (*PostgresqlDatabaseReconciler) manageDBCreationOrUpdate(
@@ -310,7 +659,7 @@ func (*PostgresqlDatabaseReconciler) manageDBCreationOrUpdate(
 	// Check if exists
 	if !exists {
 		// Create database
-		err := pg.CreateDB(ctx, instance.Spec.Database, owner)
+		err := pg.CreateDB(ctx, instance.Spec.Database, owner, getDatabaseCreationOptions(instance))
 		if err != nil {
 			return err
 		}
@@ -322,37 +671,132 @@ func (*PostgresqlDatabaseReconciler) manageDBCreationOrUpdate(
 		}
 	}
 
+	// Get effective creation options
+	options, err := pg.GetDatabaseCreationOptions(ctx, instance.Spec.Database)
+	// Check error
+	if err != nil {
+		return err
+	}
+	// Check if options are found
+	// ? Note: Database doesn't exist in plan mode
+	if options != nil {
+		instance.Status.CreationOptions = computeDatabaseCreationOptionsStatus(instance, options, !exists)
+	}
+
 	// Update status
 	instance.Status.Database = instance.Spec.Database
 
 	return nil
 }
 
+// getDatabaseCreationOptions will return engine creation options from spec.
+func getDatabaseCreationOptions(instance *postgresqlv1alpha1.PostgresqlDatabase) *postgres.DatabaseCreationOptions {
+	// Check if there isn't any option
+	if instance.Spec.CreationOptions == nil {
+		return nil
+	}
+
+	return &postgres.DatabaseCreationOptions{
+		Encoding:       instance.Spec.CreationOptions.Encoding,
+		Locale:         instance.Spec.CreationOptions.Locale,
+		LocaleProvider: instance.Spec.CreationOptions.LocaleProvider,
+		ICULocale:      instance.Spec.CreationOptions.ICULocale,
+		Template:       instance.Spec.CreationOptions.Template,
+		Tablespace:     instance.Spec.CreationOptions.Tablespace,
+	}
+}
+
+// normalizeEncoding will return encoding name without case and separators (utf-8 and UTF8 are the same).
+func normalizeEncoding(encoding string) string {
+	return strings.NewReplacer("-", "", "_", "").Replace(strings.ToUpper(encoding))
+}
+
+// computeDatabaseCreationOptionsStatus will build status from effective options
+// and report spec options that are different.
+func computeDatabaseCreationOptionsStatus(
+	instance *postgresqlv1alpha1.PostgresqlDatabase,
+	options *postgres.DatabaseCreationOptions,
+	created bool,
+) *postgresqlv1alpha1.DatabaseCreationOptionsStatus {
+	res := &postgresqlv1alpha1.DatabaseCreationOptionsStatus{
+		Encoding:       options.Encoding,
+		Locale:         options.Locale,
+		LocaleProvider: options.LocaleProvider,
+		ICULocale:      options.ICULocale,
+		Tablespace:     options.Tablespace,
+	}
+
+	// Keep previous template as engine doesn't store it
+	if instance.Status.CreationOptions != nil {
+		res.Template = instance.Status.CreationOptions.Template
+	}
+
+	// Check if there isn't any option in spec
+	spec := instance.Spec.CreationOptions
+	if spec == nil {
+		return res
+	}
+
+	// Save template if database was just created with it
+	if created {
+		res.Template = spec.Template
+	}
+
+	// Check encoding
+	if spec.Encoding != "" && normalizeEncoding(spec.Encoding) != normalizeEncoding(options.Encoding) {
+		res.Drifts = append(res.Drifts, fmt.Sprintf("encoding is %s instead of %s", options.Encoding, spec.Encoding))
+	}
+	// Check locale
+	if spec.Locale != "" && spec.Locale != options.Locale {
+		res.Drifts = append(res.Drifts, fmt.Sprintf("locale is %s instead of %s", options.Locale, spec.Locale))
+	}
+	// Check locale provider
+	if spec.LocaleProvider != "" && spec.LocaleProvider != options.LocaleProvider {
+		res.Drifts = append(res.Drifts, fmt.Sprintf("locale provider is %s instead of %s", options.LocaleProvider, spec.LocaleProvider))
+	}
+	// Check ICU locale
+	if spec.ICULocale != "" && spec.ICULocale != options.ICULocale {
+		res.Drifts = append(res.Drifts, fmt.Sprintf("icu locale is %s instead of %s", options.ICULocale, spec.ICULocale))
+	}
+	// Check tablespace
+	if spec.Tablespace != "" && spec.Tablespace != options.Tablespace {
+		res.Drifts = append(res.Drifts, fmt.Sprintf("tablespace is %s instead of %s", options.Tablespace, spec.Tablespace))
+	}
+
+	return res
+}
+
 func (r *PostgresqlDatabaseReconciler) manageDropDatabase(
 	ctx context.Context,
 	logger logr.Logger,
 	instance *postgresqlv1alpha1.PostgresqlDatabase,
-) error {
+	planMode bool,
+) ([]string, error) {
 	// Try to find PostgresqlEngineConfiguration CR
 	pgEngCfg, err := utils.FindPgEngineCfg(ctx, r.Client, instance)
 	if err != nil && !errors.IsNotFound(err) {
-		return err
+		return nil, err
 	}
 	// In case of not found => Can't delete => skip
 	if errors.IsNotFound(err) {
 		logger.Error(err, "can't delete database because PostgresEngineConfiguration didn't exists anymore")
 
-		return nil
+		return nil, nil
 	}
 
 	// Get secret linked to PostgresqlEngineConfiguration CR
 	secret, err := utils.FindSecretPgEngineCfg(ctx, r.Client, pgEngCfg)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	// Create PG instance
-	pg := utils.CreatePgInstance(logger, secret.Data, pgEngCfg)
+	pg := r.PgInstanceFactory.CreatePgInstance(logger, secret.Data, pgEngCfg)
+	// Check if plan mode is enabled
+	if planMode {
+		// Record statements instead of executing them
+		pg.EnablePlanMode()
+	}
 
 	// Drop roles first
 
@@ -364,14 +808,14 @@ func (r *PostgresqlDatabaseReconciler) manageDropDatabase(
 		exists, err = pg.IsRoleExist(ctx, instance.Status.Roles.Owner)
 		// Check error
 		if err != nil {
-			return err
+			return nil, err
 		}
 		// Check if role exists before trying to delete it
 		if exists {
 			// Delete
 			err = pg.DropRoleAndDropAndChangeOwnedBy(ctx, instance.Status.Roles.Owner, pg.GetUser(), instance.Spec.Database)
 			if err != nil {
-				return err
+				return nil, err
 			}
 		}
 		// Clear status
@@ -382,14 +826,14 @@ func (r *PostgresqlDatabaseReconciler) manageDropDatabase(
 		exists, err = pg.IsRoleExist(ctx, instance.Status.Roles.Writer)
 		// Check error
 		if err != nil {
-			return err
+			return nil, err
 		}
 		// Check if role exists before trying to delete it
 		if exists {
 			// Delete
 			err = pg.DropRoleAndDropAndChangeOwnedBy(ctx, instance.Status.Roles.Writer, pg.GetUser(), instance.Spec.Database)
 			if err != nil {
-				return err
+				return nil, err
 			}
 		}
 		// Clear status
@@ -400,31 +844,53 @@ func (r *PostgresqlDatabaseReconciler) manageDropDatabase(
 		exists, err = pg.IsRoleExist(ctx, instance.Status.Roles.Reader)
 		// Check error
 		if err != nil {
-			return err
+			return nil, err
 		}
 		// Check if role exists before trying to delete it
 		if exists {
 			// Delete
 			err = pg.DropRoleAndDropAndChangeOwnedBy(ctx, instance.Status.Roles.Reader, pg.GetUser(), instance.Spec.Database)
 			if err != nil {
-				return err
+				return nil, err
 			}
 		}
 		// Clear status
 		instance.Status.Roles.Reader = ""
 	}
+	// Drop privilege profiles
+	for _, name := range getSortedPrivilegeProfileNames(instance.Status.Roles.Profiles) {
+		role := instance.Status.Roles.Profiles[name]
+
+		exists, err = pg.IsRoleExist(ctx, role)
+		// Check error
+		if err != nil {
+			return nil, err
+		}
+		// Check if role exists before trying to delete it
+		if exists {
+			// Delete
+			err = pg.DropRoleAndDropAndChangeOwnedBy(ctx, role, pg.GetUser(), instance.Spec.Database)
+			if err != nil {
+				return nil, err
+			}
+		}
+		// Clear status
+		delete(instance.Status.Roles.Profiles, name)
+	}
+	// Clear status
+	instance.Status.Roles.Profiles = nil
 
 	// Close saved pools for this database
 	// This is done twice in the sequence, but function is idempotent => not a problem and should be kept otherwise a pool can survive
 	err = utils.CloseDatabaseSavedPoolsForName(instance, instance.Spec.Database)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	exists, err = pg.IsDatabaseExist(ctx, instance.Spec.Database)
 	// Check error
 	if err != nil {
-		return err
+		return nil, err
 	}
 	// Check if role exists before trying to delete it
 	if exists {
@@ -432,12 +898,12 @@ func (r *PostgresqlDatabaseReconciler) manageDropDatabase(
 		err = pg.DropDatabase(ctx, instance.Spec.Database)
 		// Check error
 		if err != nil {
-			return err
+			return nil, err
 		}
 	}
 
 	// Default
-	return nil
+	return pg.GetPlannedStatements(), nil
 }
 
 func (r *PostgresqlDatabaseReconciler) shouldDropDatabase(
@@ -471,6 +937,19 @@ func (r *PostgresqlDatabaseReconciler) shouldDropDatabase(
 
 			return false, err
 		}
+
+		// Check if there are subscription linked resource linked to this
+		existingSubscription, err := r.getAnySubscriptionLinked(ctx, instance)
+		if err != nil {
+			return false, err
+		}
+
+		if existingSubscription != nil {
+			// Wait for children removal
+			err = fmt.Errorf("cannot remove resource because found subscription %s in namespace %s linked to this resource and wait for deletion flag is enabled", existingSubscription.Name, existingSubscription.Namespace)
+
+			return false, err
+		}
 	}
 
 	// Check if drop on delete flag is enabled
@@ -504,6 +983,28 @@ func (r *PostgresqlDatabaseReconciler) getAnyPublicationLinked(
 	return nil, nil
 }
 
+func (r *PostgresqlDatabaseReconciler) getAnySubscriptionLinked(
+	ctx context.Context,
+	instance *postgresqlv1alpha1.PostgresqlDatabase,
+) (*postgresqlv1alpha1.PostgresqlSubscription, error) {
+	// Initialize postgres subscription list
+	list := postgresqlv1alpha1.PostgresqlSubscriptionList{}
+	// Requests for list of subscriptions
+	err := r.List(ctx, &list)
+	if err != nil {
+		return nil, err
+	}
+	// Loop over the list
+	for _, item := range list.Items {
+		// Check if db is linked to pgdatabase
+		if item.Spec.Database.Name == instance.Name && (item.Spec.Database.Namespace == instance.Namespace || item.Namespace == instance.Namespace) {
+			return &item, nil
+		}
+	}
+
+	return nil, nil
+}
+
 func (r *PostgresqlDatabaseReconciler) getAnyUserRoleLinked(
 	ctx context.Context,
 	instance *postgresqlv1alpha1.PostgresqlDatabase,
@@ -528,6 +1029,63 @@ func (r *PostgresqlDatabaseReconciler) getAnyUserRoleLinked(
 	return nil, nil
 }
 
+// validateDatabaseSettings will return a bad request error if a setting name cannot be used.
+func validateDatabaseSettings(instance *postgresqlv1alpha1.PostgresqlDatabase) error {
+	// Get all setting lists
+	lists := []map[string]string{instance.Spec.Settings}
+	if instance.Spec.RoleSettings != nil {
+		lists = append(lists, instance.Spec.RoleSettings.Owner, instance.Spec.RoleSettings.Reader, instance.Spec.RoleSettings.Writer)
+	}
+
+	// Loop over lists
+	for _, settings := range lists {
+		for k := range settings {
+			// Check name
+			if !postgres.IsValidSettingName(k) {
+				return errors.NewBadRequest(fmt.Sprintf("setting name %s is invalid, it must be a lower case setting name", k))
+			}
+			// Check if it is managed by operator
+			if k == "role" {
+				return errors.NewBadRequest("role setting is managed by operator and cannot be set")
+			}
+		}
+	}
+
+	return nil
+}
+
+func (*PostgresqlDatabaseReconciler) validateWithEngineCapabilities(
+	instance *postgresqlv1alpha1.PostgresqlDatabase,
+	pgec *postgresqlv1alpha1.PostgresqlEngineConfiguration,
+) error {
+	// Check that owner, reader and writer roles can be created
+	err := utils.CheckEngineAdminCanCreateRoles(pgec)
+	// Check error
+	if err != nil {
+		return err
+	}
+
+	// Check creation options
+	if instance.Spec.CreationOptions != nil {
+		// Check ICU locale without ICU provider
+		if instance.Spec.CreationOptions.ICULocale != "" && instance.Spec.CreationOptions.LocaleProvider != postgres.ICULocaleProvider {
+			return errors.NewBadRequest("icuLocale creation option can only be used with icu locale provider")
+		}
+
+		// Check locale provider
+		if instance.Spec.CreationOptions.LocaleProvider != "" {
+			err = utils.CheckEngineMinVersion(pgec, utils.PostgresqlVersion15, "locale provider creation option")
+			// Check error
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	// Check extensions
+	return utils.CheckEngineExtensionsAvailable(pgec, instance.Spec.Extensions.List)
+}
+
 func (r *PostgresqlDatabaseReconciler) updateInstance(
 	ctx context.Context,
 	instance *postgresqlv1alpha1.PostgresqlDatabase,
@@ -601,6 +1159,24 @@ func (*PostgresqlDatabaseReconciler) manageSchemas(ctx context.Context, pg postg
 			return err
 		}
 
+		// Set privilege profiles on schema
+		for _, profile := range instance.Spec.PrivilegeProfiles {
+			profileRole := instance.Status.Roles.Profiles[profile.Name]
+			// Get privileges to grant and revoke
+			grantPrivs, revokePrivs := getPrivilegeProfileSchemaPrivileges(profile)
+
+			err = pg.SetSchemaPrivileges(ctx, instance.Spec.Database, owner, profileRole, schema, grantPrivs)
+			if err != nil {
+				return err
+			}
+
+			// Revoke privileges removed from profile
+			err = pg.RevokeSchemaPrivileges(ctx, instance.Spec.Database, owner, profileRole, schema, revokePrivs)
+			if err != nil {
+				return err
+			}
+		}
+
 		// Get list of tables inside schema
 		tableOwnerships, err := pg.GetTablesInSchema(ctx, instance.Spec.Database, schema)
 		if err != nil {
@@ -790,6 +1366,99 @@ func (*PostgresqlDatabaseReconciler) manageWriterRole(
 	return nil
 }
 
+func (*PostgresqlDatabaseReconciler) managePrivilegeProfileRoles(
+	ctx context.Context,
+	pg postgres.PG,
+	instance *postgresqlv1alpha1.PostgresqlDatabase,
+	allowGrantAdminOption bool,
+) error {
+	// Prepare result
+	res := map[string]string{}
+
+	// Loop over profiles
+	for _, profile := range instance.Spec.PrivilegeProfiles {
+		role := getPrivilegeProfileRole(instance.Spec.Database, profile.Name)
+		oldRole := instance.Status.Roles.Profiles[profile.Name]
+
+		// Check if role was already created in the past
+		if oldRole != "" && oldRole != role {
+			// Check if role doesn't already exists
+			exists, err := pg.IsRoleExist(ctx, oldRole)
+			// Check error
+			if err != nil {
+				return err
+			}
+			// Check if "old" already exists and need to be renamed
+			// if needed rename and let create role do his job
+			if exists {
+				// Rename
+				err = pg.RenameRole(ctx, oldRole, role)
+				if err != nil {
+					return err
+				}
+			}
+		}
+
+		// Check if role doesn't already exists
+		exists, err := pg.IsRoleExist(ctx, role)
+		// Check error
+		if err != nil {
+			return err
+		}
+		// Check if exists
+		if !exists {
+			// Create it
+			err = pg.CreateGroupRole(ctx, role)
+			// Check error
+			if err != nil {
+				return err
+			}
+		}
+
+		// Grant role to current role
+		err = pg.GrantRole(ctx, role, pg.GetUser(), allowGrantAdminOption)
+		// Check error
+		if err != nil {
+			return err
+		}
+
+		// Save
+		res[profile.Name] = role
+	}
+
+	// Drop roles of profiles removed from spec
+	for _, name := range getSortedPrivilegeProfileNames(instance.Status.Roles.Profiles) {
+		// Check if profile is still present
+		if _, ok := res[name]; ok {
+			continue
+		}
+
+		role := instance.Status.Roles.Profiles[name]
+		// Check if role exists
+		exists, err := pg.IsRoleExist(ctx, role)
+		// Check error
+		if err != nil {
+			return err
+		}
+		// Check if role exists before trying to delete it
+		if exists {
+			// Delete
+			err = pg.DropRoleAndDropAndChangeOwnedBy(ctx, role, pg.GetUser(), instance.Spec.Database)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	// Update status
+	instance.Status.Roles.Profiles = nil
+	if len(res) != 0 {
+		instance.Status.Roles.Profiles = res
+	}
+
+	return nil
+}
+
 func (*PostgresqlDatabaseReconciler) manageOwnerRole(
 	ctx context.Context,
 	pg postgres.PG,
@@ -852,12 +1521,23 @@ func (r *PostgresqlDatabaseReconciler) manageError(
 	originalPatch client.Patch,
 	issue error,
 ) (ctrl.Result, error) {
+	// Check if issue is retryable
+	res, managed := manageReadOnlyTransactionError(
+		ctx, logger, r.Status(), r.Recorder, instance,
+		&instance.Status.Message, &instance.Status.Reason,
+		originalPatch, issue,
+	)
+	if managed {
+		return res, nil
+	}
+
 	logger.Error(issue, "issue raised in reconcile")
 	// Add kubernetes event
 	r.Recorder.Event(instance, "Warning", "ProcessingError", issue.Error())
 
 	// Update status
 	instance.Status.Message = issue.Error()
+	instance.Status.Reason = string(errors.ReasonForError(issue))
 	instance.Status.Ready = false
 	instance.Status.Phase = postgresqlv1alpha1.DatabaseFailedPhase
 
@@ -874,6 +1554,42 @@ func (r *PostgresqlDatabaseReconciler) manageError(
 	return ctrl.Result{}, issue
 }
 
+func (r *PostgresqlDatabaseReconciler) managePlan(
+	ctx context.Context,
+	logger logr.Logger,
+	instance *postgresqlv1alpha1.PostgresqlDatabase,
+	originalPatch client.Patch,
+	statements []string,
+) (ctrl.Result, error) {
+	// Add kubernetes events
+	for _, st := range statements {
+		r.Recorder.Event(instance, "Normal", "Planned", st)
+	}
+
+	// Update status
+	instance.Status.Message = fmt.Sprintf("plan mode enabled, %d statement(s) pending", len(statements))
+	instance.Status.Plan = &postgresqlv1alpha1.PlanStatus{
+		PendingStatements: statements,
+		LastPlanTime:      time.Now().Format(time.RFC3339),
+	}
+
+	// Patch status
+	err := r.Status().Patch(ctx, instance, originalPatch)
+	if err != nil {
+		// Increase fail counter
+		r.ControllerRuntimeDetailedErrorTotal.WithLabelValues(r.ControllerName, instance.Namespace, instance.Name).Inc()
+
+		logger.Error(err, "unable to update status")
+
+		// Return error
+		return ctrl.Result{}, err
+	}
+
+	logger.Info("Plan done")
+
+	return ctrl.Result{}, nil
+}
+
 func (r *PostgresqlDatabaseReconciler) manageSuccess(
 	ctx context.Context,
 	logger logr.Logger,
@@ -882,8 +1598,18 @@ func (r *PostgresqlDatabaseReconciler) manageSuccess(
 ) (ctrl.Result, error) {
 	// Update status
 	instance.Status.Message = ""
+	instance.Status.Reason = ""
 	instance.Status.Ready = true
 	instance.Status.Phase = postgresqlv1alpha1.DatabaseCreatedPhase
+	// Plan is outdated now
+	instance.Status.Plan = nil
+
+	// Check if connections aren't allowed
+	// ? Note: Extensions, schemas and privileges haven't been reconciled in this case
+	if instance.Spec.AllowConnections != nil && !*instance.Spec.AllowConnections {
+		instance.Status.Message = "connections aren't allowed on database, extensions, schemas and privileges haven't been reconciled"
+		instance.Status.Reason = ConnectionsNotAllowedReason
+	}
 
 	// Patch status
 	err := r.Status().Patch(ctx, instance, originalPatch)
@@ -906,5 +1632,57 @@ func (r *PostgresqlDatabaseReconciler) manageSuccess(
 func (r *PostgresqlDatabaseReconciler) SetupWithManager(mgr ctrl.Manager) error {
 	return ctrl.NewControllerManagedBy(mgr).
 		For(&postgresqlv1alpha1.PostgresqlDatabase{}).
+		Watches(&corev1.Secret{}, handler.EnqueueRequestsFromMapFunc(newDatabaseEngineSecretMapFunc(mgr.GetClient()))).
 		Complete(r)
 }
+
+// getPrivilegeProfileRole will return group role name of a privilege profile.
+func getPrivilegeProfileRole(database, profileName string) string {
+	return fmt.Sprintf("%s-%s", database, profileName)
+}
+
+// getSortedPrivilegeProfileNames will return profile names of a status sorted to have stable statements.
+func getSortedPrivilegeProfileNames(profiles map[string]string) []string {
+	res := make([]string, 0, len(profiles))
+	for name := range profiles {
+		res = append(res, name)
+	}
+
+	sort.Strings(res)
+
+	return res
+}
+
+// getPrivilegeProfileSchemaPrivileges will return privileges to grant for a profile
+// and all others privileges that must be revoked.
+func getPrivilegeProfileSchemaPrivileges(profile *postgresqlv1alpha1.DatabasePrivilegeProfile) (grant, revoke *postgres.SchemaPrivileges) {
+	tables := make([]string, 0, len(profile.Tables))
+	for _, it := range profile.Tables {
+		tables = append(tables, string(it))
+	}
+
+	sequences := make([]string, 0, len(profile.Sequences))
+	for _, it := range profile.Sequences {
+		sequences = append(sequences, string(it))
+	}
+
+	functions := make([]string, 0, len(profile.Functions))
+	for _, it := range profile.Functions {
+		functions = append(functions, string(it))
+	}
+
+	grant = &postgres.SchemaPrivileges{
+		Tables:    strings.Join(tables, ","),
+		Sequences: strings.Join(sequences, ","),
+		Functions: strings.Join(functions, ","),
+	}
+
+	// Types are kept untouched as there is only the usage privilege on them
+	revoke = &postgres.SchemaPrivileges{
+		Tables:    strings.Join(funk.SubtractString(allProfileTablePrivs, tables), ","),
+		Sequences: strings.Join(funk.SubtractString(allProfileSequencePrivs, sequences), ","),
+		Functions: strings.Join(funk.SubtractString(allProfileFunctionPrivs, functions), ","),
+	}
+
+	return grant, revoke
+}
@@ -1,18 +1,29 @@
 package postgresql
 
 import (
+	"context"
 	"errors"
 	gerrors "errors"
 	"fmt"
 	"reflect"
+	"time"
 
 	"github.com/easymile/postgresql-operator/api/postgresql/common"
 	postgresqlv1alpha1 "github.com/easymile/postgresql-operator/api/postgresql/v1alpha1"
+	"github.com/easymile/postgresql-operator/internal/controller/config"
+	"github.com/easymile/postgresql-operator/internal/controller/postgresql/postgres"
+	"github.com/easymile/postgresql-operator/internal/controller/postgresql/postgres/postgrestest"
+	"github.com/go-logr/logr"
+	"github.com/lib/pq"
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
+	"github.com/samber/lo"
+	corev1 "k8s.io/api/core/v1"
 	apimachineryErrors "k8s.io/apimachinery/pkg/api/errors"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
 )
 
 var _ = Describe("PostgresqlDatabase tests", func() {
@@ -67,7 +78,7 @@ var _ = Describe("PostgresqlDatabase tests", func() {
 			},
 			Spec: postgresqlv1alpha1.PostgresqlDatabaseSpec{
 				Database: pgdbDBName,
-				EngineConfiguration: &common.CRLink{
+				EngineConfiguration: &common.EngineCRLink{
 					Name:      "fake",
 					Namespace: "fake",
 				},
@@ -120,7 +131,7 @@ var _ = Describe("PostgresqlDatabase tests", func() {
 			},
 			Spec: postgresqlv1alpha1.PostgresqlDatabaseSpec{
 				Database: pgdbDBName,
-				EngineConfiguration: &common.CRLink{
+				EngineConfiguration: &common.EngineCRLink{
 					Name:      prov.Name,
 					Namespace: prov.Namespace,
 				},
@@ -215,7 +226,7 @@ var _ = Describe("PostgresqlDatabase tests", func() {
 			},
 			Spec: postgresqlv1alpha1.PostgresqlDatabaseSpec{
 				Database: pgdbDBName,
-				EngineConfiguration: &common.CRLink{
+				EngineConfiguration: &common.EngineCRLink{
 					Name:      prov.Name,
 					Namespace: prov.Namespace,
 				},
@@ -318,7 +329,7 @@ var _ = Describe("PostgresqlDatabase tests", func() {
 			},
 			Spec: postgresqlv1alpha1.PostgresqlDatabaseSpec{
 				Database: pgdbDBName,
-				EngineConfiguration: &common.CRLink{
+				EngineConfiguration: &common.EngineCRLink{
 					Name:      prov.Name,
 					Namespace: prov.Namespace,
 				},
@@ -402,7 +413,7 @@ var _ = Describe("PostgresqlDatabase tests", func() {
 			},
 			Spec: postgresqlv1alpha1.PostgresqlDatabaseSpec{
 				Database: pgdbDBName,
-				EngineConfiguration: &common.CRLink{
+				EngineConfiguration: &common.EngineCRLink{
 					Name:      prov.Name,
 					Namespace: prov.Namespace,
 				},
@@ -612,7 +623,7 @@ var _ = Describe("PostgresqlDatabase tests", func() {
 			},
 			Spec: postgresqlv1alpha1.PostgresqlDatabaseSpec{
 				Database: pgdbDBName,
-				EngineConfiguration: &common.CRLink{
+				EngineConfiguration: &common.EngineCRLink{
 					Name:      prov.Name,
 					Namespace: prov.Namespace,
 				},
@@ -693,7 +704,7 @@ var _ = Describe("PostgresqlDatabase tests", func() {
 			},
 			Spec: postgresqlv1alpha1.PostgresqlDatabaseSpec{
 				Database: pgdbDBName,
-				EngineConfiguration: &common.CRLink{
+				EngineConfiguration: &common.EngineCRLink{
 					Name:      prov.Name,
 					Namespace: prov.Namespace,
 				},
@@ -757,7 +768,7 @@ var _ = Describe("PostgresqlDatabase tests", func() {
 			},
 			Spec: postgresqlv1alpha1.PostgresqlDatabaseSpec{
 				Database: pgdbDBName,
-				EngineConfiguration: &common.CRLink{
+				EngineConfiguration: &common.EngineCRLink{
 					Name:      prov.Name,
 					Namespace: prov.Namespace,
 				},
@@ -825,7 +836,7 @@ var _ = Describe("PostgresqlDatabase tests", func() {
 			},
 			Spec: postgresqlv1alpha1.PostgresqlDatabaseSpec{
 				Database: pgdbDBName,
-				EngineConfiguration: &common.CRLink{
+				EngineConfiguration: &common.EngineCRLink{
 					Name:      prov.Name,
 					Namespace: prov.Namespace,
 				},
@@ -1006,7 +1017,7 @@ var _ = Describe("PostgresqlDatabase tests", func() {
 			},
 			Spec: postgresqlv1alpha1.PostgresqlDatabaseSpec{
 				Database: pgdbDBName,
-				EngineConfiguration: &common.CRLink{
+				EngineConfiguration: &common.EngineCRLink{
 					Name:      prov.Name,
 					Namespace: prov.Namespace,
 				},
@@ -1111,7 +1122,7 @@ var _ = Describe("PostgresqlDatabase tests", func() {
 			},
 			Spec: postgresqlv1alpha1.PostgresqlDatabaseSpec{
 				Database: pgdbDBName,
-				EngineConfiguration: &common.CRLink{
+				EngineConfiguration: &common.EngineCRLink{
 					Name:      prov.Name,
 					Namespace: prov.Namespace,
 				},
@@ -1220,7 +1231,7 @@ var _ = Describe("PostgresqlDatabase tests", func() {
 			},
 			Spec: postgresqlv1alpha1.PostgresqlDatabaseSpec{
 				Database: pgdbDBName,
-				EngineConfiguration: &common.CRLink{
+				EngineConfiguration: &common.EngineCRLink{
 					Name:      prov.Name,
 					Namespace: prov.Namespace,
 				},
@@ -1314,7 +1325,7 @@ var _ = Describe("PostgresqlDatabase tests", func() {
 			},
 			Spec: postgresqlv1alpha1.PostgresqlDatabaseSpec{
 				Database: pgdbDBName,
-				EngineConfiguration: &common.CRLink{
+				EngineConfiguration: &common.EngineCRLink{
 					Name:      prov.Name,
 					Namespace: prov.Namespace,
 				},
@@ -1379,7 +1390,7 @@ var _ = Describe("PostgresqlDatabase tests", func() {
 			},
 			Spec: postgresqlv1alpha1.PostgresqlDatabaseSpec{
 				Database: pgdbDBName,
-				EngineConfiguration: &common.CRLink{
+				EngineConfiguration: &common.EngineCRLink{
 					Name:      prov.Name,
 					Namespace: prov.Namespace,
 				},
@@ -1476,7 +1487,7 @@ var _ = Describe("PostgresqlDatabase tests", func() {
 			},
 			Spec: postgresqlv1alpha1.PostgresqlDatabaseSpec{
 				Database: pgdbDBName,
-				EngineConfiguration: &common.CRLink{
+				EngineConfiguration: &common.EngineCRLink{
 					Name:      prov.Name,
 					Namespace: prov.Namespace,
 				},
@@ -1545,7 +1556,7 @@ var _ = Describe("PostgresqlDatabase tests", func() {
 			},
 			Spec: postgresqlv1alpha1.PostgresqlDatabaseSpec{
 				Database: pgdbDBName,
-				EngineConfiguration: &common.CRLink{
+				EngineConfiguration: &common.EngineCRLink{
 					Name:      prov.Name,
 					Namespace: prov.Namespace,
 				},
@@ -1637,7 +1648,7 @@ var _ = Describe("PostgresqlDatabase tests", func() {
 			},
 			Spec: postgresqlv1alpha1.PostgresqlDatabaseSpec{
 				Database: pgdbDBName,
-				EngineConfiguration: &common.CRLink{
+				EngineConfiguration: &common.EngineCRLink{
 					Name:      prov.Name,
 					Namespace: prov.Namespace,
 				},
@@ -1729,7 +1740,7 @@ var _ = Describe("PostgresqlDatabase tests", func() {
 			},
 			Spec: postgresqlv1alpha1.PostgresqlDatabaseSpec{
 				Database: pgdbDBName,
-				EngineConfiguration: &common.CRLink{
+				EngineConfiguration: &common.EngineCRLink{
 					Name:      prov.Name,
 					Namespace: prov.Namespace,
 				},
@@ -1824,7 +1835,7 @@ var _ = Describe("PostgresqlDatabase tests", func() {
 			},
 			Spec: postgresqlv1alpha1.PostgresqlDatabaseSpec{
 				Database: pgdbDBName,
-				EngineConfiguration: &common.CRLink{
+				EngineConfiguration: &common.EngineCRLink{
 					Name:      prov.Name,
 					Namespace: prov.Namespace,
 				},
@@ -1892,7 +1903,7 @@ var _ = Describe("PostgresqlDatabase tests", func() {
 			},
 			Spec: postgresqlv1alpha1.PostgresqlDatabaseSpec{
 				Database: pgdbDBName,
-				EngineConfiguration: &common.CRLink{
+				EngineConfiguration: &common.EngineCRLink{
 					Name:      prov.Name,
 					Namespace: prov.Namespace,
 				},
@@ -1993,7 +2004,7 @@ var _ = Describe("PostgresqlDatabase tests", func() {
 			},
 			Spec: postgresqlv1alpha1.PostgresqlDatabaseSpec{
 				Database: pgdbDBName,
-				EngineConfiguration: &common.CRLink{
+				EngineConfiguration: &common.EngineCRLink{
 					Name:      prov.Name,
 					Namespace: prov.Namespace,
 				},
@@ -2090,7 +2101,7 @@ var _ = Describe("PostgresqlDatabase tests", func() {
 			},
 			Spec: postgresqlv1alpha1.PostgresqlDatabaseSpec{
 				Database: pgdbDBName + "-old",
-				EngineConfiguration: &common.CRLink{
+				EngineConfiguration: &common.EngineCRLink{
 					Name:      prov.Name,
 					Namespace: prov.Namespace,
 				},
@@ -2173,6 +2184,604 @@ var _ = Describe("PostgresqlDatabase tests", func() {
 		Expect(oldExists).To(BeFalse())
 	})
 
+	It("should be ok to create database with creation options", func() {
+		// Create pgec
+		prov, _ := setupPGEC("10s", false)
+
+		// Create pgdb
+		it := &postgresqlv1alpha1.PostgresqlDatabase{
+			ObjectMeta: v1.ObjectMeta{
+				Name:      pgdbName,
+				Namespace: pgdbNamespace,
+			},
+			Spec: postgresqlv1alpha1.PostgresqlDatabaseSpec{
+				Database: pgdbDBName,
+				EngineConfiguration: &common.EngineCRLink{
+					Name:      prov.Name,
+					Namespace: prov.Namespace,
+				},
+				CreationOptions: &postgresqlv1alpha1.DatabaseCreationOptions{
+					Encoding: "SQL_ASCII",
+					Locale:   "C",
+					Template: "template0",
+				},
+				DropOnDelete: true,
+			},
+		}
+
+		// Create provider
+		Expect(k8sClient.Create(ctx, it)).Should(Succeed())
+
+		item := &postgresqlv1alpha1.PostgresqlDatabase{}
+		// Get updated pgdb
+		Eventually(
+			func() error {
+				err := k8sClient.Get(ctx, types.NamespacedName{
+					Name:      pgdbName,
+					Namespace: pgdbNamespace,
+				}, item)
+				// Check error
+				if err != nil {
+					return err
+				}
+
+				// Check if status hasn't been updated
+				if item.Status.Phase == postgresqlv1alpha1.DatabaseNoPhase {
+					return errors.New("pgdb hasn't been updated by operator")
+				}
+
+				return nil
+			},
+			generalEventuallyTimeout,
+			generalEventuallyInterval,
+		).
+			Should(Succeed())
+
+		// Checks
+		Expect(item.Status.Ready).To(BeTrue())
+		Expect(item.Status.Phase).To(Equal(postgresqlv1alpha1.DatabaseCreatedPhase))
+		Expect(item.Status.CreationOptions).To(Equal(&postgresqlv1alpha1.DatabaseCreationOptionsStatus{
+			Encoding:       "SQL_ASCII",
+			Locale:         "C",
+			LocaleProvider: postgres.LibcLocaleProvider,
+			Template:       "template0",
+			Tablespace:     "pg_default",
+		}))
+
+		// Check options in sql db
+		options, err := getSQLDBCreationOptions(pgdbDBName)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(options).To(Equal(&DatabaseCreationOptionsResult{
+			Encoding:   "SQL_ASCII",
+			Collate:    "C",
+			Ctype:      "C",
+			Tablespace: "pg_default",
+		}))
+	})
+
+	It("should be ok to report creation options drifts on an existing PG database", func() {
+		// Create SQL db
+		errDB := createSQLDB(pgdbDBName, postgresUser)
+		Expect(errDB).ToNot(HaveOccurred())
+
+		// Get effective options
+		options, err := getSQLDBCreationOptions(pgdbDBName)
+		Expect(err).ToNot(HaveOccurred())
+
+		// Create pgec
+		prov, _ := setupPGEC("10s", false)
+
+		// Create pgdb
+		it := &postgresqlv1alpha1.PostgresqlDatabase{
+			ObjectMeta: v1.ObjectMeta{
+				Name:      pgdbName,
+				Namespace: pgdbNamespace,
+			},
+			Spec: postgresqlv1alpha1.PostgresqlDatabaseSpec{
+				Database: pgdbDBName,
+				EngineConfiguration: &common.EngineCRLink{
+					Name:      prov.Name,
+					Namespace: prov.Namespace,
+				},
+				CreationOptions: &postgresqlv1alpha1.DatabaseCreationOptions{
+					Encoding:   options.Encoding,
+					Tablespace: "fast",
+				},
+				DropOnDelete: true,
+			},
+		}
+
+		// Create provider
+		Expect(k8sClient.Create(ctx, it)).Should(Succeed())
+
+		item := &postgresqlv1alpha1.PostgresqlDatabase{}
+		// Get updated pgdb
+		Eventually(
+			func() error {
+				err := k8sClient.Get(ctx, types.NamespacedName{
+					Name:      pgdbName,
+					Namespace: pgdbNamespace,
+				}, item)
+				// Check error
+				if err != nil {
+					return err
+				}
+
+				// Check if status hasn't been updated
+				if item.Status.Phase == postgresqlv1alpha1.DatabaseNoPhase {
+					return errors.New("pgdb hasn't been updated by operator")
+				}
+
+				return nil
+			},
+			generalEventuallyTimeout,
+			generalEventuallyInterval,
+		).
+			Should(Succeed())
+
+		// Checks
+		// Existing database isn't recreated, only drifts are reported
+		Expect(item.Status.Ready).To(BeTrue())
+		Expect(item.Status.Phase).To(Equal(postgresqlv1alpha1.DatabaseCreatedPhase))
+		Expect(item.Status.CreationOptions.Encoding).To(Equal(options.Encoding))
+		Expect(item.Status.CreationOptions.Template).To(BeEmpty())
+		Expect(item.Status.CreationOptions.Drifts).To(Equal([]string{"tablespace is pg_default instead of fast"}))
+	})
+
+	It("should be ok to set and reset database and group role settings", func() {
+		// Create pgec
+		prov, _ := setupPGEC("10s", false)
+
+		// Create pgdb
+		it := &postgresqlv1alpha1.PostgresqlDatabase{
+			ObjectMeta: v1.ObjectMeta{
+				Name:      pgdbName,
+				Namespace: pgdbNamespace,
+			},
+			Spec: postgresqlv1alpha1.PostgresqlDatabaseSpec{
+				Database: pgdbDBName,
+				EngineConfiguration: &common.EngineCRLink{
+					Name:      prov.Name,
+					Namespace: prov.Namespace,
+				},
+				Settings: map[string]string{
+					"statement_timeout": "30s",
+					"work_mem":          "8MB",
+					"search_path":       "$user, public",
+				},
+				RoleSettings: &postgresqlv1alpha1.DatabaseRoleSettings{
+					Reader: map[string]string{"default_transaction_read_only": "on"},
+				},
+				DropOnDelete: true,
+			},
+		}
+
+		// Create provider
+		Expect(k8sClient.Create(ctx, it)).Should(Succeed())
+
+		item := &postgresqlv1alpha1.PostgresqlDatabase{}
+		// Get updated pgdb
+		Eventually(
+			func() error {
+				err := k8sClient.Get(ctx, types.NamespacedName{
+					Name:      pgdbName,
+					Namespace: pgdbNamespace,
+				}, item)
+				// Check error
+				if err != nil {
+					return err
+				}
+
+				// Check if status hasn't been updated
+				if item.Status.Phase == postgresqlv1alpha1.DatabaseNoPhase {
+					return errors.New("pgdb hasn't been updated by operator")
+				}
+
+				return nil
+			},
+			generalEventuallyTimeout,
+			generalEventuallyInterval,
+		).
+			Should(Succeed())
+
+		// Checks
+		Expect(item.Status.Ready).To(BeTrue())
+		Expect(item.Status.Settings).To(Equal(it.Spec.Settings))
+		Expect(item.Status.RoleSettings).To(Equal(it.Spec.RoleSettings))
+
+		// Check settings in sql db
+		settings, err := getSQLDBRoleSettings(pgdbDBName, "")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(settings).To(Equal(map[string]string{"statement_timeout": "30s", "work_mem": "8MB", "search_path": `"$user", public`}))
+
+		readerSettings, err := getSQLDBRoleSettings(pgdbDBName, item.Status.Roles.Reader)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(readerSettings).To(Equal(map[string]string{"default_transaction_read_only": "on"}))
+
+		// Remove settings
+		item.Spec.Settings = map[string]string{"work_mem": "8MB"}
+		item.Spec.RoleSettings = nil
+
+		Expect(k8sClient.Update(ctx, item)).Should(Succeed())
+
+		updatedItem := &postgresqlv1alpha1.PostgresqlDatabase{}
+		// Get updated pgdb
+		Eventually(
+			func() error {
+				err := k8sClient.Get(ctx, types.NamespacedName{
+					Name:      pgdbName,
+					Namespace: pgdbNamespace,
+				}, updatedItem)
+				// Check error
+				if err != nil {
+					return err
+				}
+
+				// Check if settings has been updated in pgdb
+				if !reflect.DeepEqual(updatedItem.Status.Settings, map[string]string{"work_mem": "8MB"}) || updatedItem.Status.RoleSettings != nil {
+					return errors.New("pgdb hasn't been updated by operator")
+				}
+
+				return nil
+			},
+			generalEventuallyTimeout,
+			generalEventuallyInterval,
+		).
+			Should(Succeed())
+
+		Expect(updatedItem.Status.Ready).To(BeTrue())
+
+		// Check settings have been reset in sql db
+		settings, err = getSQLDBRoleSettings(pgdbDBName, "")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(settings).To(Equal(map[string]string{"work_mem": "8MB"}))
+
+		readerSettings, err = getSQLDBRoleSettings(pgdbDBName, updatedItem.Status.Roles.Reader)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(readerSettings).To(BeEmpty())
+	})
+
+	It("should be ok to manage connection limit and allowed connections", func() {
+		// Create pgec
+		prov, _ := setupPGEC("10s", false)
+
+		// Create pgdb
+		it := &postgresqlv1alpha1.PostgresqlDatabase{
+			ObjectMeta: v1.ObjectMeta{
+				Name:      pgdbName,
+				Namespace: pgdbNamespace,
+			},
+			Spec: postgresqlv1alpha1.PostgresqlDatabaseSpec{
+				Database: pgdbDBName,
+				EngineConfiguration: &common.EngineCRLink{
+					Name:      prov.Name,
+					Namespace: prov.Namespace,
+				},
+				ConnectionLimit: starAny(10),
+				DropOnDelete:    true,
+			},
+		}
+
+		// Create provider
+		Expect(k8sClient.Create(ctx, it)).Should(Succeed())
+
+		item := &postgresqlv1alpha1.PostgresqlDatabase{}
+		// Get updated pgdb
+		Eventually(
+			func() error {
+				err := k8sClient.Get(ctx, types.NamespacedName{
+					Name:      pgdbName,
+					Namespace: pgdbNamespace,
+				}, item)
+				// Check error
+				if err != nil {
+					return err
+				}
+
+				// Check if status hasn't been updated
+				if item.Status.Phase == postgresqlv1alpha1.DatabaseNoPhase {
+					return errors.New("pgdb hasn't been updated by operator")
+				}
+
+				return nil
+			},
+			generalEventuallyTimeout,
+			generalEventuallyInterval,
+		).
+			Should(Succeed())
+
+		// Checks
+		Expect(item.Status.Ready).To(BeTrue())
+		Expect(item.Status.Connections).To(Equal(&postgresqlv1alpha1.DatabaseConnectionsStatus{
+			ConnectionLimit:  10,
+			AllowConnections: true,
+		}))
+
+		// Check connections in sql db
+		connectionLimit, allowConnections, err := getSQLDBConnections(pgdbDBName)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(connectionLimit).To(Equal(10))
+		Expect(allowConnections).To(BeTrue())
+
+		// Disable connections
+		item.Spec.AllowConnections = starAny(false)
+		item.Spec.TerminateExistingSessions = true
+
+		Expect(k8sClient.Update(ctx, item)).Should(Succeed())
+
+		updatedItem := &postgresqlv1alpha1.PostgresqlDatabase{}
+		// Get updated pgdb
+		Eventually(
+			func() error {
+				err := k8sClient.Get(ctx, types.NamespacedName{
+					Name:      pgdbName,
+					Namespace: pgdbNamespace,
+				}, updatedItem)
+				// Check error
+				if err != nil {
+					return err
+				}
+
+				// Check if connections has been updated in pgdb
+				if updatedItem.Status.Connections == nil || updatedItem.Status.Connections.AllowConnections {
+					return errors.New("pgdb hasn't been updated by operator")
+				}
+
+				return nil
+			},
+			generalEventuallyTimeout,
+			generalEventuallyInterval,
+		).
+			Should(Succeed())
+
+		// Checks
+		Expect(updatedItem.Status.Ready).To(BeTrue())
+		Expect(updatedItem.Status.Reason).To(Equal(ConnectionsNotAllowedReason))
+
+		connectionLimit, allowConnections, err = getSQLDBConnections(pgdbDBName)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(connectionLimit).To(Equal(10))
+		Expect(allowConnections).To(BeFalse())
+
+		// Remove connection configuration from spec
+		updatedItem.Spec.ConnectionLimit = nil
+		updatedItem.Spec.AllowConnections = nil
+
+		Expect(k8sClient.Update(ctx, updatedItem)).Should(Succeed())
+
+		// Get updated pgdb
+		Eventually(
+			func() error {
+				err := k8sClient.Get(ctx, types.NamespacedName{
+					Name:      pgdbName,
+					Namespace: pgdbNamespace,
+				}, updatedItem)
+				// Check error
+				if err != nil {
+					return err
+				}
+
+				// Check if connections has been updated in pgdb
+				if updatedItem.Status.Connections == nil || !updatedItem.Status.Connections.AllowConnections {
+					return errors.New("pgdb hasn't been updated by operator")
+				}
+
+				return nil
+			},
+			generalEventuallyTimeout,
+			generalEventuallyInterval,
+		).
+			Should(Succeed())
+
+		// Engine defaults must be restored
+		Expect(updatedItem.Status.Ready).To(BeTrue())
+		Expect(updatedItem.Status.Reason).To(BeEmpty())
+		Expect(updatedItem.Status.Connections.ConnectionLimit).To(Equal(postgres.UnlimitedConnectionLimit))
+
+		connectionLimit, allowConnections, err = getSQLDBConnections(pgdbDBName)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(connectionLimit).To(Equal(postgres.UnlimitedConnectionLimit))
+		Expect(allowConnections).To(BeTrue())
+	})
+
+	It("should be ok to back-fill privileges on sequences, functions and types of an existing PG database", func() {
+		// Create SQL db
+		errDB := createSQLDB(pgdbDBName, postgresUser)
+		Expect(errDB).ToNot(HaveOccurred())
+
+		// Create objects before operator manages database
+		// ? Note: Default privileges of PUBLIC on functions and types are revoked to check grants on roles
+		Expect(rawSQLQuery(`CREATE SEQUENCE public.myseq`)).To(Succeed())
+		Expect(rawSQLQuery(`CREATE FUNCTION public.myfunc() RETURNS integer LANGUAGE sql AS 'SELECT 1'`)).To(Succeed())
+		Expect(rawSQLQuery(`REVOKE EXECUTE ON FUNCTION public.myfunc() FROM PUBLIC`)).To(Succeed())
+		Expect(createTypeInSchemaAsAdmin(pgPublicSchemaName, "mytype")).To(Succeed())
+		Expect(rawSQLQuery(`REVOKE USAGE ON TYPE public.mytype FROM PUBLIC`)).To(Succeed())
+
+		// Create pgec
+		setupPGEC("10s", false)
+
+		// Create pgdb
+		item := setupPGDB(false)
+
+		// Checks
+		Expect(item.Status.Ready).To(BeTrue())
+		Expect(item.Status.Phase).To(Equal(postgresqlv1alpha1.DatabaseCreatedPhase))
+
+		// Check privileges on existing objects
+		for _, it := range []struct {
+			role       string
+			objectType string
+			object     string
+			privilege  string
+		}{
+			{role: item.Status.Roles.Reader, objectType: "sequence", object: "public.myseq", privilege: "SELECT"},
+			{role: item.Status.Roles.Reader, objectType: "function", object: "public.myfunc()", privilege: "EXECUTE"},
+			{role: item.Status.Roles.Reader, objectType: "type", object: "public.mytype", privilege: "USAGE"},
+			{role: item.Status.Roles.Writer, objectType: "sequence", object: "public.myseq", privilege: "USAGE"},
+			{role: item.Status.Roles.Writer, objectType: "sequence", object: "public.myseq", privilege: "UPDATE"},
+			{role: item.Status.Roles.Writer, objectType: "function", object: "public.myfunc()", privilege: "EXECUTE"},
+			{role: item.Status.Roles.Writer, objectType: "type", object: "public.mytype", privilege: "USAGE"},
+		} {
+			granted, err := isSQLPrivilegeGranted(pgdbDBName, it.role, it.objectType, it.object, it.privilege)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(granted).To(BeTrue(), "%s must have %s on %s", it.role, it.privilege, it.object)
+		}
+
+		// Reader mustn't be able to update sequences
+		granted, err := isSQLPrivilegeGranted(pgdbDBName, item.Status.Roles.Reader, "sequence", "public.myseq", "UPDATE")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(granted).To(BeFalse())
+
+		// Check default privileges on future objects
+		for _, role := range []string{item.Status.Roles.Reader, item.Status.Roles.Writer} {
+			objectTypes, err := getSQLDefaultPrivilegeObjectTypes(pgdbDBName, role)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(objectTypes).To(ConsistOf("r", "S", "f", "T"))
+		}
+	})
+
+	It("should be ok to manage privilege profile roles", func() {
+		// Create SQL db
+		errDB := createSQLDB(pgdbDBName, postgresUser)
+		Expect(errDB).ToNot(HaveOccurred())
+
+		// Create table before operator manages database
+		Expect(createTableInSchemaAsAdmin(pgPublicSchemaName, "tt")).To(Succeed())
+
+		// Create pgec
+		prov, _ := setupPGEC("10s", false)
+
+		// Create pgdb
+		it := &postgresqlv1alpha1.PostgresqlDatabase{
+			ObjectMeta: v1.ObjectMeta{
+				Name:      pgdbName,
+				Namespace: pgdbNamespace,
+			},
+			Spec: postgresqlv1alpha1.PostgresqlDatabaseSpec{
+				Database: pgdbDBName,
+				EngineConfiguration: &common.EngineCRLink{
+					Name:      prov.Name,
+					Namespace: prov.Namespace,
+				},
+				PrivilegeProfiles: []*postgresqlv1alpha1.DatabasePrivilegeProfile{
+					{
+						Name:      "auditor",
+						Tables:    []postgresqlv1alpha1.TablePrivilege{"SELECT", "TRUNCATE"},
+						Sequences: []postgresqlv1alpha1.SequencePrivilege{"SELECT"},
+					},
+				},
+				DropOnDelete: true,
+			},
+		}
+
+		// Create provider
+		Expect(k8sClient.Create(ctx, it)).Should(Succeed())
+
+		item := &postgresqlv1alpha1.PostgresqlDatabase{}
+		// Get updated pgdb
+		Eventually(
+			func() error {
+				err := k8sClient.Get(ctx, types.NamespacedName{
+					Name:      pgdbName,
+					Namespace: pgdbNamespace,
+				}, item)
+				// Check error
+				if err != nil {
+					return err
+				}
+
+				// Check if status hasn't been updated
+				if item.Status.Phase == postgresqlv1alpha1.DatabaseNoPhase {
+					return errors.New("pgdb hasn't been updated by operator")
+				}
+
+				return nil
+			},
+			generalEventuallyTimeout,
+			generalEventuallyInterval,
+		).
+			Should(Succeed())
+
+		// Checks
+		auditorRole := fmt.Sprintf("%s-auditor", pgdbDBName)
+		Expect(item.Status.Ready).To(BeTrue())
+		Expect(item.Status.Roles.Profiles).To(Equal(map[string]string{"auditor": auditorRole}))
+
+		// Check profile role in DB
+		checkRoleInSQLDb(auditorRole)
+
+		// Check privileges on existing table
+		granted, err := isSQLPrivilegeGranted(pgdbDBName, auditorRole, "table", "public.tt", "TRUNCATE")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(granted).To(BeTrue())
+
+		granted, err = isSQLPrivilegeGranted(pgdbDBName, auditorRole, "table", "public.tt", "INSERT")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(granted).To(BeFalse())
+
+		// Check default privileges on future objects
+		objectTypes, err := getSQLDefaultPrivilegeObjectTypes(pgdbDBName, auditorRole)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(objectTypes).To(ConsistOf("r", "S"))
+
+		// Remove a privilege from profile
+		item.Spec.PrivilegeProfiles[0].Tables = []postgresqlv1alpha1.TablePrivilege{"SELECT"}
+
+		Expect(k8sClient.Update(ctx, item)).Should(Succeed())
+
+		// Privilege must be revoked
+		Eventually(
+			func() error {
+				granted, err := isSQLPrivilegeGranted(pgdbDBName, auditorRole, "table", "public.tt", "TRUNCATE")
+				// Check error
+				if err != nil {
+					return err
+				}
+
+				// Check privilege
+				if granted {
+					return errors.New("operator didn't revoke privilege")
+				}
+
+				return nil
+			},
+			generalEventuallyTimeout,
+			generalEventuallyInterval,
+		).
+			Should(Succeed())
+
+		// Remove profile
+		Expect(k8sClient.Get(ctx, types.NamespacedName{
+			Name:      pgdbName,
+			Namespace: pgdbNamespace,
+		}, item)).Should(Succeed())
+
+		item.Spec.PrivilegeProfiles = nil
+
+		Expect(k8sClient.Update(ctx, item)).Should(Succeed())
+
+		// Profile role must be dropped
+		Eventually(
+			func() error {
+				exists, err := isSQLRoleExists(auditorRole)
+				// Check error
+				if err != nil {
+					return err
+				}
+
+				// Check role
+				if exists {
+					return errors.New("operator didn't drop profile role")
+				}
+
+				return nil
+			},
+			generalEventuallyTimeout,
+			generalEventuallyInterval,
+		).
+			Should(Succeed())
+	})
+
 	It("should be ok to delete it with wait and nothing linked", func() {
 		// Create pgec
 		setupPGEC("10s", false)
@@ -2430,3 +3039,608 @@ var _ = Describe("PostgresqlDatabase tests", func() {
 		Expect(exists).To(BeFalse())
 	})
 })
+
+var _ = Describe("PostgresqlDatabase tests with fake engine", Label(fakeEngineLabel), func() {
+	It("should wait for linked subscriptions deletion before dropping database", func() {
+		pgdb := newFakePGDB()
+		pgdb.Spec.DropOnDelete = true
+		pgdb.Spec.WaitLinkedResourcesDeletion = true
+
+		sub := &postgresqlv1alpha1.PostgresqlSubscription{
+			ObjectMeta: v1.ObjectMeta{Name: pgsubscriptionName, Namespace: pgsubscriptionNamespace},
+			Spec: postgresqlv1alpha1.PostgresqlSubscriptionSpec{
+				Publication: &common.CRLink{Name: pgpublicationName, Namespace: pgpublicationNamespace},
+				Database:    &common.CRLink{Name: pgdbName, Namespace: pgdbNamespace},
+				Name:        pgsubscriptionSubscriptionName1,
+			},
+		}
+
+		cl, fakePG, factory := setupFakeEnv(pgdb, sub)
+		r := newFakePGDBReconciler(cl, factory)
+
+		Expect(reconcileFakeUntilStable(r, pgdbName, pgdbNamespace)).To(Succeed())
+		Expect(fakePG.Databases).To(HaveKey(pgdbDBName))
+
+		item := &postgresqlv1alpha1.PostgresqlDatabase{}
+		Expect(cl.Get(context.TODO(), types.NamespacedName{Name: pgdbName, Namespace: pgdbNamespace}, item)).To(Succeed())
+
+		// Delete
+		Expect(cl.Delete(context.TODO(), item)).To(Succeed())
+
+		_, err := r.Reconcile(context.TODO(), ctrl.Request{NamespacedName: types.NamespacedName{Name: pgdbName, Namespace: pgdbNamespace}})
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(Equal(
+			"cannot remove resource because found subscription " + pgsubscriptionName + " in namespace " + pgsubscriptionNamespace +
+				" linked to this resource and wait for deletion flag is enabled",
+		))
+
+		// Checks
+		Expect(cl.Get(context.TODO(), types.NamespacedName{Name: pgdbName, Namespace: pgdbNamespace}, item)).To(Succeed())
+		Expect(item.Finalizers).To(ContainElement(config.Finalizer))
+		Expect(fakePG.Databases).To(HaveKey(pgdbDBName))
+
+		// Delete subscription
+		Expect(cl.Delete(context.TODO(), sub)).To(Succeed())
+
+		_, err = r.Reconcile(context.TODO(), ctrl.Request{NamespacedName: types.NamespacedName{Name: pgdbName, Namespace: pgdbNamespace}})
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(fakePG.Databases).NotTo(HaveKey(pgdbDBName))
+	})
+
+	It("should create database, roles, schemas and extensions", func() {
+		cl, fakePG, factory := setupFakeEnv(newFakePGDB())
+		r := newFakePGDBReconciler(cl, factory)
+
+		Expect(reconcileFakeUntilStable(r, pgdbName, pgdbNamespace)).To(Succeed())
+
+		item := &postgresqlv1alpha1.PostgresqlDatabase{}
+		Expect(cl.Get(context.TODO(), types.NamespacedName{Name: pgdbName, Namespace: pgdbNamespace}, item)).To(Succeed())
+
+		// Checks
+		Expect(item.Finalizers).To(ContainElement(config.Finalizer))
+		Expect(item.Status.Ready).To(BeTrue())
+		Expect(item.Status.Phase).To(Equal(postgresqlv1alpha1.DatabaseCreatedPhase))
+		Expect(item.Status.Database).To(Equal(pgdbDBName))
+		Expect(item.Status.Schemas).To(Equal([]string{pgdbSchemaName1}))
+		Expect(item.Status.Extensions).To(Equal([]string{pgdbExtensionName1}))
+
+		// Engine checks
+		owner := pgdbDBName + "-owner"
+		Expect(fakePG.Databases).To(HaveKey(pgdbDBName))
+		Expect(fakePG.Databases[pgdbDBName].Owner).To(Equal(owner))
+		Expect(fakePG.Databases[pgdbDBName].Schemas).To(HaveKeyWithValue(pgdbSchemaName1, owner))
+		Expect(fakePG.Databases[pgdbDBName].Extensions).To(HaveKey(pgdbExtensionName1))
+		Expect(fakePG.Roles).To(HaveKey(owner))
+		Expect(fakePG.Roles).To(HaveKey(pgdbDBName + "-reader"))
+		Expect(fakePG.Roles).To(HaveKey(pgdbDBName + "-writer"))
+		Expect(fakePG.Databases[pgdbDBName].SchemaPrivileges[pgdbSchemaName1]).To(HaveKey(pgdbDBName + "-reader"))
+		Expect(fakePG.Databases[pgdbDBName].SchemaPrivileges[pgdbSchemaName1]).To(HaveKey(pgdbDBName + "-writer"))
+	})
+
+	It("should report engine errors in status", func() {
+		cl, fakePG, factory := setupFakeEnv(newFakePGDB())
+		r := newFakePGDBReconciler(cl, factory)
+
+		// Inject insufficient privilege error
+		fakePG.InjectPqError("CreateDB", pq.ErrorCode("42501"))
+
+		err := reconcileFakeUntilStable(r, pgdbName, pgdbNamespace)
+		Expect(err).To(HaveOccurred())
+		// Engine error must stay reachable behind kubernetes error
+		Expect(apimachineryErrors.IsInternalError(err)).To(BeTrue())
+		var pqErr *pq.Error
+		Expect(errors.As(err, &pqErr)).To(BeTrue())
+		Expect(pqErr.Code).To(Equal(pq.ErrorCode("42501")))
+
+		item := &postgresqlv1alpha1.PostgresqlDatabase{}
+		Expect(cl.Get(context.TODO(), types.NamespacedName{Name: pgdbName, Namespace: pgdbNamespace}, item)).To(Succeed())
+
+		// Checks
+		Expect(item.Status.Ready).To(BeFalse())
+		Expect(item.Status.Phase).To(Equal(postgresqlv1alpha1.DatabaseFailedPhase))
+		Expect(item.Status.Message).To(ContainSubstring("injected error"))
+		Expect(item.Status.Reason).To(Equal(string(v1.StatusReasonInternalError)))
+		Expect(fakePG.Databases).NotTo(HaveKey(pgdbDBName))
+
+		// Clear and retry
+		fakePG.ClearInjectedErrors()
+
+		Expect(reconcileFakeUntilStable(r, pgdbName, pgdbNamespace)).To(Succeed())
+		Expect(cl.Get(context.TODO(), types.NamespacedName{Name: pgdbName, Namespace: pgdbNamespace}, item)).To(Succeed())
+
+		Expect(item.Status.Ready).To(BeTrue())
+		Expect(fakePG.Databases).To(HaveKey(pgdbDBName))
+	})
+
+	It("should report statements without changing engine in plan mode", func() {
+		pgdb := newFakePGDB()
+		pgdb.Annotations = map[string]string{config.PlanModeAnnotation: "true"}
+
+		cl, fakePG, factory := setupFakeEnv(pgdb)
+		r := newFakePGDBReconciler(cl, factory)
+
+		Expect(reconcileFakeUntilStable(r, pgdbName, pgdbNamespace)).To(Succeed())
+
+		item := &postgresqlv1alpha1.PostgresqlDatabase{}
+		Expect(cl.Get(context.TODO(), types.NamespacedName{Name: pgdbName, Namespace: pgdbNamespace}, item)).To(Succeed())
+
+		// Checks
+		owner := pgdbDBName + "-owner"
+		Expect(item.Status.Ready).To(BeFalse())
+		Expect(item.Status.Phase).To(Equal(postgresqlv1alpha1.DatabaseNoPhase))
+		Expect(item.Status.Database).To(BeEmpty())
+		Expect(item.Status.Plan).NotTo(BeNil())
+		Expect(item.Status.Plan.LastPlanTime).NotTo(BeEmpty())
+		Expect(item.Status.Plan.PendingStatements).To(ContainElements(
+			"CreateGroupRole("+owner+")",
+			"CreateDB("+pgdbDBName+", "+owner+")",
+			"CreateSchema("+pgdbDBName+", "+owner+", "+pgdbSchemaName1+")",
+			"CreateExtension("+pgdbDBName+", "+pgdbExtensionName1+")",
+		))
+
+		// Engine checks
+		Expect(fakePG.Databases).NotTo(HaveKey(pgdbDBName))
+		Expect(fakePG.Roles).NotTo(HaveKey(owner))
+		// Read only queries must have been executed
+		Expect(fakePG.HasCall("IsDatabaseExist")).To(BeTrue())
+		Expect(fakePG.HasCall("GetTablesInSchema")).To(BeTrue())
+
+		// Disable plan mode
+		item.Annotations = nil
+		Expect(cl.Update(context.TODO(), item)).To(Succeed())
+		fakePG.DisablePlanMode()
+
+		Expect(reconcileFakeUntilStable(r, pgdbName, pgdbNamespace)).To(Succeed())
+		Expect(cl.Get(context.TODO(), types.NamespacedName{Name: pgdbName, Namespace: pgdbNamespace}, item)).To(Succeed())
+
+		Expect(item.Status.Ready).To(BeTrue())
+		Expect(item.Status.Plan).To(BeNil())
+		Expect(fakePG.Databases).To(HaveKey(pgdbDBName))
+	})
+
+	It("should fail when engine configuration doesn't allow database namespace", func() {
+		cl, fakePG, factory := setupFakeEnv(
+			newFakePGDB(),
+			&corev1.Namespace{ObjectMeta: v1.ObjectMeta{Name: pgdbNamespace, Labels: map[string]string{"tenant": "allowed"}}},
+		)
+		fakePG.Capabilities.AvailableExtensions = []string{pgdbExtensionName1}
+
+		// Restrict engine configuration references
+		pgec := &postgresqlv1alpha1.PostgresqlEngineConfiguration{}
+		Expect(cl.Get(context.TODO(), types.NamespacedName{Name: pgecName, Namespace: pgecNamespace}, pgec)).To(Succeed())
+		pgec.Spec.AllowedNamespaces = []string{"other-ns"}
+		Expect(cl.Update(context.TODO(), pgec)).To(Succeed())
+
+		r := newFakePGDBReconciler(cl, factory)
+
+		Expect(reconcileFakeUntilStable(r, pgdbName, pgdbNamespace)).NotTo(Succeed())
+
+		item := &postgresqlv1alpha1.PostgresqlDatabase{}
+		Expect(cl.Get(context.TODO(), types.NamespacedName{Name: pgdbName, Namespace: pgdbNamespace}, item)).To(Succeed())
+
+		// Checks
+		Expect(item.Status.Ready).To(BeFalse())
+		Expect(item.Status.Reason).To(Equal(string(v1.StatusReasonForbidden)))
+		Expect(item.Status.Message).To(ContainSubstring("namespace " + pgdbNamespace + " isn't allowed to reference it"))
+		Expect(fakePG.Databases).NotTo(HaveKey(pgdbDBName))
+
+		// Allow database namespace with a selector
+		Expect(cl.Get(context.TODO(), types.NamespacedName{Name: pgecName, Namespace: pgecNamespace}, pgec)).To(Succeed())
+		pgec.Spec.AllowedNamespaceSelector = &v1.LabelSelector{MatchLabels: map[string]string{"tenant": "allowed"}}
+		Expect(cl.Update(context.TODO(), pgec)).To(Succeed())
+
+		Expect(reconcileFakeUntilStable(r, pgdbName, pgdbNamespace)).To(Succeed())
+
+		Expect(cl.Get(context.TODO(), types.NamespacedName{Name: pgdbName, Namespace: pgdbNamespace}, item)).To(Succeed())
+		Expect(item.Status.Ready).To(BeTrue())
+		Expect(item.Status.Reason).To(BeEmpty())
+		Expect(fakePG.Databases).To(HaveKey(pgdbDBName))
+	})
+
+	It("should retry after a read-only transaction error", func() {
+		cl, fakePG, factory := setupFakeEnv(newFakePGDB())
+		r := newFakePGDBReconciler(cl, factory)
+
+		// Inject read only transaction error as after a failover
+		fakePG.InjectError("CreateDB", postgrestest.NewFakePqError(
+			postgres.ReadOnlySQLTransactionErrorCode,
+			"cannot execute CREATE DATABASE in a read-only transaction",
+		))
+
+		Expect(reconcileFakeUntilStable(r, pgdbName, pgdbNamespace)).To(Succeed())
+
+		res, err := r.Reconcile(context.TODO(), ctrl.Request{NamespacedName: types.NamespacedName{Name: pgdbName, Namespace: pgdbNamespace}})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(res.RequeueAfter).To(Equal(ReadOnlyTransactionRequeueDelay))
+
+		item := &postgresqlv1alpha1.PostgresqlDatabase{}
+		Expect(cl.Get(context.TODO(), types.NamespacedName{Name: pgdbName, Namespace: pgdbNamespace}, item)).To(Succeed())
+
+		// Checks
+		Expect(item.Status.Ready).To(BeFalse())
+		Expect(item.Status.Phase).NotTo(Equal(postgresqlv1alpha1.DatabaseFailedPhase))
+		Expect(item.Status.Reason).To(Equal(ReadOnlyTransactionReason))
+		Expect(item.Status.Message).To(ContainSubstring("read-only transaction"))
+
+		// Clear and retry
+		fakePG.ClearInjectedErrors()
+
+		Expect(reconcileFakeUntilStable(r, pgdbName, pgdbNamespace)).To(Succeed())
+		Expect(cl.Get(context.TODO(), types.NamespacedName{Name: pgdbName, Namespace: pgdbNamespace}, item)).To(Succeed())
+
+		Expect(item.Status.Ready).To(BeTrue())
+		Expect(item.Status.Reason).To(BeEmpty())
+		Expect(fakePG.Databases).To(HaveKey(pgdbDBName))
+	})
+
+	It("should create database with creation options and report drifts", func() {
+		pgdb := newFakePGDB()
+		pgdb.Spec.CreationOptions = &postgresqlv1alpha1.DatabaseCreationOptions{
+			Encoding:   "utf-8",
+			Locale:     "fr_FR.utf8",
+			Template:   "template0",
+			Tablespace: "fast",
+		}
+
+		cl, fakePG, factory := setupFakeEnv(pgdb)
+		r := newFakePGDBReconciler(cl, factory)
+
+		Expect(reconcileFakeUntilStable(r, pgdbName, pgdbNamespace)).To(Succeed())
+
+		item := &postgresqlv1alpha1.PostgresqlDatabase{}
+		Expect(cl.Get(context.TODO(), types.NamespacedName{Name: pgdbName, Namespace: pgdbNamespace}, item)).To(Succeed())
+
+		// Checks
+		Expect(item.Status.Ready).To(BeTrue())
+		Expect(fakePG.Databases).To(HaveKey(pgdbDBName))
+		Expect(fakePG.Databases[pgdbDBName].CreationOptions.Locale).To(Equal("fr_FR.utf8"))
+		Expect(fakePG.Databases[pgdbDBName].CreationOptions.Tablespace).To(Equal("fast"))
+		Expect(item.Status.CreationOptions).To(Equal(&postgresqlv1alpha1.DatabaseCreationOptionsStatus{
+			Encoding:       "utf-8",
+			Locale:         "fr_FR.utf8",
+			LocaleProvider: postgres.LibcLocaleProvider,
+			Template:       "template0",
+			Tablespace:     "fast",
+		}))
+
+		// Change effective options as if database was changed outside of operator
+		fakePG.Databases[pgdbDBName].CreationOptions.Encoding = "UTF8"
+		fakePG.Databases[pgdbDBName].CreationOptions.Tablespace = "pg_default"
+
+		_, err := r.Reconcile(context.TODO(), ctrl.Request{NamespacedName: types.NamespacedName{Name: pgdbName, Namespace: pgdbNamespace}})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(cl.Get(context.TODO(), types.NamespacedName{Name: pgdbName, Namespace: pgdbNamespace}, item)).To(Succeed())
+
+		// Encoding is the same, only tablespace drifted
+		Expect(item.Status.Ready).To(BeTrue())
+		Expect(item.Status.CreationOptions.Template).To(Equal("template0"))
+		Expect(item.Status.CreationOptions.Drifts).To(Equal([]string{"tablespace is pg_default instead of fast"}))
+	})
+
+	It("should reject creation options unsupported by engine", func() {
+		pgdb := newFakePGDB()
+		pgdb.Spec.CreationOptions = &postgresqlv1alpha1.DatabaseCreationOptions{
+			LocaleProvider: postgres.ICULocaleProvider,
+			ICULocale:      "fr-FR",
+		}
+
+		cl, fakePG, factory := setupFakeEnv(pgdb)
+
+		// Save capabilities of a PostgreSQL 14 engine
+		pgec := &postgresqlv1alpha1.PostgresqlEngineConfiguration{}
+		Expect(cl.Get(context.TODO(), types.NamespacedName{Name: pgecName, Namespace: pgecNamespace}, pgec)).To(Succeed())
+		pgec.Status.Capabilities = &postgresqlv1alpha1.EngineCapabilities{ServerVersionNum: 140009}
+		Expect(cl.Status().Update(context.TODO(), pgec)).To(Succeed())
+
+		r := newFakePGDBReconciler(cl, factory)
+
+		Expect(reconcileFakeUntilStable(r, pgdbName, pgdbNamespace)).NotTo(Succeed())
+
+		item := &postgresqlv1alpha1.PostgresqlDatabase{}
+		Expect(cl.Get(context.TODO(), types.NamespacedName{Name: pgdbName, Namespace: pgdbNamespace}, item)).To(Succeed())
+
+		// Checks
+		Expect(item.Status.Phase).To(Equal(postgresqlv1alpha1.DatabaseFailedPhase))
+		Expect(item.Status.Message).To(ContainSubstring("requires PostgreSQL 15.0 or later"))
+		Expect(fakePG.Databases).NotTo(HaveKey(pgdbDBName))
+	})
+
+	It("should set and reset database and group role settings", func() {
+		pgdb := newFakePGDB()
+		pgdb.Spec.Settings = map[string]string{
+			"statement_timeout": "30s",
+			"search_path":       `"$user", public`,
+		}
+		pgdb.Spec.RoleSettings = &postgresqlv1alpha1.DatabaseRoleSettings{
+			Reader: map[string]string{"default_transaction_read_only": "on"},
+		}
+
+		cl, fakePG, factory := setupFakeEnv(pgdb)
+		r := newFakePGDBReconciler(cl, factory)
+
+		Expect(reconcileFakeUntilStable(r, pgdbName, pgdbNamespace)).To(Succeed())
+
+		item := &postgresqlv1alpha1.PostgresqlDatabase{}
+		Expect(cl.Get(context.TODO(), types.NamespacedName{Name: pgdbName, Namespace: pgdbNamespace}, item)).To(Succeed())
+
+		// Checks
+		Expect(item.Status.Ready).To(BeTrue())
+		Expect(fakePG.Databases[pgdbDBName].Settings).To(Equal(pgdb.Spec.Settings))
+		Expect(fakePG.Databases[pgdbDBName].RoleSettings[item.Status.Roles.Reader]).To(Equal(map[string]string{"default_transaction_read_only": "on"}))
+		Expect(item.Status.Settings).To(Equal(pgdb.Spec.Settings))
+		Expect(item.Status.RoleSettings).To(Equal(pgdb.Spec.RoleSettings))
+
+		// Setting engine stored value with normalized list mustn't change anything
+		fakePG.Databases[pgdbDBName].Settings["search_path"] = `"$user",public`
+		// Setting added outside of operator
+		fakePG.Databases[pgdbDBName].Settings["timezone"] = "UTC"
+
+		// Remove settings
+		item.Spec.Settings = map[string]string{"search_path": `"$user", public`}
+		item.Spec.RoleSettings = nil
+		Expect(cl.Update(context.TODO(), item)).To(Succeed())
+
+		calls := len(fakePG.Calls)
+		Expect(reconcileFakeUntilStable(r, pgdbName, pgdbNamespace)).To(Succeed())
+		Expect(cl.Get(context.TODO(), types.NamespacedName{Name: pgdbName, Namespace: pgdbNamespace}, item)).To(Succeed())
+
+		// Checks
+		Expect(item.Status.Ready).To(BeTrue())
+		Expect(fakePG.Calls[calls:]).To(ContainElement("ResetDatabaseSetting"))
+		Expect(fakePG.Calls[calls:]).NotTo(ContainElement("AlterDatabaseSetting"))
+		Expect(fakePG.Databases[pgdbDBName].Settings).To(Equal(map[string]string{"search_path": `"$user",public`, "timezone": "UTC"}))
+		Expect(fakePG.Databases[pgdbDBName].RoleSettings[item.Status.Roles.Reader]).To(BeEmpty())
+		Expect(item.Status.Settings).To(Equal(map[string]string{"search_path": `"$user", public`}))
+		Expect(item.Status.RoleSettings).To(BeNil())
+	})
+
+	It("should reject invalid setting names", func() {
+		pgdb := newFakePGDB()
+		pgdb.Spec.Settings = map[string]string{"statement_timeout = 0; DROP DATABASE postgres; --": "0"}
+
+		cl, fakePG, factory := setupFakeEnv(pgdb)
+		r := newFakePGDBReconciler(cl, factory)
+
+		Expect(reconcileFakeUntilStable(r, pgdbName, pgdbNamespace)).NotTo(Succeed())
+
+		item := &postgresqlv1alpha1.PostgresqlDatabase{}
+		Expect(cl.Get(context.TODO(), types.NamespacedName{Name: pgdbName, Namespace: pgdbNamespace}, item)).To(Succeed())
+
+		// Checks
+		Expect(item.Status.Phase).To(Equal(postgresqlv1alpha1.DatabaseFailedPhase))
+		Expect(item.Status.Message).To(ContainSubstring("is invalid"))
+		Expect(fakePG.Databases).NotTo(HaveKey(pgdbDBName))
+	})
+
+	It("should manage connection limit and allowed connections", func() {
+		pgdb := newFakePGDB()
+		pgdb.Spec.ConnectionLimit = lo.ToPtr(10)
+
+		cl, fakePG, factory := setupFakeEnv(pgdb)
+		r := newFakePGDBReconciler(cl, factory)
+
+		Expect(reconcileFakeUntilStable(r, pgdbName, pgdbNamespace)).To(Succeed())
+
+		item := &postgresqlv1alpha1.PostgresqlDatabase{}
+		Expect(cl.Get(context.TODO(), types.NamespacedName{Name: pgdbName, Namespace: pgdbNamespace}, item)).To(Succeed())
+
+		// Checks
+		Expect(item.Status.Ready).To(BeTrue())
+		Expect(fakePG.Databases[pgdbDBName].ConnectionLimit).To(Equal(10))
+		Expect(fakePG.Databases[pgdbDBName].AllowConnections).To(BeTrue())
+		Expect(item.Status.Connections).To(Equal(&postgresqlv1alpha1.DatabaseConnectionsStatus{
+			ConnectionLimit:  10,
+			AllowConnections: true,
+		}))
+
+		// Simulate connected sessions
+		fakePG.Databases[pgdbDBName].Sessions = 3
+
+		// Disable connections for a maintenance
+		item.Spec.AllowConnections = lo.ToPtr(false)
+		item.Spec.TerminateExistingSessions = true
+		Expect(cl.Update(context.TODO(), item)).To(Succeed())
+
+		calls := len(fakePG.Calls)
+		Expect(reconcileFakeUntilStable(r, pgdbName, pgdbNamespace)).To(Succeed())
+		Expect(cl.Get(context.TODO(), types.NamespacedName{Name: pgdbName, Namespace: pgdbNamespace}, item)).To(Succeed())
+
+		// Checks
+		Expect(item.Status.Ready).To(BeTrue())
+		Expect(fakePG.Databases[pgdbDBName].AllowConnections).To(BeFalse())
+		Expect(fakePG.Databases[pgdbDBName].Sessions).To(BeZero())
+		Expect(item.Status.Connections).To(Equal(&postgresqlv1alpha1.DatabaseConnectionsStatus{
+			ConnectionLimit:  10,
+			AllowConnections: false,
+		}))
+		// Schemas aren't managed without connections
+		Expect(fakePG.Calls[calls:]).NotTo(ContainElement("SetSchemaPrivileges"))
+		Expect(item.Status.Reason).To(Equal(ConnectionsNotAllowedReason))
+		Expect(item.Status.Message).To(ContainSubstring("haven't been reconciled"))
+
+		// Allow connections again
+		item.Spec.AllowConnections = lo.ToPtr(true)
+		Expect(cl.Update(context.TODO(), item)).To(Succeed())
+
+		Expect(reconcileFakeUntilStable(r, pgdbName, pgdbNamespace)).To(Succeed())
+		Expect(cl.Get(context.TODO(), types.NamespacedName{Name: pgdbName, Namespace: pgdbNamespace}, item)).To(Succeed())
+
+		Expect(item.Status.Ready).To(BeTrue())
+		Expect(item.Status.Reason).To(BeEmpty())
+		Expect(item.Status.Message).To(BeEmpty())
+		Expect(fakePG.Databases[pgdbDBName].AllowConnections).To(BeTrue())
+		Expect(item.Status.Connections.AllowConnections).To(BeTrue())
+
+		// Disable connections and remove them from spec
+		item.Spec.AllowConnections = lo.ToPtr(false)
+		Expect(cl.Update(context.TODO(), item)).To(Succeed())
+		Expect(reconcileFakeUntilStable(r, pgdbName, pgdbNamespace)).To(Succeed())
+		Expect(cl.Get(context.TODO(), types.NamespacedName{Name: pgdbName, Namespace: pgdbNamespace}, item)).To(Succeed())
+		Expect(fakePG.Databases[pgdbDBName].AllowConnections).To(BeFalse())
+
+		item.Spec.ConnectionLimit = nil
+		item.Spec.AllowConnections = nil
+		Expect(cl.Update(context.TODO(), item)).To(Succeed())
+
+		Expect(reconcileFakeUntilStable(r, pgdbName, pgdbNamespace)).To(Succeed())
+		Expect(cl.Get(context.TODO(), types.NamespacedName{Name: pgdbName, Namespace: pgdbNamespace}, item)).To(Succeed())
+
+		// Engine defaults must be restored
+		Expect(item.Status.Ready).To(BeTrue())
+		Expect(fakePG.Databases[pgdbDBName].ConnectionLimit).To(Equal(postgres.UnlimitedConnectionLimit))
+		Expect(fakePG.Databases[pgdbDBName].AllowConnections).To(BeTrue())
+		Expect(item.Status.Connections).To(Equal(&postgresqlv1alpha1.DatabaseConnectionsStatus{
+			ConnectionLimit:  postgres.UnlimitedConnectionLimit,
+			AllowConnections: true,
+		}))
+	})
+
+	It("should back-fill privileges on sequences, functions and types", func() {
+		cl, fakePG, factory := setupFakeEnv(newFakePGDB())
+		r := newFakePGDBReconciler(cl, factory)
+
+		Expect(reconcileFakeUntilStable(r, pgdbName, pgdbNamespace)).To(Succeed())
+
+		// Simulate privileges set by a previous version on tables only
+		owner := pgdbDBName + "-owner"
+		reader := pgdbDBName + "-reader"
+		writer := pgdbDBName + "-writer"
+		fakePG.Databases[pgdbDBName].SchemaPrivileges[pgdbSchemaName1][reader] = &postgrestest.FakeSchemaPrivilege{
+			Creator:    owner,
+			Privileges: postgres.SchemaPrivileges{Tables: "SELECT"},
+		}
+		fakePG.Databases[pgdbDBName].SchemaPrivileges[pgdbSchemaName1][writer] = &postgrestest.FakeSchemaPrivilege{
+			Creator:    owner,
+			Privileges: postgres.SchemaPrivileges{Tables: "SELECT,INSERT,DELETE,UPDATE"},
+		}
+
+		// Next reconcile
+		Expect(reconcileFakeUntilStable(r, pgdbName, pgdbNamespace)).To(Succeed())
+
+		item := &postgresqlv1alpha1.PostgresqlDatabase{}
+		Expect(cl.Get(context.TODO(), types.NamespacedName{Name: pgdbName, Namespace: pgdbNamespace}, item)).To(Succeed())
+
+		// Checks
+		Expect(item.Status.Ready).To(BeTrue())
+		Expect(fakePG.Databases[pgdbDBName].SchemaPrivileges[pgdbSchemaName1][reader].Privileges).To(Equal(postgres.SchemaPrivileges{
+			Tables:    "SELECT",
+			Sequences: "SELECT",
+			Functions: "EXECUTE",
+			Types:     "USAGE",
+		}))
+		Expect(fakePG.Databases[pgdbDBName].SchemaPrivileges[pgdbSchemaName1][writer].Privileges).To(Equal(postgres.SchemaPrivileges{
+			Tables:    "SELECT,INSERT,DELETE,UPDATE",
+			Sequences: "USAGE,SELECT,UPDATE",
+			Functions: "EXECUTE",
+			Types:     "USAGE",
+		}))
+	})
+
+	It("should manage privilege profile roles and privileges", func() {
+		pgdb := newFakePGDB()
+		pgdb.Spec.PrivilegeProfiles = []*postgresqlv1alpha1.DatabasePrivilegeProfile{
+			{
+				Name:      "auditor",
+				Tables:    []postgresqlv1alpha1.TablePrivilege{"SELECT", "TRUNCATE"},
+				Sequences: []postgresqlv1alpha1.SequencePrivilege{"SELECT"},
+			},
+		}
+
+		pgur := &postgresqlv1alpha1.PostgresqlUserRole{
+			ObjectMeta: v1.ObjectMeta{Name: pgurName, Namespace: pgurNamespace},
+			Spec: postgresqlv1alpha1.PostgresqlUserRoleSpec{
+				Mode:       postgresqlv1alpha1.ManagedMode,
+				RolePrefix: pgurRolePrefix,
+				Privileges: []*postgresqlv1alpha1.PostgresqlUserRolePrivilege{
+					{
+						Privilege:           postgresqlv1alpha1.ProfilePrivilege,
+						Profile:             "auditor",
+						Database:            &common.CRLink{Name: pgdbName, Namespace: pgdbNamespace},
+						GeneratedSecretName: pgurDBSecretName,
+					},
+				},
+			},
+		}
+
+		cl, fakePG, factory := setupFakeEnv(pgdb, pgur)
+		r := newFakePGDBReconciler(cl, factory)
+
+		Expect(reconcileFakeUntilStable(r, pgdbName, pgdbNamespace)).To(Succeed())
+
+		item := &postgresqlv1alpha1.PostgresqlDatabase{}
+		Expect(cl.Get(context.TODO(), types.NamespacedName{Name: pgdbName, Namespace: pgdbNamespace}, item)).To(Succeed())
+
+		// Checks
+		auditor := pgdbDBName + "-auditor"
+		Expect(item.Status.Ready).To(BeTrue())
+		Expect(item.Status.Roles.Profiles).To(Equal(map[string]string{"auditor": auditor}))
+		Expect(fakePG.Roles).To(HaveKey(auditor))
+		Expect(fakePG.Databases[pgdbDBName].SchemaPrivileges[pgdbSchemaName1][auditor].Privileges).To(Equal(postgres.SchemaPrivileges{
+			Tables:    "SELECT,TRUNCATE",
+			Sequences: "SELECT",
+		}))
+
+		// Remove a privilege from profile
+		item.Spec.PrivilegeProfiles[0].Tables = []postgresqlv1alpha1.TablePrivilege{"SELECT"}
+		Expect(cl.Update(context.TODO(), item)).To(Succeed())
+
+		Expect(reconcileFakeUntilStable(r, pgdbName, pgdbNamespace)).To(Succeed())
+
+		// Privilege must have been revoked
+		Expect(fakePG.Databases[pgdbDBName].SchemaPrivileges[pgdbSchemaName1][auditor].Privileges).To(Equal(postgres.SchemaPrivileges{
+			Tables:    "SELECT",
+			Sequences: "SELECT",
+		}))
+
+		// User role must be granted the profile role
+		ur := &PostgresqlUserRoleReconciler{
+			Client:                              cl,
+			Scheme:                              cl.Scheme(),
+			Recorder:                            record.NewFakeRecorder(100),
+			Log:                                 logr.Discard(),
+			ControllerRuntimeDetailedErrorTotal: newFakeCounter(),
+			ControllerName:                      "postgresqluserrole",
+			ReconcileTimeout:                    10 * time.Second,
+			PgInstanceFactory:                   factory,
+		}
+
+		Expect(reconcileFakeUntilStable(ur, pgurName, pgurNamespace)).To(Succeed())
+
+		login := pgurRolePrefix + Login0Suffix
+		Expect(fakePG.Memberships[login]).To(HaveKey(auditor))
+
+		// Remove profile
+		Expect(cl.Get(context.TODO(), types.NamespacedName{Name: pgdbName, Namespace: pgdbNamespace}, item)).To(Succeed())
+		item.Spec.PrivilegeProfiles = nil
+		Expect(cl.Update(context.TODO(), item)).To(Succeed())
+
+		Expect(reconcileFakeUntilStable(r, pgdbName, pgdbNamespace)).To(Succeed())
+
+		Expect(cl.Get(context.TODO(), types.NamespacedName{Name: pgdbName, Namespace: pgdbNamespace}, item)).To(Succeed())
+
+		// Profile role must have been dropped
+		Expect(item.Status.Roles.Profiles).To(BeNil())
+		Expect(fakePG.Roles).NotTo(HaveKey(auditor))
+	})
+
+	It("should reject reserved privilege profile names", func() {
+		pgdb := newFakePGDB()
+		pgdb.Spec.PrivilegeProfiles = []*postgresqlv1alpha1.DatabasePrivilegeProfile{
+			{Name: "writer", Tables: []postgresqlv1alpha1.TablePrivilege{"SELECT"}},
+		}
+
+		cl, fakePG, factory := setupFakeEnv(pgdb)
+		r := newFakePGDBReconciler(cl, factory)
+
+		Expect(reconcileFakeUntilStable(r, pgdbName, pgdbNamespace)).NotTo(Succeed())
+
+		item := &postgresqlv1alpha1.PostgresqlDatabase{}
+		Expect(cl.Get(context.TODO(), types.NamespacedName{Name: pgdbName, Namespace: pgdbNamespace}, item)).To(Succeed())
+
+		// Checks
+		Expect(item.Status.Phase).To(Equal(postgresqlv1alpha1.DatabaseFailedPhase))
+		Expect(item.Status.Message).To(ContainSubstring("is reserved"))
+		Expect(fakePG.Databases).NotTo(HaveKey(pgdbDBName))
+	})
+})
@@ -18,28 +18,43 @@ package postgresql
 
 import (
 	"context"
+	gerrors "errors"
 	"fmt"
 	"reflect"
+	"strings"
 	"time"
 
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
 
 	postgresqlv1alpha1 "github.com/easymile/postgresql-operator/api/postgresql/v1alpha1"
 	"github.com/easymile/postgresql-operator/internal/controller/config"
 	"github.com/easymile/postgresql-operator/internal/controller/postgresql/postgres"
 	"github.com/easymile/postgresql-operator/internal/controller/utils"
 	"github.com/go-logr/logr"
+	"github.com/lib/pq"
 	"github.com/prometheus/client_golang/prometheus"
+	"github.com/samber/lo"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 )
 
 const (
-	DefaultPGPort      = 5432
-	DefaultBouncerPort = 6432
+	DefaultPGPort                      = 5432
+	DefaultBouncerPort                 = 6432
+	DefaultAdminPasswordRotationLength = 32
+	// Secret key of a new admin password saved before being applied on engine.
+	AdminPendingPasswordSecretKey = "pendingPassword"
+	// Minimal delay before next check when exec credentials are about to expire.
+	MinExecCredentialsRefreshDelay = 5 * time.Second
 )
 
 // PostgresqlEngineConfigurationReconciler reconciles a PostgresqlEngineConfiguration object.
@@ -51,12 +66,13 @@ type PostgresqlEngineConfigurationReconciler struct {
 	Log                                 logr.Logger
 	ControllerName                      string
 	ReconcileTimeout                    time.Duration
+	PgInstanceFactory                   utils.PgInstanceFactory
 }
 
 //+kubebuilder:rbac:groups=postgresql.easymile.com,resources=postgresqlengineconfigurations,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups=postgresql.easymile.com,resources=postgresqlengineconfigurations/status,verbs=get;update;patch
 //+kubebuilder:rbac:groups=postgresql.easymile.com,resources=postgresqlengineconfigurations/finalizers,verbs=update
-//+kubebuilder:rbac:groups=core,resources=secrets,verbs=get;list;watch
+//+kubebuilder:rbac:groups=core,resources=secrets,verbs=get;list;watch;update
 //+kubebuilder:rbac:groups=core,resources=events,verbs=create;patch
 
 // Reconcile is part of the main kubernetes reconciliation loop which aims to
@@ -91,14 +107,37 @@ func (r *PostgresqlEngineConfigurationReconciler) Reconcile(ctx context.Context,
 		return ctrl.Result{}, err
 	}
 
+	return r.reconcileWithTimeout(ctx, reqLogger, instance)
+}
+
+// reconcileWithTimeout will run main reconcile on namespaced or cluster engine configuration with reconcile timeout.
+func (r *PostgresqlEngineConfigurationReconciler) reconcileWithTimeout(
+	ctx context.Context,
+	reqLogger logr.Logger,
+	instance postgresqlv1alpha1.EngineConfiguration,
+) (ctrl.Result, error) {
 	// Original patch
-	originalPatch := client.MergeFrom(instance.DeepCopy())
+	originalPatch := client.MergeFrom(instance.DeepCopyObject().(client.Object))
 
 	// Create timeout in ctx
 	timeoutCtx, cancel := context.WithTimeout(ctx, r.ReconcileTimeout)
 	// Defer cancel
 	defer cancel()
 
+	// Compute audit resource kind
+	kind := "PostgresqlEngineConfiguration"
+	if _, ok := instance.(*postgresqlv1alpha1.ClusterPostgresqlEngineConfiguration); ok {
+		kind = "ClusterPostgresqlEngineConfiguration"
+	}
+
+	// Add resource in context for audit
+	timeoutCtx = postgres.WithAuditResource(timeoutCtx, &postgres.AuditResource{
+		Kind:        kind,
+		Namespace:   instance.GetNamespace(),
+		Name:        instance.GetName(),
+		ReconcileID: string(controller.ReconcileIDFromContext(ctx)),
+	})
+
 	// Init result
 	var res ctrl.Result
 
@@ -129,14 +168,18 @@ func (r *PostgresqlEngineConfigurationReconciler) Reconcile(ctx context.Context,
 func (r *PostgresqlEngineConfigurationReconciler) mainReconcile(
 	ctx context.Context,
 	reqLogger logr.Logger,
-	instance *postgresqlv1alpha1.PostgresqlEngineConfiguration,
+	instance postgresqlv1alpha1.EngineConfiguration,
 	originalPatch client.Patch,
 ) (ctrl.Result, error) {
+	// Get spec and status
+	spec := instance.GetEngineSpec()
+	status := instance.GetEngineStatus()
+
 	// Deletion case
 	if !instance.GetDeletionTimestamp().IsZero() {
 		// Need to delete
 		// Check if wait linked resources deletion flag is enabled
-		if instance.Spec.WaitLinkedResourcesDeletion {
+		if spec.WaitLinkedResourcesDeletion {
 			// Check if there are linked resource linked to this
 			existingDB, err := r.getAnyDatabaseLinked(ctx, instance)
 			if err != nil {
@@ -152,12 +195,16 @@ func (r *PostgresqlEngineConfigurationReconciler) mainReconcile(
 		}
 		// Close all saved pools for that pgec
 		err := postgres.CloseAllSavedPoolsForName(
-			utils.CreateNameKeyForSavedPools(instance.Name, instance.Namespace),
+			utils.CreateNameKeyForSavedPools(instance.GetName(), instance.GetNamespace()),
 		)
 		// Check error
 		if err != nil {
 			return r.manageError(ctx, reqLogger, instance, originalPatch, err)
 		}
+		// Remove user connection endpoint metrics
+		postgres.DeleteEndpointMetrics(utils.CreateNameKeyForSavedPools(instance.GetName(), instance.GetNamespace()))
+		// Remove cached exec credentials
+		postgres.DeleteExecCredentials(utils.CreateNameKeyForSavedPools(instance.GetName(), instance.GetNamespace()))
 		// Clean finalizer
 		controllerutil.RemoveFinalizer(instance, config.Finalizer)
 		// Update CR
@@ -172,32 +219,33 @@ func (r *PostgresqlEngineConfigurationReconciler) mainReconcile(
 	// Creation or update case
 
 	// Check if the reconcile loop wasn't recall just because of update status
-	if instance.Status.Phase == postgresqlv1alpha1.EngineValidatedPhase && instance.Status.LastValidatedTime != "" {
-		dur, err := time.ParseDuration(instance.Spec.CheckInterval)
+	if status.Phase == postgresqlv1alpha1.EngineValidatedPhase && status.LastValidatedTime != "" {
+		dur, err := time.ParseDuration(spec.CheckInterval)
 		if err != nil {
-			return r.manageError(ctx, reqLogger, instance, originalPatch, errors.NewInternalError(err))
+			return r.manageError(ctx, reqLogger, instance, originalPatch, newInternalError(err))
 		}
 
 		now := time.Now()
 
-		lastValidatedTime, err := time.Parse(time.RFC3339, instance.Status.LastValidatedTime)
+		lastValidatedTime, err := time.Parse(time.RFC3339, status.LastValidatedTime)
 		if err != nil {
-			return r.manageError(ctx, reqLogger, instance, originalPatch, errors.NewInternalError(err))
+			return r.manageError(ctx, reqLogger, instance, originalPatch, newInternalError(err))
 		}
 
 		// Check if reconcile was called before interval
 		if now.Sub(lastValidatedTime) < dur {
 			// Called before
 			// Need to calculate hash to know if something has changed
-			hash, err := utils.CalculateHash(instance.Spec)
+			hash, err := r.calculateHash(ctx, instance)
 			if err != nil {
-				return r.manageError(ctx, reqLogger, instance, originalPatch, errors.NewInternalError(err))
+				return r.manageError(ctx, reqLogger, instance, originalPatch, err)
 			}
 
 			// Compare hash to check if spec has changed before interval
-			if instance.Status.Hash == hash {
+			// ? Note: Exec credentials about to expire must be refreshed even if nothing has changed
+			if status.Hash == hash && !isExecCredentialsRefreshNeeded(instance) {
 				// Not changed => Requeue
-				newWaitDuration := now.Add(dur).Sub(now)
+				newWaitDuration := getEngineCheckDelay(instance, now.Add(dur).Sub(now))
 
 				reqLogger.Info("Reconcile skipped because called before check interval and nothing has changed")
 
@@ -218,14 +266,14 @@ func (r *PostgresqlEngineConfigurationReconciler) mainReconcile(
 	}
 
 	// Calculate hash for status (this time is to update it in status)
-	hash, err := utils.CalculateHash(instance.Spec)
+	hash, err := r.calculateHash(ctx, instance)
 	if err != nil {
-		return r.manageError(ctx, reqLogger, instance, originalPatch, errors.NewInternalError(err))
+		return r.manageError(ctx, reqLogger, instance, originalPatch, err)
 	}
 	// Need to check if status hash is the same or not to force renew or not
-	if hash != instance.Status.Hash {
+	if hash != status.Hash {
 		err = postgres.CloseAllSavedPoolsForName(
-			utils.CreateNameKeyForSavedPools(instance.Name, instance.Namespace),
+			utils.CreateNameKeyForSavedPools(instance.GetName(), instance.GetNamespace()),
 		)
 		// Check error
 		if err != nil {
@@ -233,10 +281,28 @@ func (r *PostgresqlEngineConfigurationReconciler) mainReconcile(
 		}
 	}
 	// Save new hash
-	instance.Status.Hash = hash
+	status.Hash = hash
+
+	// Get namespaced view used to read secrets and create PG instance
+	// ? Note: Cluster engine configurations don't have a namespace
+	pgec := instance.ToPostgresqlEngineConfiguration()
+
+	// Check that credential source is valid
+	err = utils.ValidateCredentialSource(pgec)
+	// Check error
+	if err != nil {
+		return r.manageError(ctx, reqLogger, instance, originalPatch, errors.NewBadRequest(err.Error()))
+	}
+
+	// Check that admin password rotation is valid
+	rotationDuration, err := utils.ValidateAdminPasswordRotation(pgec)
+	// Check error
+	if err != nil {
+		return r.manageError(ctx, reqLogger, instance, originalPatch, errors.NewBadRequest(err.Error()))
+	}
 
 	// Get secret for user/password
-	secret, err := utils.FindSecretPgEngineCfg(ctx, r.Client, instance)
+	secret, err := utils.FindSecretPgEngineCfg(ctx, r.Client, pgec)
 	if err != nil {
 		return r.manageError(ctx, reqLogger, instance, originalPatch, err)
 	}
@@ -246,18 +312,96 @@ func (r *PostgresqlEngineConfigurationReconciler) mainReconcile(
 	user := string(secret.Data["user"])
 	password := string(secret.Data["password"])
 
-	if user == "" || password == "" {
+	// Check if TLS is enabled
+	if spec.TLS != nil {
+		// Password isn't mandatory with client certificate authentication
+		if user == "" {
+			return r.manageError(
+				ctx,
+				reqLogger,
+				instance,
+				originalPatch,
+				fmt.Errorf("secret %s must contain \"user\" value", spec.SecretName),
+			)
+		}
+
+		// Check that TLS secret is valid
+		if len(secret.Data[corev1.TLSCertKey]) == 0 || len(secret.Data[corev1.TLSPrivateKeyKey]) == 0 {
+			return r.manageError(
+				ctx,
+				reqLogger,
+				instance,
+				originalPatch,
+				fmt.Errorf("secret %s must contain \"%s\" and \"%s\" values", spec.TLS.SecretName, corev1.TLSCertKey, corev1.TLSPrivateKeyKey),
+			)
+		}
+	} else if user == "" || password == "" {
+		return r.manageError(
+			ctx,
+			reqLogger,
+			instance,
+			originalPatch,
+			fmt.Errorf("secret %s must contain \"user\" and \"password\" values", spec.SecretName),
+		)
+	}
+
+	// Get provider capabilities
+	providerCapabilities := postgres.GetProviderCapabilities(spec.Provider)
+	// Check that provider is registered
+	// ? Note: Provider isn't validated by CRD in order to have the registry as the only list of providers
+	if providerCapabilities == nil {
+		return r.manageError(
+			ctx,
+			reqLogger,
+			instance,
+			originalPatch,
+			errors.NewBadRequest(fmt.Sprintf("provider %q isn't supported, supported providers are %q", spec.Provider, postgres.GetRegisteredProviders())),
+		)
+	}
+
+	// Check that user is valid for provider
+	if providerCapabilities.UsernameSuffixSeparator != "" && !strings.Contains(user, providerCapabilities.UsernameSuffixSeparator) {
 		return r.manageError(
 			ctx,
 			reqLogger,
 			instance,
 			originalPatch,
-			fmt.Errorf("secret %s must contain \"user\" and \"password\" values", instance.Spec.SecretName),
+			errors.NewBadRequest(fmt.Sprintf(
+				"user must be like \"user%sserver\" with provider %q",
+				providerCapabilities.UsernameSuffixSeparator,
+				spec.Provider,
+			)),
 		)
 	}
 
+	// Check that pool settings are valid
+	_, err = utils.CreatePoolSettings(spec.Pool)
+	// Check error
+	if err != nil {
+		return r.manageError(ctx, reqLogger, instance, originalPatch, errors.NewBadRequest(err.Error()))
+	}
+
+	// Recover an interrupted admin password rotation
+	recovered, err := r.recoverAdminPasswordRotation(ctx, reqLogger, instance, secret.Data)
+	// Check error
+	if err != nil {
+		return r.manageError(ctx, reqLogger, instance, originalPatch, err)
+	}
+
+	// Check if password has been promoted
+	if recovered {
+		// Reload secret to use new password
+		secret, err = utils.FindSecretPgEngineCfg(ctx, r.Client, pgec)
+		// Check error
+		if err != nil {
+			return r.manageError(ctx, reqLogger, instance, originalPatch, err)
+		}
+
+		password = string(secret.Data["password"])
+	}
+
 	// Create PG object
-	pg := utils.CreatePgInstance(reqLogger, secret.Data, instance)
+	pg := r.PgInstanceFactory.CreatePgInstance(reqLogger, secret.Data, pgec)
 
 	// Try to connect
 	err = pg.Ping(ctx)
@@ -265,13 +409,598 @@ func (r *PostgresqlEngineConfigurationReconciler) mainReconcile(
 		return r.manageError(ctx, reqLogger, instance, originalPatch, err)
 	}
 
+	// Check server identity to detect failovers
+	err = r.manageServerIdentity(ctx, reqLogger, instance, pg)
+	// Check error
+	if err != nil {
+		return r.manageError(ctx, reqLogger, instance, originalPatch, err)
+	}
+
+	// Discover engine capabilities
+	capabilities, err := pg.GetEngineCapabilities(ctx)
+	if err != nil {
+		return r.manageError(ctx, reqLogger, instance, originalPatch, err)
+	}
+	// Save them in status
+	status.Capabilities = &postgresqlv1alpha1.EngineCapabilities{
+		ServerVersionNum: capabilities.ServerVersionNum,
+		WalLevel:         capabilities.WalLevel,
+		MaxConnections:   capabilities.MaxConnections,
+		AdminAttributes: &postgresqlv1alpha1.EngineAdminAttributes{
+			Superuser:   capabilities.Superuser,
+			CreateDB:    capabilities.CreateDB,
+			CreateRole:  capabilities.CreateRole,
+			Replication: capabilities.Replication,
+		},
+		AvailableExtensions: capabilities.AvailableExtensions,
+	}
+
+	// Probe user connections
+	// ? Note: Unreachable user connections don't fail engine as operator only uses main host
+	r.probeUserConnections(ctx, reqLogger, instance, pg)
+
+	// Compute quota usage
+	quotaUsage, err := getEngineQuotaUsage(ctx, r.Client, pgec)
+	// Check error
+	if err != nil {
+		return r.manageError(ctx, reqLogger, instance, originalPatch, err)
+	}
+	// Save it in status
+	status.QuotaUsage = quotaUsage
+
+	// Manage admin password rotation
+	// ? Note: This is done at the end as it will change password used by pg object
+	err = r.manageAdminPasswordRotation(ctx, reqLogger, instance, pg, password, rotationDuration)
+	// Check error
+	if err != nil {
+		return r.manageError(ctx, reqLogger, instance, originalPatch, err)
+	}
+
 	return r.manageSuccess(ctx, reqLogger, instance, originalPatch)
 }
 
+// isAdminPasswordRotationSkipped will return true when engine user password isn't managed with engine secret.
+// This is the case with exec credential source and with TLS client certificate authentication without password.
+func isAdminPasswordRotationSkipped(pgec *postgresqlv1alpha1.PostgresqlEngineConfiguration, password string) bool {
+	return utils.IsExecCredentialSource(pgec) || (pgec.Spec.TLS != nil && password == "")
+}
+
+// getAdminSecret will return engine secret directly as the one used for engine contains TLS data.
+func (r *PostgresqlEngineConfigurationReconciler) getAdminSecret(
+	ctx context.Context,
+	instance postgresqlv1alpha1.EngineConfiguration,
+) (*corev1.Secret, error) {
+	pgec := instance.ToPostgresqlEngineConfiguration()
+
+	secret := &corev1.Secret{}
+	err := r.Get(ctx, types.NamespacedName{
+		Name:      pgec.Spec.SecretName,
+		Namespace: utils.GetPgEngineCfgSecretNamespace(pgec),
+	}, secret)
+
+	return secret, err
+}
+
+// recoverAdminPasswordRotation will finish or clean an admin password rotation interrupted between the engine change and the secret promotion.
+// Pending password is promoted when engine accepts it and removed when engine still accepts the current one.
+// Returns true when pending password has been promoted.
+// ? Note: This must be done before any connection as engine can already use the pending password
+func (r *PostgresqlEngineConfigurationReconciler) recoverAdminPasswordRotation(
+	ctx context.Context,
+	logger logr.Logger,
+	instance postgresqlv1alpha1.EngineConfiguration,
+	secretData map[string][]byte,
+) (bool, error) {
+	pgec := instance.ToPostgresqlEngineConfiguration()
+	password := string(secretData["password"])
+
+	// Check if rotation is skipped
+	if isAdminPasswordRotationSkipped(pgec, password) {
+		return false, nil
+	}
+
+	// Get secret
+	secret, err := r.getAdminSecret(ctx, instance)
+	// Check error
+	if err != nil {
+		return false, err
+	}
+
+	// Check if there isn't any pending password
+	pendingPassword := string(secret.Data[AdminPendingPasswordSecretKey])
+	if pendingPassword == "" {
+		return false, nil
+	}
+
+	logger.Info("Pending admin password found, recovering interrupted rotation")
+
+	// Create PG object only used for login checks
+	pg := r.PgInstanceFactory.CreatePgInstance(logger, secretData, pgec)
+
+	// Check if engine already uses pending password
+	pendingErr := pg.CheckLogin(ctx, pendingPassword)
+	// Check if it isn't an authentication failure
+	// ? Note: Pending password is kept when engine state cannot be known
+	if pendingErr != nil && !isInvalidPasswordError(pendingErr) {
+		return false, fmt.Errorf("cannot check pending admin password: %w", pendingErr)
+	}
+
+	// Check if engine doesn't use pending password
+	if pendingErr != nil {
+		// Check that current password is still valid
+		err = pg.CheckLogin(ctx, password)
+		// Check error
+		if err != nil {
+			return false, fmt.Errorf("neither current nor pending admin password are accepted by engine: %w", err)
+		}
+
+		// Remove pending password as it was never applied
+		delete(secret.Data, AdminPendingPasswordSecretKey)
+
+		return false, r.Update(ctx, secret)
+	}
+
+	// Promote pending password
+	err = r.promoteAdminPassword(ctx, instance, secret, pendingPassword)
+	// Check error
+	if err != nil {
+		return false, err
+	}
+
+	// Add kubernetes event
+	r.Recorder.Event(instance, "Normal", "AdminPasswordRotated", fmt.Sprintf("Interrupted admin password rotation recovered and saved in secret %s", pgec.Spec.SecretName))
+
+	return true, nil
+}
+
+// manageAdminPasswordRotation will generate and apply a new engine user password when rotation duration is elapsed.
+// New password is saved as pending in secret before being applied on engine so it can be recovered if something is interrupted,
+// then checked with a fresh login and promoted in secret.
+func (r *PostgresqlEngineConfigurationReconciler) manageAdminPasswordRotation(
+	ctx context.Context,
+	logger logr.Logger,
+	instance postgresqlv1alpha1.EngineConfiguration,
+	pg postgres.PG,
+	oldPassword string,
+	rotationDuration time.Duration,
+) error {
+	// Get spec and status
+	spec := instance.GetEngineSpec()
+	status := instance.GetEngineStatus()
+
+	// Check if rotation isn't enabled
+	if spec.AdminPasswordRotation == nil {
+		return nil
+	}
+
+	// Check if rotation is skipped
+	if isAdminPasswordRotationSkipped(instance.ToPostgresqlEngineConfiguration(), oldPassword) {
+		logger.Info("Admin password rotation skipped because engine credentials aren't a secret password")
+		// Add kubernetes event
+		r.Recorder.Event(instance, "Warning", "AdminPasswordRotationSkipped", "Admin password rotation is only supported with a password stored in engine secret")
+
+		return nil
+	}
+
+	// Check if last change time isn't known
+	// ? Note: Consider current password as new one to avoid a rotation right after enabling it
+	if status.LastAdminPasswordChangedTime == "" {
+		status.LastAdminPasswordChangedTime = time.Now().UTC().Format(time.RFC3339)
+
+		return nil
+	}
+
+	// Parse last change time
+	lastChangedTime, err := time.Parse(time.RFC3339, status.LastAdminPasswordChangedTime)
+	// Check error
+	if err != nil {
+		return newInternalError(err)
+	}
+
+	// Check if rotation isn't needed yet
+	if time.Since(lastChangedTime) < rotationDuration {
+		return nil
+	}
+
+	logger.Info("Admin password rotation needed")
+
+	// Get secret
+	secret, err := r.getAdminSecret(ctx, instance)
+	// Check error
+	if err != nil {
+		return err
+	}
+
+	// Compute password length
+	length := spec.AdminPasswordRotation.PasswordLength
+	if length == 0 {
+		length = DefaultAdminPasswordRotationLength
+	}
+
+	// Generate new password
+	newPassword := utils.GetRandomString(length)
+
+	// Save it as pending before any engine change
+	// ? Note: Update relies on resource version so a concurrent secret change will make it fail
+	if secret.Data == nil {
+		secret.Data = map[string][]byte{}
+	}
+
+	secret.Data[AdminPendingPasswordSecretKey] = []byte(newPassword)
+
+	err = r.Update(ctx, secret)
+	// Check error
+	if err != nil {
+		return fmt.Errorf("cannot save pending admin password in secret: %w", err)
+	}
+
+	// Apply it on engine
+	// ? Note: On failure, pending password is kept as engine may have applied it. Next reconcile will recover it.
+	err = pg.UpdateCurrentUserPassword(ctx, newPassword)
+	// Check error
+	if err != nil {
+		return err
+	}
+
+	// Check that a fresh login works with new password
+	err = pg.CheckLogin(ctx, newPassword)
+	// Check error
+	if err != nil {
+		return r.revertAdminPassword(ctx, logger, pg, secret, oldPassword, fmt.Errorf("login with new admin password failed: %w", err))
+	}
+
+	// Promote new password in secret
+	// ? Note: On failure, pending password is kept and will be promoted by next reconcile
+	err = r.promoteAdminPassword(ctx, instance, secret, newPassword)
+	// Check error
+	if err != nil {
+		return err
+	}
+
+	// Add kubernetes event
+	r.Recorder.Event(instance, "Normal", "AdminPasswordRotated", fmt.Sprintf("Admin password rotated and saved in secret %s", spec.SecretName))
+
+	logger.Info("Admin password rotated")
+
+	return nil
+}
+
+// promoteAdminPassword will save pending password as engine secret password and update status accordingly.
+func (r *PostgresqlEngineConfigurationReconciler) promoteAdminPassword(
+	ctx context.Context,
+	instance postgresqlv1alpha1.EngineConfiguration,
+	secret *corev1.Secret,
+	newPassword string,
+) error {
+	// Get status
+	status := instance.GetEngineStatus()
+
+	// Update secret
+	secret.Data["password"] = []byte(newPassword)
+	delete(secret.Data, AdminPendingPasswordSecretKey)
+
+	err := r.Update(ctx, secret)
+	// Check error
+	if err != nil {
+		return fmt.Errorf("cannot promote pending admin password in secret: %w", err)
+	}
+
+	// Close all saved pools for that pgec as they use old password
+	err = postgres.CloseAllSavedPoolsForName(
+		utils.CreateNameKeyForSavedPools(instance.GetName(), instance.GetNamespace()),
+	)
+	// Check error
+	if err != nil {
+		return err
+	}
+
+	// Update hash as secret has changed
+	hash, err := r.calculateHash(ctx, instance)
+	// Check error
+	if err != nil {
+		return err
+	}
+
+	// Save in status
+	status.Hash = hash
+	status.LastAdminPasswordChangedTime = time.Now().UTC().Format(time.RFC3339)
+
+	return nil
+}
+
+// revertAdminPassword will restore old engine user password after a failed rotation, remove pending password and return rotation issue.
+// Pending password is kept when old password cannot be restored so next reconcile can recover it.
+func (r *PostgresqlEngineConfigurationReconciler) revertAdminPassword(
+	ctx context.Context,
+	logger logr.Logger,
+	pg postgres.PG,
+	secret *corev1.Secret,
+	oldPassword string,
+	issue error,
+) error {
+	logger.Info("Reverting admin password after failed rotation")
+
+	// Restore old password
+	err := pg.UpdateCurrentUserPassword(ctx, oldPassword)
+	// Check error
+	if err != nil {
+		return fmt.Errorf("%w and old admin password cannot be restored: %s", issue, err.Error())
+	}
+
+	// Remove pending password
+	delete(secret.Data, AdminPendingPasswordSecretKey)
+
+	err = r.Update(ctx, secret)
+	// Check error
+	if err != nil {
+		return fmt.Errorf("%w and pending admin password cannot be removed from secret: %s", issue, err.Error())
+	}
+
+	return issue
+}
+
+// isInvalidPasswordError will return true if error is an authentication failure.
+func isInvalidPasswordError(err error) bool {
+	var pqErr *pq.Error
+
+	return gerrors.As(err, &pqErr) && pqErr.Code == postgres.InvalidPasswordErrorCode
+}
+
+// manageServerIdentity will save identity of the server behind engine host and
+// close all saved pools when it has changed as pooled connections can still be opened on an old primary.
+func (r *PostgresqlEngineConfigurationReconciler) manageServerIdentity(
+	ctx context.Context,
+	logger logr.Logger,
+	instance postgresqlv1alpha1.EngineConfiguration,
+	pg postgres.PG,
+) error {
+	// Get status
+	status := instance.GetEngineStatus()
+
+	// Get identity
+	identity, err := pg.GetServerIdentity(ctx)
+	// Check error
+	if err != nil {
+		return err
+	}
+
+	// Check if engine host is pointing to a server in recovery
+	if identity.InRecovery {
+		logger.Info("engine host is pointing to a server in recovery, changes will fail until it is promoted")
+	}
+
+	// Check if identity was known and has changed
+	if status.ServerIdentity != nil {
+		// Build old identity
+		oldIdentity := &postgres.ServerIdentity{
+			SystemIdentifier: status.ServerIdentity.SystemIdentifier,
+			InRecovery:       status.ServerIdentity.InRecovery,
+			ServerAddress:    status.ServerIdentity.ServerAddress,
+		}
+
+		// Check if it is a different server
+		if !oldIdentity.IsSameServer(identity) {
+			// Close all saved pools for that pgec
+			err = postgres.CloseAllSavedPoolsForName(
+				utils.CreateNameKeyForSavedPools(instance.GetName(), instance.GetNamespace()),
+			)
+			// Check error
+			if err != nil {
+				return err
+			}
+
+			msg := fmt.Sprintf("Server identity changed from %s to %s, all connection pools have been closed", oldIdentity, identity)
+
+			logger.Info(msg)
+			// Add kubernetes event
+			r.Recorder.Event(instance, "Warning", "FailoverDetected", msg)
+		}
+	}
+
+	// Save in status
+	status.ServerIdentity = &postgresqlv1alpha1.EngineServerIdentity{
+		SystemIdentifier: identity.SystemIdentifier,
+		InRecovery:       identity.InRecovery,
+		ServerAddress:    identity.ServerAddress,
+	}
+
+	return nil
+}
+
+// probeUserConnections will probe all user connection endpoints and save results in status and metrics.
+func (*PostgresqlEngineConfigurationReconciler) probeUserConnections(
+	ctx context.Context,
+	logger logr.Logger,
+	instance postgresqlv1alpha1.EngineConfiguration,
+	pg postgres.PG,
+) {
+	// Get spec and status
+	spec := instance.GetEngineSpec()
+	status := instance.GetEngineStatus()
+	// Compute engine key used in metrics
+	engineKey := utils.CreateNameKeyForSavedPools(instance.GetName(), instance.GetNamespace())
+
+	// Build list of probed connections
+	type probedConnection struct {
+		connectionType postgresqlv1alpha1.UserConnectionType
+		conditionType  string
+		index          int
+		connection     *postgresqlv1alpha1.GenericUserConnection
+	}
+
+	list := make([]*probedConnection, 0)
+	// Add primary
+	if spec.UserConnections.PrimaryConnection != nil {
+		list = append(list, &probedConnection{
+			connectionType: postgresqlv1alpha1.PrimaryUserConnectionType,
+			conditionType:  postgresqlv1alpha1.PrimaryConnectionReachableCondition,
+			connection:     spec.UserConnections.PrimaryConnection,
+		})
+	}
+	// Add bouncer
+	if spec.UserConnections.BouncerConnection != nil {
+		list = append(list, &probedConnection{
+			connectionType: postgresqlv1alpha1.BouncerUserConnectionType,
+			conditionType:  postgresqlv1alpha1.BouncerConnectionReachableCondition,
+			connection:     spec.UserConnections.BouncerConnection,
+		})
+	}
+	// Add replicas
+	for i, it := range spec.UserConnections.ReplicaConnections {
+		list = append(list, &probedConnection{
+			connectionType: postgresqlv1alpha1.ReplicaUserConnectionType,
+			conditionType:  postgresqlv1alpha1.ReplicaConnectionsReachableCondition,
+			index:          i,
+			connection:     it,
+		})
+	}
+	// Add replica bouncers
+	for i, it := range spec.UserConnections.ReplicaBouncerConnections {
+		list = append(list, &probedConnection{
+			connectionType: postgresqlv1alpha1.ReplicaBouncerUserConnectionType,
+			conditionType:  postgresqlv1alpha1.ReplicaBouncerConnectionsReachableCondition,
+			index:          i,
+			connection:     it,
+		})
+	}
+
+	// Remove old metrics as user connections may have changed
+	postgres.DeleteEndpointMetrics(engineKey)
+
+	// Condition type => unreachable endpoint messages
+	unreachableMessages := map[string][]string{}
+	// Probe all of them
+	res := make([]*postgresqlv1alpha1.UserConnectionHealth, 0, len(list))
+
+	for _, it := range list {
+		endpoint := &postgres.Endpoint{Host: it.connection.Host, Port: it.connection.Port, URIArgs: it.connection.URIArgs}
+		// Init condition entry
+		if _, ok := unreachableMessages[it.conditionType]; !ok {
+			unreachableMessages[it.conditionType] = []string{}
+		}
+
+		item := &postgresqlv1alpha1.UserConnectionHealth{
+			Type:  it.connectionType,
+			Index: it.index,
+			Host:  it.connection.Host,
+			Port:  it.connection.Port,
+		}
+
+		// Probe
+		health, err := pg.ProbeEndpoint(ctx, endpoint)
+		// Check error
+		if err != nil {
+			logger.Info(fmt.Sprintf("user connection %s is unreachable: %s", it.connectionType, err))
+
+			item.Message = err.Error()
+			unreachableMessages[it.conditionType] = append(unreachableMessages[it.conditionType], err.Error())
+		} else {
+			item.Reachable = true
+			item.LatencyMilliseconds = health.Latency.Milliseconds()
+			item.InRecovery = health.InRecovery
+			// Check if replication lag is known
+			if health.ReplicationLag != nil {
+				item.ReplicationLagMilliseconds = lo.ToPtr(health.ReplicationLag.Milliseconds())
+			}
+		}
+
+		// Save metrics
+		postgres.SetEndpointMetrics(engineKey, string(it.connectionType), endpoint, health)
+
+		res = append(res, item)
+	}
+
+	// Save in status
+	status.UserConnections = res
+
+	// Update conditions
+	for _, condType := range []string{
+		postgresqlv1alpha1.PrimaryConnectionReachableCondition,
+		postgresqlv1alpha1.BouncerConnectionReachableCondition,
+		postgresqlv1alpha1.ReplicaConnectionsReachableCondition,
+		postgresqlv1alpha1.ReplicaBouncerConnectionsReachableCondition,
+	} {
+		messages, ok := unreachableMessages[condType]
+		// Check if there isn't any connection of this type
+		if !ok {
+			meta.RemoveStatusCondition(&status.Conditions, condType)
+
+			continue
+		}
+
+		// Check if all connections are reachable
+		if len(messages) == 0 {
+			meta.SetStatusCondition(&status.Conditions, metav1.Condition{
+				Type:               condType,
+				Status:             metav1.ConditionTrue,
+				Reason:             postgresqlv1alpha1.ReachableConditionReason,
+				Message:            "All endpoints are reachable",
+				ObservedGeneration: instance.GetGeneration(),
+			})
+
+			continue
+		}
+
+		meta.SetStatusCondition(&status.Conditions, metav1.Condition{
+			Type:               condType,
+			Status:             metav1.ConditionFalse,
+			Reason:             postgresqlv1alpha1.UnreachableConditionReason,
+			Message:            strings.Join(messages, "; "),
+			ObservedGeneration: instance.GetGeneration(),
+		})
+	}
+}
+
+// calculateHash will compute hash of spec and of referenced secret versions.
+// Secret versions are included in order to detect secret changes before check interval.
+func (r *PostgresqlEngineConfigurationReconciler) calculateHash(
+	ctx context.Context,
+	instance postgresqlv1alpha1.EngineConfiguration,
+) (string, error) {
+	// Get spec
+	spec := instance.GetEngineSpec()
+	// Get secret namespace
+	ns := utils.GetPgEngineCfgSecretNamespace(instance.ToPostgresqlEngineConfiguration())
+
+	// Get secret versions
+	secretVersions := make([]string, 0)
+	// Loop over secret names
+	for _, name := range getEngineSecretNames(spec) {
+		sec := &corev1.Secret{}
+		// Get secret
+		err := r.Get(ctx, types.NamespacedName{Name: name, Namespace: ns}, sec)
+		// Check error
+		if err != nil {
+			// Ignore not found secrets as they will be reported later
+			if errors.IsNotFound(err) {
+				continue
+			}
+
+			return "", err
+		}
+
+		secretVersions = append(secretVersions, sec.ResourceVersion)
+	}
+
+	// Calculate hash
+	hash, err := utils.CalculateHash(struct {
+		Spec           *postgresqlv1alpha1.PostgresqlEngineConfigurationSpec
+		SecretVersions []string
+	}{Spec: spec, SecretVersions: secretVersions})
+	// Check error
+	if err != nil {
+		return "", newInternalError(err)
+	}
+
+	return hash, nil
+}
+
 func (r *PostgresqlEngineConfigurationReconciler) getAnyDatabaseLinked(
 	ctx context.Context,
-	instance *postgresqlv1alpha1.PostgresqlEngineConfiguration,
+	instance postgresqlv1alpha1.EngineConfiguration,
 ) (*postgresqlv1alpha1.PostgresqlDatabase, error) {
+	// Compute engine key
+	key := utils.CreateNameKeyForSavedPools(instance.GetName(), instance.GetNamespace())
 	// Initialize postgres database list
 	dbL := postgresqlv1alpha1.PostgresqlDatabaseList{}
 	// Requests for list of databases
@@ -282,7 +1011,8 @@ func (r *PostgresqlEngineConfigurationReconciler) getAnyDatabaseLinked(
 	// Loop over the list
 	for _, db := range dbL.Items {
 		// Check db is linked to pgengineconfig
-		if db.Spec.EngineConfiguration.Name == instance.Name && (db.Spec.EngineConfiguration.Namespace == instance.Namespace || db.Namespace == instance.Namespace) {
+		// ? Note: Cluster engine configurations key doesn't contain namespace
+		if utils.CreateNameKeyForEngineLink(db.Spec.EngineConfiguration, db.Namespace) == key {
 			return &db, nil
 		}
 	}
@@ -292,13 +1022,13 @@ func (r *PostgresqlEngineConfigurationReconciler) getAnyDatabaseLinked(
 
 func (r *PostgresqlEngineConfigurationReconciler) updateInstance(
 	ctx context.Context,
-	instance *postgresqlv1alpha1.PostgresqlEngineConfiguration,
+	instance postgresqlv1alpha1.EngineConfiguration,
 ) (bool, error) {
 	// Deep copy
-	oCopy := instance.DeepCopy()
+	oCopy := instance.DeepCopyObject()
 
 	// Add default values
-	r.addDefaultValues(instance)
+	r.addDefaultValues(instance.GetEngineSpec())
 
 	// Add finalizer
 	controllerutil.AddFinalizer(instance, config.Finalizer)
@@ -312,53 +1042,58 @@ func (r *PostgresqlEngineConfigurationReconciler) updateInstance(
 }
 
 // Add default values here to be saved in reconcile loop in order to help people to debug.
-func (*PostgresqlEngineConfigurationReconciler) addDefaultValues(instance *postgresqlv1alpha1.PostgresqlEngineConfiguration) {
+func (*PostgresqlEngineConfigurationReconciler) addDefaultValues(spec *postgresqlv1alpha1.PostgresqlEngineConfigurationSpec) {
 	// Check port
-	if instance.Spec.Port == 0 {
-		instance.Spec.Port = DefaultPGPort
+	if spec.Port == 0 {
+		spec.Port = DefaultPGPort
 	}
 	// Check default database
-	if instance.Spec.DefaultDatabase == "" {
+	if spec.DefaultDatabase == "" {
 		// In classic pg, postgres is a default database
-		instance.Spec.DefaultDatabase = "postgres"
+		spec.DefaultDatabase = "postgres"
 	}
 	// Check "check interval"
-	if instance.Spec.CheckInterval == "" {
-		instance.Spec.CheckInterval = "30s"
+	if spec.CheckInterval == "" {
+		spec.CheckInterval = "30s"
+	}
+
+	// Check TLS ssl mode
+	if spec.TLS != nil && spec.TLS.SSLMode == "" {
+		spec.TLS.SSLMode = postgres.DefaultTLSSSLMode
 	}
 
 	// Check if user connections aren't set to init it
-	if instance.Spec.UserConnections == nil {
-		instance.Spec.UserConnections = &postgresqlv1alpha1.UserConnections{}
+	if spec.UserConnections == nil {
+		spec.UserConnections = &postgresqlv1alpha1.UserConnections{}
 	}
 
 	// Check if primary user connections aren't set to init it
-	if instance.Spec.UserConnections.PrimaryConnection == nil {
-		instance.Spec.UserConnections.PrimaryConnection = &postgresqlv1alpha1.GenericUserConnection{
-			Host:    instance.Spec.Host,
-			URIArgs: instance.Spec.URIArgs,
-			Port:    instance.Spec.Port,
+	if spec.UserConnections.PrimaryConnection == nil {
+		spec.UserConnections.PrimaryConnection = &postgresqlv1alpha1.GenericUserConnection{
+			Host:    spec.Host,
+			URIArgs: spec.URIArgs,
+			Port:    spec.Port,
 		}
 	}
 
 	// Check if primary user connections are set and fully valued
-	if instance.Spec.UserConnections.PrimaryConnection != nil {
+	if spec.UserConnections.PrimaryConnection != nil {
 		// Check port
-		if instance.Spec.UserConnections.PrimaryConnection.Port == 0 {
-			instance.Spec.UserConnections.PrimaryConnection.Port = DefaultPGPort
+		if spec.UserConnections.PrimaryConnection.Port == 0 {
+			spec.UserConnections.PrimaryConnection.Port = DefaultPGPort
 		}
 	}
 
 	// Check if bouncer user connections are set and fully valued
-	if instance.Spec.UserConnections.BouncerConnection != nil {
+	if spec.UserConnections.BouncerConnection != nil {
 		// Check port
-		if instance.Spec.UserConnections.BouncerConnection.Port == 0 {
-			instance.Spec.UserConnections.BouncerConnection.Port = DefaultBouncerPort
+		if spec.UserConnections.BouncerConnection.Port == 0 {
+			spec.UserConnections.BouncerConnection.Port = DefaultBouncerPort
 		}
 	}
 
 	// Loop over replica connections
-	for _, item := range instance.Spec.UserConnections.ReplicaConnections {
+	for _, item := range spec.UserConnections.ReplicaConnections {
 		// Check port
 		if item.Port == 0 {
 			item.Port = DefaultPGPort
@@ -366,7 +1101,7 @@ func (*PostgresqlEngineConfigurationReconciler) addDefaultValues(instance *postg
 	}
 
 	// Loop over replica bouncer connections
-	for _, item := range instance.Spec.UserConnections.ReplicaBouncerConnections {
+	for _, item := range spec.UserConnections.ReplicaBouncerConnections {
 		// Check port
 		if item.Port == 0 {
 			item.Port = DefaultBouncerPort
@@ -377,7 +1112,7 @@ func (*PostgresqlEngineConfigurationReconciler) addDefaultValues(instance *postg
 func (r *PostgresqlEngineConfigurationReconciler) manageError(
 	ctx context.Context,
 	logger logr.Logger,
-	instance *postgresqlv1alpha1.PostgresqlEngineConfiguration,
+	instance postgresqlv1alpha1.EngineConfiguration,
 	originalPatch client.Patch,
 	issue error,
 ) (ctrl.Result, error) {
@@ -385,13 +1120,16 @@ func (r *PostgresqlEngineConfigurationReconciler) manageError(
 	// Add kubernetes event
 	r.Recorder.Event(instance, "Warning", "ProcessingError", issue.Error())
 
+	// Get status
+	status := instance.GetEngineStatus()
+
 	// Update status
-	instance.Status.Message = issue.Error()
-	instance.Status.Ready = false
-	instance.Status.Phase = postgresqlv1alpha1.EngineFailedPhase
+	status.Message = issue.Error()
+	status.Ready = false
+	status.Phase = postgresqlv1alpha1.EngineFailedPhase
 
 	// Increase fail counter
-	r.ControllerRuntimeDetailedErrorTotal.WithLabelValues(r.ControllerName, instance.Namespace, instance.Name).Inc()
+	r.ControllerRuntimeDetailedErrorTotal.WithLabelValues(r.ControllerName, instance.GetNamespace(), instance.GetName()).Inc()
 
 	// Patch status
 	err := r.Status().Patch(ctx, instance, originalPatch)
@@ -406,26 +1144,29 @@ func (r *PostgresqlEngineConfigurationReconciler) manageError(
 func (r *PostgresqlEngineConfigurationReconciler) manageSuccess(
 	ctx context.Context,
 	logger logr.Logger,
-	instance *postgresqlv1alpha1.PostgresqlEngineConfiguration,
+	instance postgresqlv1alpha1.EngineConfiguration,
 	originalPatch client.Patch,
 ) (ctrl.Result, error) {
 	// Try to parse duration
-	dur, err := time.ParseDuration(instance.Spec.CheckInterval)
+	dur, err := time.ParseDuration(instance.GetEngineSpec().CheckInterval)
 	if err != nil {
-		return r.manageError(ctx, logger, instance, originalPatch, errors.NewInternalError(err))
+		return r.manageError(ctx, logger, instance, originalPatch, newInternalError(err))
 	}
 
+	// Get status
+	status := instance.GetEngineStatus()
+
 	// Update status
-	instance.Status.Message = ""
-	instance.Status.Ready = true
-	instance.Status.Phase = postgresqlv1alpha1.EngineValidatedPhase
-	instance.Status.LastValidatedTime = time.Now().UTC().Format(time.RFC3339)
+	status.Message = ""
+	status.Ready = true
+	status.Phase = postgresqlv1alpha1.EngineValidatedPhase
+	status.LastValidatedTime = time.Now().UTC().Format(time.RFC3339)
 
 	// Patch status
 	err = r.Status().Patch(ctx, instance, originalPatch)
 	if err != nil {
 		// Increase fail counter
-		r.ControllerRuntimeDetailedErrorTotal.WithLabelValues(r.ControllerName, instance.Namespace, instance.Name).Inc()
+		r.ControllerRuntimeDetailedErrorTotal.WithLabelValues(r.ControllerName, instance.GetNamespace(), instance.GetName()).Inc()
 
 		logger.Error(err, "unable to update status")
 
@@ -435,12 +1176,45 @@ func (r *PostgresqlEngineConfigurationReconciler) manageSuccess(
 
 	logger.Info("Reconcile done")
 
-	return ctrl.Result{RequeueAfter: dur, Requeue: true}, nil
+	return ctrl.Result{RequeueAfter: getEngineCheckDelay(instance, dur), Requeue: true}, nil
+}
+
+// getEngineCheckDelay will return delay before next engine check.
+// Check interval is shortened in order to refresh exec credentials before they expire.
+func getEngineCheckDelay(instance postgresqlv1alpha1.EngineConfiguration, checkInterval time.Duration) time.Duration {
+	// Check if credentials don't come from an exec plugin
+	if !utils.IsExecCredentialSource(instance.ToPostgresqlEngineConfiguration()) {
+		return checkInterval
+	}
+
+	// Get refresh time
+	refreshTime, ok := postgres.GetExecCredentialsRefreshTime(utils.CreateNameKeyForSavedPools(instance.GetName(), instance.GetNamespace()))
+	// Check if credentials never expire
+	if !ok {
+		return checkInterval
+	}
+
+	// ? Note: A minimal delay avoids a reconcile loop with plugins printing credentials already about to expire
+	return lo.Clamp(time.Until(refreshTime), MinExecCredentialsRefreshDelay, checkInterval)
+}
+
+// isExecCredentialsRefreshNeeded will return true if exec credentials of engine must be refreshed now.
+func isExecCredentialsRefreshNeeded(instance postgresqlv1alpha1.EngineConfiguration) bool {
+	// Check if credentials don't come from an exec plugin
+	if !utils.IsExecCredentialSource(instance.ToPostgresqlEngineConfiguration()) {
+		return false
+	}
+
+	// Get refresh time
+	refreshTime, ok := postgres.GetExecCredentialsRefreshTime(utils.CreateNameKeyForSavedPools(instance.GetName(), instance.GetNamespace()))
+
+	return ok && !time.Now().Before(refreshTime)
 }
 
 // SetupWithManager sets up the controller with the Manager.
 func (r *PostgresqlEngineConfigurationReconciler) SetupWithManager(mgr ctrl.Manager) error {
 	return ctrl.NewControllerManagedBy(mgr).
 		For(&postgresqlv1alpha1.PostgresqlEngineConfiguration{}).
+		Watches(&corev1.Secret{}, handler.EnqueueRequestsFromMapFunc(newEngineSecretMapFunc(mgr.GetClient(), false))).
 		Complete(r)
 }
@@ -1,17 +1,25 @@
 package postgresql
 
 import (
+	"context"
 	"errors"
 	gerrors "errors"
 	"fmt"
+	"time"
 
+	"github.com/easymile/postgresql-operator/api/postgresql/common"
 	postgresqlv1alpha1 "github.com/easymile/postgresql-operator/api/postgresql/v1alpha1"
+	"github.com/easymile/postgresql-operator/internal/controller/postgresql/postgres"
+	"github.com/go-logr/logr"
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
+	"github.com/samber/lo"
 	corev1 "k8s.io/api/core/v1"
 	apimachineryErrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
 )
 
 var _ = Describe("PostgresqlEngineConfiguration tests", func() {
@@ -763,6 +771,74 @@ var _ = Describe("PostgresqlEngineConfiguration tests", func() {
 		Expect(pgec.Status.LastValidatedTime).NotTo(BeEquivalentTo(""))
 	})
 
+	It("should fail right after secret is updated with wrong password", func() {
+		// Create pgec with a check interval longer than test
+		_, sec := setupPGEC("1h", false)
+
+		pgec := &postgresqlv1alpha1.PostgresqlEngineConfiguration{}
+		// Get pgec
+		Eventually(
+			func() error {
+				err := k8sClient.Get(ctx, types.NamespacedName{
+					Name:      pgecName,
+					Namespace: pgecNamespace,
+				}, pgec)
+				// Check error
+				if err != nil {
+					return err
+				}
+
+				// Check if status hasn't been updated
+				if pgec.Status.Phase == postgresqlv1alpha1.EngineNoPhase {
+					return errors.New("pgec hasn't been updated by operator")
+				}
+
+				return nil
+			},
+			generalEventuallyTimeout,
+			generalEventuallyInterval,
+		).
+			Should(Succeed())
+
+		// Checks
+		Expect(pgec.Status.Ready).To(BeTrue())
+		Expect(pgec.Status.Phase).To(BeEquivalentTo(postgresqlv1alpha1.EngineValidatedPhase))
+
+		// Update sec password
+		sec.Data["password"] = []byte("cannotwork")
+
+		// Update secret
+		Expect(k8sClient.Update(ctx, sec)).NotTo(HaveOccurred())
+		// Get pgec
+		// ? Note: Secret watch must trigger reconcile without waiting check interval or resync period
+		Eventually(
+			func() error {
+				err := k8sClient.Get(ctx, types.NamespacedName{
+					Name:      pgecName,
+					Namespace: pgecNamespace,
+				}, pgec)
+				// Check error
+				if err != nil {
+					return err
+				}
+
+				// Check if status hasn't been updated
+				if pgec.Status.Phase == postgresqlv1alpha1.EngineValidatedPhase {
+					return errors.New("pgec hasn't been updated by operator")
+				}
+
+				return nil
+			},
+			watchEventuallyTimeout,
+			watchEventuallyInterval,
+		).
+			Should(Succeed())
+
+		// Checks
+		Expect(pgec.Status.Ready).To(BeFalse())
+		Expect(pgec.Status.Phase).To(BeEquivalentTo(postgresqlv1alpha1.EngineFailedPhase))
+	})
+
 	It("should fail when secret is updated with wrong user", func() {
 		// Create pgec
 		_, sec := setupPGEC("10s", false)
@@ -1024,3 +1100,506 @@ var _ = Describe("PostgresqlEngineConfiguration tests", func() {
 		).Should(Succeed())
 	})
 })
+
+var _ = Describe("PostgresqlEngineConfiguration tests with fake engine", Label(fakeEngineLabel), func() {
+	It("should discover engine capabilities", func() {
+		cl, fakePG, factory := setupFakeEnv()
+		fakePG.Capabilities.AvailableExtensions = []string{pgdbExtensionName1}
+
+		r := &PostgresqlEngineConfigurationReconciler{
+			Client:                              cl,
+			Scheme:                              cl.Scheme(),
+			Recorder:                            record.NewFakeRecorder(100),
+			Log:                                 logr.Discard(),
+			ControllerRuntimeDetailedErrorTotal: newFakeCounter(),
+			ControllerName:                      "postgresqlengineconfiguration",
+			ReconcileTimeout:                    10 * time.Second,
+			PgInstanceFactory:                   factory,
+		}
+
+		Expect(reconcileFakeUntilStable(r, pgecName, pgecNamespace)).To(Succeed())
+
+		item := &postgresqlv1alpha1.PostgresqlEngineConfiguration{}
+		Expect(cl.Get(context.TODO(), types.NamespacedName{Name: pgecName, Namespace: pgecNamespace}, item)).To(Succeed())
+
+		// Checks
+		Expect(item.Status.Ready).To(BeTrue())
+		Expect(item.Status.Capabilities).To(Equal(&postgresqlv1alpha1.EngineCapabilities{
+			ServerVersionNum: fakePG.Capabilities.ServerVersionNum,
+			WalLevel:         fakePG.Capabilities.WalLevel,
+			MaxConnections:   fakePG.Capabilities.MaxConnections,
+			AdminAttributes: &postgresqlv1alpha1.EngineAdminAttributes{
+				Superuser:   true,
+				CreateDB:    true,
+				CreateRole:  true,
+				Replication: true,
+			},
+			AvailableExtensions: []string{pgdbExtensionName1},
+		}))
+	})
+
+	It("should fail with an unregistered provider", func() {
+		cl, _, factory := setupFakeEnv()
+		// Set an unknown provider
+		pgec := &postgresqlv1alpha1.PostgresqlEngineConfiguration{}
+		Expect(cl.Get(context.TODO(), types.NamespacedName{Name: pgecName, Namespace: pgecNamespace}, pgec)).To(Succeed())
+		pgec.Spec.Provider = "UNKNOWN"
+		Expect(cl.Update(context.TODO(), pgec)).To(Succeed())
+
+		r := &PostgresqlEngineConfigurationReconciler{
+			Client:                              cl,
+			Scheme:                              cl.Scheme(),
+			Recorder:                            record.NewFakeRecorder(100),
+			Log:                                 logr.Discard(),
+			ControllerRuntimeDetailedErrorTotal: newFakeCounter(),
+			ControllerName:                      "postgresqlengineconfiguration",
+			ReconcileTimeout:                    10 * time.Second,
+			PgInstanceFactory:                   factory,
+		}
+
+		_ = reconcileFakeUntilStable(r, pgecName, pgecNamespace)
+
+		item := &postgresqlv1alpha1.PostgresqlEngineConfiguration{}
+		Expect(cl.Get(context.TODO(), types.NamespacedName{Name: pgecName, Namespace: pgecNamespace}, item)).To(Succeed())
+
+		// Checks
+		Expect(item.Status.Ready).To(BeFalse())
+		Expect(item.Status.Message).To(ContainSubstring(`provider "UNKNOWN" isn't supported, supported providers are ["" "AWS" "AZURE" "GCP" "RESTRICTED"]`))
+	})
+
+	It("should fail with a user not matching provider username format", func() {
+		cl, _, factory := setupFakeEnv()
+		// Set a provider with username suffix
+		pgec := &postgresqlv1alpha1.PostgresqlEngineConfiguration{}
+		Expect(cl.Get(context.TODO(), types.NamespacedName{Name: pgecName, Namespace: pgecNamespace}, pgec)).To(Succeed())
+		pgec.Spec.Provider = postgresqlv1alpha1.AzureProvider
+		Expect(cl.Update(context.TODO(), pgec)).To(Succeed())
+
+		r := &PostgresqlEngineConfigurationReconciler{
+			Client:                              cl,
+			Scheme:                              cl.Scheme(),
+			Recorder:                            record.NewFakeRecorder(100),
+			Log:                                 logr.Discard(),
+			ControllerRuntimeDetailedErrorTotal: newFakeCounter(),
+			ControllerName:                      "postgresqlengineconfiguration",
+			ReconcileTimeout:                    10 * time.Second,
+			PgInstanceFactory:                   factory,
+		}
+
+		_ = reconcileFakeUntilStable(r, pgecName, pgecNamespace)
+
+		item := &postgresqlv1alpha1.PostgresqlEngineConfiguration{}
+		Expect(cl.Get(context.TODO(), types.NamespacedName{Name: pgecName, Namespace: pgecNamespace}, item)).To(Succeed())
+		Expect(item.Status.Ready).To(BeFalse())
+		Expect(item.Status.Message).To(ContainSubstring(`user must be like "user@server" with provider "AZURE"`))
+
+		// Set a user with server suffix
+		sec := &corev1.Secret{}
+		Expect(cl.Get(context.TODO(), types.NamespacedName{Name: pgecSecretName, Namespace: pgecNamespace}, sec)).To(Succeed())
+		sec.Data["user"] = []byte(postgresUser + "@server")
+		Expect(cl.Update(context.TODO(), sec)).To(Succeed())
+
+		Expect(reconcileFakeUntilStable(r, pgecName, pgecNamespace)).To(Succeed())
+
+		Expect(cl.Get(context.TODO(), types.NamespacedName{Name: pgecName, Namespace: pgecNamespace}, item)).To(Succeed())
+		Expect(item.Status.Ready).To(BeTrue())
+	})
+
+	It("should reject exec credential source", func() {
+		cl, _, factory := setupFakeEnv()
+		// Use an exec credential source
+		pgec := &postgresqlv1alpha1.PostgresqlEngineConfiguration{}
+		Expect(cl.Get(context.TODO(), types.NamespacedName{Name: pgecName, Namespace: pgecNamespace}, pgec)).To(Succeed())
+		pgec.Spec.SecretName = ""
+		pgec.Spec.CredentialSource = &postgresqlv1alpha1.CredentialSource{
+			Type: postgresqlv1alpha1.ExecCredentialSourceType,
+			Exec: &postgresqlv1alpha1.ExecCredentialSource{Command: "sh"},
+		}
+		Expect(cl.Update(context.TODO(), pgec)).To(Succeed())
+
+		r := &PostgresqlEngineConfigurationReconciler{
+			Client:                              cl,
+			Scheme:                              cl.Scheme(),
+			Recorder:                            record.NewFakeRecorder(100),
+			Log:                                 logr.Discard(),
+			ControllerRuntimeDetailedErrorTotal: newFakeCounter(),
+			ControllerName:                      "postgresqlengineconfiguration",
+			ReconcileTimeout:                    10 * time.Second,
+			PgInstanceFactory:                   factory,
+		}
+
+		// Allowed command must still be rejected as only cluster administrators can choose its arguments
+		postgres.SetAllowedCredentialCommands([]string{"sh"})
+		defer postgres.SetAllowedCredentialCommands(nil)
+
+		Expect(reconcileFakeUntilStable(r, pgecName, pgecNamespace)).NotTo(Succeed())
+
+		item := &postgresqlv1alpha1.PostgresqlEngineConfiguration{}
+		Expect(cl.Get(context.TODO(), types.NamespacedName{Name: pgecName, Namespace: pgecNamespace}, item)).To(Succeed())
+		Expect(item.Status.Ready).To(BeFalse())
+		Expect(item.Status.Message).To(ContainSubstring(`"exec" credential source is only allowed on ClusterPostgresqlEngineConfiguration`))
+	})
+
+	It("should probe user connections and report their health", func() {
+		pgdb := newFakePGDB()
+		pgdb.Status = postgresqlv1alpha1.PostgresqlDatabaseStatus{
+			Phase:    postgresqlv1alpha1.DatabaseCreatedPhase,
+			Ready:    true,
+			Database: pgdbDBName,
+			Roles: postgresqlv1alpha1.StatusPostgresRoles{
+				Owner:  pgdbDBName + "-owner",
+				Reader: pgdbDBName + "-reader",
+				Writer: pgdbDBName + "-writer",
+			},
+		}
+
+		pgur := &postgresqlv1alpha1.PostgresqlUserRole{
+			ObjectMeta: v1.ObjectMeta{Name: pgurName, Namespace: pgurNamespace},
+			Spec: postgresqlv1alpha1.PostgresqlUserRoleSpec{
+				Mode:       postgresqlv1alpha1.ManagedMode,
+				RolePrefix: pgurRolePrefix,
+				Privileges: []*postgresqlv1alpha1.PostgresqlUserRolePrivilege{
+					{
+						Privilege:           postgresqlv1alpha1.OwnerPrivilege,
+						Database:            &common.CRLink{Name: pgdbName, Namespace: pgdbNamespace},
+						GeneratedSecretName: pgurDBSecretName,
+					},
+				},
+			},
+		}
+
+		cl, fakePG, factory := setupFakeEnv(pgdb, pgur)
+		fakePG.Capabilities.AvailableExtensions = []string{pgdbExtensionName1}
+
+		// Add replicas and a bouncer
+		pgec := &postgresqlv1alpha1.PostgresqlEngineConfiguration{}
+		Expect(cl.Get(context.TODO(), types.NamespacedName{Name: pgecName, Namespace: pgecNamespace}, pgec)).To(Succeed())
+		pgec.Spec.UserConnections.BouncerConnection = &postgresqlv1alpha1.GenericUserConnection{Host: "bouncer"}
+		pgec.Spec.UserConnections.ReplicaConnections = []*postgresqlv1alpha1.GenericUserConnection{
+			{Host: "replica"},
+			{Host: "replica-typo"},
+		}
+		Expect(cl.Update(context.TODO(), pgec)).To(Succeed())
+
+		inRecovery := true
+		lag := 2 * time.Second
+		fakePG.SetEndpointHealth("replica", DefaultPGPort, &postgres.EndpointHealth{
+			Latency:        3 * time.Millisecond,
+			InRecovery:     &inRecovery,
+			ReplicationLag: &lag,
+		})
+		fakePG.SetEndpointUnreachable("replica-typo", DefaultPGPort, true)
+
+		r := &PostgresqlEngineConfigurationReconciler{
+			Client:                              cl,
+			Scheme:                              cl.Scheme(),
+			Recorder:                            record.NewFakeRecorder(100),
+			Log:                                 logr.Discard(),
+			ControllerRuntimeDetailedErrorTotal: newFakeCounter(),
+			ControllerName:                      "postgresqlengineconfiguration",
+			ReconcileTimeout:                    10 * time.Second,
+			PgInstanceFactory:                   factory,
+		}
+
+		Expect(reconcileFakeUntilStable(r, pgecName, pgecNamespace)).To(Succeed())
+
+		Expect(cl.Get(context.TODO(), types.NamespacedName{Name: pgecName, Namespace: pgecNamespace}, pgec)).To(Succeed())
+
+		// Unreachable user connections mustn't fail engine
+		Expect(pgec.Status.Ready).To(BeTrue())
+		Expect(pgec.Status.UserConnections).To(HaveLen(4))
+		Expect(pgec.Status.UserConnections[0].Type).To(Equal(postgresqlv1alpha1.PrimaryUserConnectionType))
+		Expect(pgec.Status.UserConnections[0].Reachable).To(BeTrue())
+		Expect(pgec.Status.UserConnections[1].Type).To(Equal(postgresqlv1alpha1.BouncerUserConnectionType))
+		Expect(pgec.Status.UserConnections[1].Port).To(Equal(DefaultBouncerPort))
+		Expect(pgec.Status.UserConnections[2]).To(Equal(&postgresqlv1alpha1.UserConnectionHealth{
+			Type:                       postgresqlv1alpha1.ReplicaUserConnectionType,
+			Host:                       "replica",
+			Port:                       DefaultPGPort,
+			Reachable:                  true,
+			LatencyMilliseconds:        3,
+			InRecovery:                 &inRecovery,
+			ReplicationLagMilliseconds: lo.ToPtr(int64(2000)),
+		}))
+		Expect(pgec.Status.UserConnections[3].Index).To(Equal(1))
+		Expect(pgec.Status.UserConnections[3].Reachable).To(BeFalse())
+		Expect(pgec.Status.UserConnections[3].Message).To(ContainSubstring("replica-typo"))
+
+		Expect(meta.IsStatusConditionTrue(pgec.Status.Conditions, postgresqlv1alpha1.PrimaryConnectionReachableCondition)).To(BeTrue())
+		Expect(meta.IsStatusConditionTrue(pgec.Status.Conditions, postgresqlv1alpha1.BouncerConnectionReachableCondition)).To(BeTrue())
+		Expect(meta.IsStatusConditionFalse(pgec.Status.Conditions, postgresqlv1alpha1.ReplicaConnectionsReachableCondition)).To(BeTrue())
+		Expect(meta.FindStatusCondition(pgec.Status.Conditions, postgresqlv1alpha1.ReplicaBouncerConnectionsReachableCondition)).To(BeNil())
+
+		// Create database and group roles in engine
+		for _, it := range []string{pgdb.Status.Roles.Owner, pgdb.Status.Roles.Reader, pgdb.Status.Roles.Writer} {
+			Expect(fakePG.CreateGroupRole(context.TODO(), it)).To(Succeed())
+		}
+
+		Expect(fakePG.CreateDB(context.TODO(), pgdbDBName, pgdb.Status.Roles.Owner, nil)).To(Succeed())
+
+		// Block unreachable user connections
+		pgec.Spec.BlockUnreachableUserConnections = true
+		Expect(cl.Update(context.TODO(), pgec)).To(Succeed())
+
+		urr := &PostgresqlUserRoleReconciler{
+			Client:                              cl,
+			Scheme:                              cl.Scheme(),
+			Recorder:                            record.NewFakeRecorder(100),
+			Log:                                 logr.Discard(),
+			ControllerRuntimeDetailedErrorTotal: newFakeCounter(),
+			ControllerName:                      "postgresqluserrole",
+			ReconcileTimeout:                    10 * time.Second,
+			PgInstanceFactory:                   factory,
+		}
+
+		Expect(reconcileFakeUntilStable(urr, pgurName, pgurNamespace)).NotTo(Succeed())
+
+		item := &postgresqlv1alpha1.PostgresqlUserRole{}
+		Expect(cl.Get(context.TODO(), types.NamespacedName{Name: pgurName, Namespace: pgurNamespace}, item)).To(Succeed())
+		Expect(item.Status.Reason).To(Equal(string(v1.StatusReasonServiceUnavailable)))
+		Expect(item.Status.Message).To(ContainSubstring("replica-typo"))
+		Expect(cl.Get(context.TODO(), types.NamespacedName{Name: pgurDBSecretName, Namespace: pgurNamespace}, &corev1.Secret{})).NotTo(Succeed())
+
+		// Fix replica
+		fakePG.SetEndpointUnreachable("replica-typo", DefaultPGPort, false)
+		Expect(reconcileFakeUntilStable(r, pgecName, pgecNamespace)).To(Succeed())
+
+		Expect(reconcileFakeUntilStable(urr, pgurName, pgurNamespace)).To(Succeed())
+		Expect(cl.Get(context.TODO(), types.NamespacedName{Name: pgurDBSecretName, Namespace: pgurNamespace}, &corev1.Secret{})).To(Succeed())
+	})
+
+	It("should check engine again on secret change before check interval", func() {
+		cl, fakePG, factory := setupFakeEnv()
+
+		r := &PostgresqlEngineConfigurationReconciler{
+			Client:                              cl,
+			Scheme:                              cl.Scheme(),
+			Recorder:                            record.NewFakeRecorder(100),
+			Log:                                 logr.Discard(),
+			ControllerRuntimeDetailedErrorTotal: newFakeCounter(),
+			ControllerName:                      "postgresqlengineconfiguration",
+			ReconcileTimeout:                    10 * time.Second,
+			PgInstanceFactory:                   factory,
+		}
+
+		Expect(reconcileFakeUntilStable(r, pgecName, pgecNamespace)).To(Succeed())
+
+		// Reconcile before check interval is skipped
+		calls := len(fakePG.Calls)
+		Expect(reconcileFakeUntilStable(r, pgecName, pgecNamespace)).To(Succeed())
+		Expect(fakePG.Calls).To(HaveLen(calls))
+
+		// Update secret
+		sec := &corev1.Secret{}
+		Expect(cl.Get(context.TODO(), types.NamespacedName{Name: pgecSecretName, Namespace: pgecNamespace}, sec)).To(Succeed())
+		sec.Data["password"] = []byte("rotated")
+		Expect(cl.Update(context.TODO(), sec)).To(Succeed())
+
+		// Reconcile triggered by secret watch must validate engine again
+		fakePG.InjectError("Ping", errors.New("password authentication failed"))
+		Expect(reconcileFakeUntilStable(r, pgecName, pgecNamespace)).NotTo(Succeed())
+
+		item := &postgresqlv1alpha1.PostgresqlEngineConfiguration{}
+		Expect(cl.Get(context.TODO(), types.NamespacedName{Name: pgecName, Namespace: pgecNamespace}, item)).To(Succeed())
+		Expect(item.Status.Phase).To(Equal(postgresqlv1alpha1.EngineFailedPhase))
+	})
+
+	It("should rotate admin password", func() {
+		cl, fakePG, factory := setupFakeEnv()
+
+		r := &PostgresqlEngineConfigurationReconciler{
+			Client:                              cl,
+			Scheme:                              cl.Scheme(),
+			Recorder:                            record.NewFakeRecorder(100),
+			Log:                                 logr.Discard(),
+			ControllerRuntimeDetailedErrorTotal: newFakeCounter(),
+			ControllerName:                      "postgresqlengineconfiguration",
+			ReconcileTimeout:                    10 * time.Second,
+			PgInstanceFactory:                   factory,
+		}
+
+		// Enable rotation with an old last change
+		item := &postgresqlv1alpha1.PostgresqlEngineConfiguration{}
+		Expect(cl.Get(context.TODO(), types.NamespacedName{Name: pgecName, Namespace: pgecNamespace}, item)).To(Succeed())
+		item.Spec.AdminPasswordRotation = &postgresqlv1alpha1.AdminPasswordRotation{Duration: "1h"}
+		Expect(cl.Update(context.TODO(), item)).To(Succeed())
+		item.Status.LastAdminPasswordChangedTime = time.Now().Add(-2 * time.Hour).UTC().Format(time.RFC3339)
+		Expect(cl.Status().Update(context.TODO(), item)).To(Succeed())
+
+		Expect(reconcileFakeUntilStable(r, pgecName, pgecNamespace)).To(Succeed())
+
+		// Check secret and engine password
+		sec := &corev1.Secret{}
+		Expect(cl.Get(context.TODO(), types.NamespacedName{Name: pgecSecretName, Namespace: pgecNamespace}, sec)).To(Succeed())
+		newPassword := string(sec.Data["password"])
+		Expect(newPassword).To(HaveLen(DefaultAdminPasswordRotationLength))
+		Expect(newPassword).NotTo(Equal(postgresPassword))
+		Expect(string(sec.Data["user"])).To(Equal(postgresUser))
+		Expect(fakePG.Roles[postgresUser].Password).To(Equal(newPassword))
+		Expect(fakePG.Calls).To(ContainElement("CheckLogin"))
+
+		// Check status
+		Expect(cl.Get(context.TODO(), types.NamespacedName{Name: pgecName, Namespace: pgecNamespace}, item)).To(Succeed())
+		Expect(item.Status.Phase).To(Equal(postgresqlv1alpha1.EngineValidatedPhase))
+		lastChanged, err := time.Parse(time.RFC3339, item.Status.LastAdminPasswordChangedTime)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(time.Since(lastChanged)).To(BeNumerically("<", time.Minute))
+
+		// Reconcile again must not rotate password
+		Expect(reconcileFakeUntilStable(r, pgecName, pgecNamespace)).To(Succeed())
+		Expect(fakePG.Roles[postgresUser].Password).To(Equal(newPassword))
+
+		// Force a new rotation with a failing login check
+		item.Spec.AdminPasswordRotation.PasswordLength = 20
+		Expect(cl.Update(context.TODO(), item)).To(Succeed())
+		item.Status.LastAdminPasswordChangedTime = time.Now().Add(-2 * time.Hour).UTC().Format(time.RFC3339)
+		Expect(cl.Status().Update(context.TODO(), item)).To(Succeed())
+
+		fakePG.InjectPqError("CheckLogin", postgres.InvalidPasswordErrorCode)
+		Expect(reconcileFakeUntilStable(r, pgecName, pgecNamespace)).NotTo(Succeed())
+
+		// Password must be reverted and secret unchanged
+		Expect(cl.Get(context.TODO(), types.NamespacedName{Name: pgecSecretName, Namespace: pgecNamespace}, sec)).To(Succeed())
+		Expect(string(sec.Data["password"])).To(Equal(newPassword))
+		Expect(sec.Data).NotTo(HaveKey(AdminPendingPasswordSecretKey))
+		Expect(fakePG.Roles[postgresUser].Password).To(Equal(newPassword))
+
+		Expect(cl.Get(context.TODO(), types.NamespacedName{Name: pgecName, Namespace: pgecNamespace}, item)).To(Succeed())
+		Expect(item.Status.Phase).To(Equal(postgresqlv1alpha1.EngineFailedPhase))
+	})
+
+	It("should keep pending admin password when engine change fails and recover it", func() {
+		cl, fakePG, factory := setupFakeEnv()
+		// Engine user password is the secret one
+		fakePG.Roles[postgresUser].Password = postgresPassword
+
+		r := &PostgresqlEngineConfigurationReconciler{
+			Client:                              cl,
+			Scheme:                              cl.Scheme(),
+			Recorder:                            record.NewFakeRecorder(100),
+			Log:                                 logr.Discard(),
+			ControllerRuntimeDetailedErrorTotal: newFakeCounter(),
+			ControllerName:                      "postgresqlengineconfiguration",
+			ReconcileTimeout:                    10 * time.Second,
+			PgInstanceFactory:                   factory,
+		}
+
+		// Enable rotation with an old last change
+		item := &postgresqlv1alpha1.PostgresqlEngineConfiguration{}
+		Expect(cl.Get(context.TODO(), types.NamespacedName{Name: pgecName, Namespace: pgecNamespace}, item)).To(Succeed())
+		item.Spec.AdminPasswordRotation = &postgresqlv1alpha1.AdminPasswordRotation{Duration: "1h"}
+		Expect(cl.Update(context.TODO(), item)).To(Succeed())
+		item.Status.LastAdminPasswordChangedTime = time.Now().Add(-2 * time.Hour).UTC().Format(time.RFC3339)
+		Expect(cl.Status().Update(context.TODO(), item)).To(Succeed())
+
+		// Engine change fails
+		fakePG.InjectError("UpdateCurrentUserPassword", fmt.Errorf("connection reset"))
+		Expect(reconcileFakeUntilStable(r, pgecName, pgecNamespace)).NotTo(Succeed())
+
+		// Pending password must be saved and current one unchanged
+		sec := &corev1.Secret{}
+		Expect(cl.Get(context.TODO(), types.NamespacedName{Name: pgecSecretName, Namespace: pgecNamespace}, sec)).To(Succeed())
+		Expect(string(sec.Data["password"])).To(Equal(postgresPassword))
+		Expect(sec.Data).To(HaveKey(AdminPendingPasswordSecretKey))
+		Expect(fakePG.Roles[postgresUser].Password).To(Equal(postgresPassword))
+
+		// Engine is back
+		fakePG.ClearInjectedErrors()
+		Expect(reconcileFakeUntilStable(r, pgecName, pgecNamespace)).To(Succeed())
+
+		// Unapplied pending password must be dropped and a new rotation done
+		Expect(cl.Get(context.TODO(), types.NamespacedName{Name: pgecSecretName, Namespace: pgecNamespace}, sec)).To(Succeed())
+		newPassword := string(sec.Data["password"])
+		Expect(newPassword).NotTo(Equal(postgresPassword))
+		Expect(sec.Data).NotTo(HaveKey(AdminPendingPasswordSecretKey))
+		Expect(fakePG.Roles[postgresUser].Password).To(Equal(newPassword))
+
+		Expect(cl.Get(context.TODO(), types.NamespacedName{Name: pgecName, Namespace: pgecNamespace}, item)).To(Succeed())
+		Expect(item.Status.Phase).To(Equal(postgresqlv1alpha1.EngineValidatedPhase))
+	})
+
+	It("should promote pending admin password already applied on engine", func() {
+		cl, fakePG, factory := setupFakeEnv()
+
+		r := &PostgresqlEngineConfigurationReconciler{
+			Client:                              cl,
+			Scheme:                              cl.Scheme(),
+			Recorder:                            record.NewFakeRecorder(100),
+			Log:                                 logr.Discard(),
+			ControllerRuntimeDetailedErrorTotal: newFakeCounter(),
+			ControllerName:                      "postgresqlengineconfiguration",
+			ReconcileTimeout:                    10 * time.Second,
+			PgInstanceFactory:                   factory,
+		}
+
+		// Enable rotation with an old last change
+		item := &postgresqlv1alpha1.PostgresqlEngineConfiguration{}
+		Expect(cl.Get(context.TODO(), types.NamespacedName{Name: pgecName, Namespace: pgecNamespace}, item)).To(Succeed())
+		item.Spec.AdminPasswordRotation = &postgresqlv1alpha1.AdminPasswordRotation{Duration: "1h"}
+		Expect(cl.Update(context.TODO(), item)).To(Succeed())
+		item.Status.LastAdminPasswordChangedTime = time.Now().Add(-2 * time.Hour).UTC().Format(time.RFC3339)
+		Expect(cl.Status().Update(context.TODO(), item)).To(Succeed())
+
+		// Simulate a crash after engine change
+		sec := &corev1.Secret{}
+		Expect(cl.Get(context.TODO(), types.NamespacedName{Name: pgecSecretName, Namespace: pgecNamespace}, sec)).To(Succeed())
+		sec.Data[AdminPendingPasswordSecretKey] = []byte("pending-password")
+		Expect(cl.Update(context.TODO(), sec)).To(Succeed())
+		fakePG.Roles[postgresUser].Password = "pending-password"
+
+		Expect(reconcileFakeUntilStable(r, pgecName, pgecNamespace)).To(Succeed())
+
+		// Pending password must be promoted without any new rotation
+		Expect(cl.Get(context.TODO(), types.NamespacedName{Name: pgecSecretName, Namespace: pgecNamespace}, sec)).To(Succeed())
+		Expect(string(sec.Data["password"])).To(Equal("pending-password"))
+		Expect(sec.Data).NotTo(HaveKey(AdminPendingPasswordSecretKey))
+		Expect(fakePG.Roles[postgresUser].Password).To(Equal("pending-password"))
+		Expect(fakePG.Calls).NotTo(ContainElement("UpdateCurrentUserPassword"))
+
+		Expect(cl.Get(context.TODO(), types.NamespacedName{Name: pgecName, Namespace: pgecNamespace}, item)).To(Succeed())
+		Expect(item.Status.Phase).To(Equal(postgresqlv1alpha1.EngineValidatedPhase))
+		lastChanged, err := time.Parse(time.RFC3339, item.Status.LastAdminPasswordChangedTime)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(time.Since(lastChanged)).To(BeNumerically("<", time.Minute))
+	})
+
+	It("should detect primary failover", func() {
+		cl, fakePG, factory := setupFakeEnv()
+		fakeRecorder := record.NewFakeRecorder(100)
+
+		r := &PostgresqlEngineConfigurationReconciler{
+			Client:                              cl,
+			Scheme:                              cl.Scheme(),
+			Recorder:                            fakeRecorder,
+			Log:                                 logr.Discard(),
+			ControllerRuntimeDetailedErrorTotal: newFakeCounter(),
+			ControllerName:                      "postgresqlengineconfiguration",
+			ReconcileTimeout:                    10 * time.Second,
+			PgInstanceFactory:                   factory,
+		}
+
+		Expect(reconcileFakeUntilStable(r, pgecName, pgecNamespace)).To(Succeed())
+
+		item := &postgresqlv1alpha1.PostgresqlEngineConfiguration{}
+		Expect(cl.Get(context.TODO(), types.NamespacedName{Name: pgecName, Namespace: pgecNamespace}, item)).To(Succeed())
+		Expect(item.Status.ServerIdentity).To(Equal(&postgresqlv1alpha1.EngineServerIdentity{
+			SystemIdentifier: "7000000000000000001",
+			ServerAddress:    "127.0.0.1",
+		}))
+		Expect(fakeRecorder.Events).NotTo(Receive(ContainSubstring("FailoverDetected")))
+
+		// Simulate a failover to another node of the same cluster
+		fakePG.ServerIdentity = &postgres.ServerIdentity{SystemIdentifier: "7000000000000000001", ServerAddress: "127.0.0.2"}
+
+		// Force a new check
+		item.Status.LastValidatedTime = ""
+		Expect(cl.Status().Update(context.TODO(), item)).To(Succeed())
+
+		Expect(reconcileFakeUntilStable(r, pgecName, pgecNamespace)).To(Succeed())
+
+		Expect(cl.Get(context.TODO(), types.NamespacedName{Name: pgecName, Namespace: pgecNamespace}, item)).To(Succeed())
+		Expect(item.Status.ServerIdentity.ServerAddress).To(Equal("127.0.0.2"))
+		Expect(item.Status.Phase).To(Equal(postgresqlv1alpha1.EngineValidatedPhase))
+		Expect(fakeRecorder.Events).To(Receive(ContainSubstring("FailoverDetected")))
+	})
+})
@@ -18,15 +18,22 @@ package postgresql
 
 import (
 	"context"
+	"fmt"
 	"reflect"
 	"time"
 
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
 	"github.com/easymile/postgresql-operator/api/postgresql/v1alpha1"
@@ -38,7 +45,13 @@ import (
 	"github.com/samber/lo"
 )
 
-const DefaultReplicationSlotPlugin = "pgoutput"
+const (
+	DefaultReplicationSlotPlugin     = "pgoutput"
+	DefaultReplicationUsernameSuffix = "_replication"
+	ReplicationUserSecretNamePrefix  = "pgrepl-"
+	// PostgreSQL identifier maximum length (NAMEDATALEN - 1)
+	MaxIdentifierLength = 63
+)
 
 // PostgresqlPublicationReconciler reconciles a PostgresqlPublication object.
 type PostgresqlPublicationReconciler struct {
@@ -49,11 +62,14 @@ type PostgresqlPublicationReconciler struct {
 	Log                                 logr.Logger
 	ControllerName                      string
 	ReconcileTimeout                    time.Duration
+	PgInstanceFactory                   utils.PgInstanceFactory
+	PlanMode                            bool
 }
 
 //+kubebuilder:rbac:groups=postgresql.easymile.com,resources=postgresqlpublications,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups=postgresql.easymile.com,resources=postgresqlpublications/status,verbs=get;update;patch
 //+kubebuilder:rbac:groups=postgresql.easymile.com,resources=postgresqlpublications/finalizers,verbs=update
+//+kubebuilder:rbac:groups=core,resources=secrets,verbs=get;list;watch;create;update;patch;delete
 
 // Reconcile is part of the main kubernetes reconciliation loop which aims to
 // move the current state of the cluster closer to the desired state.
@@ -96,6 +112,14 @@ func (r *PostgresqlPublicationReconciler) Reconcile(ctx context.Context, req ctr
 	// Defer cancel
 	defer cancel()
 
+	// Save resource at the origin of statements for audit
+	timeoutCtx = postgres.WithAuditResource(timeoutCtx, &postgres.AuditResource{
+		Kind:        "PostgresqlPublication",
+		Namespace:   req.Namespace,
+		Name:        req.Name,
+		ReconcileID: string(controller.ReconcileIDFromContext(ctx)),
+	})
+
 	// Init result
 	var res ctrl.Result
 
@@ -136,10 +160,15 @@ func (r *PostgresqlPublicationReconciler) mainReconcile(
 		// Check if drop on delete is enabled
 		if instance.Spec.DropOnDelete {
 			// Delete publication
-			err := r.manageDropPublication(ctx, reqLogger, instance)
+			statements, err := r.manageDropPublication(ctx, reqLogger, instance, utils.IsPlanModeEnabled(r.PlanMode, instance))
 			if err != nil {
 				return r.manageError(ctx, reqLogger, instance, originalPatch, err)
 			}
+			// Check if there are pending statements in plan mode
+			// In this case, finalizer is kept until plan mode is disabled
+			if len(statements) != 0 {
+				return r.managePlan(ctx, reqLogger, instance, originalPatch, statements)
+			}
 		}
 
 		// Remove finalizer
@@ -195,6 +224,20 @@ func (r *PostgresqlPublicationReconciler) mainReconcile(
 		return ctrl.Result{}, nil
 	}
 
+	// Validate with engine capabilities
+	err = r.validateWithEngineCapabilities(instance, pgEngCfg)
+	// Check error
+	if err != nil {
+		return r.manageError(ctx, reqLogger, instance, originalPatch, err)
+	}
+
+	// Check engine quotas
+	err = checkPublicationQuota(ctx, r.Client, pgEngCfg, instance)
+	// Check error
+	if err != nil {
+		return r.manageError(ctx, reqLogger, instance, originalPatch, err)
+	}
+
 	// Get secret linked to PostgresqlEngineConfiguration CR
 	secret, err := utils.FindSecretPgEngineCfg(ctx, r.Client, pgEngCfg)
 	if err != nil {
@@ -215,11 +258,17 @@ func (r *PostgresqlPublicationReconciler) mainReconcile(
 	// Calculate hash for status (this time is to update it in status)
 	hash, err := utils.CalculateHash(instance.Spec)
 	if err != nil {
-		return r.manageError(ctx, reqLogger, instance, originalPatch, errors.NewInternalError(err))
+		return r.manageError(ctx, reqLogger, instance, originalPatch, newInternalError(err))
 	}
 
 	// Create PG instance
-	pg := utils.CreatePgInstance(reqLogger, secret.Data, pgEngCfg)
+	pg := r.PgInstanceFactory.CreatePgInstance(reqLogger, secret.Data, pgEngCfg)
+	// Check if plan mode is enabled
+	planMode := utils.IsPlanModeEnabled(r.PlanMode, instance)
+	if planMode {
+		// Record statements instead of executing them
+		pg.EnablePlanMode()
+	}
 
 	// Compute name to search
 	nameToSearch := instance.Status.Name
@@ -293,6 +342,19 @@ func (r *PostgresqlPublicationReconciler) mainReconcile(
 		}
 	}
 
+	// Manage replication user used by subscriptions
+	err = r.manageReplicationUser(ctx, instance, pg, pgDB, planMode)
+	// Check error
+	if err != nil {
+		return r.manageError(ctx, reqLogger, instance, originalPatch, err)
+	}
+
+	// Check if plan mode is enabled
+	// In this case, status mustn't be updated as nothing have been done
+	if planMode {
+		return r.managePlan(ctx, reqLogger, instance, originalPatch, pg.GetPlannedStatements())
+	}
+
 	// Save name
 	instance.Status.Name = instance.Spec.Name
 	// Save hash in status
@@ -306,6 +368,160 @@ func (r *PostgresqlPublicationReconciler) mainReconcile(
 	return r.manageSuccess(ctx, reqLogger, instance, originalPatch)
 }
 
+func (r *PostgresqlPublicationReconciler) manageReplicationUser(
+	ctx context.Context,
+	instance *v1alpha1.PostgresqlPublication,
+	pg postgres.PG,
+	pgDB *v1alpha1.PostgresqlDatabase,
+	planMode bool,
+) error {
+	// Save username for easy use
+	username := instance.Spec.ReplicationUsername
+	// Compute secret name
+	secretName := ReplicationUserSecretNamePrefix + instance.Name
+
+	// Check if user was already created in the past with another name
+	if instance.Status.ReplicationUsername != "" && instance.Status.ReplicationUsername != username {
+		// Check if "old" user still exists
+		exists, err := pg.IsRoleExist(ctx, instance.Status.ReplicationUsername)
+		// Check error
+		if err != nil {
+			return err
+		}
+		// Check if it needs to be renamed
+		if exists {
+			// Rename
+			err = pg.RenameRole(ctx, instance.Status.ReplicationUsername, username)
+			// Check error
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	// Get secret containing credentials
+	sec := &corev1.Secret{}
+	err := r.Get(ctx, types.NamespacedName{Name: secretName, Namespace: instance.Namespace}, sec)
+	// Check error
+	if err != nil && !errors.IsNotFound(err) {
+		return err
+	}
+
+	// Save secret existence for later
+	secretFound := err == nil
+	// Get password from secret
+	password := string(sec.Data[PasswordSecretKey])
+	// Check if password is present
+	passwordGenerated := password == ""
+	// Generate one if needed
+	if passwordGenerated {
+		password = utils.GetRandomString(ManagedPasswordSize)
+	}
+
+	// Check if user exists
+	exists, err := pg.IsRoleExist(ctx, username)
+	// Check error
+	if err != nil {
+		return err
+	}
+
+	// Check if user must be created
+	if !exists {
+		// Create it with only replication attribute
+		_, err = pg.CreateUserRole(ctx, username, password, &postgres.RoleAttributes{Replication: lo.ToPtr(true)})
+		// Check error
+		if err != nil {
+			return err
+		}
+	} else {
+		// Check if password have been generated
+		// ? Note: Previous password is unknown in this case, so it must be updated
+		if passwordGenerated {
+			err = pg.UpdatePassword(ctx, username, password)
+			// Check error
+			if err != nil {
+				return err
+			}
+		}
+
+		// Get attributes
+		attributes, err := pg.GetRoleAttributes(ctx, username)
+		// Check error
+		if err != nil {
+			return err
+		}
+		// Check if replication attribute is missing
+		if !lo.FromPtr(attributes.Replication) {
+			err = pg.AlterRoleAttributes(ctx, username, &postgres.RoleAttributes{Replication: lo.ToPtr(true)})
+			// Check error
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	// Grant select only on published tables
+	err = pg.SetPublicationTablesSelectPrivilege(ctx, pgDB.Status.Database, username, instance.Spec.Name)
+	// Check error
+	if err != nil {
+		return err
+	}
+
+	// Check if plan mode is enabled
+	// In this case, secret and status mustn't be updated as nothing have been done
+	if planMode {
+		return nil
+	}
+
+	// Build secret data
+	data := map[string][]byte{
+		UsernameSecretKey: []byte(username),
+		PasswordSecretKey: []byte(password),
+	}
+
+	// Check if secret must be created
+	if !secretFound {
+		sec = &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      secretName,
+				Namespace: instance.Namespace,
+			},
+			Type: corev1.SecretTypeOpaque,
+			Data: data,
+		}
+
+		// Set owner
+		err = controllerutil.SetControllerReference(instance, sec, r.Scheme)
+		// Check error
+		if err != nil {
+			return err
+		}
+
+		// Create secret
+		err = r.Create(ctx, sec)
+		// Check error
+		if err != nil {
+			return err
+		}
+	} else if !reflect.DeepEqual(sec.Data, data) {
+		// Save new data
+		sec.Data = data
+
+		// Update secret
+		err = r.Update(ctx, sec)
+		// Check error
+		if err != nil {
+			return err
+		}
+	}
+
+	// Update status
+	instance.Status.ReplicationUsername = username
+	instance.Status.ReplicationUserSecretName = secretName
+
+	return nil
+}
+
 func (*PostgresqlPublicationReconciler) manageUpdate(
 	ctx context.Context,
 	instance *v1alpha1.PostgresqlPublication,
@@ -397,6 +613,55 @@ func (*PostgresqlPublicationReconciler) manageCreate(
 	return nil
 }
 
+func (*PostgresqlPublicationReconciler) validateWithEngineCapabilities(
+	instance *v1alpha1.PostgresqlPublication,
+	pgec *v1alpha1.PostgresqlEngineConfiguration,
+) error {
+	// Save spec for easy use
+	spec := instance.Spec
+
+	// Check wal level for replication slot
+	err := utils.CheckEngineWalLevel(pgec, utils.LogicalWalLevel, "publication with logical replication slot")
+	// Check error
+	if err != nil {
+		return err
+	}
+
+	// Check tables in schema
+	if len(spec.TablesInSchema) != 0 {
+		err = utils.CheckEngineMinVersion(pgec, utils.PostgresqlVersion15, "tables in schema")
+		// Check error
+		if err != nil {
+			return err
+		}
+	}
+
+	// Check column lists and row filters
+	_, found := lo.Find(spec.Tables, func(it *v1alpha1.PostgresqlPublicationTable) bool {
+		return it.Columns != nil || it.AdditionalWhere != nil
+	})
+	// Check
+	if found {
+		err = utils.CheckEngineMinVersion(pgec, utils.PostgresqlVersion15, "tables with columns or additional where")
+		// Check error
+		if err != nil {
+			return err
+		}
+	}
+
+	// Check publish via partition root
+	if spec.WithParameters != nil && spec.WithParameters.PublishViaPartitionRoot != nil {
+		err = utils.CheckEngineMinVersion(pgec, utils.PostgresqlVersion13, "publish via partition root")
+		// Check error
+		if err != nil {
+			return err
+		}
+	}
+
+	// Default
+	return nil
+}
+
 func (*PostgresqlPublicationReconciler) validate(
 	instance *v1alpha1.PostgresqlPublication,
 ) error {
@@ -410,6 +675,13 @@ func (*PostgresqlPublicationReconciler) validate(
 		return errors.NewBadRequest("name must have a value")
 	}
 
+	// Check identifiers length
+	for _, it := range []string{spec.Name, spec.ReplicationSlotName} {
+		if len(it) > postgres.MaxIdentifierLength {
+			return errors.NewBadRequest(fmt.Sprintf("identifier too long, must be <= 63, %s is %d character, must reduce name or replication slot name length", it, len(it)))
+		}
+	}
+
 	// Init some vars
 	tablesInSchemaLength := len(spec.TablesInSchema)
 	tablesLength := len(spec.Tables)
@@ -496,6 +768,15 @@ func (r *PostgresqlPublicationReconciler) updateInstance(
 		instance.Spec.ReplicationSlotPlugin = DefaultReplicationSlotPlugin
 	}
 
+	// Check if replication username isn't set
+	if instance.Spec.ReplicationUsername == "" {
+		// Set to publication name with suffix
+		// ? Note: Publication name is truncated to respect PostgreSQL identifier length
+		instance.Spec.ReplicationUsername = lo.Substring(
+			instance.Spec.Name, 0, uint(MaxIdentifierLength-len(DefaultReplicationUsernameSuffix)),
+		) + DefaultReplicationUsernameSuffix
+	}
+
 	// Check if update is needed
 	if !reflect.DeepEqual(oCopy.ObjectMeta, instance.ObjectMeta) {
 		return true, r.Update(ctx, instance)
@@ -508,44 +789,50 @@ func (r *PostgresqlPublicationReconciler) manageDropPublication(
 	ctx context.Context,
 	logger logr.Logger,
 	instance *v1alpha1.PostgresqlPublication,
-) error {
+	planMode bool,
+) ([]string, error) {
 	// Get pg db
 	pgDB, err := utils.FindPgDatabaseFromLink(ctx, r.Client, instance.Spec.Database, instance.Namespace)
 	if err != nil && !errors.IsNotFound(err) {
-		return err
+		return nil, err
 	}
 	// In case of not found => Can't delete => skip
 	if errors.IsNotFound(err) {
 		logger.Error(err, "can't delete publication because PostgresDatabase didn't exists anymore")
 
-		return nil
+		return nil, nil
 	}
 
 	// Try to find PostgresqlEngineConfiguration CR
 	pgEngCfg, err := utils.FindPgEngineCfg(ctx, r.Client, pgDB)
 	if err != nil && !errors.IsNotFound(err) {
-		return err
+		return nil, err
 	}
 	// In case of not found => Can't delete => skip
 	if errors.IsNotFound(err) {
 		logger.Error(err, "can't delete database because PostgresEngineConfiguration didn't exists anymore")
 
-		return nil
+		return nil, nil
 	}
 
 	// Get secret linked to PostgresqlEngineConfiguration CR
 	secret, err := utils.FindSecretPgEngineCfg(ctx, r.Client, pgEngCfg)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	// Create PG instance
-	pg := utils.CreatePgInstance(logger, secret.Data, pgEngCfg)
+	pg := r.PgInstanceFactory.CreatePgInstance(logger, secret.Data, pgEngCfg)
+	// Check if plan mode is enabled
+	if planMode {
+		// Record statements instead of executing them
+		pg.EnablePlanMode()
+	}
 
 	// Get publication
 	pub, err := pg.GetPublication(ctx, pgDB.Status.Database, instance.Spec.Name)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	// Check if publication is still present to delete it
@@ -554,7 +841,7 @@ func (r *PostgresqlPublicationReconciler) manageDropPublication(
 		err = pg.DropPublication(ctx, pgDB.Status.Database, instance.Spec.Name)
 		// Check error
 		if err != nil {
-			return err
+			return nil, err
 		}
 	}
 
@@ -563,7 +850,7 @@ func (r *PostgresqlPublicationReconciler) manageDropPublication(
 		// Get replication slot
 		rep, err := pg.GetReplicationSlot(ctx, instance.Spec.ReplicationSlotName)
 		if err != nil {
-			return err
+			return nil, err
 		}
 
 		// Check if replication slot is still present to delete it
@@ -572,13 +859,32 @@ func (r *PostgresqlPublicationReconciler) manageDropPublication(
 			err = pg.DropReplicationSlot(ctx, instance.Spec.ReplicationSlotName)
 			// Check error
 			if err != nil {
-				return err
+				return nil, err
+			}
+		}
+	}
+
+	// Check if replication user have been created
+	if instance.Status.ReplicationUsername != "" {
+		// Check if user still exists
+		exists, err := pg.IsRoleExist(ctx, instance.Status.ReplicationUsername)
+		if err != nil {
+			return nil, err
+		}
+
+		// Check if user is still present to delete it
+		if exists {
+			// Drop user and its privileges in database
+			err = pg.DropRoleAndDropAndChangeOwnedBy(ctx, instance.Status.ReplicationUsername, pg.GetUser(), pgDB.Status.Database)
+			// Check error
+			if err != nil {
+				return nil, err
 			}
 		}
 	}
 
 	// Default
-	return nil
+	return pg.GetPlannedStatements(), nil
 }
 
 func (r *PostgresqlPublicationReconciler) manageError(
@@ -588,12 +894,23 @@ func (r *PostgresqlPublicationReconciler) manageError(
 	originalPatch client.Patch,
 	issue error,
 ) (reconcile.Result, error) {
+	// Check if issue is retryable
+	res, managed := manageReadOnlyTransactionError(
+		ctx, logger, r.Status(), r.Recorder, instance,
+		&instance.Status.Message, &instance.Status.Reason,
+		originalPatch, issue,
+	)
+	if managed {
+		return res, nil
+	}
+
 	logger.Error(issue, "issue raised in reconcile")
 	// Add kubernetes event
 	r.Recorder.Event(instance, "Warning", "ProcessingError", issue.Error())
 
 	// Update status
 	instance.Status.Message = issue.Error()
+	instance.Status.Reason = string(errors.ReasonForError(issue))
 	instance.Status.Ready = false
 	instance.Status.Phase = v1alpha1.PublicationFailedPhase
 
@@ -610,6 +927,42 @@ func (r *PostgresqlPublicationReconciler) manageError(
 	return ctrl.Result{}, issue
 }
 
+func (r *PostgresqlPublicationReconciler) managePlan(
+	ctx context.Context,
+	logger logr.Logger,
+	instance *v1alpha1.PostgresqlPublication,
+	originalPatch client.Patch,
+	statements []string,
+) (reconcile.Result, error) {
+	// Add kubernetes events
+	for _, st := range statements {
+		r.Recorder.Event(instance, "Normal", "Planned", st)
+	}
+
+	// Update status
+	instance.Status.Message = fmt.Sprintf("plan mode enabled, %d statement(s) pending", len(statements))
+	instance.Status.Plan = &v1alpha1.PlanStatus{
+		PendingStatements: statements,
+		LastPlanTime:      time.Now().Format(time.RFC3339),
+	}
+
+	// Patch status
+	err := r.Status().Patch(ctx, instance, originalPatch)
+	if err != nil {
+		// Increase fail counter
+		r.ControllerRuntimeDetailedErrorTotal.WithLabelValues(r.ControllerName, instance.Namespace, instance.Name).Inc()
+
+		logger.Error(err, "unable to update status")
+
+		// Return error
+		return ctrl.Result{}, err
+	}
+
+	logger.Info("Plan done")
+
+	return reconcile.Result{}, nil
+}
+
 func (r *PostgresqlPublicationReconciler) manageSuccess(
 	ctx context.Context,
 	logger logr.Logger,
@@ -618,8 +971,11 @@ func (r *PostgresqlPublicationReconciler) manageSuccess(
 ) (reconcile.Result, error) {
 	// Update status
 	instance.Status.Message = ""
+	instance.Status.Reason = ""
 	instance.Status.Ready = true
 	instance.Status.Phase = v1alpha1.PublicationCreatedPhase
+	// Plan is outdated now
+	instance.Status.Plan = nil
 
 	// Patch status
 	err := r.Status().Patch(ctx, instance, originalPatch)
@@ -642,5 +998,19 @@ func (r *PostgresqlPublicationReconciler) manageSuccess(
 func (r *PostgresqlPublicationReconciler) SetupWithManager(mgr ctrl.Manager) error {
 	return ctrl.NewControllerManagedBy(mgr).
 		For(&v1alpha1.PostgresqlPublication{}).
+		// Replication user secret changes
+		Owns(&corev1.Secret{}).
+		// Database status changes
+		Watches(
+			&v1alpha1.PostgresqlDatabase{},
+			handler.EnqueueRequestsFromMapFunc(newIndexedMapFunc(
+				mgr.GetClient(),
+				func() client.ObjectList { return &v1alpha1.PostgresqlPublicationList{} },
+				PublicationDatabaseIndexField,
+				objectNameKey,
+				false,
+			)),
+			builder.WithPredicates(databaseStatusChangedPredicate),
+		).
 		Complete(r)
 }
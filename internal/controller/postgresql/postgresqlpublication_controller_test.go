@@ -1,6 +1,7 @@
 package postgresql
 
 import (
+	"context"
 	"errors"
 	gerrors "errors"
 	"fmt"
@@ -8,11 +9,16 @@ import (
 
 	"github.com/easymile/postgresql-operator/api/postgresql/common"
 	postgresqlv1alpha1 "github.com/easymile/postgresql-operator/api/postgresql/v1alpha1"
+	"github.com/easymile/postgresql-operator/internal/controller/config"
+	"github.com/easymile/postgresql-operator/internal/controller/postgresql/postgres"
+	"github.com/easymile/postgresql-operator/internal/controller/utils"
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
 	apimachineryErrors "k8s.io/apimachinery/pkg/api/errors"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
 )
 
 var _ = Describe("PostgresqlPublication tests", func() {
@@ -552,6 +558,8 @@ var _ = Describe("PostgresqlPublication tests", func() {
 				Expect(item.Status.Name).To(Equal(pgpublicationPublicationName1))
 				Expect(item.Status.ReplicationSlotName).To(Equal(pgpublicationPublicationName1))
 				Expect(item.Status.ReplicationSlotPlugin).To(Equal(DefaultReplicationSlotPlugin))
+				Expect(item.Status.ReplicationUsername).To(Equal(pgpublicationPublicationName1 + DefaultReplicationUsernameSuffix))
+				Expect(item.Status.ReplicationUserSecretName).To(Equal(ReplicationUserSecretNamePrefix + pgpublicationName))
 				Expect(item.Spec.ReplicationSlotName).To(Equal(pgpublicationPublicationName1))
 				Expect(item.Spec.ReplicationSlotPlugin).To(Equal(DefaultReplicationSlotPlugin))
 
@@ -4070,3 +4078,223 @@ var _ = Describe("PostgresqlPublication tests", func() {
 		})
 	})
 })
+
+var _ = Describe("PostgresqlPublication tests with fake engine", Label(fakeEngineLabel), func() {
+	It("should create and drop publication", func() {
+		pgdb := newFakePGDB()
+		pgdb.Status = postgresqlv1alpha1.PostgresqlDatabaseStatus{
+			Phase:    postgresqlv1alpha1.DatabaseCreatedPhase,
+			Ready:    true,
+			Database: pgdbDBName,
+		}
+
+		pub := &postgresqlv1alpha1.PostgresqlPublication{
+			ObjectMeta: v1.ObjectMeta{Name: pgpublicationName, Namespace: pgpublicationNamespace},
+			Spec: postgresqlv1alpha1.PostgresqlPublicationSpec{
+				Database:     &common.CRLink{Name: pgdbName, Namespace: pgdbNamespace},
+				Name:         pgpublicationPublicationName1,
+				AllTables:    true,
+				DropOnDelete: true,
+			},
+		}
+
+		cl, fakePG, factory := setupFakeEnv(pgdb, pub)
+		// Create database in engine
+		Expect(fakePG.CreateDB(context.TODO(), pgdbDBName, postgresUser, nil)).To(Succeed())
+
+		r := newFakePGPublicationReconciler(cl, factory)
+
+		Expect(reconcileFakeUntilStable(r, pgpublicationName, pgpublicationNamespace)).To(Succeed())
+
+		item := &postgresqlv1alpha1.PostgresqlPublication{}
+		Expect(cl.Get(context.TODO(), types.NamespacedName{Name: pgpublicationName, Namespace: pgpublicationNamespace}, item)).To(Succeed())
+
+		// Checks
+		Expect(item.Status.Ready).To(BeTrue())
+		Expect(item.Status.Phase).To(Equal(postgresqlv1alpha1.PublicationCreatedPhase))
+		Expect(item.Status.ReplicationSlotName).To(Equal(pgpublicationPublicationName1))
+		Expect(fakePG.Databases[pgdbDBName].Publications).To(HaveKey(pgpublicationPublicationName1))
+		Expect(fakePG.Databases[pgdbDBName].Publications[pgpublicationPublicationName1].AllTables).To(BeTrue())
+		Expect(fakePG.ReplicationSlots).To(HaveKeyWithValue(pgpublicationPublicationName1, &postgres.ReplicationSlotResult{
+			SlotName: pgpublicationPublicationName1,
+			Plugin:   DefaultReplicationSlotPlugin,
+			Database: pgdbDBName,
+		}))
+
+		// Replication user checks
+		replicationUser := pgpublicationPublicationName1 + DefaultReplicationUsernameSuffix
+		Expect(item.Status.ReplicationUsername).To(Equal(replicationUser))
+		Expect(item.Status.ReplicationUserSecretName).To(Equal(ReplicationUserSecretNamePrefix + pgpublicationName))
+		Expect(fakePG.Roles).To(HaveKey(replicationUser))
+		Expect(fakePG.Roles[replicationUser].Login).To(BeTrue())
+		Expect(fakePG.Roles[replicationUser].Replication).To(BeTrue())
+		Expect(fakePG.Databases[pgdbDBName].PublicationSelectGrants).To(HaveKeyWithValue(replicationUser, pgpublicationPublicationName1))
+
+		sec := &corev1.Secret{}
+		Expect(cl.Get(context.TODO(), types.NamespacedName{Name: item.Status.ReplicationUserSecretName, Namespace: pgpublicationNamespace}, sec)).To(Succeed())
+		Expect(string(sec.Data[UsernameSecretKey])).To(Equal(replicationUser))
+		Expect(string(sec.Data[PasswordSecretKey])).To(Equal(fakePG.Roles[replicationUser].Password))
+		Expect(sec.OwnerReferences).To(HaveLen(1))
+		Expect(sec.OwnerReferences[0].Name).To(Equal(pgpublicationName))
+
+		// Delete
+		Expect(cl.Delete(context.TODO(), item)).To(Succeed())
+
+		_, err := r.Reconcile(context.TODO(), ctrl.Request{NamespacedName: types.NamespacedName{Name: pgpublicationName, Namespace: pgpublicationNamespace}})
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(fakePG.Databases[pgdbDBName].Publications).NotTo(HaveKey(pgpublicationPublicationName1))
+		Expect(fakePG.ReplicationSlots).NotTo(HaveKey(pgpublicationPublicationName1))
+		Expect(fakePG.Roles).NotTo(HaveKey(replicationUser))
+		Expect(fakePG.Databases[pgdbDBName].PublicationSelectGrants).NotTo(HaveKey(replicationUser))
+		Expect(fakePG.HasCall("DropPublication")).To(BeTrue())
+	})
+
+	It("should reset replication user password when its secret is removed", func() {
+		pgdb := newFakePGDB()
+		pgdb.Status = postgresqlv1alpha1.PostgresqlDatabaseStatus{
+			Phase:    postgresqlv1alpha1.DatabaseCreatedPhase,
+			Ready:    true,
+			Database: pgdbDBName,
+		}
+
+		pub := &postgresqlv1alpha1.PostgresqlPublication{
+			ObjectMeta: v1.ObjectMeta{Name: pgpublicationName, Namespace: pgpublicationNamespace},
+			Spec: postgresqlv1alpha1.PostgresqlPublicationSpec{
+				Database:            &common.CRLink{Name: pgdbName, Namespace: pgdbNamespace},
+				Name:                pgpublicationPublicationName1,
+				ReplicationUsername: "custom-replication",
+				AllTables:           true,
+			},
+		}
+
+		cl, fakePG, factory := setupFakeEnv(pgdb, pub)
+		// Create database in engine
+		Expect(fakePG.CreateDB(context.TODO(), pgdbDBName, postgresUser, nil)).To(Succeed())
+
+		r := newFakePGPublicationReconciler(cl, factory)
+
+		Expect(reconcileFakeUntilStable(r, pgpublicationName, pgpublicationNamespace)).To(Succeed())
+		Expect(fakePG.Roles).To(HaveKey("custom-replication"))
+
+		// Save password
+		oldPassword := fakePG.Roles["custom-replication"].Password
+
+		// Remove secret
+		sec := &corev1.Secret{}
+		Expect(cl.Get(context.TODO(), types.NamespacedName{Name: ReplicationUserSecretNamePrefix + pgpublicationName, Namespace: pgpublicationNamespace}, sec)).To(Succeed())
+		Expect(cl.Delete(context.TODO(), sec)).To(Succeed())
+
+		Expect(reconcileFakeUntilStable(r, pgpublicationName, pgpublicationNamespace)).To(Succeed())
+
+		// Checks
+		Expect(cl.Get(context.TODO(), types.NamespacedName{Name: ReplicationUserSecretNamePrefix + pgpublicationName, Namespace: pgpublicationNamespace}, sec)).To(Succeed())
+		Expect(string(sec.Data[UsernameSecretKey])).To(Equal("custom-replication"))
+		Expect(string(sec.Data[PasswordSecretKey])).NotTo(Equal(oldPassword))
+		Expect(string(sec.Data[PasswordSecretKey])).To(Equal(fakePG.Roles["custom-replication"].Password))
+	})
+
+	It("should report drop statements without changing engine in plan mode", func() {
+		pgdb := newFakePGDB()
+		pgdb.Status = postgresqlv1alpha1.PostgresqlDatabaseStatus{
+			Phase:    postgresqlv1alpha1.DatabaseCreatedPhase,
+			Ready:    true,
+			Database: pgdbDBName,
+		}
+
+		pub := &postgresqlv1alpha1.PostgresqlPublication{
+			ObjectMeta: v1.ObjectMeta{Name: pgpublicationName, Namespace: pgpublicationNamespace},
+			Spec: postgresqlv1alpha1.PostgresqlPublicationSpec{
+				Database:     &common.CRLink{Name: pgdbName, Namespace: pgdbNamespace},
+				Name:         pgpublicationPublicationName1,
+				AllTables:    true,
+				DropOnDelete: true,
+			},
+		}
+
+		cl, fakePG, factory := setupFakeEnv(pgdb, pub)
+		// Create database in engine
+		Expect(fakePG.CreateDB(context.TODO(), pgdbDBName, postgresUser, nil)).To(Succeed())
+
+		r := newFakePGPublicationReconciler(cl, factory)
+
+		Expect(reconcileFakeUntilStable(r, pgpublicationName, pgpublicationNamespace)).To(Succeed())
+		Expect(fakePG.Databases[pgdbDBName].Publications).To(HaveKey(pgpublicationPublicationName1))
+
+		// Enable plan mode operator wide
+		r.PlanMode = true
+
+		item := &postgresqlv1alpha1.PostgresqlPublication{}
+		Expect(cl.Get(context.TODO(), types.NamespacedName{Name: pgpublicationName, Namespace: pgpublicationNamespace}, item)).To(Succeed())
+
+		// Delete
+		Expect(cl.Delete(context.TODO(), item)).To(Succeed())
+
+		_, err := r.Reconcile(context.TODO(), ctrl.Request{NamespacedName: types.NamespacedName{Name: pgpublicationName, Namespace: pgpublicationNamespace}})
+		Expect(err).NotTo(HaveOccurred())
+
+		// Finalizer must be kept with plan in status
+		Expect(cl.Get(context.TODO(), types.NamespacedName{Name: pgpublicationName, Namespace: pgpublicationNamespace}, item)).To(Succeed())
+		Expect(item.Finalizers).To(ContainElement(config.Finalizer))
+		Expect(item.Status.Plan).NotTo(BeNil())
+		Expect(item.Status.Plan.PendingStatements).To(Equal([]string{
+			"DropPublication(" + pgdbDBName + ", " + pgpublicationPublicationName1 + ")",
+			"DropReplicationSlot(" + pgpublicationPublicationName1 + ")",
+			"DropRoleAndDropAndChangeOwnedBy(" + pgpublicationPublicationName1 + DefaultReplicationUsernameSuffix + ", " + postgresUser + ", " + pgdbDBName + ")",
+		}))
+		Expect(fakePG.Databases[pgdbDBName].Publications).To(HaveKey(pgpublicationPublicationName1))
+		Expect(fakePG.ReplicationSlots).To(HaveKey(pgpublicationPublicationName1))
+
+		// Disable plan mode
+		r.PlanMode = false
+		fakePG.DisablePlanMode()
+
+		_, err = r.Reconcile(context.TODO(), ctrl.Request{NamespacedName: types.NamespacedName{Name: pgpublicationName, Namespace: pgpublicationNamespace}})
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(fakePG.Databases[pgdbDBName].Publications).NotTo(HaveKey(pgpublicationPublicationName1))
+		Expect(fakePG.ReplicationSlots).NotTo(HaveKey(pgpublicationPublicationName1))
+	})
+
+	It("should reject publication options unsupported by engine", func() {
+		pgdb := newFakePGDB()
+		pgdb.Status = postgresqlv1alpha1.PostgresqlDatabaseStatus{
+			Phase:    postgresqlv1alpha1.DatabaseCreatedPhase,
+			Ready:    true,
+			Database: pgdbDBName,
+		}
+
+		pub := &postgresqlv1alpha1.PostgresqlPublication{
+			ObjectMeta: v1.ObjectMeta{Name: pgpublicationName, Namespace: pgpublicationNamespace},
+			Spec: postgresqlv1alpha1.PostgresqlPublicationSpec{
+				Database:       &common.CRLink{Name: pgdbName, Namespace: pgdbNamespace},
+				Name:           pgpublicationPublicationName1,
+				TablesInSchema: []string{pgdbSchemaName1},
+			},
+		}
+
+		cl, fakePG, factory := setupFakeEnv(pgdb, pub)
+		// Create database in engine
+		Expect(fakePG.CreateDB(context.TODO(), pgdbDBName, postgresUser, nil)).To(Succeed())
+
+		// Save capabilities of a PostgreSQL 14 engine
+		pgec := &postgresqlv1alpha1.PostgresqlEngineConfiguration{}
+		Expect(cl.Get(context.TODO(), types.NamespacedName{Name: pgecName, Namespace: pgecNamespace}, pgec)).To(Succeed())
+		pgec.Status.Capabilities = &postgresqlv1alpha1.EngineCapabilities{ServerVersionNum: 140009, WalLevel: utils.LogicalWalLevel}
+		Expect(cl.Status().Update(context.TODO(), pgec)).To(Succeed())
+
+		r := newFakePGPublicationReconciler(cl, factory)
+
+		Expect(reconcileFakeUntilStable(r, pgpublicationName, pgpublicationNamespace)).NotTo(Succeed())
+
+		item := &postgresqlv1alpha1.PostgresqlPublication{}
+		Expect(cl.Get(context.TODO(), types.NamespacedName{Name: pgpublicationName, Namespace: pgpublicationNamespace}, item)).To(Succeed())
+
+		// Checks
+		Expect(item.Status.Ready).To(BeFalse())
+		Expect(item.Status.Phase).To(Equal(postgresqlv1alpha1.PublicationFailedPhase))
+		Expect(item.Status.Message).To(Equal("tables in schema requires PostgreSQL 15.0 or later but engine version is 14.9"))
+		Expect(fakePG.Databases[pgdbDBName].Publications).NotTo(HaveKey(pgpublicationPublicationName1))
+		Expect(fakePG.HasCall("GetPublication")).To(BeFalse())
+	})
+})
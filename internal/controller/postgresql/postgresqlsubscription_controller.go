@@ -0,0 +1,665 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postgresql
+
+import (
+	"context"
+	"reflect"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"github.com/easymile/postgresql-operator/api/postgresql/v1alpha1"
+	"github.com/easymile/postgresql-operator/internal/controller/config"
+	"github.com/easymile/postgresql-operator/internal/controller/postgresql/postgres"
+	"github.com/easymile/postgresql-operator/internal/controller/utils"
+	"github.com/go-logr/logr"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// PostgresqlSubscriptionReconciler reconciles a PostgresqlSubscription object.
+type PostgresqlSubscriptionReconciler struct {
+	Recorder record.EventRecorder
+	client.Client
+	Scheme                              *runtime.Scheme
+	ControllerRuntimeDetailedErrorTotal *prometheus.CounterVec
+	Log                                 logr.Logger
+	ControllerName                      string
+	ReconcileTimeout                    time.Duration
+	PgInstanceFactory                   utils.PgInstanceFactory
+}
+
+//+kubebuilder:rbac:groups=postgresql.easymile.com,resources=postgresqlsubscriptions,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=postgresql.easymile.com,resources=postgresqlsubscriptions/status,verbs=get;update;patch
+//+kubebuilder:rbac:groups=postgresql.easymile.com,resources=postgresqlsubscriptions/finalizers,verbs=update
+
+// Reconcile is part of the main kubernetes reconciliation loop which aims to
+// move the current state of the cluster closer to the desired state.
+// Reconcile function to compare the state specified by
+// the PostgresqlSubscription object against the actual cluster state, and then
+// perform operations to make the cluster state reflect the state specified by
+// the user.
+//
+// For more details, check Reconcile and its Result here:
+// - https://pkg.go.dev/sigs.k8s.io/controller-runtime@v0.15.0/pkg/reconcile
+func (r *PostgresqlSubscriptionReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) { //nolint:wsl // it is like that
+	// Issue with this logger: controller and controllerKind are incorrect
+	// Build another logger from upper to fix this.
+	// reqLogger := log.FromContext(ctx)
+
+	reqLogger := r.Log.WithValues("Request.Namespace", req.Namespace, "Request.Name", req.Name)
+
+	reqLogger.Info("Reconciling PostgresqlSubscription")
+
+	// Fetch the PostgresqlSubscription instance
+	instance := &v1alpha1.PostgresqlSubscription{}
+	err := r.Get(ctx, req.NamespacedName, instance)
+
+	if err != nil {
+		if errors.IsNotFound(err) {
+			// Request object not found, could have been deleted after reconcile request.
+			// Owned objects are automatically garbage collected. For additional cleanup logic use finalizers.
+			// Return and don't requeue
+			return reconcile.Result{}, nil
+		}
+		// Error reading the object - requeue the request.
+		return reconcile.Result{}, err
+	}
+
+	// Original patch
+	originalPatch := client.MergeFrom(instance.DeepCopy())
+
+	// Create timeout in ctx
+	timeoutCtx, cancel := context.WithTimeout(ctx, r.ReconcileTimeout)
+	// Defer cancel
+	defer cancel()
+
+	// Save resource at the origin of statements for audit
+	timeoutCtx = postgres.WithAuditResource(timeoutCtx, &postgres.AuditResource{
+		Kind:        "PostgresqlSubscription",
+		Namespace:   req.Namespace,
+		Name:        req.Name,
+		ReconcileID: string(controller.ReconcileIDFromContext(ctx)),
+	})
+
+	// Init result
+	var res ctrl.Result
+
+	errC := make(chan error, 1)
+
+	// Create wrapping function
+	cb := func() {
+		a, err := r.mainReconcile(timeoutCtx, reqLogger, instance, originalPatch)
+		// Save result
+		res = a
+		// Send error
+		errC <- err
+	}
+
+	// Start wrapped function
+	go cb()
+
+	// Run or timeout
+	select {
+	case <-timeoutCtx.Done():
+		// ? Note: Here use primary context otherwise update to set error will be aborted
+		return r.manageError(ctx, reqLogger, instance, originalPatch, timeoutCtx.Err())
+	case err := <-errC:
+		return res, err
+	}
+}
+
+func (r *PostgresqlSubscriptionReconciler) mainReconcile(
+	ctx context.Context,
+	reqLogger logr.Logger,
+	instance *v1alpha1.PostgresqlSubscription,
+	originalPatch client.Patch,
+) (ctrl.Result, error) {
+	// Deletion case
+	if !instance.GetDeletionTimestamp().IsZero() { //nolint:wsl
+		// Deletion detected
+
+		// Check if drop on delete is enabled
+		if instance.Spec.DropOnDelete {
+			// Delete subscription
+			err := r.manageDropSubscription(ctx, reqLogger, instance)
+			if err != nil {
+				return r.manageError(ctx, reqLogger, instance, originalPatch, err)
+			}
+		}
+
+		// Remove finalizer
+		controllerutil.RemoveFinalizer(instance, config.Finalizer)
+
+		// Update CR
+		err := r.Update(ctx, instance)
+		if err != nil {
+			return r.manageError(ctx, reqLogger, instance, originalPatch, err)
+		}
+
+		reqLogger.Info("Successfully deleted")
+		// Stop reconcile
+		return reconcile.Result{}, nil
+	}
+
+	// Creation / Update case
+
+	// Validate
+	err := r.validate(instance)
+	// Check error
+	if err != nil {
+		return r.manageError(ctx, reqLogger, instance, originalPatch, err)
+	}
+
+	// Try to find pg publication CR
+	pgPub, err := utils.FindPgPublicationFromLink(ctx, r.Client, instance.Spec.Publication, instance.Namespace)
+	if err != nil {
+		return r.manageError(ctx, reqLogger, instance, originalPatch, err)
+	}
+
+	// Check that postgres publication is ready before continue but only if it is the first time
+	// If not, requeue event
+	if instance.Status.Phase == v1alpha1.SubscriptionNoPhase && !pgPub.Status.Ready {
+		reqLogger.Info("PostgresqlPublication not ready, waiting for it")
+		r.Recorder.Event(instance, "Warning", "Processing", "Processing stopped because PostgresqlPublication isn't ready. Waiting for it.")
+
+		return ctrl.Result{}, nil
+	}
+
+	// Build connection info to the publication engine
+	connInfo, err := r.buildPublicationConnectionInfo(ctx, instance, pgPub)
+	if err != nil {
+		return r.manageError(ctx, reqLogger, instance, originalPatch, err)
+	}
+
+	// Try to find pg db CR
+	pgDB, err := utils.FindPgDatabaseFromLink(ctx, r.Client, instance.Spec.Database, instance.Namespace)
+	if err != nil {
+		return r.manageError(ctx, reqLogger, instance, originalPatch, err)
+	}
+
+	// Check that postgres database is ready before continue but only if it is the first time
+	// If not, requeue event
+	if instance.Status.Phase == v1alpha1.SubscriptionNoPhase && !pgDB.Status.Ready {
+		reqLogger.Info("PostgresqlDatabase not ready, waiting for it")
+		r.Recorder.Event(instance, "Warning", "Processing", "Processing stopped because PostgresqlDatabase isn't ready. Waiting for it.")
+
+		return ctrl.Result{}, nil
+	}
+
+	// Try to find PostgresqlEngineConfiguration CR
+	pgEngCfg, err := utils.FindPgEngineCfg(ctx, r.Client, pgDB)
+	if err != nil {
+		return r.manageError(ctx, reqLogger, instance, originalPatch, err)
+	}
+
+	// Check that postgres engine configuration is ready before continue but only if it is the first time
+	// If not, requeue event
+	if instance.Status.Phase == v1alpha1.SubscriptionNoPhase && !pgEngCfg.Status.Ready {
+		reqLogger.Info("PostgresqlEngineConfiguration not ready, waiting for it")
+		r.Recorder.Event(instance, "Warning", "Processing", "Processing stopped because PostgresqlEngineConfiguration isn't ready. Waiting for it.")
+
+		return ctrl.Result{}, nil
+	}
+
+	// Validate with engine capabilities
+	// ? Note: Logical replication subscriptions exist since PostgreSQL 10
+	err = utils.CheckEngineMinVersion(pgEngCfg, utils.PostgresqlVersion10, "subscription")
+	// Check error
+	if err != nil {
+		return r.manageError(ctx, reqLogger, instance, originalPatch, err)
+	}
+
+	// Check engine quotas
+	err = checkReplicationSlotQuota(ctx, r.Client, pgEngCfg, instance)
+	// Check error
+	if err != nil {
+		return r.manageError(ctx, reqLogger, instance, originalPatch, err)
+	}
+
+	// Get secret linked to PostgresqlEngineConfiguration CR
+	secret, err := utils.FindSecretPgEngineCfg(ctx, r.Client, pgEngCfg)
+	if err != nil {
+		return r.manageError(ctx, reqLogger, instance, originalPatch, err)
+	}
+
+	// Add finalizer, owners and default values
+	updated, err := r.updateInstance(ctx, instance)
+	// Check error
+	if err != nil {
+		return r.manageError(ctx, reqLogger, instance, originalPatch, err)
+	}
+	// Check if it has been updated in order to stop this reconcile loop here for the moment
+	if updated {
+		return ctrl.Result{}, nil
+	}
+
+	// Calculate hash for status (this time is to update it in status)
+	// ? Note: Publication data and connection info are included to detect changes on publication side
+	hash, err := utils.CalculateHash(map[string]interface{}{
+		"spec":        instance.Spec,
+		"publication": pgPub.Status.Name,
+		"slot":        pgPub.Status.ReplicationSlotName,
+		"connInfo":    connInfo,
+	})
+	if err != nil {
+		return r.manageError(ctx, reqLogger, instance, originalPatch, newInternalError(err))
+	}
+
+	// Create PG instance
+	pg := r.PgInstanceFactory.CreatePgInstance(reqLogger, secret.Data, pgEngCfg)
+
+	// Compute name to search
+	nameToSearch := instance.Status.Name
+	// Check
+	if nameToSearch == "" {
+		// ? This is done to recover the first creation with an existing subscription with the same name
+		nameToSearch = instance.Spec.Name
+	}
+
+	// Get subscription
+	subRes, err := pg.GetSubscription(ctx, pgDB.Status.Database, nameToSearch)
+	// Check error
+	if err != nil {
+		return r.manageError(ctx, reqLogger, instance, originalPatch, err)
+	}
+
+	// Check if subscription haven't been found
+	if subRes == nil {
+		// Create case
+		reqLogger.Info("Subscription creation case detected")
+
+		err = r.manageCreate(ctx, instance, pg, pgDB, pgPub, connInfo)
+		// Check error
+		if err != nil {
+			return r.manageError(ctx, reqLogger, instance, originalPatch, err)
+		}
+	} else if hash != instance.Status.Hash {
+		// Update case
+		// Need to check if status hash is the same or not to force renew or not
+		reqLogger.Info("Subscription update case detected and specs are different, update need to be done")
+
+		err = r.manageUpdate(ctx, instance, pg, pgDB, pgPub, subRes, nameToSearch, connInfo)
+		// Check error
+		if err != nil {
+			return r.manageError(ctx, reqLogger, instance, originalPatch, err)
+		}
+	}
+
+	// Get subscription after changes to have an up to date state
+	subRes, err = pg.GetSubscription(ctx, pgDB.Status.Database, instance.Spec.Name)
+	// Check error
+	if err != nil {
+		return r.manageError(ctx, reqLogger, instance, originalPatch, err)
+	}
+	// Check if it is still not found
+	if subRes == nil {
+		return r.manageError(ctx, reqLogger, instance, originalPatch, errors.NewBadRequest("subscription cannot be found after creation or update"))
+	}
+
+	// Save name
+	instance.Status.Name = instance.Spec.Name
+	// Save hash in status
+	instance.Status.Hash = hash
+	// Save publication data
+	instance.Status.PublicationName = pgPub.Status.Name
+	instance.Status.ReplicationSlotName = subRes.SlotName
+	// Save state
+	instance.Status.State = computeSubscriptionState(subRes)
+	instance.Status.LastReceivedLSN = subRes.ReceivedLSN
+
+	return r.manageSuccess(ctx, reqLogger, instance, originalPatch)
+}
+
+func computeSubscriptionState(subRes *postgres.SubscriptionResult) v1alpha1.SubscriptionState {
+	// Check if it is disabled
+	if !subRes.Enabled {
+		return v1alpha1.SubscriptionDisabledState
+	}
+
+	// Check if worker is running
+	if subRes.WorkerRunning {
+		return v1alpha1.SubscriptionStreamingState
+	}
+
+	return v1alpha1.SubscriptionEnabledState
+}
+
+func (r *PostgresqlSubscriptionReconciler) buildPublicationConnectionInfo(
+	ctx context.Context,
+	instance *v1alpha1.PostgresqlSubscription,
+	pgPub *v1alpha1.PostgresqlPublication,
+) (string, error) {
+	// Check that publication is created
+	if pgPub.Status.Name == "" || pgPub.Status.ReplicationSlotName == "" || pgPub.Status.ReplicationUserSecretName == "" {
+		return "", errors.NewBadRequest("publication isn't created yet")
+	}
+
+	// Try to find publication pg db CR
+	// ? Note: Subscription namespace must be allowed to reference it as it will consume its data
+	pubDB, err := utils.FindPgPublicationPgDatabase(ctx, r.Client, pgPub, instance.Namespace)
+	if err != nil {
+		return "", err
+	}
+
+	// Try to find publication PostgresqlEngineConfiguration CR
+	pubEngCfg, err := utils.FindPgEngineCfg(ctx, r.Client, pubDB)
+	if err != nil {
+		return "", err
+	}
+
+	// Check that primary user connection is available
+	if pubEngCfg.Spec.UserConnections == nil || pubEngCfg.Spec.UserConnections.PrimaryConnection == nil {
+		return "", errors.NewBadRequest("publication PostgresqlEngineConfiguration has no primary user connection")
+	}
+
+	// Get secret containing publication replication user credentials
+	// ? Note: Engine credentials mustn't be used as they would be stored in subscription
+	secret := &corev1.Secret{}
+	err = r.Get(ctx, types.NamespacedName{Name: pgPub.Status.ReplicationUserSecretName, Namespace: pgPub.Namespace}, secret)
+	if err != nil {
+		return "", err
+	}
+
+	// Save primary connection for easy use
+	primary := pubEngCfg.Spec.UserConnections.PrimaryConnection
+
+	return postgres.BuildSubscriptionConnectionInfo(
+		primary.Host,
+		string(secret.Data[UsernameSecretKey]),
+		string(secret.Data[PasswordSecretKey]),
+		primary.URIArgs,
+		pubDB.Status.Database,
+		primary.Port,
+	), nil
+}
+
+func (*PostgresqlSubscriptionReconciler) manageUpdate(
+	ctx context.Context,
+	instance *v1alpha1.PostgresqlSubscription,
+	pg postgres.PG,
+	pgDB *v1alpha1.PostgresqlDatabase,
+	pgPub *v1alpha1.PostgresqlPublication,
+	subRes *postgres.SubscriptionResult,
+	currentSubscriptionName string,
+	connInfo string,
+) error {
+	// Create builder
+	builder := postgres.NewUpdateSubscriptionBuilder()
+
+	// Check if subscription has to be renamed
+	if instance.Spec.Name != currentSubscriptionName {
+		builder = builder.RenameTo(instance.Spec.Name)
+	}
+
+	// Set connection and publication
+	builder = builder.SetConnectionInfo(connInfo).SetPublication(pgPub.Status.Name)
+
+	// Check if replication slot has to be changed
+	if subRes.SlotName != pgPub.Status.ReplicationSlotName {
+		builder = builder.SetReplicationSlot(pgPub.Status.ReplicationSlotName)
+	}
+
+	// Check if there are with options
+	if instance.Spec.WithParameters != nil {
+		// Change enabled
+		builder = builder.SetEnabled(instance.Spec.WithParameters.Enabled)
+	}
+
+	// Perform update
+	// ? Note: this will do an alter even if it is unnecessary
+	// ? Detecting real diff will be long and painful, perform an alter with what is asked will ensure that nothing can be changed
+	err := pg.AlterSubscription(ctx, pgDB.Status.Database, currentSubscriptionName, builder)
+	// Check error
+	if err != nil {
+		return err
+	}
+
+	// Default
+	return nil
+}
+
+func (*PostgresqlSubscriptionReconciler) manageCreate(
+	ctx context.Context,
+	instance *v1alpha1.PostgresqlSubscription,
+	pg postgres.PG,
+	pgDB *v1alpha1.PostgresqlDatabase,
+	pgPub *v1alpha1.PostgresqlPublication,
+	connInfo string,
+) error {
+	// Save spec for easy use
+	spec := instance.Spec
+
+	// Create builder
+	builder := postgres.NewCreateSubscriptionBuilder()
+
+	// Add name, connection, publication and replication slot
+	builder = builder.
+		SetName(spec.Name).
+		SetConnectionInfo(connInfo).
+		SetPublication(pgPub.Status.Name).
+		SetReplicationSlot(pgPub.Status.ReplicationSlotName)
+
+	// Check if with is set
+	if spec.WithParameters != nil {
+		// Manage with
+		builder = builder.SetWith(spec.WithParameters.Enabled, spec.WithParameters.CopyData)
+	}
+
+	// Create subscription
+	err := pg.CreateSubscription(ctx, pgDB.Status.Database, builder)
+	// Check error
+	if err != nil {
+		return err
+	}
+
+	// Default
+	return nil
+}
+
+func (*PostgresqlSubscriptionReconciler) validate(
+	instance *v1alpha1.PostgresqlSubscription,
+) error {
+	// Save spec for easy use
+	spec := instance.Spec
+
+	// Check name
+	if spec.Name == "" {
+		return errors.NewBadRequest("name must have a value")
+	}
+
+	// Check publication link
+	if spec.Publication == nil || spec.Publication.Name == "" {
+		return errors.NewBadRequest("publication must be set")
+	}
+
+	// Check database link
+	if spec.Database == nil || spec.Database.Name == "" {
+		return errors.NewBadRequest("database must be set")
+	}
+
+	// Default
+	return nil
+}
+
+func (r *PostgresqlSubscriptionReconciler) updateInstance(
+	ctx context.Context,
+	instance *v1alpha1.PostgresqlSubscription,
+) (bool, error) {
+	// Deep copy
+	oCopy := instance.DeepCopy()
+
+	// Add finalizer
+	controllerutil.AddFinalizer(instance, config.Finalizer)
+
+	// Check if update is needed
+	if !reflect.DeepEqual(oCopy.ObjectMeta, instance.ObjectMeta) {
+		return true, r.Update(ctx, instance)
+	}
+
+	return false, nil
+}
+
+func (r *PostgresqlSubscriptionReconciler) manageDropSubscription(
+	ctx context.Context,
+	logger logr.Logger,
+	instance *v1alpha1.PostgresqlSubscription,
+) error {
+	// Get pg db
+	pgDB, err := utils.FindPgDatabaseFromLink(ctx, r.Client, instance.Spec.Database, instance.Namespace)
+	if err != nil && !errors.IsNotFound(err) {
+		return err
+	}
+	// In case of not found => Can't delete => skip
+	if errors.IsNotFound(err) {
+		logger.Error(err, "can't delete subscription because PostgresDatabase didn't exists anymore")
+
+		return nil
+	}
+
+	// Try to find PostgresqlEngineConfiguration CR
+	pgEngCfg, err := utils.FindPgEngineCfg(ctx, r.Client, pgDB)
+	if err != nil && !errors.IsNotFound(err) {
+		return err
+	}
+	// In case of not found => Can't delete => skip
+	if errors.IsNotFound(err) {
+		logger.Error(err, "can't delete subscription because PostgresEngineConfiguration didn't exists anymore")
+
+		return nil
+	}
+
+	// Get secret linked to PostgresqlEngineConfiguration CR
+	secret, err := utils.FindSecretPgEngineCfg(ctx, r.Client, pgEngCfg)
+	if err != nil {
+		return err
+	}
+
+	// Create PG instance
+	pg := r.PgInstanceFactory.CreatePgInstance(logger, secret.Data, pgEngCfg)
+
+	// Compute name to delete
+	name := instance.Status.Name
+	// Check
+	if name == "" {
+		name = instance.Spec.Name
+	}
+
+	// Get subscription
+	sub, err := pg.GetSubscription(ctx, pgDB.Status.Database, name)
+	if err != nil {
+		return err
+	}
+
+	// Check if subscription is still present to delete it
+	if sub != nil {
+		// Drop subscription
+		err = pg.DropSubscription(ctx, pgDB.Status.Database, name)
+		// Check error
+		if err != nil {
+			return err
+		}
+	}
+
+	// Default
+	return nil
+}
+
+func (r *PostgresqlSubscriptionReconciler) manageError(
+	ctx context.Context,
+	logger logr.Logger,
+	instance *v1alpha1.PostgresqlSubscription,
+	originalPatch client.Patch,
+	issue error,
+) (reconcile.Result, error) {
+	// Check if issue is retryable
+	res, managed := manageReadOnlyTransactionError(
+		ctx, logger, r.Status(), r.Recorder, instance,
+		&instance.Status.Message, &instance.Status.Reason,
+		originalPatch, issue,
+	)
+	if managed {
+		return res, nil
+	}
+
+	logger.Error(issue, "issue raised in reconcile")
+	// Add kubernetes event
+	r.Recorder.Event(instance, "Warning", "ProcessingError", issue.Error())
+
+	// Update status
+	instance.Status.Message = issue.Error()
+	instance.Status.Reason = string(errors.ReasonForError(issue))
+	instance.Status.Ready = false
+	instance.Status.Phase = v1alpha1.SubscriptionFailedPhase
+
+	// Increase fail counter
+	r.ControllerRuntimeDetailedErrorTotal.WithLabelValues(r.ControllerName, instance.Namespace, instance.Name).Inc()
+
+	// Patch status
+	err := r.Status().Patch(ctx, instance, originalPatch)
+	if err != nil {
+		logger.Error(err, "unable to update status")
+	}
+
+	// Return error
+	return ctrl.Result{}, issue
+}
+
+func (r *PostgresqlSubscriptionReconciler) manageSuccess(
+	ctx context.Context,
+	logger logr.Logger,
+	instance *v1alpha1.PostgresqlSubscription,
+	originalPatch client.Patch,
+) (reconcile.Result, error) {
+	// Update status
+	instance.Status.Message = ""
+	instance.Status.Reason = ""
+	instance.Status.Ready = true
+	instance.Status.Phase = v1alpha1.SubscriptionCreatedPhase
+
+	// Patch status
+	err := r.Status().Patch(ctx, instance, originalPatch)
+	if err != nil {
+		// Increase fail counter
+		r.ControllerRuntimeDetailedErrorTotal.WithLabelValues(r.ControllerName, instance.Namespace, instance.Name).Inc()
+
+		logger.Error(err, "unable to update status")
+
+		// Return error
+		return ctrl.Result{}, err
+	}
+
+	logger.Info("Reconcile done")
+
+	return reconcile.Result{}, nil
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *PostgresqlSubscriptionReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&v1alpha1.PostgresqlSubscription{}).
+		Complete(r)
+}
@@ -0,0 +1,472 @@
+package postgresql
+
+import (
+	"context"
+	gerrors "errors"
+	"fmt"
+
+	"github.com/easymile/postgresql-operator/api/postgresql/common"
+	postgresqlv1alpha1 "github.com/easymile/postgresql-operator/api/postgresql/v1alpha1"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	apimachineryErrors "k8s.io/apimachinery/pkg/api/errors"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+var _ = Describe("PostgresqlSubscription tests", func() {
+	AfterEach(cleanupFunction)
+
+	Describe("Spec error", func() {
+		It("shouldn't accept input without any specs", func() {
+			err := k8sClient.Create(ctx, &postgresqlv1alpha1.PostgresqlSubscription{
+				ObjectMeta: v1.ObjectMeta{
+					Name:      pgsubscriptionName,
+					Namespace: pgsubscriptionNamespace,
+				},
+			})
+
+			Expect(err).To(HaveOccurred())
+
+			// Cast error
+			stErr, ok := err.(*apimachineryErrors.StatusError)
+
+			Expect(ok).To(BeTrue())
+
+			// Check that content is correct
+			causes := stErr.Status().Details.Causes
+
+			Expect(causes).To(HaveLen(3))
+
+			// Search all fields
+			fields := map[string]bool{
+				"spec.publication": false,
+				"spec.database":    false,
+				"spec.name":        false,
+			}
+
+			// Loop over all causes
+			for _, cause := range causes {
+				fields[cause.Field] = true
+			}
+
+			// Check that all fields are found
+			for key, value := range fields {
+				if !value {
+					err := fmt.Errorf("%s found be found in error causes", key)
+					Expect(err).ToNot(HaveOccurred())
+				}
+			}
+		})
+
+		It("should fail when name is empty", func() {
+			it := &postgresqlv1alpha1.PostgresqlSubscription{
+				ObjectMeta: v1.ObjectMeta{
+					Name:      pgsubscriptionName,
+					Namespace: pgsubscriptionNamespace,
+				},
+				Spec: postgresqlv1alpha1.PostgresqlSubscriptionSpec{
+					Publication: &common.CRLink{
+						Name:      pgpublicationName,
+						Namespace: pgpublicationNamespace,
+					},
+					Database: &common.CRLink{
+						Name:      pgdbName2,
+						Namespace: pgdbNamespace,
+					},
+				},
+			}
+
+			item := setupSavePGSubscriptionInternal(it)
+
+			// Checks
+			Expect(item.Status.Ready).To(BeFalse())
+			Expect(item.Status.Phase).To(Equal(postgresqlv1alpha1.SubscriptionFailedPhase))
+			Expect(item.Status.Message).To(Equal("name must have a value"))
+		})
+
+		It("should fail when publication doesn't exist", func() {
+			// Setup pgec
+			setupPGEC("30s", false)
+			// Create pgdb
+			setupPGDB2()
+
+			// Setup a pg subscription
+			item := setupPGSubscriptionWithPartialSpec(postgresqlv1alpha1.PostgresqlSubscriptionSpec{})
+
+			// Checks
+			Expect(item.Status.Ready).To(BeFalse())
+			Expect(item.Status.Phase).To(Equal(postgresqlv1alpha1.SubscriptionFailedPhase))
+			Expect(item.Status.Message).To(ContainSubstring("not found"))
+		})
+	})
+
+	Describe("Creation", func() {
+		It("should be ok with default values", func() {
+			// Setup pgec
+			setupPGEC("30s", false)
+			// Create pgdb
+			setupPGDB(false)
+			setupPGDB2()
+
+			// Setup a pg publication
+			pub := setupPGPublicationWithPartialSpec(postgresqlv1alpha1.PostgresqlPublicationSpec{AllTables: true})
+			Expect(pub.Status.Phase).To(Equal(postgresqlv1alpha1.PublicationCreatedPhase))
+
+			// Setup a pg subscription
+			item := setupPGSubscriptionWithPartialSpec(postgresqlv1alpha1.PostgresqlSubscriptionSpec{
+				WithParameters: &postgresqlv1alpha1.PostgresqlSubscriptionWith{CopyData: starAny(false)},
+			})
+
+			// Checks
+			Expect(item.Status.Ready).To(BeTrue())
+			Expect(item.Status.Phase).To(Equal(postgresqlv1alpha1.SubscriptionCreatedPhase))
+			Expect(item.Status.Message).To(Equal(""))
+			Expect(item.Status.Hash).NotTo(Equal(""))
+			Expect(item.Status.Name).To(Equal(pgsubscriptionSubscriptionName1))
+			Expect(item.Status.PublicationName).To(Equal(pgpublicationPublicationName1))
+			Expect(item.Status.ReplicationSlotName).To(Equal(pgpublicationPublicationName1))
+			Expect(item.Status.State).NotTo(Equal(postgresqlv1alpha1.SubscriptionDisabledState))
+
+			data, err := getSubscription(item.Status.Name)
+			if Expect(err).NotTo(HaveOccurred()) {
+				Expect(data).To(Equal(&SubscriptionResult{
+					Enabled:      true,
+					SlotName:     pgpublicationPublicationName1,
+					Publications: pgpublicationPublicationName1,
+				}))
+			}
+
+			// Check that replication slot is still owned by the publication database
+			data2, err := getReplicationSlot(pub.Status.ReplicationSlotName)
+			if Expect(err).NotTo(HaveOccurred()) {
+				Expect(data2).To(Equal(&replicationSlotResult{
+					SlotName: pub.Status.ReplicationSlotName,
+					Plugin:   DefaultReplicationSlotPlugin,
+					Database: pgdbDBName,
+				}))
+			}
+		})
+
+		It("should be ok with a disabled subscription", func() {
+			// Setup pgec
+			setupPGEC("30s", false)
+			// Create pgdb
+			setupPGDB(false)
+			setupPGDB2()
+
+			// Setup a pg publication
+			setupPGPublicationWithPartialSpec(postgresqlv1alpha1.PostgresqlPublicationSpec{AllTables: true})
+
+			// Setup a pg subscription
+			item := setupPGSubscriptionWithPartialSpec(postgresqlv1alpha1.PostgresqlSubscriptionSpec{
+				WithParameters: &postgresqlv1alpha1.PostgresqlSubscriptionWith{
+					Enabled:  starAny(false),
+					CopyData: starAny(false),
+				},
+			})
+
+			// Checks
+			Expect(item.Status.Ready).To(BeTrue())
+			Expect(item.Status.Phase).To(Equal(postgresqlv1alpha1.SubscriptionCreatedPhase))
+			Expect(item.Status.State).To(Equal(postgresqlv1alpha1.SubscriptionDisabledState))
+			Expect(item.Status.LastReceivedLSN).To(Equal(""))
+
+			data, err := getSubscription(item.Status.Name)
+			if Expect(err).NotTo(HaveOccurred()) {
+				Expect(data).To(Equal(&SubscriptionResult{
+					Enabled:      false,
+					SlotName:     pgpublicationPublicationName1,
+					Publications: pgpublicationPublicationName1,
+				}))
+			}
+		})
+	})
+
+	Describe("Update", func() {
+		It("should be ok to disable", func() {
+			// Setup pgec
+			setupPGEC("30s", false)
+			// Create pgdb
+			setupPGDB(false)
+			setupPGDB2()
+
+			// Setup a pg publication
+			setupPGPublicationWithPartialSpec(postgresqlv1alpha1.PostgresqlPublicationSpec{AllTables: true})
+
+			// Setup a pg subscription
+			item := setupPGSubscriptionWithPartialSpec(postgresqlv1alpha1.PostgresqlSubscriptionSpec{
+				WithParameters: &postgresqlv1alpha1.PostgresqlSubscriptionWith{CopyData: starAny(false)},
+			})
+
+			Expect(item.Status.Phase).To(Equal(postgresqlv1alpha1.SubscriptionCreatedPhase))
+
+			// Save
+			hash := item.Status.Hash
+			// Update
+			item.Spec.WithParameters.Enabled = starAny(false)
+			// Update
+			err := k8sClient.Update(ctx, item)
+			Expect(err).NotTo(HaveOccurred())
+
+			updatedItem := &postgresqlv1alpha1.PostgresqlSubscription{}
+
+			Eventually(
+				func() error {
+					err := k8sClient.Get(ctx, types.NamespacedName{
+						Name:      item.Name,
+						Namespace: item.Namespace,
+					}, updatedItem)
+					// Check error
+					if err != nil {
+						return err
+					}
+
+					// Check if status hasn't been updated
+					if updatedItem.Status.Hash == hash {
+						return gerrors.New("hasn't been updated by operator")
+					}
+
+					return nil
+				},
+				generalEventuallyTimeout,
+				generalEventuallyInterval,
+			).
+				Should(Succeed())
+
+			// Checks
+			Expect(updatedItem.Status.Ready).To(BeTrue())
+			Expect(updatedItem.Status.Phase).To(Equal(postgresqlv1alpha1.SubscriptionCreatedPhase))
+			Expect(updatedItem.Status.State).To(Equal(postgresqlv1alpha1.SubscriptionDisabledState))
+
+			data, err := getSubscription(updatedItem.Status.Name)
+			if Expect(err).NotTo(HaveOccurred()) {
+				Expect(data).To(Equal(&SubscriptionResult{
+					Enabled:      false,
+					SlotName:     pgpublicationPublicationName1,
+					Publications: pgpublicationPublicationName1,
+				}))
+			}
+		})
+
+		It("should be ok to rename", func() {
+			// Setup pgec
+			setupPGEC("30s", false)
+			// Create pgdb
+			setupPGDB(false)
+			setupPGDB2()
+
+			// Setup a pg publication
+			setupPGPublicationWithPartialSpec(postgresqlv1alpha1.PostgresqlPublicationSpec{AllTables: true})
+
+			// Setup a pg subscription
+			item := setupPGSubscriptionWithPartialSpec(postgresqlv1alpha1.PostgresqlSubscriptionSpec{
+				WithParameters: &postgresqlv1alpha1.PostgresqlSubscriptionWith{CopyData: starAny(false)},
+			})
+
+			Expect(item.Status.Phase).To(Equal(postgresqlv1alpha1.SubscriptionCreatedPhase))
+
+			// Save
+			hash := item.Status.Hash
+			// Build new name
+			oldName := item.Spec.Name
+			newName := oldName + "rename"
+			// Update
+			item.Spec.Name = newName
+			// Update
+			err := k8sClient.Update(ctx, item)
+			Expect(err).NotTo(HaveOccurred())
+
+			updatedItem := &postgresqlv1alpha1.PostgresqlSubscription{}
+
+			Eventually(
+				func() error {
+					err := k8sClient.Get(ctx, types.NamespacedName{
+						Name:      item.Name,
+						Namespace: item.Namespace,
+					}, updatedItem)
+					// Check error
+					if err != nil {
+						return err
+					}
+
+					// Check if status hasn't been updated
+					if updatedItem.Status.Hash == hash {
+						return gerrors.New("hasn't been updated by operator")
+					}
+
+					return nil
+				},
+				generalEventuallyTimeout,
+				generalEventuallyInterval,
+			).
+				Should(Succeed())
+
+			// Checks
+			Expect(updatedItem.Status.Ready).To(BeTrue())
+			Expect(updatedItem.Status.Phase).To(Equal(postgresqlv1alpha1.SubscriptionCreatedPhase))
+			Expect(updatedItem.Status.Name).To(Equal(newName))
+
+			oldData, err := getSubscription(oldName)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(oldData).To(BeNil())
+
+			data, err := getSubscription(newName)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(data).NotTo(BeNil())
+		})
+	})
+
+	Describe("Deletion", func() {
+		It("should keep subscription when drop on delete isn't enabled", func() {
+			// Setup pgec
+			setupPGEC("30s", false)
+			// Create pgdb
+			setupPGDB(false)
+			setupPGDB2()
+
+			// Setup a pg publication
+			setupPGPublicationWithPartialSpec(postgresqlv1alpha1.PostgresqlPublicationSpec{AllTables: true})
+
+			// Setup a pg subscription
+			item := setupPGSubscriptionWithPartialSpec(postgresqlv1alpha1.PostgresqlSubscriptionSpec{
+				WithParameters: &postgresqlv1alpha1.PostgresqlSubscriptionWith{CopyData: starAny(false)},
+			})
+
+			Expect(item.Status.Phase).To(Equal(postgresqlv1alpha1.SubscriptionCreatedPhase))
+
+			// Delete
+			Expect(k8sClient.Delete(ctx, item)).To(Succeed())
+
+			Eventually(
+				func() error {
+					err := k8sClient.Get(ctx, types.NamespacedName{
+						Name:      item.Name,
+						Namespace: item.Namespace,
+					}, &postgresqlv1alpha1.PostgresqlSubscription{})
+					// Check error
+					if err != nil && apimachineryErrors.IsNotFound(err) {
+						return nil
+					}
+
+					return gerrors.New("object still present")
+				},
+				generalEventuallyTimeout,
+				generalEventuallyInterval,
+			).
+				Should(Succeed())
+
+			data, err := getSubscription(item.Status.Name)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(data).NotTo(BeNil())
+		})
+
+		It("should drop subscription and keep replication slot when drop on delete is enabled", func() {
+			// Setup pgec
+			setupPGEC("30s", false)
+			// Create pgdb
+			setupPGDB(false)
+			setupPGDB2()
+
+			// Setup a pg publication
+			pub := setupPGPublicationWithPartialSpec(postgresqlv1alpha1.PostgresqlPublicationSpec{AllTables: true})
+
+			// Setup a pg subscription
+			item := setupPGSubscriptionWithPartialSpec(postgresqlv1alpha1.PostgresqlSubscriptionSpec{
+				DropOnDelete:   true,
+				WithParameters: &postgresqlv1alpha1.PostgresqlSubscriptionWith{CopyData: starAny(false)},
+			})
+
+			Expect(item.Status.Phase).To(Equal(postgresqlv1alpha1.SubscriptionCreatedPhase))
+
+			// Delete
+			Expect(k8sClient.Delete(ctx, item)).To(Succeed())
+
+			Eventually(
+				func() error {
+					err := k8sClient.Get(ctx, types.NamespacedName{
+						Name:      item.Name,
+						Namespace: item.Namespace,
+					}, &postgresqlv1alpha1.PostgresqlSubscription{})
+					// Check error
+					if err != nil && apimachineryErrors.IsNotFound(err) {
+						return nil
+					}
+
+					return gerrors.New("object still present")
+				},
+				generalEventuallyTimeout,
+				generalEventuallyInterval,
+			).
+				Should(Succeed())
+
+			data, err := getSubscription(item.Status.Name)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(data).To(BeNil())
+
+			data2, err := getReplicationSlot(pub.Status.ReplicationSlotName)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(data2).NotTo(BeNil())
+		})
+	})
+})
+
+var _ = Describe("PostgresqlSubscription tests with fake engine", Label(fakeEngineLabel), func() {
+	It("should create subscription", func() {
+		pubDB, subDB, pub, sub := newFakePGSubscriptionEnv()
+
+		cl, fakePG, factory := setupFakeEnv(pubDB, subDB, pub, sub)
+		// Create databases in engine
+		Expect(fakePG.CreateDB(context.TODO(), pgdbDBName, postgresUser, nil)).To(Succeed())
+		Expect(fakePG.CreateDB(context.TODO(), pgdbDBName2, postgresUser, nil)).To(Succeed())
+
+		// Create publication
+		Expect(reconcileFakeUntilStable(newFakePGPublicationReconciler(cl, factory), pgpublicationName, pgpublicationNamespace)).To(Succeed())
+
+		r := newFakePGSubscriptionReconciler(cl, factory)
+
+		Expect(reconcileFakeUntilStable(r, pgsubscriptionName, pgsubscriptionNamespace)).To(Succeed())
+
+		item := &postgresqlv1alpha1.PostgresqlSubscription{}
+		Expect(cl.Get(context.TODO(), types.NamespacedName{Name: pgsubscriptionName, Namespace: pgsubscriptionNamespace}, item)).To(Succeed())
+
+		// Checks
+		Expect(item.Status.Ready).To(BeTrue())
+		Expect(item.Status.Phase).To(Equal(postgresqlv1alpha1.SubscriptionCreatedPhase))
+		Expect(fakePG.Databases[pgdbDBName2].Subscriptions).To(HaveKey(pgsubscriptionSubscriptionName1))
+
+		// Check that replication user is used instead of engine user
+		replicationUser := pgpublicationPublicationName1 + DefaultReplicationUsernameSuffix
+		Expect(fakePG.Databases[pgdbDBName2].Subscriptions[pgsubscriptionSubscriptionName1].ConnectionInfo).To(ContainSubstring(
+			"user='" + replicationUser + "' password='" + fakePG.Roles[replicationUser].Password + "'",
+		))
+		Expect(fakePG.Databases[pgdbDBName2].Subscriptions[pgsubscriptionSubscriptionName1].ConnectionInfo).NotTo(ContainSubstring("user='" + postgresUser + "'"))
+	})
+
+	It("should fail when publication database doesn't allow subscription namespace", func() {
+		pubDB, subDB, pub, sub := newFakePGSubscriptionEnv()
+		// Only allow publication namespace
+		pubDB.Spec.AllowedNamespaces = []string{pgpublicationNamespace}
+
+		cl, fakePG, factory := setupFakeEnv(pubDB, subDB, pub, sub)
+		// Create databases in engine
+		Expect(fakePG.CreateDB(context.TODO(), pgdbDBName, postgresUser, nil)).To(Succeed())
+		Expect(fakePG.CreateDB(context.TODO(), pgdbDBName2, postgresUser, nil)).To(Succeed())
+
+		// Create publication
+		Expect(reconcileFakeUntilStable(newFakePGPublicationReconciler(cl, factory), pgpublicationName, pgpublicationNamespace)).To(Succeed())
+
+		r := newFakePGSubscriptionReconciler(cl, factory)
+
+		Expect(reconcileFakeUntilStable(r, pgsubscriptionName, pgsubscriptionNamespace)).NotTo(Succeed())
+
+		item := &postgresqlv1alpha1.PostgresqlSubscription{}
+		Expect(cl.Get(context.TODO(), types.NamespacedName{Name: pgsubscriptionName, Namespace: pgsubscriptionNamespace}, item)).To(Succeed())
+
+		// Checks
+		Expect(item.Status.Ready).To(BeFalse())
+		Expect(item.Status.Phase).To(Equal(postgresqlv1alpha1.SubscriptionFailedPhase))
+		Expect(item.Status.Message).To(ContainSubstring("namespace " + pgsubscriptionNamespace + " isn't allowed to reference it"))
+		Expect(fakePG.Databases[pgdbDBName2].Subscriptions).To(BeEmpty())
+	})
+})
@@ -20,6 +20,7 @@ import (
 	"context"
 	"fmt"
 	"reflect"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
@@ -31,8 +32,11 @@ import (
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
 	"github.com/easymile/postgresql-operator/api/postgresql/v1alpha1"
@@ -62,6 +66,7 @@ const (
 	SecretMainKeyHost            = "HOST"
 	SecretMainKeyPort            = "PORT"
 	SecretMainKeyArgs            = "ARGS"
+	SecretMainKeyCACert          = "CA_CERT"
 
 	SecretKeyReplicaPrefix = "REPLICA"
 )
@@ -75,6 +80,8 @@ type PostgresqlUserRoleReconciler struct {
 	Log                                 logr.Logger
 	ControllerName                      string
 	ReconcileTimeout                    time.Duration
+	PgInstanceFactory                   utils.PgInstanceFactory
+	PlanMode                            bool
 }
 
 type dbPrivilegeCache struct {
@@ -129,6 +136,14 @@ func (r *PostgresqlUserRoleReconciler) Reconcile(ctx context.Context, req ctrl.R
 	// Defer cancel
 	defer cancel()
 
+	// Save resource at the origin of statements for audit
+	timeoutCtx = postgres.WithAuditResource(timeoutCtx, &postgres.AuditResource{
+		Kind:        "PostgresqlUserRole",
+		Namespace:   req.Namespace,
+		Name:        req.Name,
+		ReconcileID: string(controller.ReconcileIDFromContext(ctx)),
+	})
+
 	// Init result
 	var res ctrl.Result
 
@@ -195,12 +210,35 @@ func (r *PostgresqlUserRoleReconciler) mainReconcile(
 			return r.manageError(ctx, reqLogger, instance, originalPatch, err)
 		}
 
+		// Check if plan mode is enabled
+		planMode := utils.IsPlanModeEnabled(r.PlanMode, instance)
+		// In plan mode, work on a copy in order to keep status untouched
+		dropInstance := instance
+		if planMode {
+			r.enablePlanMode(pgInstancesCache)
+
+			dropInstance = instance.DeepCopy()
+		}
+
 		// Delete roles
-		err = r.manageActiveSessionsAndDropOldRoles(ctx, reqLogger, instance, pgInstancesCache, pgecCache, pgecDBPrivilegeCache)
+		err = r.manageActiveSessionsAndDropOldRoles(ctx, reqLogger, dropInstance, pgInstancesCache, pgecCache, pgecDBPrivilegeCache)
 		// Check error
 		if err != nil {
 			return r.manageError(ctx, reqLogger, instance, originalPatch, err)
 		}
+
+		// Check if plan mode is enabled
+		if planMode {
+			statements := r.getPlannedStatements(pgInstancesCache)
+			// Check if there are pending statements
+			// In this case, finalizer is kept until plan mode is disabled
+			if len(statements) != 0 {
+				return r.managePlan(ctx, reqLogger, instance, originalPatch, statements)
+			}
+
+			// Nothing is pending, so changes made on copy are valid
+			instance.Status = dropInstance.Status
+		}
 		// Check if there is still users
 		if len(instance.Status.OldPostgresRoles) != 0 {
 			return r.manageError(ctx, reqLogger, instance, originalPatch, errors.NewBadRequest("old postgres roles still present"))
@@ -262,6 +300,15 @@ func (r *PostgresqlUserRoleReconciler) mainReconcile(
 		return r.manageError(ctx, reqLogger, instance, originalPatch, err)
 	}
 
+	// Check engine quotas
+	for _, pgec := range pgecCache {
+		err = checkLoginRoleQuota(ctx, r.Client, pgec, instance)
+		// Check error
+		if err != nil {
+			return r.manageError(ctx, reqLogger, instance, originalPatch, err)
+		}
+	}
+
 	// Add finalizer
 	updated, err := r.updateInstance(ctx, instance)
 	// Check error
@@ -273,6 +320,17 @@ func (r *PostgresqlUserRoleReconciler) mainReconcile(
 		return reconcile.Result{}, nil
 	}
 
+	// Check if plan mode is enabled
+	planMode := utils.IsPlanModeEnabled(r.PlanMode, instance)
+	// Save reconciler and instance used for the work
+	wr, wInstance := r, instance
+	// In plan mode, kubernetes writes are only performed in dry run and status is kept untouched
+	// ? Note: This is needed to avoid consuming a password rotation or changing generated secrets
+	if planMode {
+		wr = r.newDryRunReconciler()
+		wInstance = instance.DeepCopy()
+	}
+
 	var usernameChanged, passwordChanged, rotateUserPasswordError bool
 
 	var workSec *corev1.Secret
@@ -280,17 +338,17 @@ func (r *PostgresqlUserRoleReconciler) mainReconcile(
 	var oldUsername string
 
 	// Check if it is a provided user
-	if instance.Spec.Mode == v1alpha1.ProvidedMode {
-		workSec, oldUsername, passwordChanged, err = r.createOrUpdateWorkSecretForProvidedMode(ctx, reqLogger, instance)
+	if wInstance.Spec.Mode == v1alpha1.ProvidedMode {
+		workSec, oldUsername, passwordChanged, err = wr.createOrUpdateWorkSecretForProvidedMode(ctx, reqLogger, wInstance)
 		// Check error
 		if err != nil {
 			return r.manageError(ctx, reqLogger, instance, originalPatch, err)
 		}
 	} else {
-		workSec, oldUsername, passwordChanged, rotateUserPasswordError, err = r.createOrUpdateWorkSecretForManagedMode(
+		workSec, oldUsername, passwordChanged, rotateUserPasswordError, err = wr.createOrUpdateWorkSecretForManagedMode(
 			ctx,
 			reqLogger,
-			instance,
+			wInstance,
 		)
 		// Check error
 		if err != nil {
@@ -313,25 +371,29 @@ func (r *PostgresqlUserRoleReconciler) mainReconcile(
 	// Check if username have changed
 	if usernameChanged {
 		// Update status to add username for deletion
-		instance.Status.OldPostgresRoles = append(instance.Status.OldPostgresRoles, oldUsername)
+		wInstance.Status.OldPostgresRoles = append(wInstance.Status.OldPostgresRoles, oldUsername)
 	}
 
 	// Create PG instances
-	pgInstancesCache, err := r.getPGInstances(ctx, reqLogger, pgecCache, false)
+	pgInstancesCache, err := wr.getPGInstances(ctx, reqLogger, pgecCache, false)
 	// Check error
 	if err != nil {
 		return r.manageError(ctx, reqLogger, instance, originalPatch, err)
 	}
+	// Check if plan mode is enabled
+	if planMode {
+		r.enablePlanMode(pgInstancesCache)
+	}
 
 	//
 	// Now need to manage user creation
 	//
 
 	// Manage deletion with active sessions
-	err = r.manageActiveSessionsAndDropOldRoles(
+	err = wr.manageActiveSessionsAndDropOldRoles(
 		ctx,
 		reqLogger,
-		instance,
+		wInstance,
 		pgInstancesCache,
 		pgecCache,
 		pgecDBPrivilegeCache,
@@ -341,7 +403,7 @@ func (r *PostgresqlUserRoleReconciler) mainReconcile(
 		return r.manageError(ctx, reqLogger, instance, originalPatch, err)
 	}
 	// Check if we are in the user password rotation error case and old roles haven't been cleaned
-	if rotateUserPasswordError && len(instance.Status.OldPostgresRoles) != 0 {
+	if rotateUserPasswordError && len(wInstance.Status.OldPostgresRoles) != 0 {
 		// Stop here and throw an error
 		err := errors.NewBadRequest("Old user password rotation wasn't a success and another one must be done.")
 
@@ -349,7 +411,7 @@ func (r *PostgresqlUserRoleReconciler) mainReconcile(
 	}
 
 	// Create or update user role if necessary
-	err = r.managePGUserRoles(ctx, reqLogger, instance, pgInstancesCache, pgecCache, username, password, passwordChanged)
+	err = wr.managePGUserRoles(ctx, reqLogger, wInstance, pgInstancesCache, pgecCache, username, password, passwordChanged)
 	// Check error
 	if err != nil {
 		return r.manageError(ctx, reqLogger, instance, originalPatch, err)
@@ -357,11 +419,11 @@ func (r *PostgresqlUserRoleReconciler) mainReconcile(
 
 	// Save important status now
 	// Note: This is important to have a chance to have old username for deletion
-	instance.Status.PostgresRole = username
-	instance.Status.RolePrefix = instance.Spec.RolePrefix
+	wInstance.Status.PostgresRole = username
+	wInstance.Status.RolePrefix = wInstance.Spec.RolePrefix
 
-	if passwordChanged || usernameChanged || instance.Status.LastPasswordChangedTime == "" {
-		instance.Status.LastPasswordChangedTime = time.Now().Format(time.RFC3339)
+	if passwordChanged || usernameChanged || wInstance.Status.LastPasswordChangedTime == "" {
+		wInstance.Status.LastPasswordChangedTime = time.Now().Format(time.RFC3339)
 	}
 
 	//
@@ -369,7 +431,7 @@ func (r *PostgresqlUserRoleReconciler) mainReconcile(
 	//
 
 	// Manage rights
-	err = r.managePGUserRights(ctx, reqLogger, instance, pgInstancesCache, pgecDBPrivilegeCache, username)
+	err = wr.managePGUserRights(ctx, reqLogger, wInstance, pgInstancesCache, pgecDBPrivilegeCache, username)
 	// Check error
 	if err != nil {
 		return r.manageError(ctx, reqLogger, instance, originalPatch, err)
@@ -380,19 +442,24 @@ func (r *PostgresqlUserRoleReconciler) mainReconcile(
 	//
 
 	// Manage secrets
-	err = r.manageSecrets(ctx, reqLogger, instance, pgecCache, pgecDBPrivilegeCache, username, password)
+	err = wr.manageSecrets(ctx, reqLogger, wInstance, pgecCache, pgecDBPrivilegeCache, username, password)
 	// Check error
 	if err != nil {
 		return r.manageError(ctx, reqLogger, instance, originalPatch, err)
 	}
 
 	// Clean old secrets
-	err = r.cleanOldSecrets(ctx, reqLogger, instance, pgecDBPrivilegeCache)
+	err = wr.cleanOldSecrets(ctx, reqLogger, wInstance, pgecDBPrivilegeCache)
 	// Check error
 	if err != nil {
 		return r.manageError(ctx, reqLogger, instance, originalPatch, err)
 	}
 
+	// Check if plan mode is enabled
+	if planMode {
+		return r.managePlan(ctx, reqLogger, instance, originalPatch, r.getPlannedStatements(pgInstancesCache))
+	}
+
 	return r.manageSuccess(ctx, reqLogger, instance, originalPatch)
 }
 
@@ -406,11 +473,18 @@ func (r *PostgresqlUserRoleReconciler) manageSecrets(
 ) error {
 	// Loop
 	for key, pgecDBPrivilegeList := range pgecDBPrivilegeCache {
+		// Get CA bundle to propagate
+		caCert, err := r.getCACertToPropagate(ctx, pgecCache[key])
+		// Check error
+		if err != nil {
+			return err
+		}
+
 		// Loop over dbs
 		for _, privilegeCache := range pgecDBPrivilegeList {
 			// Check if this Secret already exists
 			secrFound := &corev1.Secret{}
-			err := r.Get(
+			err = r.Get(
 				ctx,
 				types.NamespacedName{
 					Name:      privilegeCache.UserPrivilege.GeneratedSecretName,
@@ -430,6 +504,7 @@ func (r *PostgresqlUserRoleReconciler) manageSecrets(
 				privilegeCache.DBInstance,
 				username, password,
 				pgecCache[key],
+				caCert,
 			)
 			// Check error
 			if err2 != nil {
@@ -547,12 +622,33 @@ func (r *PostgresqlUserRoleReconciler) cleanOldSecrets(
 	return nil
 }
 
+// getCACertToPropagate will return engine CA bundle if it must be propagated in generated secrets.
+func (r *PostgresqlUserRoleReconciler) getCACertToPropagate(
+	ctx context.Context,
+	pgec *v1alpha1.PostgresqlEngineConfiguration,
+) ([]byte, error) {
+	// Check if propagation isn't enabled
+	if pgec == nil || pgec.Spec.TLS == nil || !pgec.Spec.TLS.PropagateCAInUserSecrets {
+		return nil, nil
+	}
+
+	// Get TLS secret
+	sec, err := utils.GetSecret(ctx, r.Client, pgec.Spec.TLS.SecretName, utils.GetPgEngineCfgSecretNamespace(pgec))
+	// Check error
+	if err != nil {
+		return nil, err
+	}
+
+	return sec.Data[utils.TLSSecretCAKey], nil
+}
+
 func (r *PostgresqlUserRoleReconciler) newSecretForPGUser(
 	instance *v1alpha1.PostgresqlUserRole,
 	rolePrivilege *v1alpha1.PostgresqlUserRolePrivilege,
 	dbInstance *v1alpha1.PostgresqlDatabase,
 	username, password string,
 	pgec *v1alpha1.PostgresqlEngineConfiguration,
+	caCert []byte,
 ) (*corev1.Secret, error) {
 	// Prepare user connections with primary as default value
 	uc := pgec.Spec.UserConnections.PrimaryConnection
@@ -585,6 +681,11 @@ func (r *PostgresqlUserRoleReconciler) newSecretForPGUser(
 		SecretMainKeyArgs:            []byte(uriArgs),
 	}
 
+	// Check if CA bundle must be propagated
+	if len(caCert) != 0 {
+		data[SecretMainKeyCACert] = caCert
+	}
+
 	// Manage replica connections
 	// Prepare replica user connections
 	rucList := pgec.Spec.UserConnections.ReplicaConnections
@@ -764,6 +865,8 @@ func (*PostgresqlUserRoleReconciler) getDBRoleFromPrivilege(
 		return dbInstance.Status.Roles.Reader
 	case v1alpha1.WriterPrivilege:
 		return dbInstance.Status.Roles.Writer
+	case v1alpha1.ProfilePrivilege:
+		return dbInstance.Status.Roles.Profiles[userRolePrivilege.Profile]
 	default:
 		return dbInstance.Status.Roles.Owner
 	}
@@ -913,10 +1016,18 @@ func (r *PostgresqlUserRoleReconciler) managePGUserRoles(
 			}
 		}
 
+		// Check if stored password is encrypted with the wanted method
+		passwordMethodMatch, err := pgInstance.DoesRolePasswordMethodMatch(ctx, username)
+		// Check error
+		if err != nil {
+			return err
+		}
+
 		// Check if it is the first time this instance is managed
 		// If yes and if the user exist, the password must be ensured
 		// Or if the password have changed, change password
-		if passwordChanged || instance.Status.Phase == v1alpha1.UserRoleNoPhase {
+		// Or if the password isn't stored with the wanted method, set it again to encrypt it
+		if passwordChanged || instance.Status.Phase == v1alpha1.UserRoleNoPhase || !passwordMethodMatch {
 			err = pgInstance.UpdatePassword(ctx, username, password)
 			// Check error
 			if err != nil {
@@ -1262,6 +1373,12 @@ func (r *PostgresqlUserRoleReconciler) manageActiveSessionsAndDropOldRoles(
 						return err
 					}
 
+					// Check if deletion have only been planned
+					// ? Note: Role is removed from the list as the real run will do
+					if pgInstance.IsPlanMode() {
+						continue
+					}
+
 					logger.Info("Role successfully deleted", "engine", key, "role", oldUsername)
 					r.Recorder.Eventf(instance, "Normal", "Processing", "Role %s successfully deleted on engine %s", oldUsername, key)
 				} else {
@@ -1306,12 +1423,50 @@ func (r *PostgresqlUserRoleReconciler) getPGInstances(
 
 		// Save
 		// Side note: The key is the same as for the pgec map. Do not change it, otherwise it will have side effect on other part of the global algo
-		res[key] = utils.CreatePgInstance(logger, sec.Data, pgec)
+		res[key] = r.PgInstanceFactory.CreatePgInstance(logger, sec.Data, pgec)
 	}
 
 	return res, nil
 }
 
+func (*PostgresqlUserRoleReconciler) enablePlanMode(pgInstanceCache map[string]postgres.PG) {
+	// Loop
+	for _, pgInstance := range pgInstanceCache {
+		// Record statements instead of executing them
+		pgInstance.EnablePlanMode()
+	}
+}
+
+func (*PostgresqlUserRoleReconciler) getPlannedStatements(pgInstanceCache map[string]postgres.PG) []string {
+	// Prepare result
+	res := make([]string, 0)
+
+	// Sort keys to have a stable result
+	keys := funk.Keys(pgInstanceCache).([]string)
+	sort.Strings(keys)
+
+	// Loop
+	for _, key := range keys {
+		// Loop over statements
+		for _, st := range pgInstanceCache[key].GetPlannedStatements() {
+			// Prefix with engine as user role can be on multiple engines
+			res = append(res, fmt.Sprintf("[%s] %s", key, st))
+		}
+	}
+
+	return res
+}
+
+// newDryRunReconciler will create a copy of the reconciler performing kubernetes writes in dry run mode.
+func (r *PostgresqlUserRoleReconciler) newDryRunReconciler() *PostgresqlUserRoleReconciler {
+	// Shallow copy
+	res := *r
+	// Writes will be validated but not persisted
+	res.Client = client.NewDryRunClient(r.Client)
+
+	return &res
+}
+
 func (r *PostgresqlUserRoleReconciler) getPGECInstances(
 	ctx context.Context,
 	dbCache map[string]*v1alpha1.PostgresqlDatabase,
@@ -1323,7 +1478,7 @@ func (r *PostgresqlUserRoleReconciler) getPGECInstances(
 	// Loop
 	for _, item := range dbCache {
 		// Build key
-		key := utils.CreateNameKey(item.Spec.EngineConfiguration.Name, item.Spec.EngineConfiguration.Namespace, item.Namespace)
+		key := utils.CreateNameKeyForEngineLink(item.Spec.EngineConfiguration, item.Namespace)
 
 		// Get value from cache
 		_, ok := res[key]
@@ -1380,7 +1535,7 @@ func (r *PostgresqlUserRoleReconciler) getDatabaseInstances(
 		res[utils.CreateNameKey(pgdb.Name, pgdb.Namespace, instance.Namespace)] = pgdb
 
 		// Create pgec instance key
-		pgecKey := utils.CreateNameKey(pgdb.Spec.EngineConfiguration.Name, pgdb.Spec.EngineConfiguration.Namespace, pgdb.Namespace)
+		pgecKey := utils.CreateNameKeyForEngineLink(pgdb.Spec.EngineConfiguration, pgdb.Namespace)
 		// Get item
 		arry, ok := res2[pgecKey]
 		// Check if array exists
@@ -1413,13 +1568,37 @@ func (*PostgresqlUserRoleReconciler) validateInstanceWithClusterInfo(
 		// Get pgdb
 		pgdb := dbCache[dbKey]
 		// Create pgec key
-		pgecKey := utils.CreateNameKey(pgdb.Spec.EngineConfiguration.Name, pgdb.Spec.EngineConfiguration.Namespace, pgdb.Namespace)
+		pgecKey := utils.CreateNameKeyForEngineLink(pgdb.Spec.EngineConfiguration, pgdb.Namespace)
 		// Get pgec
 		pgec := pgecCache[pgecKey]
+		// Check that privilege profile exists on database
+		if privi.Privilege == v1alpha1.ProfilePrivilege && pgdb.Status.Roles.Profiles[privi.Profile] == "" {
+			return errors.NewBadRequest(fmt.Sprintf("privilege profile %s doesn't exist on database %s", privi.Profile, pgdb.Name))
+		}
 		// Check if bouncer mode is asked and not available
 		if privi.ConnectionType == v1alpha1.BouncerConnectionType && pgec.Spec.UserConnections.BouncerConnection == nil {
 			return errors.NewBadRequest("bouncer connection asked but not supported in engine configuration")
 		}
+		// Check that published user connections are reachable if engine blocks unreachable ones
+		err := utils.CheckEngineUserConnectionsReachable(pgec, privi.ConnectionType)
+		// Check error
+		if err != nil {
+			return err
+		}
+		// Check that engine user can create roles
+		err = utils.CheckEngineAdminCanCreateRoles(pgec)
+		// Check error
+		if err != nil {
+			return err
+		}
+		// Check that engine user can give replication attribute if asked
+		if instance.Spec.RoleAttributes != nil && instance.Spec.RoleAttributes.Replication != nil && *instance.Spec.RoleAttributes.Replication {
+			err = utils.CheckEngineAdminCanGrantReplication(pgec)
+			// Check error
+			if err != nil {
+				return err
+			}
+		}
 	}
 
 	// Default
@@ -1484,6 +1663,19 @@ func (r *PostgresqlUserRoleReconciler) validateInstance(
 		}
 	}
 
+	// Validate privilege profiles
+	for _, privi := range instance.Spec.Privileges {
+		// Check if profile is missing
+		if privi.Privilege == v1alpha1.ProfilePrivilege && privi.Profile == "" {
+			return errors.NewBadRequest("Privilege profile must be set with PROFILE privilege")
+		}
+
+		// Check if profile is set with another privilege
+		if privi.Privilege != v1alpha1.ProfilePrivilege && privi.Profile != "" {
+			return errors.NewBadRequest("Privilege profile can only be set with PROFILE privilege")
+		}
+	}
+
 	// Validate not multiple time the same db in the list of privileges
 	for i, privi := range instance.Spec.Privileges {
 		// Prepare values
@@ -1579,12 +1771,23 @@ func (r *PostgresqlUserRoleReconciler) manageError(
 	originalPatch client.Patch,
 	issue error,
 ) (reconcile.Result, error) {
+	// Check if issue is retryable
+	res, managed := manageReadOnlyTransactionError(
+		ctx, logger, r.Status(), r.Recorder, instance,
+		&instance.Status.Message, &instance.Status.Reason,
+		originalPatch, issue,
+	)
+	if managed {
+		return res, nil
+	}
+
 	logger.Error(issue, "issue raised in reconcile")
 	// Add kubernetes event
 	r.Recorder.Event(instance, "Warning", "ProcessingError", issue.Error())
 
 	// Update status
 	instance.Status.Message = issue.Error()
+	instance.Status.Reason = string(errors.ReasonForError(issue))
 	instance.Status.Ready = false
 	instance.Status.Phase = v1alpha1.UserRoleFailedPhase
 
@@ -1601,6 +1804,42 @@ func (r *PostgresqlUserRoleReconciler) manageError(
 	return ctrl.Result{}, issue
 }
 
+func (r *PostgresqlUserRoleReconciler) managePlan(
+	ctx context.Context,
+	logger logr.Logger,
+	instance *v1alpha1.PostgresqlUserRole,
+	originalPatch client.Patch,
+	statements []string,
+) (reconcile.Result, error) {
+	// Add kubernetes events
+	for _, st := range statements {
+		r.Recorder.Event(instance, "Normal", "Planned", st)
+	}
+
+	// Update status
+	instance.Status.Message = fmt.Sprintf("plan mode enabled, %d statement(s) pending", len(statements))
+	instance.Status.Plan = &v1alpha1.PlanStatus{
+		PendingStatements: statements,
+		LastPlanTime:      time.Now().Format(time.RFC3339),
+	}
+
+	// Patch status
+	err := r.Status().Patch(ctx, instance, originalPatch)
+	if err != nil {
+		// Increase fail counter
+		r.ControllerRuntimeDetailedErrorTotal.WithLabelValues(r.ControllerName, instance.Namespace, instance.Name).Inc()
+
+		logger.Error(err, "unable to update status")
+
+		// Return error
+		return ctrl.Result{}, err
+	}
+
+	logger.Info("Plan done")
+
+	return reconcile.Result{}, nil
+}
+
 func (r *PostgresqlUserRoleReconciler) manageSuccess(
 	ctx context.Context,
 	logger logr.Logger,
@@ -1609,8 +1848,11 @@ func (r *PostgresqlUserRoleReconciler) manageSuccess(
 ) (reconcile.Result, error) {
 	// Update status
 	instance.Status.Message = ""
+	instance.Status.Reason = ""
 	instance.Status.Ready = true
 	instance.Status.Phase = v1alpha1.UserRoleCreatedPhase
+	// Plan is outdated now
+	instance.Status.Plan = nil
 
 	// Patch status
 	err := r.Status().Patch(ctx, instance, originalPatch)
@@ -1633,5 +1875,30 @@ func (r *PostgresqlUserRoleReconciler) manageSuccess(
 func (r *PostgresqlUserRoleReconciler) SetupWithManager(mgr ctrl.Manager) error {
 	return ctrl.NewControllerManagedBy(mgr).
 		For(&v1alpha1.PostgresqlUserRole{}).
+		// Generated secrets
+		Owns(&corev1.Secret{}).
+		// Import secrets
+		Watches(
+			&corev1.Secret{},
+			handler.EnqueueRequestsFromMapFunc(newIndexedMapFunc(
+				mgr.GetClient(),
+				func() client.ObjectList { return &v1alpha1.PostgresqlUserRoleList{} },
+				UserRoleImportSecretIndexField,
+				objectName,
+				true,
+			)),
+		).
+		// Database status changes
+		Watches(
+			&v1alpha1.PostgresqlDatabase{},
+			handler.EnqueueRequestsFromMapFunc(newIndexedMapFunc(
+				mgr.GetClient(),
+				func() client.ObjectList { return &v1alpha1.PostgresqlUserRoleList{} },
+				UserRoleDatabaseIndexField,
+				objectNameKey,
+				false,
+			)),
+			builder.WithPredicates(databaseStatusChangedPredicate),
+		).
 		Complete(r)
 }
@@ -1,6 +1,7 @@
 package postgresql
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"strings"
@@ -9,12 +10,17 @@ import (
 	"github.com/easymile/postgresql-operator/api/postgresql/common"
 	"github.com/easymile/postgresql-operator/api/postgresql/v1alpha1"
 	postgresqlv1alpha1 "github.com/easymile/postgresql-operator/api/postgresql/v1alpha1"
+	"github.com/easymile/postgresql-operator/internal/controller/config"
+	"github.com/easymile/postgresql-operator/internal/controller/postgresql/postgres"
+	"github.com/easymile/postgresql-operator/internal/controller/utils"
+	"github.com/go-logr/logr"
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 	corev1 "k8s.io/api/core/v1"
 	apimachineryErrors "k8s.io/apimachinery/pkg/api/errors"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
 )
 
 var _ = Describe("PostgresqlUserRole tests", func() {
@@ -560,7 +566,7 @@ var _ = Describe("PostgresqlUserRole tests", func() {
 				},
 				Spec: postgresqlv1alpha1.PostgresqlDatabaseSpec{
 					Database: pgdbDBName,
-					EngineConfiguration: &common.CRLink{
+					EngineConfiguration: &common.EngineCRLink{
 						Name:      "fake",
 						Namespace: "fake",
 					},
@@ -1359,6 +1365,56 @@ var _ = Describe("PostgresqlUserRole tests", func() {
 				Should(Succeed())
 		})
 
+		It("should restore deleted db secret right away", func() {
+			// Setup pgec
+			setupPGEC("30s", false)
+			// Create pgdb
+			setupPGDB(false)
+
+			// Create secret
+			setupPGURImportSecret()
+
+			item := setupProvidedPGUR()
+
+			// Checks
+			Expect(item.Status.Ready).To(BeTrue())
+
+			// Get db secret
+			sec := &corev1.Secret{}
+			Expect(k8sClient.Get(ctx, types.NamespacedName{
+				Name:      pgurDBSecretName,
+				Namespace: pgurNamespace,
+			}, sec)).Should(Succeed())
+
+			// Delete it
+			Expect(k8sClient.Delete(ctx, sec)).To(Succeed())
+
+			// Get db secret
+			// ? Note: Owned secret watch must trigger reconcile without waiting resync period
+			sec2 := &corev1.Secret{}
+			Eventually(
+				func() error {
+					err := k8sClient.Get(ctx, types.NamespacedName{
+						Name:      pgurDBSecretName,
+						Namespace: pgurNamespace,
+					}, sec2)
+					// Check error
+					if err != nil {
+						return err
+					}
+
+					if sec2.UID == sec.UID {
+						return errors.New("db secret not recreated")
+					}
+
+					return nil
+				},
+				watchEventuallyTimeout,
+				watchEventuallyInterval,
+			).
+				Should(Succeed())
+		})
+
 		It("should be ok to remove key in db secret", func() {
 			// Setup pgec
 			setupPGEC("30s", false)
@@ -3242,7 +3298,7 @@ var _ = Describe("PostgresqlUserRole tests", func() {
 				},
 				Spec: postgresqlv1alpha1.PostgresqlDatabaseSpec{
 					Database: pgdbDBName,
-					EngineConfiguration: &common.CRLink{
+					EngineConfiguration: &common.EngineCRLink{
 						Name:      "fake",
 						Namespace: "fake",
 					},
@@ -6258,3 +6314,230 @@ var _ = Describe("PostgresqlUserRole tests", func() {
 		})
 	})
 })
+
+var _ = Describe("PostgresqlUserRole tests with fake engine", Label(fakeEngineLabel), func() {
+	It("should report statements without changing engine in plan mode", func() {
+		pgdb := newFakePGDB()
+		pgdb.Status = postgresqlv1alpha1.PostgresqlDatabaseStatus{
+			Phase:    postgresqlv1alpha1.DatabaseCreatedPhase,
+			Ready:    true,
+			Database: pgdbDBName,
+			Roles: postgresqlv1alpha1.StatusPostgresRoles{
+				Owner:  pgdbDBName + "-owner",
+				Reader: pgdbDBName + "-reader",
+				Writer: pgdbDBName + "-writer",
+			},
+		}
+
+		pgur := &postgresqlv1alpha1.PostgresqlUserRole{
+			ObjectMeta: v1.ObjectMeta{
+				Name:        pgurName,
+				Namespace:   pgurNamespace,
+				Annotations: map[string]string{config.PlanModeAnnotation: "true"},
+			},
+			Spec: postgresqlv1alpha1.PostgresqlUserRoleSpec{
+				Mode:       postgresqlv1alpha1.ManagedMode,
+				RolePrefix: pgurRolePrefix,
+				Privileges: []*postgresqlv1alpha1.PostgresqlUserRolePrivilege{
+					{
+						Privilege:           postgresqlv1alpha1.OwnerPrivilege,
+						Database:            &common.CRLink{Name: pgdbName, Namespace: pgdbNamespace},
+						GeneratedSecretName: pgurDBSecretName,
+					},
+				},
+			},
+		}
+
+		cl, fakePG, factory := setupFakeEnv(pgdb, pgur)
+		// Create database and group roles in engine
+		for _, it := range []string{pgdb.Status.Roles.Owner, pgdb.Status.Roles.Reader, pgdb.Status.Roles.Writer} {
+			Expect(fakePG.CreateGroupRole(context.TODO(), it)).To(Succeed())
+		}
+
+		Expect(fakePG.CreateDB(context.TODO(), pgdbDBName, pgdb.Status.Roles.Owner, nil)).To(Succeed())
+
+		r := &PostgresqlUserRoleReconciler{
+			Client:                              cl,
+			Scheme:                              cl.Scheme(),
+			Recorder:                            record.NewFakeRecorder(100),
+			Log:                                 logr.Discard(),
+			ControllerRuntimeDetailedErrorTotal: newFakeCounter(),
+			ControllerName:                      "postgresqluserrole",
+			ReconcileTimeout:                    10 * time.Second,
+			PgInstanceFactory:                   factory,
+		}
+
+		Expect(reconcileFakeUntilStable(r, pgurName, pgurNamespace)).To(Succeed())
+
+		item := &postgresqlv1alpha1.PostgresqlUserRole{}
+		Expect(cl.Get(context.TODO(), types.NamespacedName{Name: pgurName, Namespace: pgurNamespace}, item)).To(Succeed())
+
+		// Checks
+		login := pgurRolePrefix + Login0Suffix
+		Expect(item.Status.Ready).To(BeFalse())
+		Expect(item.Status.PostgresRole).To(BeEmpty())
+		Expect(item.Status.Plan).NotTo(BeNil())
+		Expect(item.Status.Plan.PendingStatements).To(ContainElements(
+			"["+utils.CreateNameKeyForSavedPools(pgecName, pgecNamespace)+"] CreateUserRole("+login+", "+postgres.RedactedValue+")",
+			"["+utils.CreateNameKeyForSavedPools(pgecName, pgecNamespace)+"] GrantRole("+pgdb.Status.Roles.Owner+", "+login+", false)",
+		))
+
+		// Engine checks
+		Expect(fakePG.Roles).NotTo(HaveKey(login))
+
+		// Secrets mustn't be created
+		sec := &corev1.Secret{}
+		err := cl.Get(context.TODO(), types.NamespacedName{Name: item.Spec.WorkGeneratedSecretName, Namespace: pgurNamespace}, sec)
+		Expect(err).To(HaveOccurred())
+		err = cl.Get(context.TODO(), types.NamespacedName{Name: pgurDBSecretName, Namespace: pgurNamespace}, sec)
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("should set password again when stored with another method", func() {
+		pgdb := newFakePGDB()
+		pgdb.Status = postgresqlv1alpha1.PostgresqlDatabaseStatus{
+			Phase:    postgresqlv1alpha1.DatabaseCreatedPhase,
+			Ready:    true,
+			Database: pgdbDBName,
+			Roles: postgresqlv1alpha1.StatusPostgresRoles{
+				Owner:  pgdbDBName + "-owner",
+				Reader: pgdbDBName + "-reader",
+				Writer: pgdbDBName + "-writer",
+			},
+		}
+
+		pgur := &postgresqlv1alpha1.PostgresqlUserRole{
+			ObjectMeta: v1.ObjectMeta{Name: pgurName, Namespace: pgurNamespace},
+			Spec: postgresqlv1alpha1.PostgresqlUserRoleSpec{
+				Mode:       postgresqlv1alpha1.ManagedMode,
+				RolePrefix: pgurRolePrefix,
+				Privileges: []*postgresqlv1alpha1.PostgresqlUserRolePrivilege{
+					{
+						Privilege:           postgresqlv1alpha1.OwnerPrivilege,
+						Database:            &common.CRLink{Name: pgdbName, Namespace: pgdbNamespace},
+						GeneratedSecretName: pgurDBSecretName,
+					},
+				},
+			},
+		}
+
+		cl, fakePG, factory := setupFakeEnv(pgdb, pgur)
+		// Create database and group roles in engine
+		for _, it := range []string{pgdb.Status.Roles.Owner, pgdb.Status.Roles.Reader, pgdb.Status.Roles.Writer} {
+			Expect(fakePG.CreateGroupRole(context.TODO(), it)).To(Succeed())
+		}
+
+		Expect(fakePG.CreateDB(context.TODO(), pgdbDBName, pgdb.Status.Roles.Owner, nil)).To(Succeed())
+
+		r := &PostgresqlUserRoleReconciler{
+			Client:                              cl,
+			Scheme:                              cl.Scheme(),
+			Recorder:                            record.NewFakeRecorder(100),
+			Log:                                 logr.Discard(),
+			ControllerRuntimeDetailedErrorTotal: newFakeCounter(),
+			ControllerName:                      "postgresqluserrole",
+			ReconcileTimeout:                    10 * time.Second,
+			PgInstanceFactory:                   factory,
+		}
+
+		Expect(reconcileFakeUntilStable(r, pgurName, pgurNamespace)).To(Succeed())
+
+		// Checks
+		login := pgurRolePrefix + Login0Suffix
+		Expect(fakePG.Roles).To(HaveKey(login))
+		Expect(fakePG.Roles[login].PasswordMethod).To(Equal(postgres.PasswordMethodScram))
+
+		password := fakePG.Roles[login].Password
+
+		// Simulate a password stored with another method
+		fakePG.Roles[login].PasswordMethod = postgres.PasswordMethodMD5
+
+		Expect(reconcileFakeUntilStable(r, pgurName, pgurNamespace)).To(Succeed())
+
+		// Password must have been set again with the same value
+		Expect(fakePG.Roles[login].PasswordMethod).To(Equal(postgres.PasswordMethodScram))
+		Expect(fakePG.Roles[login].Password).To(Equal(password))
+	})
+
+	It("should propagate engine TLS CA in generated secrets", func() {
+		pgdb := newFakePGDB()
+		pgdb.Status = postgresqlv1alpha1.PostgresqlDatabaseStatus{
+			Phase:    postgresqlv1alpha1.DatabaseCreatedPhase,
+			Ready:    true,
+			Database: pgdbDBName,
+			Roles: postgresqlv1alpha1.StatusPostgresRoles{
+				Owner:  pgdbDBName + "-owner",
+				Reader: pgdbDBName + "-reader",
+				Writer: pgdbDBName + "-writer",
+			},
+		}
+
+		pgur := &postgresqlv1alpha1.PostgresqlUserRole{
+			ObjectMeta: v1.ObjectMeta{Name: pgurName, Namespace: pgurNamespace},
+			Spec: postgresqlv1alpha1.PostgresqlUserRoleSpec{
+				Mode:       postgresqlv1alpha1.ManagedMode,
+				RolePrefix: pgurRolePrefix,
+				Privileges: []*postgresqlv1alpha1.PostgresqlUserRolePrivilege{
+					{
+						Privilege:           postgresqlv1alpha1.OwnerPrivilege,
+						Database:            &common.CRLink{Name: pgdbName, Namespace: pgdbNamespace},
+						GeneratedSecretName: pgurDBSecretName,
+					},
+				},
+			},
+		}
+
+		tlsSecret := &corev1.Secret{
+			ObjectMeta: v1.ObjectMeta{Name: "pgec-tls", Namespace: pgecNamespace},
+			Data: map[string][]byte{
+				corev1.TLSCertKey:       []byte("certificate"),
+				corev1.TLSPrivateKeyKey: []byte("key"),
+				utils.TLSSecretCAKey:    []byte("ca"),
+			},
+		}
+
+		cl, fakePG, factory := setupFakeEnv(pgdb, pgur, tlsSecret)
+		// Enable TLS on engine
+		pgec := &postgresqlv1alpha1.PostgresqlEngineConfiguration{}
+		Expect(cl.Get(context.TODO(), types.NamespacedName{Name: pgecName, Namespace: pgecNamespace}, pgec)).To(Succeed())
+		pgec.Spec.TLS = &postgresqlv1alpha1.EngineTLS{SecretName: tlsSecret.Name, PropagateCAInUserSecrets: true}
+		Expect(cl.Update(context.TODO(), pgec)).To(Succeed())
+
+		// Create database and group roles in engine
+		for _, it := range []string{pgdb.Status.Roles.Owner, pgdb.Status.Roles.Reader, pgdb.Status.Roles.Writer} {
+			Expect(fakePG.CreateGroupRole(context.TODO(), it)).To(Succeed())
+		}
+
+		Expect(fakePG.CreateDB(context.TODO(), pgdbDBName, pgdb.Status.Roles.Owner, nil)).To(Succeed())
+
+		// Save secret data given to factory
+		var secretData map[string][]byte
+
+		r := &PostgresqlUserRoleReconciler{
+			Client:                              cl,
+			Scheme:                              cl.Scheme(),
+			Recorder:                            record.NewFakeRecorder(100),
+			Log:                                 logr.Discard(),
+			ControllerRuntimeDetailedErrorTotal: newFakeCounter(),
+			ControllerName:                      "postgresqluserrole",
+			ReconcileTimeout:                    10 * time.Second,
+			PgInstanceFactory: func(l logr.Logger, data map[string][]byte, pgec *postgresqlv1alpha1.PostgresqlEngineConfiguration) postgres.PG {
+				secretData = data
+
+				return factory(l, data, pgec)
+			},
+		}
+
+		Expect(reconcileFakeUntilStable(r, pgurName, pgurNamespace)).To(Succeed())
+
+		// TLS data must be given to PG instance creation
+		Expect(secretData).To(HaveKeyWithValue(corev1.TLSCertKey, []byte("certificate")))
+		Expect(secretData).To(HaveKeyWithValue(corev1.TLSPrivateKeyKey, []byte("key")))
+		Expect(secretData).To(HaveKeyWithValue(utils.TLSSecretCAKey, []byte("ca")))
+
+		// CA must be propagated in generated secret
+		sec := &corev1.Secret{}
+		Expect(cl.Get(context.TODO(), types.NamespacedName{Name: pgurDBSecretName, Namespace: pgurNamespace}, sec)).To(Succeed())
+		Expect(sec.Data).To(HaveKeyWithValue(SecretMainKeyCACert, []byte("ca")))
+	})
+})
@@ -0,0 +1,417 @@
+package postgresql
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	postgresqlv1alpha1 "github.com/easymile/postgresql-operator/api/postgresql/v1alpha1"
+	"github.com/easymile/postgresql-operator/internal/controller/utils"
+	"github.com/samber/lo"
+)
+
+// QuotaExceededReason is the status reason of resources refused because of engine quotas.
+const QuotaExceededReason metav1.StatusReason = "QuotaExceeded"
+
+// engineQuotaResources are resources counted in engine quotas.
+type engineQuotaResources struct {
+	databases     []client.Object
+	loginRoles    []client.Object
+	publications  []client.Object
+	subscriptions []client.Object
+}
+
+// newQuotaExceededError will return a forbidden error with the quota exceeded reason.
+func newQuotaExceededError(message string) error {
+	return &errors.StatusError{ErrStatus: metav1.Status{
+		Status:  metav1.StatusFailure,
+		Code:    http.StatusForbidden,
+		Reason:  QuotaExceededReason,
+		Message: message,
+	}}
+}
+
+// listEngineQuotaResources will list databases, user roles, publications and subscriptions using engine.
+// Each user role is counted as one login role on engine and each subscription as one replication slot.
+// ? Note: Lists are filtered with indexer functions to work with cached and direct clients.
+func listEngineQuotaResources(ctx context.Context, cl client.Reader, engineKey string) (*engineQuotaResources, error) {
+	res := &engineQuotaResources{
+		databases:     make([]client.Object, 0),
+		loginRoles:    make([]client.Object, 0),
+		publications:  make([]client.Object, 0),
+		subscriptions: make([]client.Object, 0),
+	}
+
+	// List databases
+	dbList := &postgresqlv1alpha1.PostgresqlDatabaseList{}
+	err := cl.List(ctx, dbList)
+	// Check error
+	if err != nil {
+		return nil, err
+	}
+
+	// Database keys linked to engine
+	dbKeys := make([]string, 0)
+	// Loop over databases
+	for i := range dbList.Items {
+		db := &dbList.Items[i]
+		// Check if database is linked to engine
+		if lo.Contains(databaseEngineIndexer(db), engineKey) {
+			res.databases = append(res.databases, db)
+			dbKeys = append(dbKeys, objectNameKey(db))
+		}
+	}
+
+	// List user roles
+	urList := &postgresqlv1alpha1.PostgresqlUserRoleList{}
+	err = cl.List(ctx, urList)
+	// Check error
+	if err != nil {
+		return nil, err
+	}
+	// Loop over user roles
+	for i := range urList.Items {
+		ur := &urList.Items[i]
+		// Check if user role is linked to one of engine databases
+		if lo.Some(userRoleDatabaseIndexer(ur), dbKeys) {
+			res.loginRoles = append(res.loginRoles, ur)
+		}
+	}
+
+	// List publications
+	pubList := &postgresqlv1alpha1.PostgresqlPublicationList{}
+	err = cl.List(ctx, pubList)
+	// Check error
+	if err != nil {
+		return nil, err
+	}
+	// Loop over publications
+	for i := range pubList.Items {
+		pub := &pubList.Items[i]
+		// Check if publication is linked to one of engine databases
+		if lo.Some(publicationDatabaseIndexer(pub), dbKeys) {
+			res.publications = append(res.publications, pub)
+		}
+	}
+
+	// List subscriptions
+	subList := &postgresqlv1alpha1.PostgresqlSubscriptionList{}
+	err = cl.List(ctx, subList)
+	// Check error
+	if err != nil {
+		return nil, err
+	}
+	// Loop over subscriptions
+	for i := range subList.Items {
+		sub := &subList.Items[i]
+		// Check if subscription is created in one of engine databases
+		if sub.Spec.Database != nil && lo.Contains(dbKeys, utils.CreateNameKey(sub.Spec.Database.Name, sub.Spec.Database.Namespace, sub.Namespace)) {
+			res.subscriptions = append(res.subscriptions, sub)
+		}
+	}
+
+	return res, nil
+}
+
+// sortByCreation will sort objects by creation time and by namespace/name key for the same creation time.
+func sortByCreation(list []client.Object) {
+	sort.Slice(list, func(i, j int) bool {
+		ti := list[i].GetCreationTimestamp()
+		tj := list[j].GetCreationTimestamp()
+		// Check if creation times are different
+		if !ti.Equal(&tj) {
+			return ti.Before(&tj)
+		}
+
+		return objectNameKey(list[i]) < objectNameKey(list[j])
+	})
+}
+
+// getNamespaceQuota will return the part of a maximum that a namespace can use.
+func getNamespaceQuota(maxValue, sharePercent int) int {
+	res := maxValue * sharePercent / 100 //nolint:gomnd // Percentage
+	// A namespace can always use at least one resource when maximum allows it
+	if res == 0 && maxValue > 0 {
+		return 1
+	}
+
+	return res
+}
+
+// admitEngineQuotaResources will admit resources by creation order and return refusal errors per resource key.
+// Admitted resources have a nil error. Refused ones don't use quota,
+// so oldest resources are kept when quota is lowered.
+func admitEngineQuotaResources(
+	pgec *postgresqlv1alpha1.PostgresqlEngineConfiguration,
+	list []client.Object,
+	maxValue *int,
+	resourceName string,
+) map[string]error {
+	res := map[string]error{}
+
+	// Check if there isn't any maximum
+	if maxValue == nil {
+		for _, it := range list {
+			res[objectNameKey(it)] = nil
+		}
+
+		return res
+	}
+
+	// Compute namespace maximum
+	nsMax := *maxValue
+	if pgec.Spec.Quotas.NamespaceSharePercent != nil {
+		nsMax = getNamespaceQuota(*maxValue, *pgec.Spec.Quotas.NamespaceSharePercent)
+	}
+
+	// Sort by creation order
+	sortByCreation(list)
+
+	admitted := 0
+	nsAdmitted := map[string]int{}
+
+	for _, it := range list {
+		key := objectNameKey(it)
+
+		// Check engine maximum
+		if admitted >= *maxValue {
+			res[key] = newQuotaExceededError(fmt.Sprintf(
+				"engine configuration %s quota of %d %s is exceeded",
+				pgec.Name, *maxValue, resourceName,
+			))
+
+			continue
+		}
+
+		// Check namespace share
+		if nsAdmitted[it.GetNamespace()] >= nsMax {
+			res[key] = newQuotaExceededError(fmt.Sprintf(
+				"namespace %s share of engine configuration %s quota is exceeded (%d %s on %d)",
+				it.GetNamespace(), pgec.Name, nsMax, resourceName, *maxValue,
+			))
+
+			continue
+		}
+
+		// Admit resource
+		admitted++
+		nsAdmitted[it.GetNamespace()]++
+		res[key] = nil
+	}
+
+	return res
+}
+
+// checkEngineQuota will return a quota exceeded error if object is over engine maximum or namespace share.
+func checkEngineQuota(
+	pgec *postgresqlv1alpha1.PostgresqlEngineConfiguration,
+	list []client.Object,
+	obj client.Object,
+	maxValue *int,
+	resourceName string,
+) error {
+	// Check if there isn't any maximum
+	if maxValue == nil {
+		return nil
+	}
+
+	key := objectNameKey(obj)
+	// Check if object isn't in list
+	// ? Note: Object not found in list (cache not up to date) is considered as the newest one
+	if !lo.ContainsBy(list, func(it client.Object) bool { return objectNameKey(it) == key }) {
+		// Set creation time if object isn't created yet
+		creation := obj.GetCreationTimestamp()
+		if creation.IsZero() {
+			obj = obj.DeepCopyObject().(client.Object)
+			obj.SetCreationTimestamp(metav1.Now())
+		}
+
+		list = append(list[:len(list):len(list)], obj)
+	}
+
+	return admitEngineQuotaResources(pgec, list, maxValue, resourceName)[key]
+}
+
+// checkDatabaseQuota will return a quota exceeded error if database is over engine quotas.
+func checkDatabaseQuota(
+	ctx context.Context,
+	cl client.Reader,
+	pgec *postgresqlv1alpha1.PostgresqlEngineConfiguration,
+	instance *postgresqlv1alpha1.PostgresqlDatabase,
+) error {
+	// Check if there isn't any quota
+	if pgec.Spec.Quotas == nil || pgec.Spec.Quotas.MaxDatabases == nil {
+		return nil
+	}
+
+	// List resources
+	resources, err := listEngineQuotaResources(ctx, cl, utils.CreateNameKeyForSavedPools(pgec.Name, pgec.Namespace))
+	// Check error
+	if err != nil {
+		return err
+	}
+
+	return checkEngineQuota(pgec, resources.databases, instance, pgec.Spec.Quotas.MaxDatabases, "databases")
+}
+
+// checkLoginRoleQuota will return a quota exceeded error if user role is over engine quotas.
+func checkLoginRoleQuota(
+	ctx context.Context,
+	cl client.Reader,
+	pgec *postgresqlv1alpha1.PostgresqlEngineConfiguration,
+	instance *postgresqlv1alpha1.PostgresqlUserRole,
+) error {
+	// Check if there isn't any quota
+	if pgec.Spec.Quotas == nil || pgec.Spec.Quotas.MaxLoginRoles == nil {
+		return nil
+	}
+
+	// List resources
+	resources, err := listEngineQuotaResources(ctx, cl, utils.CreateNameKeyForSavedPools(pgec.Name, pgec.Namespace))
+	// Check error
+	if err != nil {
+		return err
+	}
+
+	return checkEngineQuota(pgec, resources.loginRoles, instance, pgec.Spec.Quotas.MaxLoginRoles, "login roles")
+}
+
+// checkPublicationQuota will return a quota exceeded error if publication is over engine quotas.
+func checkPublicationQuota(
+	ctx context.Context,
+	cl client.Reader,
+	pgec *postgresqlv1alpha1.PostgresqlEngineConfiguration,
+	instance *postgresqlv1alpha1.PostgresqlPublication,
+) error {
+	// Check if there isn't any quota
+	if pgec.Spec.Quotas == nil || pgec.Spec.Quotas.MaxPublications == nil {
+		return nil
+	}
+
+	// List resources
+	resources, err := listEngineQuotaResources(ctx, cl, utils.CreateNameKeyForSavedPools(pgec.Name, pgec.Namespace))
+	// Check error
+	if err != nil {
+		return err
+	}
+
+	return checkEngineQuota(pgec, resources.publications, instance, pgec.Spec.Quotas.MaxPublications, "publications")
+}
+
+// checkReplicationSlotQuota will return a quota exceeded error if subscription is over engine quotas.
+func checkReplicationSlotQuota(
+	ctx context.Context,
+	cl client.Reader,
+	pgec *postgresqlv1alpha1.PostgresqlEngineConfiguration,
+	instance *postgresqlv1alpha1.PostgresqlSubscription,
+) error {
+	// Check if there isn't any quota
+	if pgec.Spec.Quotas == nil || pgec.Spec.Quotas.MaxReplicationSlots == nil {
+		return nil
+	}
+
+	// List resources
+	resources, err := listEngineQuotaResources(ctx, cl, utils.CreateNameKeyForSavedPools(pgec.Name, pgec.Namespace))
+	// Check error
+	if err != nil {
+		return err
+	}
+
+	return checkEngineQuota(pgec, resources.subscriptions, instance, pgec.Spec.Quotas.MaxReplicationSlots, "replication slots")
+}
+
+// getEngineQuotaUsage will compute resources usage of engine for status.
+// Only admitted resources are counted in usage, refused ones are counted separately.
+func getEngineQuotaUsage(ctx context.Context, cl client.Reader, pgec *postgresqlv1alpha1.PostgresqlEngineConfiguration) (*postgresqlv1alpha1.EngineQuotaUsage, error) {
+	// List resources
+	resources, err := listEngineQuotaResources(ctx, cl, utils.CreateNameKeyForSavedPools(pgec.Name, pgec.Namespace))
+	// Check error
+	if err != nil {
+		return nil, err
+	}
+
+	// Get quotas
+	quotas := pgec.Spec.Quotas
+	if quotas == nil {
+		quotas = &postgresqlv1alpha1.EngineQuotas{}
+	}
+
+	res := &postgresqlv1alpha1.EngineQuotaUsage{Refused: &postgresqlv1alpha1.QuotaRefusedUsage{}}
+
+	// Compute usage per namespace
+	nsUsages := map[string]*postgresqlv1alpha1.NamespaceQuotaUsage{}
+	getNsUsage := func(ns string) *postgresqlv1alpha1.NamespaceQuotaUsage {
+		// Check if it doesn't exist
+		if _, ok := nsUsages[ns]; !ok {
+			nsUsages[ns] = &postgresqlv1alpha1.NamespaceQuotaUsage{Namespace: ns, Refused: &postgresqlv1alpha1.QuotaRefusedUsage{}}
+		}
+
+		return nsUsages[ns]
+	}
+
+	// Count admitted resources in namespace usage and refused ones in namespace and engine refused usages
+	count := func(
+		list []client.Object,
+		maxValue *int,
+		resourceName string,
+		admitted func(u *postgresqlv1alpha1.NamespaceQuotaUsage) *int,
+		refused func(u *postgresqlv1alpha1.QuotaRefusedUsage) *int,
+	) {
+		refusals := admitEngineQuotaResources(pgec, list, maxValue, resourceName)
+		// Loop over resources
+		for _, it := range list {
+			nsUsage := getNsUsage(it.GetNamespace())
+			// Check if resource is refused
+			if refusals[objectNameKey(it)] != nil {
+				*refused(nsUsage.Refused)++
+				*refused(res.Refused)++
+
+				continue
+			}
+
+			*admitted(nsUsage)++
+		}
+	}
+
+	count(resources.databases, quotas.MaxDatabases, "databases",
+		func(u *postgresqlv1alpha1.NamespaceQuotaUsage) *int { return &u.Databases },
+		func(u *postgresqlv1alpha1.QuotaRefusedUsage) *int { return &u.Databases })
+	count(resources.loginRoles, quotas.MaxLoginRoles, "login roles",
+		func(u *postgresqlv1alpha1.NamespaceQuotaUsage) *int { return &u.LoginRoles },
+		func(u *postgresqlv1alpha1.QuotaRefusedUsage) *int { return &u.LoginRoles })
+	count(resources.publications, quotas.MaxPublications, "publications",
+		func(u *postgresqlv1alpha1.NamespaceQuotaUsage) *int { return &u.Publications },
+		func(u *postgresqlv1alpha1.QuotaRefusedUsage) *int { return &u.Publications })
+	count(resources.subscriptions, quotas.MaxReplicationSlots, "replication slots",
+		func(u *postgresqlv1alpha1.NamespaceQuotaUsage) *int { return &u.ReplicationSlots },
+		func(u *postgresqlv1alpha1.QuotaRefusedUsage) *int { return &u.ReplicationSlots })
+
+	// Save them sorted by namespace and compute engine usage
+	for _, it := range nsUsages {
+		res.Databases += it.Databases
+		res.LoginRoles += it.LoginRoles
+		res.Publications += it.Publications
+		res.ReplicationSlots += it.ReplicationSlots
+
+		// Remove empty refused usage
+		if *it.Refused == (postgresqlv1alpha1.QuotaRefusedUsage{}) {
+			it.Refused = nil
+		}
+
+		res.Namespaces = append(res.Namespaces, it)
+	}
+
+	sort.Slice(res.Namespaces, func(i, j int) bool { return res.Namespaces[i].Namespace < res.Namespaces[j].Namespace })
+
+	// Remove empty refused usage
+	if *res.Refused == (postgresqlv1alpha1.QuotaRefusedUsage{}) {
+		res.Refused = nil
+	}
+
+	return res, nil
+}
@@ -0,0 +1,165 @@
+package postgresql
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-logr/logr"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/samber/lo"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+
+	postgresqlv1alpha1 "github.com/easymile/postgresql-operator/api/postgresql/v1alpha1"
+)
+
+var _ = Describe("Quotas tests with fake engine", Label(fakeEngineLabel), func() {
+	It("should enforce engine quotas", func() {
+		// Create databases with a known creation order
+		now := time.Now()
+		newDB := func(name, namespace string, creation time.Time) *postgresqlv1alpha1.PostgresqlDatabase {
+			db := newFakePGDB()
+			db.Name = name
+			db.Namespace = namespace
+			db.Spec.Database = name
+			db.CreationTimestamp = v1.NewTime(creation)
+
+			return db
+		}
+
+		cl, fakePG, factory := setupFakeEnv(
+			newDB("quota1", "ns1", now.Add(-3*time.Minute)),
+			newDB("quota2", "ns1", now.Add(-2*time.Minute)),
+			newDB("quota3", "ns2", now.Add(-time.Minute)),
+			newDB("quota4", "ns3", now),
+		)
+		fakePG.Capabilities.AvailableExtensions = []string{pgdbExtensionName1}
+
+		// Set quotas: 2 databases and a namespace can only use half of them
+		pgec := &postgresqlv1alpha1.PostgresqlEngineConfiguration{}
+		Expect(cl.Get(context.TODO(), types.NamespacedName{Name: pgecName, Namespace: pgecNamespace}, pgec)).To(Succeed())
+		pgec.Spec.Quotas = &postgresqlv1alpha1.EngineQuotas{
+			MaxDatabases:          lo.ToPtr(2),
+			NamespaceSharePercent: lo.ToPtr(50),
+		}
+		Expect(cl.Update(context.TODO(), pgec)).To(Succeed())
+
+		r := newFakePGDBReconciler(cl, factory)
+
+		Expect(reconcileFakeUntilStable(r, "quota1", "ns1")).To(Succeed())
+		Expect(reconcileFakeUntilStable(r, "quota2", "ns1")).NotTo(Succeed())
+		Expect(reconcileFakeUntilStable(r, "quota3", "ns2")).To(Succeed())
+		Expect(reconcileFakeUntilStable(r, "quota4", "ns3")).NotTo(Succeed())
+
+		// Checks
+		Expect(fakePG.Databases).To(HaveKey("quota1"))
+		Expect(fakePG.Databases).NotTo(HaveKey("quota2"))
+		Expect(fakePG.Databases).To(HaveKey("quota3"))
+		Expect(fakePG.Databases).NotTo(HaveKey("quota4"))
+
+		item := &postgresqlv1alpha1.PostgresqlDatabase{}
+		Expect(cl.Get(context.TODO(), types.NamespacedName{Name: "quota2", Namespace: "ns1"}, item)).To(Succeed())
+		Expect(item.Status.Ready).To(BeFalse())
+		Expect(item.Status.Reason).To(Equal(string(QuotaExceededReason)))
+		Expect(item.Status.Message).To(ContainSubstring("namespace ns1 share"))
+
+		Expect(cl.Get(context.TODO(), types.NamespacedName{Name: "quota4", Namespace: "ns3"}, item)).To(Succeed())
+		Expect(item.Status.Ready).To(BeFalse())
+		Expect(item.Status.Reason).To(Equal(string(QuotaExceededReason)))
+		Expect(item.Status.Message).To(ContainSubstring("quota of 2 databases is exceeded"))
+
+		// Check usage in engine configuration status
+		er := &PostgresqlEngineConfigurationReconciler{
+			Client:                              cl,
+			Scheme:                              cl.Scheme(),
+			Recorder:                            record.NewFakeRecorder(100),
+			Log:                                 logr.Discard(),
+			ControllerRuntimeDetailedErrorTotal: newFakeCounter(),
+			ControllerName:                      "postgresqlengineconfiguration",
+			ReconcileTimeout:                    10 * time.Second,
+			PgInstanceFactory:                   factory,
+		}
+
+		Expect(reconcileFakeUntilStable(er, pgecName, pgecNamespace)).To(Succeed())
+
+		Expect(cl.Get(context.TODO(), types.NamespacedName{Name: pgecName, Namespace: pgecNamespace}, pgec)).To(Succeed())
+		Expect(pgec.Status.QuotaUsage).NotTo(BeNil())
+		// Refused databases must not be counted in usage
+		Expect(pgec.Status.QuotaUsage.Databases).To(Equal(2))
+		Expect(pgec.Status.QuotaUsage.LoginRoles).To(Equal(0))
+		Expect(pgec.Status.QuotaUsage.Publications).To(Equal(0))
+		Expect(pgec.Status.QuotaUsage.ReplicationSlots).To(Equal(0))
+		Expect(pgec.Status.QuotaUsage.Refused).To(Equal(&postgresqlv1alpha1.QuotaRefusedUsage{Databases: 2}))
+		Expect(pgec.Status.QuotaUsage.Namespaces).To(Equal([]*postgresqlv1alpha1.NamespaceQuotaUsage{
+			{Namespace: "ns1", Databases: 1, Refused: &postgresqlv1alpha1.QuotaRefusedUsage{Databases: 1}},
+			{Namespace: "ns2", Databases: 1},
+			{Namespace: "ns3", Refused: &postgresqlv1alpha1.QuotaRefusedUsage{Databases: 1}},
+		}))
+
+		// Remove oldest database of ns1 to let the next one be created
+		Expect(cl.Delete(context.TODO(), newDB("quota1", "ns1", now))).To(Succeed())
+		Expect(reconcileFakeUntilStable(r, "quota1", "ns1")).To(Succeed())
+		Expect(reconcileFakeUntilStable(r, "quota2", "ns1")).To(Succeed())
+		Expect(fakePG.Databases).To(HaveKey("quota2"))
+	})
+
+	It("should enforce replication slot quota on subscriptions", func() {
+		pubDB, subDB, pub, sub := newFakePGSubscriptionEnv()
+		sub.CreationTimestamp = v1.NewTime(time.Now().Add(-time.Minute))
+
+		// Second subscription created after the first one
+		sub2 := sub.DeepCopy()
+		sub2.Name = pgsubscriptionName + "2"
+		sub2.Spec.Name = pgsubscriptionSubscriptionName1 + "2"
+		sub2.CreationTimestamp = v1.NewTime(time.Now())
+
+		cl, fakePG, factory := setupFakeEnv(pubDB, subDB, pub, sub, sub2)
+		// Create databases in engine
+		Expect(fakePG.CreateDB(context.TODO(), pgdbDBName, postgresUser, nil)).To(Succeed())
+		Expect(fakePG.CreateDB(context.TODO(), pgdbDBName2, postgresUser, nil)).To(Succeed())
+
+		// Set quota: 1 replication slot
+		pgec := &postgresqlv1alpha1.PostgresqlEngineConfiguration{}
+		Expect(cl.Get(context.TODO(), types.NamespacedName{Name: pgecName, Namespace: pgecNamespace}, pgec)).To(Succeed())
+		pgec.Spec.Quotas = &postgresqlv1alpha1.EngineQuotas{MaxReplicationSlots: lo.ToPtr(1)}
+		Expect(cl.Update(context.TODO(), pgec)).To(Succeed())
+
+		// Create publication
+		Expect(reconcileFakeUntilStable(newFakePGPublicationReconciler(cl, factory), pgpublicationName, pgpublicationNamespace)).To(Succeed())
+
+		r := newFakePGSubscriptionReconciler(cl, factory)
+
+		Expect(reconcileFakeUntilStable(r, sub.Name, pgsubscriptionNamespace)).To(Succeed())
+		Expect(reconcileFakeUntilStable(r, sub2.Name, pgsubscriptionNamespace)).NotTo(Succeed())
+
+		// Checks
+		Expect(fakePG.Databases[pgdbDBName2].Subscriptions).To(HaveKey(sub.Spec.Name))
+		Expect(fakePG.Databases[pgdbDBName2].Subscriptions).NotTo(HaveKey(sub2.Spec.Name))
+
+		item := &postgresqlv1alpha1.PostgresqlSubscription{}
+		Expect(cl.Get(context.TODO(), types.NamespacedName{Name: sub2.Name, Namespace: pgsubscriptionNamespace}, item)).To(Succeed())
+		Expect(item.Status.Ready).To(BeFalse())
+		Expect(item.Status.Reason).To(Equal(string(QuotaExceededReason)))
+		Expect(item.Status.Message).To(ContainSubstring("quota of 1 replication slots is exceeded"))
+
+		// Check usage in engine configuration status
+		er := &PostgresqlEngineConfigurationReconciler{
+			Client:                              cl,
+			Scheme:                              cl.Scheme(),
+			Recorder:                            record.NewFakeRecorder(100),
+			Log:                                 logr.Discard(),
+			ControllerRuntimeDetailedErrorTotal: newFakeCounter(),
+			ControllerName:                      "postgresqlengineconfiguration",
+			ReconcileTimeout:                    10 * time.Second,
+			PgInstanceFactory:                   factory,
+		}
+
+		Expect(reconcileFakeUntilStable(er, pgecName, pgecNamespace)).To(Succeed())
+
+		Expect(cl.Get(context.TODO(), types.NamespacedName{Name: pgecName, Namespace: pgecNamespace}, pgec)).To(Succeed())
+		Expect(pgec.Status.QuotaUsage.ReplicationSlots).To(Equal(1))
+		Expect(pgec.Status.QuotaUsage.Refused).To(Equal(&postgresqlv1alpha1.QuotaRefusedUsage{ReplicationSlots: 1}))
+	})
+})
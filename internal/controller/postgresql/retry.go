@@ -0,0 +1,75 @@
+package postgresql
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-logr/logr"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/easymile/postgresql-operator/internal/controller/postgresql/postgres"
+)
+
+const (
+	// Requeue delay of resources reconciles failing because of a read only transaction error.
+	// This happens after a failover when pooled connections are still opened on the old primary
+	// and until the engine configuration check detects it and closes pools.
+	ReadOnlyTransactionRequeueDelay = 5 * time.Second
+	// Status reason of resources waiting for a retry after a read only transaction error.
+	ReadOnlyTransactionReason = "ReadOnlyTransaction"
+)
+
+// internalError is a kubernetes internal error keeping the original error reachable with errors.As.
+type internalError struct {
+	*errors.StatusError
+	err error
+}
+
+func (e *internalError) Unwrap() error {
+	return e.err
+}
+
+// newInternalError will wrap error in a kubernetes internal error.
+// Contrary to errors.NewInternalError, original error (like a *pq.Error) stays reachable with errors.As.
+func newInternalError(err error) error {
+	return &internalError{StatusError: errors.NewInternalError(err), err: err}
+}
+
+// manageReadOnlyTransactionError will update status and requeue resource when issue is a read only transaction error.
+// Status message and reason are given as they are specific to each resource.
+// Returns true if issue was managed.
+func manageReadOnlyTransactionError(
+	ctx context.Context,
+	logger logr.Logger,
+	statusWriter client.SubResourceWriter,
+	recorder record.EventRecorder,
+	instance client.Object,
+	message, reason *string,
+	originalPatch client.Patch,
+	issue error,
+) (ctrl.Result, bool) {
+	// Check if issue is retryable
+	// ? Note: Read only transaction errors are raised by connections still opened on an old primary after a failover
+	if !postgres.IsReadOnlySQLTransactionError(issue) {
+		return ctrl.Result{}, false
+	}
+
+	logger.Info("read only transaction error raised in reconcile, retrying soon", "error", issue.Error())
+	// Add kubernetes event
+	recorder.Event(instance, "Warning", "Retrying", issue.Error())
+
+	// Update status without failing phase
+	*message = issue.Error()
+	*reason = ReadOnlyTransactionReason
+
+	// Patch status
+	err := statusWriter.Patch(ctx, instance, originalPatch)
+	if err != nil {
+		logger.Error(err, "unable to update status")
+	}
+
+	return ctrl.Result{RequeueAfter: ReadOnlyTransactionRequeueDelay}, true
+}
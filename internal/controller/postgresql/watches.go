@@ -0,0 +1,346 @@
+package postgresql
+
+import (
+	"context"
+	"reflect"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	postgresqlv1alpha1 "github.com/easymile/postgresql-operator/api/postgresql/v1alpha1"
+	"github.com/easymile/postgresql-operator/internal/controller/config"
+	"github.com/easymile/postgresql-operator/internal/controller/utils"
+)
+
+// Field indexes used by watches to find resources linked to a changed object.
+const (
+	// Secrets (user/password and TLS) of engine configurations
+	EngineSecretIndexField = "spec.secretName"
+	// Engine key of databases
+	DatabaseEngineIndexField = "spec.engineConfiguration"
+	// Database keys of user role privileges
+	UserRoleDatabaseIndexField = "spec.privileges.database"
+	// Import secret of user roles
+	UserRoleImportSecretIndexField = "spec.importSecretName"
+	// Database key of publications
+	PublicationDatabaseIndexField = "spec.database"
+)
+
+// SetupIndexes will register field indexes used by controller watches.
+// This must be called once before controllers setup.
+func SetupIndexes(ctx context.Context, mgr ctrl.Manager) error {
+	// Get indexer
+	indexer := mgr.GetFieldIndexer()
+
+	// Engine configuration secrets
+	err := indexer.IndexField(ctx, &postgresqlv1alpha1.PostgresqlEngineConfiguration{}, EngineSecretIndexField, engineSecretIndexer)
+	// Check error
+	if err != nil {
+		return err
+	}
+	// Cluster engine configuration secrets
+	err = indexer.IndexField(ctx, &postgresqlv1alpha1.ClusterPostgresqlEngineConfiguration{}, EngineSecretIndexField, engineSecretIndexer)
+	// Check error
+	if err != nil {
+		return err
+	}
+	// Database engine configurations
+	err = indexer.IndexField(ctx, &postgresqlv1alpha1.PostgresqlDatabase{}, DatabaseEngineIndexField, databaseEngineIndexer)
+	// Check error
+	if err != nil {
+		return err
+	}
+	// User role databases
+	err = indexer.IndexField(ctx, &postgresqlv1alpha1.PostgresqlUserRole{}, UserRoleDatabaseIndexField, userRoleDatabaseIndexer)
+	// Check error
+	if err != nil {
+		return err
+	}
+	// User role import secrets
+	err = indexer.IndexField(ctx, &postgresqlv1alpha1.PostgresqlUserRole{}, UserRoleImportSecretIndexField, userRoleImportSecretIndexer)
+	// Check error
+	if err != nil {
+		return err
+	}
+
+	// Publication databases
+	return indexer.IndexField(ctx, &postgresqlv1alpha1.PostgresqlPublication{}, PublicationDatabaseIndexField, publicationDatabaseIndexer)
+}
+
+// getEngineSecretNames will return names of secrets used by engine configuration.
+func getEngineSecretNames(spec *postgresqlv1alpha1.PostgresqlEngineConfigurationSpec) []string {
+	res := make([]string, 0)
+	// Check user and password secret
+	if spec.SecretName != "" {
+		res = append(res, spec.SecretName)
+	}
+	// Check TLS secret
+	if spec.TLS != nil && spec.TLS.SecretName != "" {
+		res = append(res, spec.TLS.SecretName)
+	}
+
+	return res
+}
+
+func engineSecretIndexer(obj client.Object) []string {
+	// Cast
+	instance, ok := obj.(postgresqlv1alpha1.EngineConfiguration)
+	// Check if cast is ok
+	if !ok {
+		return nil
+	}
+
+	return getEngineSecretNames(instance.GetEngineSpec())
+}
+
+func databaseEngineIndexer(obj client.Object) []string {
+	// Cast
+	instance, ok := obj.(*postgresqlv1alpha1.PostgresqlDatabase)
+	// Check if cast is ok
+	if !ok || instance.Spec.EngineConfiguration == nil {
+		return nil
+	}
+
+	return []string{utils.CreateNameKeyForEngineLink(instance.Spec.EngineConfiguration, instance.Namespace)}
+}
+
+func userRoleDatabaseIndexer(obj client.Object) []string {
+	// Cast
+	instance, ok := obj.(*postgresqlv1alpha1.PostgresqlUserRole)
+	// Check if cast is ok
+	if !ok {
+		return nil
+	}
+
+	res := make([]string, 0)
+	// Loop over privileges
+	for _, it := range instance.Spec.Privileges {
+		// Check if database is set
+		if it.Database == nil {
+			continue
+		}
+
+		res = append(res, utils.CreateNameKey(it.Database.Name, it.Database.Namespace, instance.Namespace))
+	}
+
+	return res
+}
+
+func userRoleImportSecretIndexer(obj client.Object) []string {
+	// Cast
+	instance, ok := obj.(*postgresqlv1alpha1.PostgresqlUserRole)
+	// Check if cast is ok
+	if !ok || instance.Spec.ImportSecretName == "" {
+		return nil
+	}
+
+	return []string{instance.Spec.ImportSecretName}
+}
+
+func publicationDatabaseIndexer(obj client.Object) []string {
+	// Cast
+	instance, ok := obj.(*postgresqlv1alpha1.PostgresqlPublication)
+	// Check if cast is ok
+	if !ok || instance.Spec.Database == nil {
+		return nil
+	}
+
+	return []string{utils.CreateNameKey(instance.Spec.Database.Name, instance.Spec.Database.Namespace, instance.Namespace)}
+}
+
+// findEngineKeysForSecret will return keys of engine configurations (namespaced and cluster ones) using secret.
+func findEngineKeysForSecret(ctx context.Context, cl client.Reader, secret client.Object) ([]string, error) {
+	res := make([]string, 0)
+
+	// List engine configurations in secret namespace
+	list := &postgresqlv1alpha1.PostgresqlEngineConfigurationList{}
+	err := cl.List(ctx, list, client.InNamespace(secret.GetNamespace()), client.MatchingFields{EngineSecretIndexField: secret.GetName()})
+	// Check error
+	if err != nil {
+		return nil, err
+	}
+	// Loop over them
+	for _, it := range list.Items {
+		res = append(res, utils.CreateNameKeyForSavedPools(it.Name, it.Namespace))
+	}
+
+	// Check if secret isn't in operator namespace
+	// ? Note: Cluster engine configuration secrets are in operator namespace
+	if secret.GetNamespace() != config.GetOperatorNamespace() {
+		return res, nil
+	}
+
+	// List cluster engine configurations
+	clusterList := &postgresqlv1alpha1.ClusterPostgresqlEngineConfigurationList{}
+	err = cl.List(ctx, clusterList, client.MatchingFields{EngineSecretIndexField: secret.GetName()})
+	// Check error
+	if err != nil {
+		return nil, err
+	}
+	// Loop over them
+	for _, it := range clusterList.Items {
+		res = append(res, utils.CreateNameKeyForSavedPools(it.Name, ""))
+	}
+
+	return res, nil
+}
+
+// newEngineSecretMapFunc will return a map function enqueuing namespaced or cluster engine configurations using a secret.
+func newEngineSecretMapFunc(cl client.Reader, clusterKind bool) handler.MapFunc {
+	return func(ctx context.Context, obj client.Object) []reconcile.Request {
+		// Get engine keys
+		keys, err := findEngineKeysForSecret(ctx, cl, obj)
+		// Check error
+		if err != nil {
+			ctrl.LoggerFrom(ctx).Error(err, "unable to list engine configurations linked to secret")
+
+			return nil
+		}
+
+		res := make([]reconcile.Request, 0)
+		// Loop over keys
+		for _, k := range keys {
+			// Split key
+			ns, name := splitNameKey(k)
+			// Ignore other engine configuration kind
+			if clusterKind != (ns == "") {
+				continue
+			}
+
+			res = append(res, reconcile.Request{NamespacedName: types.NamespacedName{Name: name, Namespace: ns}})
+		}
+
+		return res
+	}
+}
+
+// newDatabaseEngineSecretMapFunc will return a map function enqueuing databases linked to engine configurations using a secret.
+func newDatabaseEngineSecretMapFunc(cl client.Reader) handler.MapFunc {
+	return func(ctx context.Context, obj client.Object) []reconcile.Request {
+		// Get engine keys
+		keys, err := findEngineKeysForSecret(ctx, cl, obj)
+		// Check error
+		if err != nil {
+			ctrl.LoggerFrom(ctx).Error(err, "unable to list engine configurations linked to secret")
+
+			return nil
+		}
+
+		res := make([]reconcile.Request, 0)
+		// Loop over keys
+		for _, k := range keys {
+			// List databases linked to engine configuration
+			list := &postgresqlv1alpha1.PostgresqlDatabaseList{}
+			err = cl.List(ctx, list, client.MatchingFields{DatabaseEngineIndexField: k})
+			// Check error
+			if err != nil {
+				ctrl.LoggerFrom(ctx).Error(err, "unable to list databases linked to engine configuration")
+
+				return nil
+			}
+
+			// Loop over them
+			for _, it := range list.Items {
+				res = append(res, reconcile.Request{NamespacedName: types.NamespacedName{Name: it.Name, Namespace: it.Namespace}})
+			}
+		}
+
+		return res
+	}
+}
+
+// newIndexedMapFunc will return a map function enqueuing objects of list type having indexed field equal to the value computed from changed object.
+func newIndexedMapFunc(
+	cl client.Reader,
+	newList func() client.ObjectList,
+	field string,
+	value func(obj client.Object) string,
+	sameNamespace bool,
+) handler.MapFunc {
+	return func(ctx context.Context, obj client.Object) []reconcile.Request {
+		// Prepare list options
+		opts := []client.ListOption{client.MatchingFields{field: value(obj)}}
+		// Check if list must be restricted to changed object namespace
+		if sameNamespace {
+			opts = append(opts, client.InNamespace(obj.GetNamespace()))
+		}
+
+		// List
+		list := newList()
+		err := cl.List(ctx, list, opts...)
+		// Check error
+		if err != nil {
+			ctrl.LoggerFrom(ctx).Error(err, "unable to list resources linked to changed object", "field", field)
+
+			return nil
+		}
+
+		// Extract objects
+		items, err := meta.ExtractList(list)
+		// Check error
+		if err != nil {
+			ctrl.LoggerFrom(ctx).Error(err, "unable to extract list items")
+
+			return nil
+		}
+
+		res := make([]reconcile.Request, 0, len(items))
+		// Loop over items
+		for _, it := range items {
+			// Cast
+			o, ok := it.(client.Object)
+			// Check if cast is ok
+			if !ok {
+				continue
+			}
+
+			res = append(res, reconcile.Request{NamespacedName: types.NamespacedName{Name: o.GetName(), Namespace: o.GetNamespace()}})
+		}
+
+		return res
+	}
+}
+
+// objectNameKey will return namespace/name key of object.
+func objectNameKey(obj client.Object) string {
+	return utils.CreateNameKey(obj.GetName(), obj.GetNamespace(), "")
+}
+
+// objectName will return name of object.
+func objectName(obj client.Object) string {
+	return obj.GetName()
+}
+
+// splitNameKey will return namespace and name from a namespace/name key.
+func splitNameKey(key string) (string, string) {
+	// Search separator
+	i := strings.LastIndex(key, "/")
+	// Check if it isn't found
+	if i == -1 {
+		return "", key
+	}
+
+	return key[:i], key[i+1:]
+}
+
+// databaseStatusChangedPredicate will filter database update events to keep only status changes.
+var databaseStatusChangedPredicate = predicate.Funcs{
+	UpdateFunc: func(e event.UpdateEvent) bool {
+		// Cast
+		oldDB, ok1 := e.ObjectOld.(*postgresqlv1alpha1.PostgresqlDatabase)
+		newDB, ok2 := e.ObjectNew.(*postgresqlv1alpha1.PostgresqlDatabase)
+		// Check if cast is ok
+		if !ok1 || !ok2 {
+			return false
+		}
+
+		return !reflect.DeepEqual(oldDB.Status, newDB.Status)
+	},
+}
@@ -0,0 +1,136 @@
+package postgresql
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"github.com/easymile/postgresql-operator/api/postgresql/common"
+	postgresqlv1alpha1 "github.com/easymile/postgresql-operator/api/postgresql/v1alpha1"
+	"github.com/easymile/postgresql-operator/internal/controller/config"
+)
+
+var _ = Describe("Watches tests with fake engine", Label(fakeEngineLabel), func() {
+	It("should map referenced objects to requests", func() {
+		operatorNamespace := "operator"
+		config.SetOperatorNamespace(operatorNamespace)
+		defer config.SetOperatorNamespace("")
+
+		// Database linked to cluster engine configuration
+		clusterPGDB := newFakePGDB()
+		clusterPGDB.Name = pgdbName2
+		clusterPGDB.Spec.EngineConfiguration = &common.EngineCRLink{Kind: common.ClusterPostgresqlEngineConfigurationKind, Name: pgecName}
+
+		cl, _, _ := setupFakeEnv(
+			newFakePGDB(),
+			clusterPGDB,
+			&postgresqlv1alpha1.ClusterPostgresqlEngineConfiguration{
+				ObjectMeta: v1.ObjectMeta{Name: pgecName},
+				Spec: postgresqlv1alpha1.PostgresqlEngineConfigurationSpec{
+					Host:       "localhost",
+					SecretName: pgecSecretName,
+					TLS:        &postgresqlv1alpha1.EngineTLS{SecretName: "cluster-tls"},
+				},
+			},
+			&postgresqlv1alpha1.PostgresqlUserRole{
+				ObjectMeta: v1.ObjectMeta{Name: pgurName, Namespace: pgurNamespace},
+				Spec: postgresqlv1alpha1.PostgresqlUserRoleSpec{
+					Mode:             postgresqlv1alpha1.ProvidedMode,
+					ImportSecretName: pgurImportSecretName,
+					Privileges: []*postgresqlv1alpha1.PostgresqlUserRolePrivilege{
+						{Database: &common.CRLink{Name: pgdbName, Namespace: pgdbNamespace}},
+					},
+				},
+			},
+			&postgresqlv1alpha1.PostgresqlPublication{
+				ObjectMeta: v1.ObjectMeta{Name: pgpublicationName, Namespace: pgdbNamespace},
+				Spec: postgresqlv1alpha1.PostgresqlPublicationSpec{
+					Database: &common.CRLink{Name: pgdbName},
+				},
+			},
+		)
+
+		pgecSecret := &corev1.Secret{ObjectMeta: v1.ObjectMeta{Name: pgecSecretName, Namespace: pgecNamespace}}
+		clusterSecret := &corev1.Secret{ObjectMeta: v1.ObjectMeta{Name: pgecSecretName, Namespace: operatorNamespace}}
+		clusterTLSSecret := &corev1.Secret{ObjectMeta: v1.ObjectMeta{Name: "cluster-tls", Namespace: operatorNamespace}}
+		otherSecret := &corev1.Secret{ObjectMeta: v1.ObjectMeta{Name: "other", Namespace: pgecNamespace}}
+		importSecret := &corev1.Secret{ObjectMeta: v1.ObjectMeta{Name: pgurImportSecretName, Namespace: pgurNamespace}}
+		pgdbRequest := reconcile.Request{NamespacedName: types.NamespacedName{Name: pgdbName, Namespace: pgdbNamespace}}
+		clusterPGDBRequest := reconcile.Request{NamespacedName: types.NamespacedName{Name: pgdbName2, Namespace: pgdbNamespace}}
+
+		// Engine secrets => engine configurations
+		engineMap := newEngineSecretMapFunc(cl, false)
+		Expect(engineMap(context.TODO(), pgecSecret)).To(ConsistOf(
+			reconcile.Request{NamespacedName: types.NamespacedName{Name: pgecName, Namespace: pgecNamespace}},
+		))
+		Expect(engineMap(context.TODO(), clusterSecret)).To(BeEmpty())
+		Expect(engineMap(context.TODO(), otherSecret)).To(BeEmpty())
+
+		clusterEngineMap := newEngineSecretMapFunc(cl, true)
+		Expect(clusterEngineMap(context.TODO(), clusterSecret)).To(ConsistOf(
+			reconcile.Request{NamespacedName: types.NamespacedName{Name: pgecName}},
+		))
+		Expect(clusterEngineMap(context.TODO(), clusterTLSSecret)).To(ConsistOf(
+			reconcile.Request{NamespacedName: types.NamespacedName{Name: pgecName}},
+		))
+		Expect(clusterEngineMap(context.TODO(), pgecSecret)).To(BeEmpty())
+
+		// Engine secrets => databases
+		dbMap := newDatabaseEngineSecretMapFunc(cl)
+		Expect(dbMap(context.TODO(), pgecSecret)).To(ConsistOf(pgdbRequest))
+		Expect(dbMap(context.TODO(), clusterTLSSecret)).To(ConsistOf(clusterPGDBRequest))
+		Expect(dbMap(context.TODO(), otherSecret)).To(BeEmpty())
+
+		// Import secrets => user roles
+		userRoleSecretMap := newIndexedMapFunc(
+			cl,
+			func() client.ObjectList { return &postgresqlv1alpha1.PostgresqlUserRoleList{} },
+			UserRoleImportSecretIndexField,
+			objectName,
+			true,
+		)
+		Expect(userRoleSecretMap(context.TODO(), importSecret)).To(ConsistOf(
+			reconcile.Request{NamespacedName: types.NamespacedName{Name: pgurName, Namespace: pgurNamespace}},
+		))
+		Expect(userRoleSecretMap(context.TODO(), otherSecret)).To(BeEmpty())
+
+		// Databases => user roles and publications
+		userRoleDBMap := newIndexedMapFunc(
+			cl,
+			func() client.ObjectList { return &postgresqlv1alpha1.PostgresqlUserRoleList{} },
+			UserRoleDatabaseIndexField,
+			objectNameKey,
+			false,
+		)
+		Expect(userRoleDBMap(context.TODO(), newFakePGDB())).To(ConsistOf(
+			reconcile.Request{NamespacedName: types.NamespacedName{Name: pgurName, Namespace: pgurNamespace}},
+		))
+		Expect(userRoleDBMap(context.TODO(), clusterPGDB)).To(BeEmpty())
+
+		publicationDBMap := newIndexedMapFunc(
+			cl,
+			func() client.ObjectList { return &postgresqlv1alpha1.PostgresqlPublicationList{} },
+			PublicationDatabaseIndexField,
+			objectNameKey,
+			false,
+		)
+		Expect(publicationDBMap(context.TODO(), newFakePGDB())).To(ConsistOf(
+			reconcile.Request{NamespacedName: types.NamespacedName{Name: pgpublicationName, Namespace: pgdbNamespace}},
+		))
+
+		// Only database status changes are kept
+		oldDB := newFakePGDB()
+		newDB := newFakePGDB()
+		newDB.Spec.DropOnDelete = true
+		Expect(databaseStatusChangedPredicate.Update(event.UpdateEvent{ObjectOld: oldDB, ObjectNew: newDB})).To(BeFalse())
+		newDB.Status.Ready = true
+		Expect(databaseStatusChangedPredicate.Update(event.UpdateEvent{ObjectOld: oldDB, ObjectNew: newDB})).To(BeTrue())
+	})
+})
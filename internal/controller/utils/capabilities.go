@@ -0,0 +1,167 @@
+package utils
+
+import (
+	"fmt"
+
+	postgresqlv1alpha1 "github.com/easymile/postgresql-operator/api/postgresql/v1alpha1"
+	"github.com/samber/lo"
+	"k8s.io/apimachinery/pkg/api/errors"
+)
+
+const (
+	PostgresqlVersion10 = 100000
+	PostgresqlVersion13 = 130000
+	PostgresqlVersion15 = 150000
+
+	LogicalWalLevel = "logical"
+)
+
+// FormatServerVersionNum will transform a server_version_num value to a readable version.
+func FormatServerVersionNum(versionNum int) string {
+	// Since PostgreSQL 10, version is major.minor
+	if versionNum >= PostgresqlVersion10 {
+		return fmt.Sprintf("%d.%d", versionNum/10000, versionNum%10000) //nolint:gomnd // Version format
+	}
+
+	return fmt.Sprintf("%d.%d.%d", versionNum/10000, versionNum/100%100, versionNum%100) //nolint:gomnd // Version format
+}
+
+// CheckEngineMinVersion will return a bad request error if engine version is known and lower than the minimum one.
+func CheckEngineMinVersion(pgec *postgresqlv1alpha1.PostgresqlEngineConfiguration, minVersionNum int, feature string) error {
+	// Check if capabilities aren't discovered yet
+	if pgec.Status.Capabilities == nil {
+		return nil
+	}
+
+	// Check version
+	if pgec.Status.Capabilities.ServerVersionNum < minVersionNum {
+		return errors.NewBadRequest(fmt.Sprintf(
+			"%s requires PostgreSQL %s or later but engine version is %s",
+			feature,
+			FormatServerVersionNum(minVersionNum),
+			FormatServerVersionNum(pgec.Status.Capabilities.ServerVersionNum),
+		))
+	}
+
+	return nil
+}
+
+// CheckEngineWalLevel will return a bad request error if engine wal level is known and isn't the wanted one.
+func CheckEngineWalLevel(pgec *postgresqlv1alpha1.PostgresqlEngineConfiguration, walLevel, feature string) error {
+	// Check if capabilities aren't discovered yet
+	if pgec.Status.Capabilities == nil {
+		return nil
+	}
+
+	// Check wal level
+	if pgec.Status.Capabilities.WalLevel != walLevel {
+		return errors.NewBadRequest(fmt.Sprintf(
+			"%s requires wal_level to be %s but engine wal_level is %s",
+			feature,
+			walLevel,
+			pgec.Status.Capabilities.WalLevel,
+		))
+	}
+
+	return nil
+}
+
+// CheckEngineExtensionsAvailable will return a bad request error if engine extensions are known and one isn't available.
+func CheckEngineExtensionsAvailable(pgec *postgresqlv1alpha1.PostgresqlEngineConfiguration, extensions []string) error {
+	// Check if capabilities aren't discovered yet
+	if pgec.Status.Capabilities == nil {
+		return nil
+	}
+
+	// Find missing extensions
+	missing, _ := lo.Difference(extensions, pgec.Status.Capabilities.AvailableExtensions)
+	// Check if there are missing extensions
+	if len(missing) != 0 {
+		return errors.NewBadRequest(fmt.Sprintf("extensions %v aren't available on engine", missing))
+	}
+
+	return nil
+}
+
+// CheckEngineAdminCanCreateRoles will return a bad request error if engine user is known to be unable to create roles.
+func CheckEngineAdminCanCreateRoles(pgec *postgresqlv1alpha1.PostgresqlEngineConfiguration) error {
+	// Check if capabilities aren't discovered yet
+	if pgec.Status.Capabilities == nil || pgec.Status.Capabilities.AdminAttributes == nil {
+		return nil
+	}
+
+	// Save attributes for easy use
+	attrs := pgec.Status.Capabilities.AdminAttributes
+	// Check attributes
+	if !attrs.Superuser && !attrs.CreateRole {
+		return errors.NewBadRequest("engine configuration user must have CREATEROLE attribute to create roles")
+	}
+
+	return nil
+}
+
+// CheckEngineAdminCanGrantReplication will return a bad request error if engine user is known to be unable to give REPLICATION attribute.
+func CheckEngineAdminCanGrantReplication(pgec *postgresqlv1alpha1.PostgresqlEngineConfiguration) error {
+	// Check if capabilities aren't discovered yet
+	if pgec.Status.Capabilities == nil || pgec.Status.Capabilities.AdminAttributes == nil {
+		return nil
+	}
+
+	// Save attributes for easy use
+	attrs := pgec.Status.Capabilities.AdminAttributes
+	// Check attributes
+	if !attrs.Superuser && !attrs.Replication {
+		return errors.NewBadRequest("engine configuration user must have REPLICATION attribute to create roles with replication")
+	}
+
+	return nil
+}
+
+// CheckEngineUserConnectionsReachable will return a service unavailable error if engine is configured to block unreachable
+// user connections and one of the connections that would be published for connection type is known to be unreachable.
+func CheckEngineUserConnectionsReachable(
+	pgec *postgresqlv1alpha1.PostgresqlEngineConfiguration,
+	connectionType postgresqlv1alpha1.ConnectionTypesSpecEnum,
+) error {
+	// Check if blocking isn't enabled or user connections aren't probed yet
+	if !pgec.Spec.BlockUnreachableUserConnections || pgec.Spec.UserConnections == nil {
+		return nil
+	}
+
+	// Compute published connections with primary as default value
+	published := map[postgresqlv1alpha1.UserConnectionType][]*postgresqlv1alpha1.GenericUserConnection{
+		postgresqlv1alpha1.PrimaryUserConnectionType: {pgec.Spec.UserConnections.PrimaryConnection},
+		postgresqlv1alpha1.ReplicaUserConnectionType: pgec.Spec.UserConnections.ReplicaConnections,
+	}
+	// Check if it is a bouncer connection
+	if connectionType == postgresqlv1alpha1.BouncerConnectionType {
+		published = map[postgresqlv1alpha1.UserConnectionType][]*postgresqlv1alpha1.GenericUserConnection{
+			postgresqlv1alpha1.BouncerUserConnectionType:        {pgec.Spec.UserConnections.BouncerConnection},
+			postgresqlv1alpha1.ReplicaBouncerUserConnectionType: pgec.Spec.UserConnections.ReplicaBouncerConnections,
+		}
+	}
+
+	// Loop over probe results
+	for _, it := range pgec.Status.UserConnections {
+		// Ignore reachable ones
+		if it.Reachable {
+			continue
+		}
+
+		// Check if connection is still published
+		// ? Note: Probe results of old spec values are ignored
+		found := lo.ContainsBy(published[it.Type], func(uc *postgresqlv1alpha1.GenericUserConnection) bool {
+			return uc != nil && uc.Host == it.Host && uc.Port == it.Port
+		})
+		if found {
+			return errors.NewServiceUnavailable(fmt.Sprintf(
+				"%s user connection %s:%d is unreachable and engine configuration blocks unreachable user connections",
+				it.Type,
+				it.Host,
+				it.Port,
+			))
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,128 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/samber/lo"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	postgresqlv1alpha1 "github.com/easymile/postgresql-operator/api/postgresql/v1alpha1"
+)
+
+var (
+	PostgresqlEngineConfigurationGroupResource        = postgresqlv1alpha1.GroupVersion.WithResource("postgresqlengineconfigurations").GroupResource()
+	ClusterPostgresqlEngineConfigurationGroupResource = postgresqlv1alpha1.GroupVersion.WithResource("clusterpostgresqlengineconfigurations").GroupResource()
+	PostgresqlDatabaseGroupResource                   = postgresqlv1alpha1.GroupVersion.WithResource("postgresqldatabases").GroupResource()
+)
+
+// IsNamespaceAllowed will return true if namespace is allowed to reference a resource.
+// Resource namespace is always allowed and all namespaces are allowed when allowed namespaces and selector aren't set.
+func IsNamespaceAllowed(
+	ctx context.Context,
+	cl client.Client,
+	namespace, resourceNamespace string,
+	allowedNamespaces []string,
+	allowedNamespaceSelector *metav1.LabelSelector,
+) (bool, error) {
+	// Check if it is the same namespace
+	if namespace == resourceNamespace {
+		return true, nil
+	}
+
+	// Check if there isn't any restriction
+	if len(allowedNamespaces) == 0 && allowedNamespaceSelector == nil {
+		return true, nil
+	}
+
+	// Check if namespace is in allowed list
+	if lo.Contains(allowedNamespaces, namespace) {
+		return true, nil
+	}
+
+	// Check if there isn't any selector
+	if allowedNamespaceSelector == nil {
+		return false, nil
+	}
+
+	// Parse selector
+	selector, err := metav1.LabelSelectorAsSelector(allowedNamespaceSelector)
+	// Check error
+	if err != nil {
+		return false, errors.NewBadRequest(fmt.Sprintf("invalid allowedNamespaceSelector: %s", err.Error()))
+	}
+
+	// Get namespace
+	ns := &corev1.Namespace{}
+	err = cl.Get(ctx, client.ObjectKey{Name: namespace}, ns)
+	// Check error
+	if err != nil {
+		return false, err
+	}
+
+	return selector.Matches(labels.Set(ns.Labels)), nil
+}
+
+// CheckReferenceAllowed will return a forbidden error if namespace isn't allowed to reference a resource.
+func CheckReferenceAllowed(
+	ctx context.Context,
+	cl client.Client,
+	gr schema.GroupResource,
+	name, namespace, resourceNamespace string,
+	allowedNamespaces []string,
+	allowedNamespaceSelector *metav1.LabelSelector,
+) error {
+	// Check namespace
+	allowed, err := IsNamespaceAllowed(ctx, cl, namespace, resourceNamespace, allowedNamespaces, allowedNamespaceSelector)
+	// Check error
+	if err != nil {
+		return err
+	}
+
+	// Check if it isn't allowed
+	if !allowed {
+		return errors.NewForbidden(gr, name, fmt.Errorf("namespace %s isn't allowed to reference it", namespace))
+	}
+
+	return nil
+}
+
+// CheckPgEngineCfgReferenceAllowed will return a forbidden error if database namespace isn't allowed to reference engine configuration.
+func CheckPgEngineCfgReferenceAllowed(
+	ctx context.Context,
+	cl client.Client,
+	pgec *postgresqlv1alpha1.PostgresqlEngineConfiguration,
+	namespace string,
+) error {
+	// Select group resource
+	gr := PostgresqlEngineConfigurationGroupResource
+	// Check if it is a cluster engine configuration
+	if pgec.Namespace == "" {
+		gr = ClusterPostgresqlEngineConfigurationGroupResource
+	}
+
+	return CheckReferenceAllowed(
+		ctx, cl, gr,
+		pgec.Name, namespace, pgec.Namespace,
+		pgec.Spec.AllowedNamespaces, pgec.Spec.AllowedNamespaceSelector,
+	)
+}
+
+// CheckPgDatabaseReferenceAllowed will return a forbidden error if namespace isn't allowed to reference database.
+func CheckPgDatabaseReferenceAllowed(
+	ctx context.Context,
+	cl client.Client,
+	pgdb *postgresqlv1alpha1.PostgresqlDatabase,
+	namespace string,
+) error {
+	return CheckReferenceAllowed(
+		ctx, cl, PostgresqlDatabaseGroupResource,
+		pgdb.Name, namespace, pgdb.Namespace,
+		pgdb.Spec.AllowedNamespaces, pgdb.Spec.AllowedNamespaceSelector,
+	)
+}
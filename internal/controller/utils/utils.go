@@ -5,9 +5,12 @@ import (
 	"crypto/sha256"
 	"encoding/hex"
 	"encoding/json"
+	"fmt"
+	"time"
 
 	"github.com/easymile/postgresql-operator/api/postgresql/common"
 	postgresqlv1alpha1 "github.com/easymile/postgresql-operator/api/postgresql/v1alpha1"
+	"github.com/easymile/postgresql-operator/internal/controller/config"
 	"github.com/easymile/postgresql-operator/internal/controller/postgresql/postgres"
 	"github.com/go-logr/logr"
 	corev1 "k8s.io/api/core/v1"
@@ -15,6 +18,8 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
+const TLSSecretCAKey = "ca.crt"
+
 func CalculateHash(spec interface{}) (string, error) {
 	// Json marshal spec
 	bytes, err := json.Marshal(spec)
@@ -28,6 +33,28 @@ func CalculateHash(spec interface{}) (string, error) {
 	return hex.EncodeToString(sha256Bytes), nil
 }
 
+// PgInstanceFactory is the function type used by reconcilers to create a PG instance.
+// It is an injection point that allows tests to provide another PG implementation.
+type PgInstanceFactory func(
+	reqLogger logr.Logger,
+	secretData map[string][]byte,
+	pgec *postgresqlv1alpha1.PostgresqlEngineConfiguration,
+) postgres.PG
+
+// CreatePgInstance will use factory if set or fallback on the default PG instance creation.
+func (f PgInstanceFactory) CreatePgInstance(
+	reqLogger logr.Logger,
+	secretData map[string][]byte,
+	pgec *postgresqlv1alpha1.PostgresqlEngineConfiguration,
+) postgres.PG {
+	// Check if factory isn't set
+	if f == nil {
+		return CreatePgInstance(reqLogger, secretData, pgec)
+	}
+
+	return f(reqLogger, secretData, pgec)
+}
+
 func CreatePgInstance(
 	reqLogger logr.Logger,
 	secretData map[string][]byte,
@@ -37,6 +64,14 @@ func CreatePgInstance(
 	user := string(secretData["user"])
 	password := string(secretData["password"])
 
+	// Create pool settings
+	poolSettings, err := CreatePoolSettings(spec.Pool)
+	// Check error
+	if err != nil {
+		// ? Note: Engine reconcile will report this error, use operator defaults here
+		reqLogger.Error(err, "invalid pool settings, operator defaults will be used")
+	}
+
 	return postgres.NewPG(
 		CreateNameKeyForSavedPools(pgec.Name, pgec.Namespace),
 		spec.Host,
@@ -45,11 +80,90 @@ func CreatePgInstance(
 		spec.URIArgs,
 		spec.DefaultDatabase,
 		spec.Port,
+		poolSettings,
+		&postgres.PasswordSettings{
+			Encryption:      spec.PasswordEncryption,
+			ScramIterations: spec.ScramIterations,
+		},
+		CreateTLSSettings(secretData, spec.TLS),
 		spec.Provider,
 		reqLogger,
 	)
 }
 
+// CreatePoolSettings will override operator wide pool settings with engine ones.
+func CreatePoolSettings(pool *postgresqlv1alpha1.PoolSettings) (*postgres.PoolSettings, error) {
+	// Get defaults
+	res := postgres.GetDefaultPoolSettings()
+
+	// Check if there isn't any override
+	if pool == nil {
+		return res, nil
+	}
+
+	if pool.MaxOpenConnections != 0 {
+		res.MaxOpenConnections = pool.MaxOpenConnections
+	}
+
+	if pool.MaxIdleConnections != nil {
+		res.MaxIdleConnections = *pool.MaxIdleConnections
+	}
+
+	if pool.MaxTotalConnections != nil {
+		res.MaxTotalConnections = *pool.MaxTotalConnections
+	}
+
+	if pool.ConnMaxLifetime != "" {
+		// Parse duration
+		dur, err := time.ParseDuration(pool.ConnMaxLifetime)
+		// Check error
+		if err != nil {
+			return nil, fmt.Errorf("invalid pool connMaxLifetime: %w", err)
+		}
+
+		res.ConnMaxLifetime = dur
+	}
+
+	if pool.IdleTimeout != "" {
+		// Parse duration
+		dur, err := time.ParseDuration(pool.IdleTimeout)
+		// Check error
+		if err != nil {
+			return nil, fmt.Errorf("invalid pool idleTimeout: %w", err)
+		}
+
+		res.IdleTimeout = dur
+	}
+
+	return res, nil
+}
+
+// CreateTLSSettings will create TLS settings from engine secret data containing TLS secret data.
+// Nil is returned when TLS isn't enabled on engine.
+func CreateTLSSettings(secretData map[string][]byte, tls *postgresqlv1alpha1.EngineTLS) *postgres.TLSSettings {
+	// Check if TLS isn't enabled
+	if tls == nil {
+		return nil
+	}
+
+	return &postgres.TLSSettings{
+		SSLMode:     tls.SSLMode,
+		Certificate: secretData[corev1.TLSCertKey],
+		Key:         secretData[corev1.TLSPrivateKeyKey],
+		CA:          secretData[TLSSecretCAKey],
+	}
+}
+
+// IsPlanModeEnabled will return true if plan mode is enabled operator wide or on the object with annotation.
+func IsPlanModeEnabled(operatorPlanMode bool, obj client.Object) bool {
+	// Check operator flag
+	if operatorPlanMode {
+		return true
+	}
+
+	return obj.GetAnnotations()[config.PlanModeAnnotation] == "true"
+}
+
 func GetSecret(ctx context.Context, cl client.Client, name, namespace string) (*corev1.Secret, error) {
 	secret := &corev1.Secret{}
 	err := cl.Get(ctx, types.NamespacedName{Name: name, Namespace: namespace}, secret)
@@ -57,27 +171,196 @@ func GetSecret(ctx context.Context, cl client.Client, name, namespace string) (*
 	return secret, err
 }
 
+// FindSecretPgEngineCfg will return engine secret.
+// When credential source is an exec plugin, returned secret data contain "user" and "password" printed by plugin.
+// When TLS is enabled on engine, TLS secret data are injected in the returned secret data.
 func FindSecretPgEngineCfg(
 	ctx context.Context,
 	cl client.Client,
 	instance *postgresqlv1alpha1.PostgresqlEngineConfiguration,
 ) (*corev1.Secret, error) {
 	secret := &corev1.Secret{}
-	err := cl.Get(ctx, types.NamespacedName{Name: instance.Spec.SecretName, Namespace: instance.Namespace}, secret)
 
-	return secret, err
+	// Check if credentials come from an exec plugin
+	if IsExecCredentialSource(instance) {
+		// Get credentials
+		creds, err := GetExecCredentials(ctx, instance)
+		// Check error
+		if err != nil {
+			return secret, err
+		}
+
+		secret.Data = map[string][]byte{
+			"user":     []byte(creds.User),
+			"password": []byte(creds.Password),
+		}
+	} else {
+		err := cl.Get(ctx, types.NamespacedName{Name: instance.Spec.SecretName, Namespace: GetPgEngineCfgSecretNamespace(instance)}, secret)
+		// Check error
+		if err != nil {
+			return secret, err
+		}
+	}
+
+	// Check if TLS isn't enabled
+	if instance.Spec.TLS == nil {
+		return secret, nil
+	}
+
+	// Get TLS secret
+	tlsSecret, err := GetSecret(ctx, cl, instance.Spec.TLS.SecretName, GetPgEngineCfgSecretNamespace(instance))
+	// Check error
+	if err != nil {
+		return secret, err
+	}
+
+	// Check if data must be initialized
+	if secret.Data == nil {
+		secret.Data = map[string][]byte{}
+	}
+	// Inject TLS data
+	for _, k := range []string{corev1.TLSCertKey, corev1.TLSPrivateKeyKey, TLSSecretCAKey} {
+		secret.Data[k] = tlsSecret.Data[k]
+	}
+
+	return secret, nil
 }
 
-func CloseDatabaseSavedPoolsForName(instance *postgresqlv1alpha1.PostgresqlDatabase, database string) error {
-	// Try to get namespace from spec
-	namespace := instance.Spec.EngineConfiguration.Namespace
-	if namespace == "" {
-		// Namespace not found, take it from instance namespace
-		namespace = instance.Namespace
+// GetPgEngineCfgSecretNamespace will return the namespace where engine secrets are stored.
+// Cluster engine configurations don't have a namespace, their secrets are in the operator namespace.
+func GetPgEngineCfgSecretNamespace(instance *postgresqlv1alpha1.PostgresqlEngineConfiguration) string {
+	// Check if it is a cluster engine configuration
+	if instance.Namespace == "" {
+		return config.GetOperatorNamespace()
+	}
+
+	return instance.Namespace
+}
+
+// IsExecCredentialSource will return true if engine credentials come from an exec plugin.
+func IsExecCredentialSource(instance *postgresqlv1alpha1.PostgresqlEngineConfiguration) bool {
+	return instance.Spec.CredentialSource != nil && instance.Spec.CredentialSource.Type == postgresqlv1alpha1.ExecCredentialSourceType
+}
+
+// ValidateCredentialSource will check that engine credential source is valid.
+func ValidateCredentialSource(instance *postgresqlv1alpha1.PostgresqlEngineConfiguration) error {
+	// Check exec source
+	if IsExecCredentialSource(instance) {
+		// Check engine kind
+		err := validateExecCredentialSourceKind(instance)
+		// Check error
+		if err != nil {
+			return err
+		}
+
+		// Create plugin
+		_, err = CreateExecCredentialPlugin(instance.Spec.CredentialSource.Exec)
+
+		return err
 	}
 
+	// Check secret source
+	if instance.Spec.SecretName == "" {
+		return fmt.Errorf("secretName must be set with %q credential source", postgresqlv1alpha1.SecretCredentialSourceType)
+	}
+
+	return nil
+}
+
+// validateExecCredentialSourceKind will check that exec credential source is used by a cluster engine configuration.
+// Commands run inside operator, so only cluster administrators are able to choose their arguments.
+func validateExecCredentialSourceKind(instance *postgresqlv1alpha1.PostgresqlEngineConfiguration) error {
+	// Check if it isn't a cluster engine configuration
+	if instance.Namespace != "" {
+		return fmt.Errorf("%q credential source is only allowed on ClusterPostgresqlEngineConfiguration", postgresqlv1alpha1.ExecCredentialSourceType)
+	}
+
+	return nil
+}
+
+// ValidateAdminPasswordRotation will check that engine admin password rotation is valid and return rotation duration.
+// Zero duration is returned when rotation isn't enabled.
+func ValidateAdminPasswordRotation(instance *postgresqlv1alpha1.PostgresqlEngineConfiguration) (time.Duration, error) {
+	// Check if rotation isn't enabled
+	if instance.Spec.AdminPasswordRotation == nil {
+		return 0, nil
+	}
+
+	// Parse duration
+	// ? Note: Rotation is skipped in reconcile when credentials aren't a secret password (exec source or TLS only)
+	dur, err := time.ParseDuration(instance.Spec.AdminPasswordRotation.Duration)
+	// Check error
+	if err != nil {
+		return 0, fmt.Errorf("invalid adminPasswordRotation duration: %w", err)
+	}
+	// Check value
+	if dur <= 0 {
+		return 0, fmt.Errorf("adminPasswordRotation duration must be positive")
+	}
+
+	return dur, nil
+}
+
+// CreateExecCredentialPlugin will create exec credential plugin from engine exec credential source.
+func CreateExecCredentialPlugin(source *postgresqlv1alpha1.ExecCredentialSource) (*postgres.ExecCredentialPlugin, error) {
+	// Check if source isn't set
+	if source == nil {
+		return nil, fmt.Errorf("exec must be set with %q credential source", postgresqlv1alpha1.ExecCredentialSourceType)
+	}
+
+	res := &postgres.ExecCredentialPlugin{
+		Command:       source.Command,
+		Args:          source.Args,
+		Env:           map[string]string{},
+		RefreshBefore: postgres.DefaultExecCredentialRefreshBefore,
+	}
+
+	// Loop over env
+	for _, it := range source.Env {
+		res.Env[it.Name] = it.Value
+	}
+
+	if source.RefreshBefore != "" {
+		// Parse duration
+		dur, err := time.ParseDuration(source.RefreshBefore)
+		// Check error
+		if err != nil {
+			return nil, fmt.Errorf("invalid exec credential refreshBefore: %w", err)
+		}
+
+		res.RefreshBefore = dur
+	}
+
+	return res, nil
+}
+
+// GetExecCredentials will return engine credentials printed by exec plugin.
+// Credentials are cached and refreshed before expiry.
+func GetExecCredentials(
+	ctx context.Context,
+	instance *postgresqlv1alpha1.PostgresqlEngineConfiguration,
+) (*postgres.Credentials, error) {
+	// Check engine kind
+	// ? Note: This is checked again here as other controllers don't validate engine configurations
+	err := validateExecCredentialSourceKind(instance)
+	// Check error
+	if err != nil {
+		return nil, err
+	}
+
+	// Create plugin
+	plugin, err := CreateExecCredentialPlugin(instance.Spec.CredentialSource.Exec)
+	// Check error
+	if err != nil {
+		return nil, err
+	}
+
+	return postgres.GetExecCredentials(ctx, CreateNameKeyForSavedPools(instance.Name, instance.Namespace), plugin)
+}
+
+func CloseDatabaseSavedPoolsForName(instance *postgresqlv1alpha1.PostgresqlDatabase, database string) error {
 	return postgres.CloseDatabaseSavedPoolsForName(
-		CreateNameKeyForSavedPools(instance.Spec.EngineConfiguration.Name, namespace),
+		CreateNameKeyForEngineLink(instance.Spec.EngineConfiguration, instance.Namespace),
 		database,
 	)
 }
@@ -100,11 +383,61 @@ func CreateNameKeyForSavedPools(pgecName, pgecNamespace string) string {
 	return pgecNamespace + "/" + pgecName
 }
 
+// CreateNameKeyForEngineLink will return the engine key (same as saved pools one) for an engine link.
+// Cluster engine configurations don't have a namespace.
+func CreateNameKeyForEngineLink(link *common.EngineCRLink, instanceNamespace string) string {
+	// Check if it is a cluster engine configuration
+	if link.IsClusterKind() {
+		return CreateNameKeyForSavedPools(link.Name, "")
+	}
+
+	return CreateNameKey(link.Name, link.Namespace, instanceNamespace)
+}
+
+// FindPgEngineCfg will return the engine configuration linked to database.
+// For cluster engine configurations, a namespaced view without namespace is returned.
+// A forbidden error is returned if database namespace isn't allowed to reference it.
 func FindPgEngineCfg(
 	ctx context.Context,
 	cl client.Client,
 	instance *postgresqlv1alpha1.PostgresqlDatabase,
 ) (*postgresqlv1alpha1.PostgresqlEngineConfiguration, error) {
+	// Get engine configuration
+	pgEngineCfg, err := getPgEngineCfg(ctx, cl, instance)
+	// Check error
+	if err != nil {
+		return nil, err
+	}
+
+	// Check that reference is allowed
+	err = CheckPgEngineCfgReferenceAllowed(ctx, cl, pgEngineCfg, instance.Namespace)
+	// Check error
+	if err != nil {
+		return nil, err
+	}
+
+	return pgEngineCfg, nil
+}
+
+func getPgEngineCfg(
+	ctx context.Context,
+	cl client.Client,
+	instance *postgresqlv1alpha1.PostgresqlDatabase,
+) (*postgresqlv1alpha1.PostgresqlEngineConfiguration, error) {
+	// Check if it is a cluster engine configuration
+	if instance.Spec.EngineConfiguration.IsClusterKind() {
+		cpgEngineCfg := &postgresqlv1alpha1.ClusterPostgresqlEngineConfiguration{}
+		err := cl.Get(ctx, client.ObjectKey{
+			Name: instance.Spec.EngineConfiguration.Name,
+		}, cpgEngineCfg)
+		// Check error
+		if err != nil {
+			return nil, err
+		}
+
+		return cpgEngineCfg.ToPostgresqlEngineConfiguration(), nil
+	}
+
 	// Try to get namespace from spec
 	namespace := instance.Spec.EngineConfiguration.Namespace
 	if namespace == "" {
@@ -117,10 +450,16 @@ func FindPgEngineCfg(
 		Name:      instance.Spec.EngineConfiguration.Name,
 		Namespace: namespace,
 	}, pgEngineCfg)
+	// Check error
+	if err != nil {
+		return nil, err
+	}
 
-	return pgEngineCfg, err
+	return pgEngineCfg, nil
 }
 
+// FindPgDatabaseFromLink will return the database targeted by link.
+// A forbidden error is returned if instance namespace isn't allowed to reference it.
 func FindPgDatabaseFromLink(
 	ctx context.Context,
 	cl client.Client,
@@ -139,6 +478,78 @@ func FindPgDatabaseFromLink(
 		Name:      link.Name,
 		Namespace: namespace,
 	}, pgDatabase)
+	// Check error
+	if err != nil {
+		return nil, err
+	}
+
+	// Check that reference is allowed
+	err = CheckPgDatabaseReferenceAllowed(ctx, cl, pgDatabase, instanceNamespace)
+	// Check error
+	if err != nil {
+		return nil, err
+	}
+
+	return pgDatabase, nil
+}
+
+// FindPgPublicationFromLink will return the publication targeted by link.
+// Publications don't have their own allow list, so a forbidden error is returned
+// if instance namespace isn't allowed to reference publication database.
+func FindPgPublicationFromLink(
+	ctx context.Context,
+	cl client.Client,
+	link *common.CRLink,
+	instanceNamespace string,
+) (*postgresqlv1alpha1.PostgresqlPublication, error) {
+	// Try to get namespace from spec
+	namespace := link.Namespace
+	if namespace == "" {
+		// Namespace not found, take it from instance namespace
+		namespace = instanceNamespace
+	}
+
+	pgPublication := &postgresqlv1alpha1.PostgresqlPublication{}
+	err := cl.Get(ctx, client.ObjectKey{
+		Name:      link.Name,
+		Namespace: namespace,
+	}, pgPublication)
+	// Check error
+	if err != nil {
+		return nil, err
+	}
+
+	// Check that publication database reference is allowed
+	_, err = FindPgPublicationPgDatabase(ctx, cl, pgPublication, instanceNamespace)
+	// Check error
+	if err != nil {
+		return nil, err
+	}
+
+	return pgPublication, nil
+}
+
+// FindPgPublicationPgDatabase will return the database of publication.
+// A forbidden error is returned if publication namespace or instance namespace isn't allowed to reference it.
+func FindPgPublicationPgDatabase(
+	ctx context.Context,
+	cl client.Client,
+	pgPublication *postgresqlv1alpha1.PostgresqlPublication,
+	instanceNamespace string,
+) (*postgresqlv1alpha1.PostgresqlDatabase, error) {
+	// Find database from publication namespace
+	pgDatabase, err := FindPgDatabaseFromLink(ctx, cl, pgPublication.Spec.Database, pgPublication.Namespace)
+	// Check error
+	if err != nil {
+		return nil, err
+	}
+
+	// Check that instance namespace is allowed to reference it too
+	err = CheckPgDatabaseReferenceAllowed(ctx, cl, pgDatabase, instanceNamespace)
+	// Check error
+	if err != nil {
+		return nil, err
+	}
 
-	return pgDatabase, err
+	return pgDatabase, nil
 }
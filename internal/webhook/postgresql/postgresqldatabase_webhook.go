@@ -0,0 +1,93 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postgresql
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	postgresqlv1alpha1 "github.com/easymile/postgresql-operator/api/postgresql/v1alpha1"
+	"github.com/easymile/postgresql-operator/internal/controller/utils"
+)
+
+// PostgresqlDatabaseValidator validates that PostgresqlDatabase is allowed to reference its engine configuration.
+type PostgresqlDatabaseValidator struct {
+	Client client.Client
+}
+
+//+kubebuilder:webhook:path=/validate-postgresql-easymile-com-v1alpha1-postgresqldatabase,mutating=false,failurePolicy=fail,sideEffects=None,groups=postgresql.easymile.com,resources=postgresqldatabases,verbs=create;update,versions=v1alpha1,name=vpostgresqldatabase.kb.io,admissionReviewVersions=v1
+
+// SetupWebhookWithManager sets up the webhook with the Manager.
+func (v *PostgresqlDatabaseValidator) SetupWebhookWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewWebhookManagedBy(mgr).
+		For(&postgresqlv1alpha1.PostgresqlDatabase{}).
+		WithValidator(v).
+		Complete()
+}
+
+func (v *PostgresqlDatabaseValidator) ValidateCreate(ctx context.Context, obj runtime.Object) (admission.Warnings, error) {
+	// Cast
+	instance, ok := obj.(*postgresqlv1alpha1.PostgresqlDatabase)
+	if !ok {
+		return nil, fmt.Errorf("expected a PostgresqlDatabase but got a %T", obj)
+	}
+
+	return v.validate(ctx, instance)
+}
+
+func (v *PostgresqlDatabaseValidator) ValidateUpdate(ctx context.Context, oldObj, newObj runtime.Object) (admission.Warnings, error) {
+	// Cast
+	oldInstance, ok := oldObj.(*postgresqlv1alpha1.PostgresqlDatabase)
+	if !ok {
+		return nil, fmt.Errorf("expected a PostgresqlDatabase but got a %T", oldObj)
+	}
+	// Cast
+	instance, ok := newObj.(*postgresqlv1alpha1.PostgresqlDatabase)
+	if !ok {
+		return nil, fmt.Errorf("expected a PostgresqlDatabase but got a %T", newObj)
+	}
+
+	// Check if reference haven't changed
+	// ? Note: Operator updates (finalizers, ...) mustn't be blocked by allow list changes
+	if reflect.DeepEqual(oldInstance.Spec.EngineConfiguration, instance.Spec.EngineConfiguration) {
+		return nil, nil
+	}
+
+	return v.validate(ctx, instance)
+}
+
+func (*PostgresqlDatabaseValidator) ValidateDelete(_ context.Context, _ runtime.Object) (admission.Warnings, error) {
+	return nil, nil
+}
+
+func (v *PostgresqlDatabaseValidator) validate(ctx context.Context, instance *postgresqlv1alpha1.PostgresqlDatabase) (admission.Warnings, error) {
+	// Check if engine configuration link isn't set
+	if instance.Spec.EngineConfiguration == nil {
+		return nil, nil
+	}
+
+	// Find engine configuration and check that reference is allowed
+	_, err := utils.FindPgEngineCfg(ctx, v.Client, instance)
+
+	return manageReferenceError(err)
+}
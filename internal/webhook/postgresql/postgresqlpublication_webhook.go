@@ -0,0 +1,93 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postgresql
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	postgresqlv1alpha1 "github.com/easymile/postgresql-operator/api/postgresql/v1alpha1"
+	"github.com/easymile/postgresql-operator/internal/controller/utils"
+)
+
+// PostgresqlPublicationValidator validates that PostgresqlPublication is allowed to reference its database.
+type PostgresqlPublicationValidator struct {
+	Client client.Client
+}
+
+//+kubebuilder:webhook:path=/validate-postgresql-easymile-com-v1alpha1-postgresqlpublication,mutating=false,failurePolicy=fail,sideEffects=None,groups=postgresql.easymile.com,resources=postgresqlpublications,verbs=create;update,versions=v1alpha1,name=vpostgresqlpublication.kb.io,admissionReviewVersions=v1
+
+// SetupWebhookWithManager sets up the webhook with the Manager.
+func (v *PostgresqlPublicationValidator) SetupWebhookWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewWebhookManagedBy(mgr).
+		For(&postgresqlv1alpha1.PostgresqlPublication{}).
+		WithValidator(v).
+		Complete()
+}
+
+func (v *PostgresqlPublicationValidator) ValidateCreate(ctx context.Context, obj runtime.Object) (admission.Warnings, error) {
+	// Cast
+	instance, ok := obj.(*postgresqlv1alpha1.PostgresqlPublication)
+	if !ok {
+		return nil, fmt.Errorf("expected a PostgresqlPublication but got a %T", obj)
+	}
+
+	return v.validate(ctx, instance)
+}
+
+func (v *PostgresqlPublicationValidator) ValidateUpdate(ctx context.Context, oldObj, newObj runtime.Object) (admission.Warnings, error) {
+	// Cast
+	oldInstance, ok := oldObj.(*postgresqlv1alpha1.PostgresqlPublication)
+	if !ok {
+		return nil, fmt.Errorf("expected a PostgresqlPublication but got a %T", oldObj)
+	}
+	// Cast
+	instance, ok := newObj.(*postgresqlv1alpha1.PostgresqlPublication)
+	if !ok {
+		return nil, fmt.Errorf("expected a PostgresqlPublication but got a %T", newObj)
+	}
+
+	// Check if reference haven't changed
+	// ? Note: Operator updates (finalizers, ...) mustn't be blocked by allow list changes
+	if reflect.DeepEqual(oldInstance.Spec.Database, instance.Spec.Database) {
+		return nil, nil
+	}
+
+	return v.validate(ctx, instance)
+}
+
+func (*PostgresqlPublicationValidator) ValidateDelete(_ context.Context, _ runtime.Object) (admission.Warnings, error) {
+	return nil, nil
+}
+
+func (v *PostgresqlPublicationValidator) validate(ctx context.Context, instance *postgresqlv1alpha1.PostgresqlPublication) (admission.Warnings, error) {
+	// Check if database link isn't set
+	if instance.Spec.Database == nil {
+		return nil, nil
+	}
+
+	// Find database and check that reference is allowed
+	_, err := utils.FindPgDatabaseFromLink(ctx, v.Client, instance.Spec.Database, instance.Namespace)
+
+	return manageReferenceError(err)
+}
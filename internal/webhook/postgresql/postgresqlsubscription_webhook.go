@@ -0,0 +1,116 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postgresql
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	postgresqlv1alpha1 "github.com/easymile/postgresql-operator/api/postgresql/v1alpha1"
+	"github.com/easymile/postgresql-operator/internal/controller/utils"
+)
+
+// PostgresqlSubscriptionValidator validates that PostgresqlSubscription is allowed to reference its database and its publication.
+type PostgresqlSubscriptionValidator struct {
+	Client client.Client
+}
+
+//+kubebuilder:webhook:path=/validate-postgresql-easymile-com-v1alpha1-postgresqlsubscription,mutating=false,failurePolicy=fail,sideEffects=None,groups=postgresql.easymile.com,resources=postgresqlsubscriptions,verbs=create;update,versions=v1alpha1,name=vpostgresqlsubscription.kb.io,admissionReviewVersions=v1
+
+// SetupWebhookWithManager sets up the webhook with the Manager.
+func (v *PostgresqlSubscriptionValidator) SetupWebhookWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewWebhookManagedBy(mgr).
+		For(&postgresqlv1alpha1.PostgresqlSubscription{}).
+		WithValidator(v).
+		Complete()
+}
+
+func (v *PostgresqlSubscriptionValidator) ValidateCreate(ctx context.Context, obj runtime.Object) (admission.Warnings, error) {
+	// Cast
+	instance, ok := obj.(*postgresqlv1alpha1.PostgresqlSubscription)
+	if !ok {
+		return nil, fmt.Errorf("expected a PostgresqlSubscription but got a %T", obj)
+	}
+
+	return v.validate(ctx, instance)
+}
+
+func (v *PostgresqlSubscriptionValidator) ValidateUpdate(ctx context.Context, oldObj, newObj runtime.Object) (admission.Warnings, error) {
+	// Cast
+	oldInstance, ok := oldObj.(*postgresqlv1alpha1.PostgresqlSubscription)
+	if !ok {
+		return nil, fmt.Errorf("expected a PostgresqlSubscription but got a %T", oldObj)
+	}
+	// Cast
+	instance, ok := newObj.(*postgresqlv1alpha1.PostgresqlSubscription)
+	if !ok {
+		return nil, fmt.Errorf("expected a PostgresqlSubscription but got a %T", newObj)
+	}
+
+	// Check if references haven't changed
+	// ? Note: Operator updates (finalizers, ...) mustn't be blocked by allow list changes
+	if reflect.DeepEqual(oldInstance.Spec.Database, instance.Spec.Database) &&
+		reflect.DeepEqual(oldInstance.Spec.Publication, instance.Spec.Publication) {
+		return nil, nil
+	}
+
+	return v.validate(ctx, instance)
+}
+
+func (*PostgresqlSubscriptionValidator) ValidateDelete(_ context.Context, _ runtime.Object) (admission.Warnings, error) {
+	return nil, nil
+}
+
+func (v *PostgresqlSubscriptionValidator) validate(ctx context.Context, instance *postgresqlv1alpha1.PostgresqlSubscription) (admission.Warnings, error) {
+	var res admission.Warnings
+
+	// Check if database link is set
+	if instance.Spec.Database != nil {
+		// Find database and check that reference is allowed
+		_, err := utils.FindPgDatabaseFromLink(ctx, v.Client, instance.Spec.Database, instance.Namespace)
+		// Manage error
+		warnings, err := manageReferenceError(err)
+		// Check error
+		if err != nil {
+			return nil, err
+		}
+
+		res = append(res, warnings...)
+	}
+
+	// Check if publication link is set
+	if instance.Spec.Publication != nil {
+		// Find publication and check that its database reference is allowed
+		_, err := utils.FindPgPublicationFromLink(ctx, v.Client, instance.Spec.Publication, instance.Namespace)
+		// Manage error
+		warnings, err := manageReferenceError(err)
+		// Check error
+		if err != nil {
+			return nil, err
+		}
+
+		res = append(res, warnings...)
+	}
+
+	return res, nil
+}
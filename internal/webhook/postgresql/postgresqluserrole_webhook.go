@@ -0,0 +1,121 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postgresql
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	"github.com/easymile/postgresql-operator/api/postgresql/common"
+	postgresqlv1alpha1 "github.com/easymile/postgresql-operator/api/postgresql/v1alpha1"
+	"github.com/easymile/postgresql-operator/internal/controller/utils"
+)
+
+// PostgresqlUserRoleValidator validates that PostgresqlUserRole is allowed to reference its databases.
+type PostgresqlUserRoleValidator struct {
+	Client client.Client
+}
+
+//+kubebuilder:webhook:path=/validate-postgresql-easymile-com-v1alpha1-postgresqluserrole,mutating=false,failurePolicy=fail,sideEffects=None,groups=postgresql.easymile.com,resources=postgresqluserroles,verbs=create;update,versions=v1alpha1,name=vpostgresqluserrole.kb.io,admissionReviewVersions=v1
+
+// SetupWebhookWithManager sets up the webhook with the Manager.
+func (v *PostgresqlUserRoleValidator) SetupWebhookWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewWebhookManagedBy(mgr).
+		For(&postgresqlv1alpha1.PostgresqlUserRole{}).
+		WithValidator(v).
+		Complete()
+}
+
+func (v *PostgresqlUserRoleValidator) ValidateCreate(ctx context.Context, obj runtime.Object) (admission.Warnings, error) {
+	// Cast
+	instance, ok := obj.(*postgresqlv1alpha1.PostgresqlUserRole)
+	if !ok {
+		return nil, fmt.Errorf("expected a PostgresqlUserRole but got a %T", obj)
+	}
+
+	return v.validate(ctx, instance, nil)
+}
+
+func (v *PostgresqlUserRoleValidator) ValidateUpdate(ctx context.Context, oldObj, newObj runtime.Object) (admission.Warnings, error) {
+	// Cast
+	oldInstance, ok := oldObj.(*postgresqlv1alpha1.PostgresqlUserRole)
+	if !ok {
+		return nil, fmt.Errorf("expected a PostgresqlUserRole but got a %T", oldObj)
+	}
+	// Cast
+	instance, ok := newObj.(*postgresqlv1alpha1.PostgresqlUserRole)
+	if !ok {
+		return nil, fmt.Errorf("expected a PostgresqlUserRole but got a %T", newObj)
+	}
+
+	return v.validate(ctx, instance, oldInstance)
+}
+
+func (*PostgresqlUserRoleValidator) ValidateDelete(_ context.Context, _ runtime.Object) (admission.Warnings, error) {
+	return nil, nil
+}
+
+func (v *PostgresqlUserRoleValidator) validate(
+	ctx context.Context,
+	instance, oldInstance *postgresqlv1alpha1.PostgresqlUserRole,
+) (admission.Warnings, error) {
+	var res admission.Warnings
+
+	// Loop over privileges
+	for _, item := range instance.Spec.Privileges {
+		// Check if database link isn't set
+		if item.Database == nil {
+			continue
+		}
+
+		// Check if reference was already there
+		// ? Note: Operator updates (finalizers, ...) mustn't be blocked by allow list changes
+		if oldInstance != nil && isDatabaseLinkInPrivileges(item.Database, oldInstance.Spec.Privileges) {
+			continue
+		}
+
+		// Find database and check that reference is allowed
+		_, err := utils.FindPgDatabaseFromLink(ctx, v.Client, item.Database, instance.Namespace)
+		// Manage error
+		warnings, err := manageReferenceError(err)
+		// Check error
+		if err != nil {
+			return nil, err
+		}
+
+		res = append(res, warnings...)
+	}
+
+	return res, nil
+}
+
+func isDatabaseLinkInPrivileges(link *common.CRLink, privileges []*postgresqlv1alpha1.PostgresqlUserRolePrivilege) bool {
+	// Loop over privileges
+	for _, item := range privileges {
+		if reflect.DeepEqual(item.Database, link) {
+			return true
+		}
+	}
+
+	return false
+}
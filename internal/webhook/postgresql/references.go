@@ -0,0 +1,40 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postgresql
+
+import (
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+// manageReferenceError will transform reference lookup error into webhook result.
+// Missing references are accepted with a warning because they can be created later.
+func manageReferenceError(err error) (admission.Warnings, error) {
+	// Check if there isn't any error
+	if err == nil {
+		return nil, nil
+	}
+
+	// Check if reference isn't found
+	if errors.IsNotFound(err) {
+		return admission.Warnings{fmt.Sprintf("reference cannot be checked: %s", err.Error())}, nil
+	}
+
+	return nil, err
+}